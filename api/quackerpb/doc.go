@@ -0,0 +1,12 @@
+// Package quackerpb is the gRPC interface of "Quacker serve", for Go services
+// that synthesize through it:
+//
+//	conn, err := grpc.NewClient("127.0.0.1:8766", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client := quackerpb.NewSynthesizerClient(conn)
+//	stream, err := client.Synthesize(ctx, &quackerpb.SynthesizeRequest{Text: "Hello", Provider: "openai"})
+//
+// A server started with a token expects it as "authorization: Bearer <token>"
+// metadata on every call.
+package quackerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative quacker.proto
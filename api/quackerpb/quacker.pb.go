@@ -0,0 +1,724 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: quacker.proto
+
+package quackerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SynthesizeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Text  string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// The provider; empty for the default of the server.
+	Provider string `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	// The voice; empty for the default of the provider.
+	Voice string `protobuf:"bytes,3,opt,name=voice,proto3" json:"voice,omitempty"`
+	// The speaking rate; 0 for 1.0.
+	Speed        float64 `protobuf:"fixed64,4,opt,name=speed,proto3" json:"speed,omitempty"`
+	Style        string  `protobuf:"bytes,5,opt,name=style,proto3" json:"style,omitempty"`
+	Instructions string  `protobuf:"bytes,6,opt,name=instructions,proto3" json:"instructions,omitempty"`
+	// "mp3", "wav" or "ogg"; empty for the output format of the settings.
+	Format        string `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SynthesizeRequest) Reset() {
+	*x = SynthesizeRequest{}
+	mi := &file_quacker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SynthesizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SynthesizeRequest) ProtoMessage() {}
+
+func (x *SynthesizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SynthesizeRequest.ProtoReflect.Descriptor instead.
+func (*SynthesizeRequest) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SynthesizeRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetVoice() string {
+	if x != nil {
+		return x.Voice
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *SynthesizeRequest) GetStyle() string {
+	if x != nil {
+		return x.Style
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetInstructions() string {
+	if x != nil {
+		return x.Instructions
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type SynthesizeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SynthesizeResponse_Progress
+	//	*SynthesizeResponse_Audio
+	Event         isSynthesizeResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SynthesizeResponse) Reset() {
+	*x = SynthesizeResponse{}
+	mi := &file_quacker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SynthesizeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SynthesizeResponse) ProtoMessage() {}
+
+func (x *SynthesizeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SynthesizeResponse.ProtoReflect.Descriptor instead.
+func (*SynthesizeResponse) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SynthesizeResponse) GetEvent() isSynthesizeResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*SynthesizeResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetAudio() []byte {
+	if x != nil {
+		if x, ok := x.Event.(*SynthesizeResponse_Audio); ok {
+			return x.Audio
+		}
+	}
+	return nil
+}
+
+type isSynthesizeResponse_Event interface {
+	isSynthesizeResponse_Event()
+}
+
+type SynthesizeResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type SynthesizeResponse_Audio struct {
+	// The next part of the audio file.
+	Audio []byte `protobuf:"bytes,2,opt,name=audio,proto3,oneof"`
+}
+
+func (*SynthesizeResponse_Progress) isSynthesizeResponse_Event() {}
+
+func (*SynthesizeResponse_Audio) isSynthesizeResponse_Event() {}
+
+type Progress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chunks done so far, of chunks.
+	Chunk         int32 `protobuf:"varint,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Chunks        int32 `protobuf:"varint,2,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_quacker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetChunk() int32 {
+	if x != nil {
+		return x.Chunk
+	}
+	return 0
+}
+
+func (x *Progress) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+type Job struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// "queued", "running", "done" or "failed".
+	Status   string    `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Provider string    `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	Voice    string    `protobuf:"bytes,4,opt,name=voice,proto3" json:"voice,omitempty"`
+	Progress *Progress `protobuf:"bytes,5,opt,name=progress,proto3" json:"progress,omitempty"`
+	// The path of the saved audio on the server, once done.
+	Output string `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	// Why the job failed, or which sections of a done job failed.
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created,proto3" json:"created,omitempty"`
+	Finished      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=finished,proto3" json:"finished,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_quacker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Job) GetVoice() string {
+	if x != nil {
+		return x.Voice
+	}
+	return ""
+}
+
+func (x *Job) GetProgress() *Progress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *Job) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Job) GetFinished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Finished
+	}
+	return nil
+}
+
+type JobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_quacker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListVoicesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The provider; empty for all configured providers.
+	Provider string `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	// A language code or prefix such as "de" or "en-GB".
+	Language string `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	// "female", "male" or "neutral".
+	Gender        string `protobuf:"bytes,3,opt,name=gender,proto3" json:"gender,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVoicesRequest) Reset() {
+	*x = ListVoicesRequest{}
+	mi := &file_quacker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVoicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVoicesRequest) ProtoMessage() {}
+
+func (x *ListVoicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVoicesRequest.ProtoReflect.Descriptor instead.
+func (*ListVoicesRequest) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListVoicesRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ListVoicesRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ListVoicesRequest) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+type ListVoicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Voices        []*Voice               `protobuf:"bytes,1,rep,name=voices,proto3" json:"voices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVoicesResponse) Reset() {
+	*x = ListVoicesResponse{}
+	mi := &file_quacker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVoicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVoicesResponse) ProtoMessage() {}
+
+func (x *ListVoicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVoicesResponse.ProtoReflect.Descriptor instead.
+func (*ListVoicesResponse) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListVoicesResponse) GetVoices() []*Voice {
+	if x != nil {
+		return x.Voices
+	}
+	return nil
+}
+
+type Voice struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	// Empty for voices that speak any language.
+	LanguageCode  string `protobuf:"bytes,3,opt,name=language_code,json=languageCode,proto3" json:"language_code,omitempty"`
+	Gender        string `protobuf:"bytes,4,opt,name=gender,proto3" json:"gender,omitempty"`
+	Provider      string `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Voice) Reset() {
+	*x = Voice{}
+	mi := &file_quacker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Voice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Voice) ProtoMessage() {}
+
+func (x *Voice) ProtoReflect() protoreflect.Message {
+	mi := &file_quacker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Voice.ProtoReflect.Descriptor instead.
+func (*Voice) Descriptor() ([]byte, []int) {
+	return file_quacker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Voice) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Voice) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *Voice) GetLanguageCode() string {
+	if x != nil {
+		return x.LanguageCode
+	}
+	return ""
+}
+
+func (x *Voice) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *Voice) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+var File_quacker_proto protoreflect.FileDescriptor
+
+const file_quacker_proto_rawDesc = "" +
+	"\n" +
+	"\rquacker.proto\x12\n" +
+	"quacker.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc1\x01\n" +
+	"\x11SynthesizeRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05voice\x18\x03 \x01(\tR\x05voice\x12\x14\n" +
+	"\x05speed\x18\x04 \x01(\x01R\x05speed\x12\x14\n" +
+	"\x05style\x18\x05 \x01(\tR\x05style\x12\"\n" +
+	"\finstructions\x18\x06 \x01(\tR\finstructions\x12\x16\n" +
+	"\x06format\x18\a \x01(\tR\x06format\"i\n" +
+	"\x12SynthesizeResponse\x122\n" +
+	"\bprogress\x18\x01 \x01(\v2\x14.quacker.v1.ProgressH\x00R\bprogress\x12\x16\n" +
+	"\x05audio\x18\x02 \x01(\fH\x00R\x05audioB\a\n" +
+	"\x05event\"8\n" +
+	"\bProgress\x12\x14\n" +
+	"\x05chunk\x18\x01 \x01(\x05R\x05chunk\x12\x16\n" +
+	"\x06chunks\x18\x02 \x01(\x05R\x06chunks\"\xad\x02\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05voice\x18\x04 \x01(\tR\x05voice\x120\n" +
+	"\bprogress\x18\x05 \x01(\v2\x14.quacker.v1.ProgressR\bprogress\x12\x16\n" +
+	"\x06output\x18\x06 \x01(\tR\x06output\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x124\n" +
+	"\acreated\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x126\n" +
+	"\bfinished\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bfinished\"\x1c\n" +
+	"\n" +
+	"JobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"c\n" +
+	"\x11ListVoicesRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12\x16\n" +
+	"\x06gender\x18\x03 \x01(\tR\x06gender\"?\n" +
+	"\x12ListVoicesResponse\x12)\n" +
+	"\x06voices\x18\x01 \x03(\v2\x11.quacker.v1.VoiceR\x06voices\"\x97\x01\n" +
+	"\x05Voice\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12#\n" +
+	"\rlanguage_code\x18\x03 \x01(\tR\flanguageCode\x12\x16\n" +
+	"\x06gender\x18\x04 \x01(\tR\x06gender\x12\x1a\n" +
+	"\bprovider\x18\x05 \x01(\tR\bprovider2\xd0\x02\n" +
+	"\vSynthesizer\x12M\n" +
+	"\n" +
+	"Synthesize\x12\x1d.quacker.v1.SynthesizeRequest\x1a\x1e.quacker.v1.SynthesizeResponse0\x01\x12;\n" +
+	"\tSubmitJob\x12\x1d.quacker.v1.SynthesizeRequest\x1a\x0f.quacker.v1.Job\x121\n" +
+	"\x06GetJob\x12\x16.quacker.v1.JobRequest\x1a\x0f.quacker.v1.Job\x125\n" +
+	"\bWatchJob\x12\x16.quacker.v1.JobRequest\x1a\x0f.quacker.v1.Job0\x01\x12K\n" +
+	"\n" +
+	"ListVoices\x12\x1d.quacker.v1.ListVoicesRequest\x1a\x1e.quacker.v1.ListVoicesResponseB\x18Z\x16easy-tts/api/quackerpbb\x06proto3"
+
+var (
+	file_quacker_proto_rawDescOnce sync.Once
+	file_quacker_proto_rawDescData []byte
+)
+
+func file_quacker_proto_rawDescGZIP() []byte {
+	file_quacker_proto_rawDescOnce.Do(func() {
+		file_quacker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_quacker_proto_rawDesc), len(file_quacker_proto_rawDesc)))
+	})
+	return file_quacker_proto_rawDescData
+}
+
+var file_quacker_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_quacker_proto_goTypes = []any{
+	(*SynthesizeRequest)(nil),     // 0: quacker.v1.SynthesizeRequest
+	(*SynthesizeResponse)(nil),    // 1: quacker.v1.SynthesizeResponse
+	(*Progress)(nil),              // 2: quacker.v1.Progress
+	(*Job)(nil),                   // 3: quacker.v1.Job
+	(*JobRequest)(nil),            // 4: quacker.v1.JobRequest
+	(*ListVoicesRequest)(nil),     // 5: quacker.v1.ListVoicesRequest
+	(*ListVoicesResponse)(nil),    // 6: quacker.v1.ListVoicesResponse
+	(*Voice)(nil),                 // 7: quacker.v1.Voice
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_quacker_proto_depIdxs = []int32{
+	2,  // 0: quacker.v1.SynthesizeResponse.progress:type_name -> quacker.v1.Progress
+	2,  // 1: quacker.v1.Job.progress:type_name -> quacker.v1.Progress
+	8,  // 2: quacker.v1.Job.created:type_name -> google.protobuf.Timestamp
+	8,  // 3: quacker.v1.Job.finished:type_name -> google.protobuf.Timestamp
+	7,  // 4: quacker.v1.ListVoicesResponse.voices:type_name -> quacker.v1.Voice
+	0,  // 5: quacker.v1.Synthesizer.Synthesize:input_type -> quacker.v1.SynthesizeRequest
+	0,  // 6: quacker.v1.Synthesizer.SubmitJob:input_type -> quacker.v1.SynthesizeRequest
+	4,  // 7: quacker.v1.Synthesizer.GetJob:input_type -> quacker.v1.JobRequest
+	4,  // 8: quacker.v1.Synthesizer.WatchJob:input_type -> quacker.v1.JobRequest
+	5,  // 9: quacker.v1.Synthesizer.ListVoices:input_type -> quacker.v1.ListVoicesRequest
+	1,  // 10: quacker.v1.Synthesizer.Synthesize:output_type -> quacker.v1.SynthesizeResponse
+	3,  // 11: quacker.v1.Synthesizer.SubmitJob:output_type -> quacker.v1.Job
+	3,  // 12: quacker.v1.Synthesizer.GetJob:output_type -> quacker.v1.Job
+	3,  // 13: quacker.v1.Synthesizer.WatchJob:output_type -> quacker.v1.Job
+	6,  // 14: quacker.v1.Synthesizer.ListVoices:output_type -> quacker.v1.ListVoicesResponse
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_quacker_proto_init() }
+func file_quacker_proto_init() {
+	if File_quacker_proto != nil {
+		return
+	}
+	file_quacker_proto_msgTypes[1].OneofWrappers = []any{
+		(*SynthesizeResponse_Progress)(nil),
+		(*SynthesizeResponse_Audio)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_quacker_proto_rawDesc), len(file_quacker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_quacker_proto_goTypes,
+		DependencyIndexes: file_quacker_proto_depIdxs,
+		MessageInfos:      file_quacker_proto_msgTypes,
+	}.Build()
+	File_quacker_proto = out.File
+	file_quacker_proto_goTypes = nil
+	file_quacker_proto_depIdxs = nil
+}
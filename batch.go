@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/config"
+	"easy-tts/internal/gui"
+	"easy-tts/internal/history"
+	"easy-tts/internal/tts"
+	"easy-tts/internal/util"
+)
+
+// Errors of a conversion that exitCodeFor tells apart.
+var (
+	errEmptyDocument  = errors.New("the document is empty")
+	errSectionsFailed = errors.New("section(s) could not be processed")
+)
+
+// batchExtensions are the documents a batch converts.
+var batchExtensions = []string{".txt", ".md", ".markdown"}
+
+// batchJob is what every document of a batch shares.
+type batchJob struct {
+	Provider     string
+	Voice        string // "" for the provider's default
+	Speed        float64
+	Style        string
+	Instructions string
+	Model        string    // "" for the model of the settings
+	OutDir       string    // "" for the output folder of the settings
+	Output       string    // The file to write, replaced if it exists; "" names it after the document in OutDir, "-" is stdout
+	Format       string    // The output format; "" for the one of the settings
+	Stream       io.Writer // Receives the audio of an Output of "-" instead of stdout
+}
+
+// batchProgress reports how far a batch got.
+type batchProgress struct {
+	Doc, Docs     int    // 1-based document being converted, of Docs
+	Name          string // File name of the document
+	Chunk, Chunks int    // Chunks of the document done so far
+	Fraction      float64
+}
+
+// batchResult is the outcome of one document of a batch.
+type batchResult struct {
+	Source string
+	Output string // "" if nothing was saved
+	Err    error  // Also set when some sections of a saved output failed
+}
+
+// batchDocuments lists the documents in dir a batch converts, sorted by name.
+// Subfolders and hidden files are left out.
+func batchDocuments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var docs []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") && slices.Contains(batchExtensions, ext) {
+			docs = append(docs, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s has no %s files", dir, strings.Join(batchExtensions, ", "))
+	}
+	return docs, nil
+}
+
+// runBatch converts docs one after the other with the settings of job, calling
+// progress as chunks complete. The progress is weighed by the length of the
+// documents. A failed document does not stop the batch; a cancelled ctx does.
+func runBatch(ctx context.Context, ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store,
+	job batchJob, docs []string, progress func(batchProgress)) []batchResult {
+	sizes := make([]int64, len(docs))
+	var total, done int64
+	for i, doc := range docs {
+		if info, err := os.Stat(doc); err == nil {
+			sizes[i] = max(info.Size(), 1)
+		} else {
+			sizes[i] = 1
+		}
+		total += sizes[i]
+	}
+	var results []batchResult
+	for i, doc := range docs {
+		if ctx.Err() != nil {
+			break
+		}
+		p := batchProgress{Doc: i + 1, Docs: len(docs), Name: filepath.Base(doc), Fraction: float64(done) / float64(total)}
+		if progress != nil {
+			progress(p)
+		}
+		output, err := synthesizeDocument(ctx, ttsManager, settings, jobHistory, job, doc, func(completed, chunks int) {
+			p.Chunk, p.Chunks = completed, chunks
+			p.Fraction = (float64(done) + float64(sizes[i])*float64(completed)/float64(chunks)) / float64(total)
+			if progress != nil {
+				progress(p)
+			}
+		})
+		if err != nil {
+			slog.Error("Batch document failed", "file", doc, "err", err)
+		}
+		results = append(results, batchResult{Source: doc, Output: output, Err: err})
+		done += sizes[i]
+	}
+	return results
+}
+
+// synthesizeDocument converts the document at path into an audio file named
+// after it, "chapter1.txt" becoming "chapter1.mp3" in job.OutDir, or into
+// job.Output if set. Nobody is around to answer a conflict prompt, so an
+// existing file is never overwritten unless it was named in job.Output. A path
+// of "-" reads the document from stdin, a job.Output of "-" writes the audio to
+// stdout. It returns the path saved, which is set along with an error if some
+// sections failed.
+func synthesizeDocument(ctx context.Context, ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store,
+	job batchJob, path string, progressCb tts.ProgressCallback) (string, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return "", err
+		}
+		defer f.Close()
+	}
+	inputText, err := util.ReadTextFile(f)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(inputText) == "" {
+		return "", errEmptyDocument
+	}
+	return synthesizeText(ctx, ttsManager, settings, jobHistory, job, path, inputText, progressCb)
+}
+
+// synthesizeText is synthesizeDocument for inputText read from path. Without a
+// path, "" or "-", the output is named by the filename template of the settings.
+func synthesizeText(ctx context.Context, ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store,
+	job batchJob, path, inputText string, progressCb tts.ProgressCallback) (string, error) {
+	provider, err := ttsManager.GetProvider(job.Provider)
+	if err != nil {
+		return "", err
+	}
+	voice := job.Voice
+	if voice == "" {
+		voice = settings.DefaultVoices[job.Provider]
+	}
+	if voice == "" {
+		voice = provider.GetDefaultVoice()
+	}
+	text, segments, hook, err := prepareJob(job.Provider, inputText, voice, settings.Script, settings)
+	if err != nil {
+		return "", err
+	}
+	request := &tts.UnifiedRequest{
+		Text:       text,
+		Voice:      voice,
+		Speed:      job.Speed,
+		Format:     tts.FormatFor(provider, settings.OutputFormat),
+		Style:      job.Style,
+		SayAs:      settings.SayAsHints,
+		SampleRate: settings.SampleRate,
+		Bitrate:    settings.Bitrate,
+	}
+	if job.Format != "" {
+		request.Format = tts.FormatFor(provider, job.Format)
+	}
+	request.Model = job.Model
+	if request.Model == "" {
+		request.Model = modelFor(job.Provider, settings)
+	}
+	if job.Provider == "openai" {
+		request.Instructions = job.Instructions
+	}
+
+	cfg := tts.DefaultProcessorConfig()
+	cfg.ChunkLimit = settings.ChunkLimits[job.Provider]
+	cfg.StitchContext = settings.StitchContext
+	cfg.Cache = synthesisCache(settings)
+	applyRetrySettings(cfg, settings)
+	applyClipSettings(cfg, settings, true)
+	cfg.Crossfade = time.Duration(settings.Crossfade * float64(time.Second))
+	cfg.JobID = tts.NewJobID()
+	errorCb := func(msg string) { slog.Warn("Batch document", "file", filepath.Base(path), "msg", msg) }
+	if job.Output == "-" {
+		w := job.Stream
+		if w == nil {
+			w = os.Stdout
+		}
+		return "", writeStream(ctx, w, provider, request, segments, progressCb, errorCb, cfg)
+	}
+	cfg.SpeechMarks = settings.Subtitles != "" || settings.TimingJSON
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "." + audio.NormalizeFormat(request.Format)
+	if path == "" || path == "-" {
+		name = outputFilename(settings, job.Provider, inputText, request, nil)
+	}
+	outPath := filepath.Join(job.OutDir, name)
+	if job.Output != "" {
+		outPath, name = job.Output, filepath.Base(job.Output)
+	} else if job.OutDir == "" {
+		if outPath, err = util.OutputPath(settings.OutputDir, name); err != nil {
+			return "", err
+		}
+	}
+	out, err := util.CreateTempOutput(outPath)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(out.Name()) // already gone once moved into place
+	cfg.Output = out
+	_, report, err := tts.ProcessSegments(ctx, provider, request, segments, progressCb, errorCb, cfg)
+	if fixErr := audio.FixFile(out); fixErr != nil && err == nil {
+		err = fixErr
+	}
+	size := int64(0)
+	if info, statErr := out.Stat(); statErr == nil {
+		size = info.Size()
+	}
+	if closeErr := out.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to write audio: %w", closeErr)
+	}
+	if size == 0 {
+		if failed := report.Failed(); err == nil && len(failed) > 0 {
+			// The cause tells the exit code of the command line apart
+			err = fmt.Errorf("no audio could be generated: %s", failed[0].Error)
+		} else if err == nil {
+			err = errors.New("no audio could be generated")
+		}
+		return "", err
+	}
+	if err == nil && len(report.Failed()) > 0 {
+		err = fmt.Errorf("%d %w", len(report.Failed()), errSectionsFailed)
+	}
+
+	if job.Output == "" {
+		outPath = util.UniqueOutputPath(outPath)
+		for !util.LockOutputPath(outPath) {
+			outPath = util.UniqueOutputPath(outPath)
+		}
+	}
+	moveErr := util.MoveAudioFile(out.Name(), outPath)
+	if job.Output == "" {
+		util.UnlockOutputPath(outPath)
+	}
+	if moveErr != nil {
+		return "", moveErr
+	}
+	slog.Info("Batch document saved", "output", outPath)
+
+	partPaths, partErr := writeParts(outPath, report.Chapters, nil)
+	if partErr != nil {
+		slog.Error("Failed to save the chapter files", "err", partErr)
+	}
+	// Everything written along with the output, for the history to delete later
+	files := slices.Clone(partPaths)
+	sidecars, sidecarErr := writeSidecars(settings, append([]string{outPath}, partPaths...))
+	if sidecarErr != nil {
+		slog.Warn("Failed to save the sidecars", "output", name, "err", sidecarErr)
+	}
+	files = append(files, sidecars...)
+	timings, timingErr := writeTimings(settings, outPath, report)
+	if timingErr != nil {
+		slog.Warn("Failed to save the timings", "output", name, "err", timingErr)
+	}
+	files = append(files, timings...)
+	transcript, transcriptErr := writeTranscript(settings, outPath, provider, request, cfg.ChunkLimit, cfg.StitchContext, hook != nil)
+	if transcriptErr != nil {
+		slog.Warn("Failed to save the transcript", "output", name, "err", transcriptErr)
+	}
+	files = append(files, transcript...)
+	chunkDir, chunkErr := writeChunkFiles(settings, outPath, report, nil)
+	if chunkErr != nil {
+		slog.Warn("Failed to save the chunk files", "output", name, "err", chunkErr)
+	}
+	if chunkDir != "" {
+		files = append(files, chunkDir)
+	}
+	if len(report.Failed()) == 0 {
+		removeWholeOutput(settings, outPath, partPaths)
+	}
+	if _, _, uploadErr := uploadOutputs(ctx, settings, append([]string{outPath}, files...)); uploadErr != nil {
+		err = errors.Join(err, fmt.Errorf("upload failed: %w", uploadErr))
+	}
+
+	if jobHistory != nil {
+		if histErr := jobHistory.SaveText(inputText); histErr != nil {
+			slog.Warn("Failed to cache input text", "err", histErr)
+		}
+		if _, histErr := jobHistory.Add(history.Entry{
+			Title:      history.TitleFromText(inputText),
+			TextHash:   history.HashText(inputText),
+			Provider:   job.Provider,
+			Voice:      voice,
+			Speed:      job.Speed,
+			OutputPath: outPath,
+			Files:      files,
+		}); histErr != nil {
+			slog.Warn("Failed to record history entry", "err", histErr)
+		}
+	}
+	return outPath, err
+}
+
+// writeStream synthesizes segments into w, such as stdout. MP3 is streamed chunk
+// by chunk; WAV and Ogg headers need the final sizes, and a stream cannot be read
+// back to fix them, so these are assembled in a temporary file and copied once
+// complete.
+func writeStream(ctx context.Context, w io.Writer, provider tts.Provider, request *tts.UnifiedRequest, segments []tts.Segment,
+	progressCb tts.ProgressCallback, errorCb tts.ErrorCallback, cfg *tts.ProcessorConfig) error {
+	if audio.NormalizeFormat(request.Format) == "mp3" {
+		cfg.Output = w
+		_, report, err := tts.ProcessSegments(ctx, provider, request, segments, progressCb, errorCb, cfg)
+		if err == nil && len(report.Failed()) > 0 {
+			err = fmt.Errorf("%d %w", len(report.Failed()), errSectionsFailed)
+		}
+		return err
+	}
+	tmp, err := os.CreateTemp("", "quacker-*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	cfg.Output = tmp
+	_, report, err := tts.ProcessSegments(ctx, provider, request, segments, progressCb, errorCb, cfg)
+	if fixErr := audio.FixFile(tmp); fixErr != nil && err == nil {
+		err = fixErr
+	}
+	if err == nil {
+		if _, err = tmp.Seek(0, io.SeekStart); err == nil {
+			_, err = io.Copy(w, tmp)
+		}
+	}
+	if err == nil && len(report.Failed()) > 0 {
+		err = fmt.Errorf("%d %w", len(report.Failed()), errSectionsFailed)
+	}
+	return err
+}
+
+// batchSummary sums up the results of a batch in one line.
+func batchSummary(results []batchResult, docs int) string {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	msg := fmt.Sprintf("Converted %d of %d document(s)", len(results)-failed, docs)
+	if failed > 0 {
+		msg += fmt.Sprintf(", %d with errors", failed)
+	}
+	if len(results) < docs {
+		msg += ", cancelled"
+	}
+	return msg
+}
+
+// convertFolder asks for a folder and converts its documents with the provider,
+// voice and speed chosen in the main window, showing the progress of the batch.
+func convertFolder(ui *gui.UI, ttsManager *tts.Manager, providerName string, settings *config.Settings, jobHistory *history.Store) {
+	if err := ttsManager.ValidateProvider(providerName); err != nil {
+		ui.ShowError(fmt.Sprintf("Provider '%s' configuration error: %v", providerName, err))
+		return
+	}
+	job := batchJob{
+		Provider:     providerName,
+		Voice:        ui.Voice.Text,
+		Speed:        ui.Speed.Value,
+		Style:        ui.SelectedStyle(),
+		Instructions: ui.Instructions.Text,
+	}
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to open the folder: %v", err))
+			return
+		}
+		if dir == nil {
+			return
+		}
+		docs, err := batchDocuments(dir.Path())
+		if err != nil {
+			ui.ShowError(err.Error())
+			return
+		}
+		go func() {
+			if !ui.AskConfirm("Convert folder", fmt.Sprintf("Convert %d document(s) in %s into one audio file each, using %s with the current voice and settings?",
+				len(docs), filepath.Base(dir.Path()), providerName)) {
+				return
+			}
+			ui.SetSubmitEnabled(false)
+			defer ui.SetSubmitEnabled(true)
+			ui.SetProgress(0)
+			results := runBatch(context.Background(), ttsManager, settings, jobHistory, job, docs, func(p batchProgress) {
+				ui.SetProgress(p.Fraction)
+				msg := fmt.Sprintf("Document %d of %d: %s", p.Doc, p.Docs, p.Name)
+				if p.Chunks > 0 {
+					msg += fmt.Sprintf(", chunk %d of %d", p.Chunk, p.Chunks)
+				}
+				ui.SetProcessingMessage(msg + "...")
+			})
+			summary := batchSummary(results, len(docs))
+			var failed []string
+			for _, r := range results {
+				if r.Err != nil {
+					failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(r.Source), r.Err))
+				}
+			}
+			if len(failed) > 0 {
+				ui.ShowError(summary + " – " + strings.Join(failed, "; "))
+			} else {
+				ui.ShowSuccess(summary)
+			}
+			fyne.CurrentApp().SendNotification(&fyne.Notification{Title: "Batch complete", Content: summary})
+		}()
+	}, ui.Window)
+}
+
+// batchCommand runs "batch [flags] DIR" from the command line and returns the
+// exit code, see printResults.
+func batchCommand(ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store, defaultProvider string, args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s batch [flags] DIR\n\nConverts every %s file in DIR into an audio file of the same name.\n\nFlags:\n",
+			filepath.Base(os.Args[0]), strings.Join(batchExtensions, ", "))
+		fs.PrintDefaults()
+	}
+	job := batchJob{}
+	jobFlags(fs, &job, defaultProvider, settings)
+	fs.StringVar(&job.OutDir, "out", "", "output folder (default: the output folder of the settings, or Downloads)")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitInvalid
+	}
+	if code := startCommand(ttsManager, job); code != exitOK {
+		return code
+	}
+	if job.OutDir != "" {
+		if err := os.MkdirAll(job.OutDir, 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitInvalid
+		}
+	}
+	docs, err := batchDocuments(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalid
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := runBatch(ctx, ttsManager, settings, jobHistory, job, docs, func(p batchProgress) {
+		fmt.Fprintf(os.Stderr, "\r[%3.0f%%] %d/%d %s: chunk %d of %d\033[K", 100*p.Fraction, p.Doc, p.Docs, p.Name, p.Chunk, p.Chunks)
+	})
+	fmt.Fprintln(os.Stderr)
+	return printResults(results, len(docs))
+}
+
+// printResults prints the results of a batch of docs documents, of which fewer
+// have results if it was interrupted, and returns the exit code: exitOK when
+// every document was converted, exitPartial when only some were, otherwise the
+// code for the first document that failed.
+func printResults(results []batchResult, docs int) int {
+	converted, code := 0, exitOK
+	for _, r := range results {
+		switch {
+		case r.Err != nil && r.Output != "":
+			fmt.Printf("%s -> %s (%v)\n", r.Source, r.Output, r.Err)
+		case r.Err != nil:
+			fmt.Printf("%s: %v\n", r.Source, r.Err)
+		default:
+			fmt.Printf("%s -> %s\n", r.Source, r.Output)
+		}
+		if r.Output != "" {
+			converted++
+		}
+		if r.Err != nil && code == exitOK {
+			code = exitCodeFor(r.Output, r.Err)
+		}
+	}
+	switch {
+	case converted > 0 && (code != exitOK || len(results) < docs):
+		code = exitPartial
+	case converted == 0 && len(results) < docs && code == exitOK:
+		code = exitFailed // interrupted before the first document
+	}
+	fmt.Fprintln(os.Stderr, batchSummary(results, docs))
+	return code
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/config"
+	"easy-tts/internal/history"
+	"easy-tts/internal/manifest"
+	"easy-tts/internal/preprocess"
+	"easy-tts/internal/tts"
+	"easy-tts/internal/util"
+)
+
+// manifestJobs turns the jobs of m into batch jobs, each writing an explicit
+// output so a rebuild replaces the files of the last build.
+func manifestJobs(m *manifest.Manifest, settings *config.Settings, defaultProvider string) ([]batchJob, error) {
+	jobs := make([]batchJob, len(m.Jobs))
+	for i := range m.Jobs {
+		d := m.Settings(i)
+		job := batchJob{Provider: d.Provider, Voice: d.Voice, Speed: d.Speed, Style: d.Style, Instructions: d.Instructions, Format: d.Format}
+		if job.Provider == "" {
+			job.Provider = defaultProvider
+		}
+		if job.Speed == 0 {
+			job.Speed = defaultSpeed(settings)
+		}
+		name := m.Jobs[i].Output
+		if job.Format == "" {
+			job.Format = strings.TrimPrefix(filepath.Ext(name), ".")
+		}
+		if job.Format != "" && !strings.Contains(" mp3 wav ogg ", " "+audio.NormalizeFormat(job.Format)+" ") {
+			return nil, fmt.Errorf("job %d: unsupported output format %q, use mp3, wav or ogg", i+1, job.Format)
+		}
+		if name == "" {
+			source := m.Jobs[i].Source()
+			format := job.Format
+			if format == "" {
+				format = settings.OutputFormat
+			}
+			name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)) + "." + audio.NormalizeFormat(format)
+		}
+		if m.OutDir != "" {
+			job.Output = filepath.Join(m.OutDir, name)
+		} else {
+			var err error
+			if job.Output, err = util.OutputPath(settings.OutputDir, name); err != nil {
+				return nil, err
+			}
+		}
+		jobs[i] = job
+	}
+	return jobs, nil
+}
+
+// chapterSettings returns settings that start a new file at every top-level
+// heading, which a chapter title of a manifest becomes.
+func chapterSettings(settings *config.Settings) *config.Settings {
+	s := *settings
+	s.HeadingStyles = maps.Clone(settings.HeadingStyles)
+	if s.HeadingStyles == nil {
+		s.HeadingStyles = map[int]preprocess.HeadingStyle{}
+	}
+	style := s.HeadingStyles[1]
+	style.Split = true
+	s.HeadingStyles[1] = style
+	return &s
+}
+
+// buildCommand runs "build [flags] MANIFEST" from the command line, converting
+// every job of a manifest, see package manifest. It returns the exit code, see
+// printResults.
+func buildCommand(ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store, defaultProvider string, args []string) int {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s build [flags] MANIFEST\n\nConverts the jobs of a YAML or JSON manifest, such as the chapters of an\naudiobook, replacing the outputs of the last build.\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "list the jobs and their outputs without converting")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitInvalid
+	}
+	m, err := manifest.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalid
+	}
+	jobs, err := manifestJobs(m, settings, defaultProvider)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalid
+	}
+	if *dryRun {
+		for i, job := range jobs {
+			fmt.Printf("%s -> %s (%s)\n", m.Jobs[i].Source(), job.Output, job.Provider)
+		}
+		return exitOK
+	}
+	checked := map[string]bool{}
+	for _, job := range jobs {
+		if checked[job.Provider] {
+			continue
+		}
+		if code := startCommand(ttsManager, job); code != exitOK {
+			return code
+		}
+		checked[job.Provider] = true
+	}
+	if m.OutDir != "" {
+		if err := os.MkdirAll(m.OutDir, 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var results []batchResult
+	for i, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		source := m.Jobs[i].Source()
+		jobSettings := settings
+		if m.Jobs[i].SplitChapters {
+			jobSettings = chapterSettings(settings)
+		}
+		text, err := m.Jobs[i].Text()
+		if err == nil && strings.TrimSpace(text) == "" {
+			err = errEmptyDocument
+		}
+		output := ""
+		if err == nil {
+			output, err = synthesizeText(ctx, ttsManager, jobSettings, jobHistory, job, source, text, func(completed, chunks int) {
+				fmt.Fprintf(os.Stderr, "\r%d/%d %s: chunk %d of %d\033[K", i+1, len(jobs), filepath.Base(job.Output), completed, chunks)
+			})
+		}
+		results = append(results, batchResult{Source: source, Output: output, Err: err})
+	}
+	fmt.Fprintln(os.Stderr)
+	return printResults(results, len(jobs))
+}
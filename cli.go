@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/config"
+	"easy-tts/internal/history"
+	"easy-tts/internal/tts"
+)
+
+// Exit codes of the commands, so scripts can tell why a conversion failed.
+const (
+	exitOK      = 0
+	exitFailed  = 1 // Nothing was converted, for another reason than below
+	exitInvalid = 2 // Invalid arguments or input, or a request the provider rejected
+	exitPartial = 3 // Converted, but some sections or documents failed
+	exitAuth    = 4 // No provider configured, or credentials missing or rejected
+	exitQuota   = 5 // The quota or rate limit of the provider is exhausted
+)
+
+// exitCodeFor returns the exit code of a conversion that saved output, "" if
+// nothing, and returned err.
+func exitCodeFor(output string, err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case output != "" || errors.Is(err, errSectionsFailed):
+		return exitPartial
+	case errors.Is(err, errEmptyDocument) || errors.Is(err, os.ErrNotExist):
+		return exitInvalid
+	}
+	switch tts.ErrorType(err) {
+	case tts.ErrorTypeQuota, tts.ErrorTypeRateLimit:
+		return exitQuota
+	case tts.ErrorTypeAuth:
+		return exitAuth
+	case tts.ErrorTypeRequest:
+		return exitInvalid
+	}
+	return exitFailed
+}
+
+// runCommand runs the subcommand name with args from the command line instead of
+// opening the window. It returns the exit code, or false if name is none.
+func runCommand(ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store, defaultProvider, name string, args []string) (int, bool) {
+	switch name {
+	case "synth":
+		return synthCommand(ttsManager, settings, jobHistory, defaultProvider, args), true
+	case "batch":
+		return batchCommand(ttsManager, settings, jobHistory, defaultProvider, args), true
+	case "build":
+		return buildCommand(ttsManager, settings, jobHistory, defaultProvider, args), true
+	case "voices":
+		return voicesCommand(ttsManager, args), true
+	case "serve":
+		return serveCommand(ttsManager, settings, jobHistory, defaultProvider, args), true
+	case "submit":
+		return submitCommand(settings, args), true
+	case "config":
+		return configCommand(settings, args), true
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nWithout a command the window opens. Commands:\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "  synth   convert one document into an audio file")
+		fmt.Fprintln(os.Stderr, "  batch   convert every document of a folder")
+		fmt.Fprintln(os.Stderr, "  build   convert the jobs of a manifest, e.g. the chapters of an audiobook")
+		fmt.Fprintln(os.Stderr, "  voices  list the voices of the providers")
+		fmt.Fprintln(os.Stderr, "  serve   run a server with a job queue kept on disk, and a local API for other apps")
+		fmt.Fprintln(os.Stderr, "  submit  queue documents with a running server")
+		fmt.Fprintln(os.Stderr, "  config  show or change API keys, the default provider and the output folder")
+		fmt.Fprintf(os.Stderr, "\nRun \"%s COMMAND -h\" for the flags of a command.\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "\nExit codes: 0 success, 1 failure, 2 invalid arguments or input, 3 partial success,")
+		fmt.Fprintln(os.Stderr, "4 missing or rejected credentials, 5 quota or rate limit exhausted.")
+		return 0, true
+	}
+	return 0, false
+}
+
+// jobFlags registers the flags shared by the commands that synthesize, filling job.
+func jobFlags(fs *flag.FlagSet, job *batchJob, defaultProvider string, settings *config.Settings) {
+	fs.StringVar(&job.Provider, "provider", defaultProvider, "TTS provider")
+	fs.StringVar(&job.Voice, "voice", "", "voice (default: the voice of the config file, or the provider's default voice)")
+	fs.Float64Var(&job.Speed, "speed", defaultSpeed(settings), "speaking speed")
+	fs.StringVar(&job.Style, "style", "", "speaking style, where the provider supports it")
+	fs.StringVar(&job.Instructions, "instructions", "", "speaking instructions for OpenAI voices")
+	fs.StringVar(&job.Model, "model", "", "model, e.g. tts-1-hd for OpenAI (default: the model of the settings, or the provider's default)")
+}
+
+// defaultSpeed is the speed of jobs that choose none: that of the config file,
+// or 1.0.
+func defaultSpeed(settings *config.Settings) float64 {
+	if settings.DefaultSpeed != 0 {
+		return settings.DefaultSpeed
+	}
+	return 1.0
+}
+
+// startCommand prepares a command once its flags are parsed: the log only shows
+// warnings unless asked for, as the progress goes to the terminal, and the
+// provider of job must be configured or "demo". It returns exitOK, or the exit
+// code after reporting a problem.
+func startCommand(ttsManager *tts.Manager, job batchJob) int {
+	if os.Getenv("QUACKER_LOG_LEVEL") == "" {
+		os.Setenv("QUACKER_LOG_LEVEL", "warn")
+		setupLogging()
+	}
+	if job.Provider == "" {
+		fmt.Fprintln(os.Stderr, "No TTS provider configured. Please configure at least one provider.")
+		return exitAuth
+	}
+	return checkProvider(ttsManager, job.Provider)
+}
+
+// checkProvider returns exitOK if the provider name exists and is configured,
+// or the exit code after reporting why not. "demo" enables the demo provider.
+func checkProvider(ttsManager *tts.Manager, name string) int {
+	if name == "demo" {
+		// The credential-free demo voice, e.g. to try a pipeline
+		ttsManager.EnableDemo()
+	}
+	provider, err := ttsManager.GetProvider(name)
+	if err != nil && (name == "openai" || name == "google") {
+		// Known, but only set up once its credentials are
+		fmt.Fprintf(os.Stderr, "Provider '%s' is not configured, see \"%s config\"\n", name, filepath.Base(os.Args[0]))
+		return exitAuth
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalid
+	}
+	if err := provider.ValidateConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Provider '%s' configuration error: %v\n", name, err)
+		return exitAuth
+	}
+	return exitOK
+}
+
+// synthCommand runs "synth [flags] -input FILE" from the command line and
+// returns the exit code, see exitCodeFor; a document streamed to stdout with some
+// sections missing is a partial success too. An input of "-" is read
+// from stdin and, without -out, the audio goes to stdout, so the command can
+// be part of a pipeline.
+func synthCommand(ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store, defaultProvider string, args []string) int {
+	fs := flag.NewFlagSet("synth", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s synth [flags] -input FILE\n\nConverts a text or Markdown document into an audio file. With FILE \"-\" the\ndocument is read from stdin and the audio written to stdout:\n\n  cat notes.md | %[1]s synth - > notes.mp3\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	job := batchJob{}
+	var input string
+	fs.StringVar(&input, "input", "", "document to convert (or the first argument); \"-\" reads stdin")
+	jobFlags(fs, &job, defaultProvider, settings)
+	fs.StringVar(&job.Output, "out", "", "audio file to write, replaced if it exists, or \"-\" for stdout; its extension selects the format (default: named after the input in the output folder, stdout for input from stdin)")
+	fs.StringVar(&job.Format, "format", "", "output format: mp3, wav or ogg (default: the extension of -out, or the setting)")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if input == "" && fs.NArg() == 1 {
+		input = fs.Arg(0)
+	} else if input == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitInvalid
+	}
+	if job.Output == "" && input == "-" {
+		job.Output = "-"
+	}
+	if job.Format == "" && job.Output != "-" {
+		job.Format = strings.TrimPrefix(filepath.Ext(job.Output), ".")
+	}
+	if job.Format != "" && !strings.Contains(" mp3 wav ogg ", " "+audio.NormalizeFormat(job.Format)+" ") {
+		fmt.Fprintf(os.Stderr, "Unsupported output format %q, use mp3, wav or ogg\n", job.Format)
+		return exitInvalid
+	}
+	if code := startCommand(ttsManager, job); code != exitOK {
+		return code
+	}
+	if input != "-" {
+		if _, err := os.Stat(input); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitInvalid
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	output, err := synthesizeDocument(ctx, ttsManager, settings, jobHistory, job, input, func(completed, chunks int) {
+		fmt.Fprintf(os.Stderr, "\r[%3.0f%%] chunk %d of %d\033[K", 100*float64(completed)/float64(chunks), completed, chunks)
+	})
+	fmt.Fprintln(os.Stderr)
+	if output != "" {
+		fmt.Println(output)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", input, err)
+	}
+	return exitCodeFor(output, err)
+}
+
+// voicesCommand runs "voices [flags]" from the command line, listing the voices
+// of every configured provider or the one asked for, and returns the exit code:
+// exitOK when all providers could be asked, otherwise the code for the first
+// that failed.
+func voicesCommand(ttsManager *tts.Manager, args []string) int {
+	fs := flag.NewFlagSet("voices", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s voices [flags]\n\nLists the voices of the configured providers.\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	providerName := fs.String("provider", "", "list only the voices of this provider")
+	language := fs.String("language", "", "only voices speaking this language, e.g. de or de-DE")
+	gender := fs.String("gender", "", "only voices of this gender: female, male or neutral")
+	asJSON := fs.Bool("json", false, "print the voices as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitInvalid
+	}
+	if os.Getenv("QUACKER_LOG_LEVEL") == "" {
+		os.Setenv("QUACKER_LOG_LEVEL", "warn")
+		setupLogging()
+	}
+	providers := ttsManager.GetAvailableProviders()
+	if *providerName != "" {
+		if code := checkProvider(ttsManager, *providerName); code != exitOK {
+			return code
+		}
+		providers = []string{*providerName}
+	}
+	if len(providers) == 0 {
+		fmt.Fprintln(os.Stderr, "No TTS provider configured. Please configure at least one provider.")
+		return exitAuth
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	code := exitOK
+	voices := []tts.VoiceInfo{}
+	for _, name := range providers {
+		listed, err := ttsManager.ListVoices(ctx, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			if code == exitOK {
+				code = exitCodeFor("", err)
+			}
+			continue
+		}
+		voices = append(voices, tts.FilterVoices(listed, *language, *gender)...)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(voices); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		return code
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tVOICE\tLANGUAGE\tGENDER")
+	for _, v := range voices {
+		language := v.LanguageCode
+		if language == "" {
+			language = "any"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Provider, v.Name, language, v.Gender)
+	}
+	w.Flush()
+	return code
+}
+
+// outputDirKey names Settings.OutputDir for the config command; the other keys
+// are kept in the keychain, see config.KeychainKeys.
+const outputDirKey = "output-dir"
+
+// configCommand runs "config list|get|set|delete" from the command line, so a
+// machine without a display can be set up, and returns the exit code: 0 on
+// success, 1 when the keychain or settings failed and 2 for invalid arguments.
+func configCommand(settings *config.Settings, args []string) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	keys := append(config.KeychainKeys(), outputDirKey)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s config list\n       %[1]s config get [-reveal] KEY\n       %[1]s config set KEY VALUE\n       %[1]s config delete KEY\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(fs.Output(), "Keys: %s\n\nA VALUE of \"-\" is read from stdin, keeping secrets out of the shell history.\n\nFlags:\n", strings.Join(keys, ", "))
+		fs.PrintDefaults()
+	}
+	reveal := fs.Bool("reveal", false, "show secrets in full")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	args = fs.Args()
+	want := map[string]int{"list": 1, "get": 2, "set": 3, "delete": 2}
+	if len(args) == 0 || want[args[0]] != len(args) || (len(args) > 1 && !slices.Contains(keys, args[1])) {
+		fs.Usage()
+		return exitInvalid
+	}
+	show := func(key, value string) string {
+		if value != "" && config.IsSecretKey(key) && !*reveal {
+			return maskSecret(value)
+		}
+		return value
+	}
+
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		code := exitOK
+		for _, key := range keys {
+			value, err := configValue(settings, key)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", key, err)
+				code = exitFailed
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\n", key, show(key, value))
+		}
+		w.Flush()
+		if store := config.SecretStore(); store != "keychain" {
+			fmt.Fprintf(os.Stderr, "No keychain available, secrets are kept encrypted in %s\n", store)
+		}
+		return code
+	case "get":
+		value, err := configValue(settings, args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		fmt.Println(show(args[1], value))
+		return exitOK
+	case "set":
+		value := args[2]
+		if value == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitFailed
+			}
+			value = string(data)
+		}
+		value = strings.TrimSpace(value)
+		if err := validateConfigValue(args[1], value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitInvalid
+		}
+		if err := setConfigValue(settings, args[1], value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		return exitOK
+	default:
+		if err := setConfigValue(settings, args[1], ""); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		return exitOK
+	}
+}
+
+// configValue returns the value of a config command key.
+func configValue(settings *config.Settings, key string) (string, error) {
+	if key == outputDirKey {
+		return settings.OutputDir, nil
+	}
+	return config.GetKeychainValue(key)
+}
+
+// setConfigValue stores a config command key; an empty value removes it.
+func setConfigValue(settings *config.Settings, key, value string) error {
+	if key == outputDirKey {
+		if value != "" {
+			// Later runs start from other directories
+			abs, err := filepath.Abs(value)
+			if err != nil {
+				return fmt.Errorf("cannot resolve the output folder %s: %w", value, err)
+			}
+			value = abs
+		}
+		settings.OutputDir = value
+		return config.SaveSettings(settings)
+	}
+	if value == "" {
+		return config.DeleteKeychainValue(key)
+	}
+	if key == "google-credentials" && !strings.HasPrefix(value, "{") {
+		// The key file is read from wherever Quacker runs
+		if abs, err := filepath.Abs(value); err == nil {
+			value = abs
+		}
+	}
+	return config.SetKeychainValue(key, value)
+}
+
+// validateConfigValue checks a value for a config command key before it is stored.
+func validateConfigValue(key, value string) error {
+	switch key {
+	case "default-provider":
+		if value != "openai" && value != "google" {
+			return fmt.Errorf("%s must be openai or google", key)
+		}
+	case "google-auth-method":
+		if value != "gcloud auth" && value != "API Key" && value != "Service Account" && value != "Google Account" {
+			return fmt.Errorf("%s must be \"gcloud auth\", \"API Key\", \"Service Account\" or \"Google Account\"", key)
+		}
+	case "google-credentials":
+		if _, err := config.GoogleCredentialsProjectID(value); err != nil {
+			return fmt.Errorf("%s must be a service account key file or its JSON: %w", key, err)
+		}
+	case outputDirKey:
+		if info, err := os.Stat(value); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a folder", value)
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("no value for %s, use \"config delete %[1]s\" to remove it", key)
+	}
+	return nil
+}
+
+// maskSecret shows only the start and end of a secret.
+func maskSecret(value string) string {
+	if len(value) < 12 {
+		return "****"
+	}
+	return value[:3] + "..." + value[len(value)-4:]
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"easy-tts/api/quackerpb"
+	"easy-tts/internal/queue"
+	"easy-tts/internal/tts"
+)
+
+// grpcChunkSize caps the audio of one message, well below the 4 MB gRPC limit.
+const grpcChunkSize = 64 << 10
+
+// grpcService serves the jobs and voices of the server over gRPC.
+type grpcService struct {
+	quackerpb.UnimplementedSynthesizerServer
+	s *server
+}
+
+// newGRPCServer returns a gRPC server for s, which checks its token if set and
+// otherwise only serves connections to a loopback address.
+func newGRPCServer(s *server) *grpc.Server {
+	authorized := func(ctx context.Context) error {
+		if s.token == "" {
+			if p, ok := peer.FromContext(ctx); !ok || !isLoopbackAddr(p.LocalAddr) {
+				return status.Error(codes.PermissionDenied, "without a token only 127.0.0.1 and ::1 are served")
+			}
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			if s.validToken(v) {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorized(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorized(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	quackerpb.RegisterSynthesizerServer(srv, &grpcService{s: s})
+	return srv
+}
+
+// Synthesize runs the request right away, next to the queued jobs, and streams
+// the audio and progress back. Nothing is saved on the server.
+func (g *grpcService) Synthesize(req *quackerpb.SynthesizeRequest, stream grpc.ServerStreamingServer[quackerpb.SynthesizeResponse]) error {
+	job, err := g.s.newJob(synthesizeRequestFrom(req))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	// Progress is reported from the workers of the processor
+	var mu sync.Mutex
+	send := func(resp *quackerpb.SynthesizeResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(resp)
+	}
+	job.Output = "-"
+	job.Stream = audioStream(send)
+	_, err = synthesizeText(stream.Context(), g.s.ttsManager, g.s.settings, nil, job, "", req.Text, func(completed, chunks int) {
+		send(&quackerpb.SynthesizeResponse{Event: &quackerpb.SynthesizeResponse_Progress{
+			Progress: &quackerpb.Progress{Chunk: int32(completed), Chunks: int32(chunks)},
+		}})
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return status.FromContextError(err).Err()
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// SubmitJob queues the request like POST /synthesize.
+func (g *grpcService) SubmitJob(_ context.Context, req *quackerpb.SynthesizeRequest) (*quackerpb.Job, error) {
+	if _, err := g.s.newJob(synthesizeRequestFrom(req)); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	j, err := g.s.submit(synthesizeRequestFrom(req))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return jobProto(j), nil
+}
+
+func (g *grpcService) GetJob(_ context.Context, req *quackerpb.JobRequest) (*quackerpb.Job, error) {
+	j, ok := g.s.job(req.Id)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such job")
+	}
+	return jobProto(j), nil
+}
+
+// WatchJob sends the job, then again every time it changes until it is done
+// or failed.
+func (g *grpcService) WatchJob(req *quackerpb.JobRequest, stream grpc.ServerStreamingServer[quackerpb.Job]) error {
+	for {
+		changed := g.s.queue.Changed()
+		j, ok := g.s.job(req.Id)
+		if !ok {
+			return status.Error(codes.NotFound, "no such job")
+		}
+		if err := stream.Send(jobProto(j)); err != nil {
+			return err
+		}
+		if j.Status == queue.Done || j.Status == queue.Failed {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func (g *grpcService) ListVoices(ctx context.Context, req *quackerpb.ListVoicesRequest) (*quackerpb.ListVoicesResponse, error) {
+	providers := g.s.ttsManager.GetAvailableProviders()
+	if req.Provider != "" {
+		if err := g.s.ttsManager.ValidateProvider(req.Provider); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		providers = []string{req.Provider}
+	}
+	resp := &quackerpb.ListVoicesResponse{}
+	for _, name := range providers {
+		listed, err := g.s.ttsManager.ListVoices(ctx, name)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "%s: %v", name, err)
+		}
+		for _, v := range tts.FilterVoices(listed, req.Language, req.Gender) {
+			resp.Voices = append(resp.Voices, &quackerpb.Voice{
+				Name:         v.Name,
+				DisplayName:  v.DisplayName,
+				LanguageCode: v.LanguageCode,
+				Gender:       v.Gender,
+				Provider:     v.Provider,
+			})
+		}
+	}
+	return resp, nil
+}
+
+func synthesizeRequestFrom(req *quackerpb.SynthesizeRequest) synthesizeRequest {
+	return synthesizeRequest{
+		Text:         req.Text,
+		Provider:     req.Provider,
+		Voice:        req.Voice,
+		Speed:        req.Speed,
+		Style:        req.Style,
+		Instructions: req.Instructions,
+		Format:       req.Format,
+	}
+}
+
+func jobProto(j serverJob) *quackerpb.Job {
+	pb := &quackerpb.Job{
+		Id:       j.ID,
+		Status:   j.Status,
+		Provider: j.Provider,
+		Voice:    j.Voice,
+		Progress: &quackerpb.Progress{Chunk: int32(j.Chunk), Chunks: int32(j.Chunks)},
+		Output:   j.Output,
+		Error:    j.Error,
+		Created:  timestamppb.New(j.Created),
+	}
+	if j.Finished != nil {
+		pb.Finished = timestamppb.New(*j.Finished)
+	}
+	return pb
+}
+
+// audioStream sends what is written to it as audio messages.
+type audioStream func(*quackerpb.SynthesizeResponse) error
+
+func (a audioStream) Write(p []byte) (int, error) {
+	for written := 0; written < len(p); {
+		n := min(len(p)-written, grpcChunkSize)
+		if err := a(&quackerpb.SynthesizeResponse{Event: &quackerpb.SynthesizeResponse_Audio{Audio: p[written : written+n]}}); err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return len(p), nil
+}
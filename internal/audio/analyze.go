@@ -0,0 +1,235 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Analysis holds simple acoustic metrics for an assembled output file.
+type Analysis struct {
+	Duration       time.Duration
+	LongestSilence time.Duration
+	LoudnessRange  float64 // dB between quiet (10th percentile) and loud (95th percentile) passages
+	HasLevels      bool    // False when the format cannot be analyzed for silence and loudness
+}
+
+// silenceThresholdDB is the level below which a PCM window counts as silence.
+const silenceThresholdDB = -50.0
+
+// Analyze measures duration, longest silence and loudness range of data.
+// WAV is measured from its PCM samples; MP3 levels are approximated from each
+// granule's global gain, which avoids a full decode. Other formats report only duration.
+func Analyze(data []byte) (*Analysis, error) {
+	switch Sniff(data) {
+	case "wav":
+		return analyzeWAV(data)
+	case "mp3":
+		return analyzeMP3(data), nil
+	}
+	info, err := Probe(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Analysis{Duration: info.Duration}, nil
+}
+
+// levelStats turns a sequence of window levels (dB, or NaN for silence) into an Analysis.
+func levelStats(levels []float64, window time.Duration) *Analysis {
+	a := &Analysis{Duration: time.Duration(len(levels)) * window, HasLevels: true}
+	var loud []float64
+	run := 0
+	for _, l := range levels {
+		if math.IsNaN(l) {
+			run++
+			if d := time.Duration(run) * window; d > a.LongestSilence {
+				a.LongestSilence = d
+			}
+			continue
+		}
+		run = 0
+		loud = append(loud, l)
+	}
+	if len(loud) > 1 {
+		sort.Float64s(loud)
+		low := loud[len(loud)*10/100]
+		high := loud[len(loud)*95/100]
+		a.LoudnessRange = high - low
+	}
+	return a
+}
+
+func analyzeWAV(data []byte) (*Analysis, error) {
+	format, payload, err := ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if format.AudioFormat != 1 || format.BitsPerSample != 16 || format.Channels == 0 {
+		// Companded or unusual PCM: report the duration only.
+		info, err := probeWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Analysis{Duration: info.Duration}, nil
+	}
+
+	window := 20 * time.Millisecond
+	frameBytes := int(format.BlockAlign)
+	perWindow := int(format.SampleRate) * int(window/time.Millisecond) / 1000 * frameBytes
+	if perWindow <= 0 {
+		return nil, fmt.Errorf("%w: bad WAV block alignment", ErrInvalidAudio)
+	}
+	var levels []float64
+	for start := 0; start+perWindow <= len(payload); start += perWindow {
+		var sum float64
+		n := 0
+		for i := start; i+1 < start+perWindow; i += 2 {
+			s := float64(int16(binary.LittleEndian.Uint16(payload[i:]))) / 32768
+			sum += s * s
+			n++
+		}
+		rms := math.Sqrt(sum / float64(n))
+		db := 20 * math.Log10(rms+1e-12)
+		if db < silenceThresholdDB {
+			db = math.NaN()
+		}
+		levels = append(levels, db)
+	}
+	return levelStats(levels, window), nil
+}
+
+// analyzeMP3 walks all Layer III granules, tolerating ID3 tags and junk between
+// concatenated chunks, and uses global_gain (1.5 dB per step) as a level estimate.
+func analyzeMP3(data []byte) *Analysis {
+	var levels []float64
+	var window time.Duration
+	pos := 0
+	for pos < len(data) {
+		if bytes.HasPrefix(data[pos:], []byte("ID3")) {
+			skip := SkipID3v2(data[pos:])
+			if skip == 0 {
+				skip = 3
+			}
+			pos += skip
+			continue
+		}
+		frame, ok := ParseMP3Frame(data[pos:])
+		if !ok || pos+frame.Length > len(data) {
+			pos++ // resynchronize on the next frame header
+			continue
+		}
+		granules := mp3Granules(data[pos : pos+frame.Length])
+		if len(granules) > 0 {
+			window = time.Duration(float64(frame.Samples) / float64(len(granules)) / float64(frame.SampleRate) * float64(time.Second))
+		}
+		for _, g := range granules {
+			if g.silent {
+				levels = append(levels, math.NaN())
+			} else {
+				levels = append(levels, 1.5*float64(g.globalGain-210))
+			}
+		}
+		pos += frame.Length
+	}
+	if window == 0 {
+		return &Analysis{}
+	}
+	return levelStats(levels, window)
+}
+
+type mp3Granule struct {
+	globalGain int
+	silent     bool
+}
+
+// mp3Granules reads the side information of a Layer III frame. Channels of a
+// granule are merged: the granule is silent only if every channel is empty.
+func mp3Granules(frame []byte) []mp3Granule {
+	if len(frame) < 6 || (frame[1]>>1)&3 != 1 {
+		return nil // not Layer III
+	}
+	mpeg1 := (frame[1]>>3)&3 == 3
+	mono := frame[3]>>6 == 3
+	channels := 2
+	if mono {
+		channels = 1
+	}
+	pos := 4
+	if frame[1]&1 == 0 {
+		pos += 2 // CRC
+	}
+	r := &bitReader{data: frame[pos:]}
+
+	granuleCount, blockBits := 1, 63
+	if mpeg1 {
+		granuleCount, blockBits = 2, 59
+		r.skip(9)
+		if mono {
+			r.skip(5)
+		} else {
+			r.skip(3)
+		}
+		r.skip(4 * channels) // scfsi
+	} else {
+		r.skip(8)
+		if mono {
+			r.skip(1)
+		} else {
+			r.skip(2)
+		}
+	}
+
+	granules := make([]mp3Granule, 0, granuleCount)
+	for gr := 0; gr < granuleCount; gr++ {
+		g := mp3Granule{silent: true}
+		for ch := 0; ch < channels; ch++ {
+			part23 := r.read(12)
+			bigValues := r.read(9)
+			gain := r.read(8)
+			r.skip(blockBits - 29)
+			if r.overrun {
+				return granules
+			}
+			if part23 > 0 && bigValues > 0 {
+				g.silent = false
+			}
+			if gain > g.globalGain {
+				g.globalGain = gain
+			}
+		}
+		granules = append(granules, g)
+	}
+	return granules
+}
+
+// bitReader reads big-endian bit fields.
+type bitReader struct {
+	data    []byte
+	pos     int
+	overrun bool
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		byteIdx := r.pos / 8
+		if byteIdx >= len(r.data) {
+			r.overrun = true
+			return 0
+		}
+		bit := (r.data[byteIdx] >> (7 - uint(r.pos%8))) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) skip(n int) {
+	r.pos += n
+	if r.pos > len(r.data)*8 {
+		r.overrun = true
+	}
+}
@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// beepFrequency and beepLevel give a clearly audible but not harsh tone: 880 Hz
+// at a quarter of full scale, faded in and out over beepFade to avoid clicks.
+const (
+	beepFrequency = 880.0
+	beepLevel     = 0.25
+	beepFade      = 10 * time.Millisecond
+)
+
+// Beep returns a d long tone in the same format as like, so it can be joined
+// with neighbouring chunks. Only 16-bit PCM WAV is supported; compressed formats
+// would need an encoder.
+func Beep(like []byte, d time.Duration) ([]byte, error) {
+	if Sniff(like) != "wav" {
+		return nil, fmt.Errorf("beeps are not supported for %q audio", Sniff(like))
+	}
+	format, _, err := ParseWAV(like)
+	if err != nil {
+		return nil, err
+	}
+	if format.AudioFormat != 1 || format.BitsPerSample != 16 || format.Channels == 0 {
+		return nil, fmt.Errorf("beeps are only supported for 16-bit PCM WAV audio")
+	}
+	frames := int(float64(format.SampleRate) * d.Seconds())
+	fade := int(float64(format.SampleRate) * beepFade.Seconds())
+	channels := int(format.Channels)
+	data := make([]byte, frames*channels*2)
+	for i := 0; i < frames; i++ {
+		gain := beepLevel
+		if edge := min(i, frames-1-i); edge < fade {
+			gain *= float64(edge) / float64(fade)
+		}
+		sample := int16(gain * math.MaxInt16 * math.Sin(2*math.Pi*beepFrequency*float64(i)/float64(format.SampleRate)))
+		for c := 0; c < channels; c++ {
+			binary.LittleEndian.PutUint16(data[(i*channels+c)*2:], uint16(sample))
+		}
+	}
+	return append(WAVHeader(format, len(data)), data...), nil
+}
@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// SplitTail splits data, MP3 or 16-bit PCM WAV audio, before its last d. head
+// is the audio before in its own format, cut between MP3 frames or WAV samples
+// so it is not re-encoded; tail is the rest decoded, for Crossfade to mix into
+// the audio that follows.
+func SplitTail(data []byte, d time.Duration) (head []byte, tail *PCM, err error) {
+	format := Sniff(data)
+	if format == "wav" {
+		if f, _, err := ParseWAV(data); err == nil && (f.AudioFormat != 1 || f.BitsPerSample != 16) {
+			return nil, nil, fmt.Errorf("crossfading WAV format %d with %d bits per sample is not supported", f.AudioFormat, f.BitsPerSample)
+		}
+	}
+	pcm, err := Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	keep := pcm.frames() - int(d.Seconds()*float64(pcm.SampleRate))
+	if keep <= 0 {
+		return nil, nil, errors.New("audio is shorter than the crossfade")
+	}
+	switch format {
+	case "mp3":
+		pos, frames := SkipID3v2(data), 0
+		for {
+			frame, ok := ParseMP3Frame(data[pos:])
+			if !ok || pos+frame.Length > len(data) || frames+frame.Samples > keep {
+				break
+			}
+			pos += frame.Length
+			frames += frame.Samples
+		}
+		head, keep = data[:pos], frames
+	case "wav":
+		format, payload, _ := ParseWAV(data)
+		n := min(keep*int(format.BlockAlign), len(payload))
+		head = append(WAVHeader(format, n), payload[:n]...)
+	}
+	tail = &PCM{SampleRate: pcm.SampleRate, Channels: pcm.Channels, Samples: pcm.Samples[keep*pcm.Channels:]}
+	return head, tail, nil
+}
+
+// Crossfade returns next, MP3 or WAV audio, with tail mixed into its start:
+// tail fades out linearly as next fades in, so there is no hard cut between
+// them. The result has the sample rate and channels of tail; MP3 is re-encoded
+// at bitrate kbit/s.
+func Crossfade(tail *PCM, next []byte, bitrate int) ([]byte, error) {
+	pcm, err := Decode(next)
+	if err != nil {
+		return nil, err
+	}
+	pcm = pcm.Remix(tail.Channels).Resample(tail.SampleRate)
+	n := len(tail.Samples)
+	out := &PCM{SampleRate: tail.SampleRate, Channels: tail.Channels, Samples: make([]int16, max(n, len(pcm.Samples)))}
+	copy(out.Samples, pcm.Samples)
+	for i := 0; i < n; i++ {
+		gain := float64(i/tail.Channels) / float64(tail.frames())
+		s := float64(tail.Samples[i])*(1-gain) + float64(out.Samples[i])*gain
+		out.Samples[i] = int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(s))))
+	}
+	return out.Encode(Sniff(next), bitrate)
+}
@@ -0,0 +1,145 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// MP3 frames can simply be appended to each other, but a WAV file has a single
+// header whose sizes cover all of its samples. Assembled WAV audio is therefore
+// kept as a canonical header followed by the samples of every piece: Piece drops
+// the header of every piece but the first, and FixHeaders sets the sizes once
+// all pieces are in. Ogg Opus audio is assembled the same way, see ogg.go.
+
+// wavHeaderSize is the size of the canonical header written by WAVHeader.
+const wavHeaderSize = 44
+
+// Piece returns data as it is appended to assembled audio at offset. A WAV piece
+// after the first loses its header; the first gets a canonical header, since
+// providers may send streaming headers with unknown sizes or extra chunks. An Ogg
+// piece after the first loses its header pages. Other formats are returned as
+// they are.
+func Piece(data []byte, offset int) []byte {
+	switch Sniff(data) {
+	case "ogg":
+		if h := oggHeaderEnd(data); offset > 0 && h > 0 {
+			return data[h:]
+		}
+		return data
+	case "wav":
+		return wavPiece(data, offset)
+	}
+	return data
+}
+
+// wavPiece is Piece for WAV data.
+func wavPiece(data []byte, offset int) []byte {
+	if isCanonicalWAV(data) {
+		if offset > 0 {
+			return data[wavHeaderSize:]
+		}
+		return data
+	}
+	format, payload, err := ParseWAV(data)
+	if err != nil {
+		return data
+	}
+	if offset > 0 {
+		return payload
+	}
+	return append(WAVHeader(format, len(payload)), payload...)
+}
+
+// HasHeader reports whether only the first piece of assembled audio in the
+// format of data keeps its header, so nothing can be inserted before it.
+func HasHeader(data []byte) bool {
+	format := Sniff(data)
+	return format == "wav" || format == "ogg"
+}
+
+// Join appends the assembled audio next to data and returns the result with a
+// correct header.
+func Join(data, next []byte) []byte {
+	data = append(data, Piece(next, len(data))...)
+	FixHeaders(data)
+	return data
+}
+
+// FixHeaders sets the sizes in the header of assembled WAV audio to its length,
+// and renumbers the pages of assembled Ogg audio as a single stream. Other audio
+// is left alone.
+func FixHeaders(data []byte) {
+	if Sniff(data) == "ogg" {
+		fixOgg(data)
+		return
+	}
+	if !isCanonicalWAV(data) {
+		return
+	}
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+	binary.LittleEndian.PutUint32(data[40:44], uint32(len(data)-wavHeaderSize))
+}
+
+// FixFile is FixHeaders for assembled audio written to f.
+func FixFile(f *os.File) error {
+	head := make([]byte, wavHeaderSize)
+	n, _ := f.ReadAt(head, 0)
+	if Sniff(head[:n]) == "ogg" {
+		return fixOggFile(f)
+	}
+	if n < wavHeaderSize || !isCanonicalWAV(head) {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(head[4:8], uint32(info.Size()-8))
+	binary.LittleEndian.PutUint32(head[40:44], uint32(info.Size()-wavHeaderSize))
+	if _, err := f.WriteAt(head, 0); err != nil {
+		return fmt.Errorf("failed to write the WAV header: %w", err)
+	}
+	return nil
+}
+
+// Slice returns the bytes [start, end) of assembled audio as a file of its own.
+func Slice(data []byte, start, end int) []byte {
+	header, start := SliceHeader(data, start, end)
+	part := append(header, data[start:end]...)
+	FixHeaders(part)
+	return part
+}
+
+// SliceHeader returns the header that the bytes [start, end) of assembled audio
+// need to form a file of their own, given the audio's first bytes head, and
+// where the samples of the slice start. A WAV slice gets a header of its own
+// instead of the audio's, an Ogg slice the audio's header pages; other formats
+// need none. An Ogg slice needs FixHeaders or FixFile afterwards.
+func SliceHeader(head []byte, start, end int) ([]byte, int) {
+	if Sniff(head) == "ogg" {
+		h := oggHeaderEnd(head)
+		if h == 0 {
+			return nil, start
+		}
+		return bytes.Clone(head[:h]), min(max(start, h), end)
+	}
+	if !isCanonicalWAV(head) {
+		return nil, start
+	}
+	format, _, err := ParseWAV(head[:wavHeaderSize])
+	if err != nil {
+		return nil, start
+	}
+	start = min(max(start, wavHeaderSize), end)
+	return WAVHeader(format, end-start), start
+}
+
+// isCanonicalWAV reports whether data starts with a header as written by
+// WAVHeader: the fmt chunk and then the samples.
+func isCanonicalWAV(data []byte) bool {
+	return len(data) >= wavHeaderSize &&
+		bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:16], []byte("WAVEfmt ")) &&
+		binary.LittleEndian.Uint32(data[16:20]) == 16 && bytes.Equal(data[36:40], []byte("data"))
+}
@@ -0,0 +1,265 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestJoinWAV(t *testing.T) {
+	tests := []struct {
+		name        string
+		first, next []byte
+		samples     []int16
+	}{
+		{"canonical", testWAV(nil, 1, 2, 3), testWAV(nil, 4, 5), []int16{1, 2, 3, 4, 5}},
+		{"extra chunk", testWAV(nil, 1, 2, 3), testWAV([]byte("LIST"), 4, 5), []int16{1, 2, 3, 4, 5}},
+		{"extra chunk first", testWAV([]byte("LIST"), 1), testWAV(nil, 2, 3), []int16{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := Join(bytes.Clone(Piece(tt.first, 0)), tt.next)
+			if len(data) != wavHeaderSize+2*len(tt.samples) {
+				t.Fatalf("joined %d bytes, want the header and %d samples", len(data), len(tt.samples))
+			}
+			if got, want := binary.LittleEndian.Uint32(data[4:8]), uint32(len(data)-8); got != want {
+				t.Errorf("RIFF size = %d, want %d", got, want)
+			}
+			if got, want := binary.LittleEndian.Uint32(data[40:44]), uint32(2*len(tt.samples)); got != want {
+				t.Errorf("data size = %d, want %d", got, want)
+			}
+			_, payload, err := ParseWAV(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pcm16(payload); !slices.Equal(got, tt.samples) {
+				t.Errorf("samples = %v, want %v", got, tt.samples)
+			}
+
+			f := writeTemp(t, append(Piece(tt.first, 0), Piece(tt.next, 1)...))
+			if err := FixFile(f); err != nil {
+				t.Fatal(err)
+			}
+			if fixed := readAll(t, f); !bytes.Equal(fixed, data) {
+				t.Errorf("FixFile wrote %x, want %x as Join", fixed, data)
+			}
+		})
+	}
+}
+
+func TestJoinOgg(t *testing.T) {
+	const preSkip = 312
+	samples20ms := opusPacket(31<<3, 20) // CELT fullband, a single 20 ms frame
+	// A piece of two packets, whose encoder trimmed 100 samples of padding
+	first := testOgg(1, preSkip, 2*960-100, [][][]byte{{samples20ms, samples20ms}})
+	tests := []struct {
+		name     string
+		pieces   [][]byte
+		flags    []byte
+		granules []int64
+	}{
+		{
+			name:     "two pieces",
+			pieces:   [][]byte{first, testOgg(2, preSkip, 3*960-200, [][][]byte{{samples20ms, samples20ms, samples20ms}})},
+			flags:    []byte{oggFirst, 0, 0, oggLast},
+			granules: []int64{0, 0, 2 * 960, 2*960 + 3*960 - 200},
+		},
+		{
+			name: "packet across pages",
+			pieces: [][]byte{first, testOgg(2, preSkip, 2*960-50, [][][]byte{
+				{opusPacket(31<<3, 255)},
+				{opusPacket(31<<3, 45), samples20ms},
+			})},
+			flags:    []byte{oggFirst, 0, 0, 0, oggContinued | oggLast},
+			granules: []int64{0, 0, 2 * 960, -1, 2*960 + 2*960 - 50},
+		},
+		{
+			name:     "two frames per packet",
+			pieces:   [][]byte{first, testOgg(2, preSkip, 2*960, [][][]byte{{opusPacket(31<<3|1, 40)}})},
+			flags:    []byte{oggFirst, 0, 0, oggLast},
+			granules: []int64{0, 0, 2 * 960, 4 * 960},
+		},
+		{
+			name:     "not before the pre-skip",
+			pieces:   [][]byte{testOgg(1, preSkip, 100, [][][]byte{{samples20ms}})},
+			flags:    []byte{oggFirst, 0, oggLast},
+			granules: []int64{0, 0, preSkip},
+		},
+		{
+			name:     "untrimmed silence",
+			pieces:   [][]byte{first, testOgg(2, preSkip, 0, [][][]byte{{samples20ms}})},
+			flags:    []byte{oggFirst, 0, 0, oggLast},
+			granules: []int64{0, 0, 2 * 960, 3 * 960},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Clone(Piece(tt.pieces[0], 0))
+			for _, piece := range tt.pieces[1:] {
+				data = Join(data, piece)
+			}
+			FixHeaders(data) // a single piece is only fixed here
+			pages, err := ParseOggPages(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pages) != len(tt.granules) {
+				t.Fatalf("joined %d pages, want %d", len(pages), len(tt.granules))
+			}
+			for i, page := range pages {
+				if page.Sequence != uint32(i) {
+					t.Errorf("page %d has sequence number %d", i, page.Sequence)
+				}
+				if page.Serial != 1 {
+					t.Errorf("page %d has serial number %d, want that of the first piece", i, page.Serial)
+				}
+				if page.HeaderType != tt.flags[i] {
+					t.Errorf("page %d has flags %#x, want %#x", i, page.HeaderType, tt.flags[i])
+				}
+				if page.Granule != tt.granules[i] {
+					t.Errorf("page %d has granule position %d, want %d", i, page.Granule, tt.granules[i])
+				}
+				raw := bytes.Clone(page.Raw)
+				binary.LittleEndian.PutUint32(raw[22:26], 0)
+				if got, want := binary.LittleEndian.Uint32(page.Raw[22:26]), oggCRC(raw); got != want {
+					t.Errorf("page %d has CRC %#x, want %#x", i, got, want)
+				}
+			}
+
+			again := bytes.Clone(data)
+			FixHeaders(again)
+			if !bytes.Equal(again, data) {
+				t.Error("fixing the joined audio again changed it")
+			}
+
+			var unfixed []byte
+			for _, piece := range tt.pieces {
+				unfixed = append(unfixed, Piece(piece, len(unfixed))...)
+			}
+			f := writeTemp(t, unfixed)
+			if err := FixFile(f); err != nil {
+				t.Fatal(err)
+			}
+			if fixed := readAll(t, f); !bytes.Equal(fixed, data) {
+				t.Error("FixFile wrote other pages than Join")
+			}
+		})
+	}
+}
+
+func TestOggCRC(t *testing.T) {
+	// The check value of CRC-32 with polynomial 0x04C11DB7, initial value 0,
+	// not reflected and without a final XOR
+	if got := oggCRC([]byte("123456789")); got != 0x89A1897F {
+		t.Errorf("oggCRC(%q) = %#x, want 0x89a1897f", "123456789", got)
+	}
+}
+
+// testWAV returns a 16-bit mono WAV file of samples, with a chunk named extra
+// before the samples if set.
+func testWAV(extra []byte, samples ...int16) []byte {
+	var data []byte
+	for _, s := range samples {
+		data = binary.LittleEndian.AppendUint16(data, uint16(s))
+	}
+	wav := WAVHeader(WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 24000, ByteRate: 48000, BlockAlign: 2, BitsPerSample: 16}, len(data))
+	if extra != nil {
+		chunk := append(bytes.Clone(extra), 4, 0, 0, 0, 'a', 'b', 'c', 'd')
+		wav = append(wav[:36:36], append(chunk, wav[36:]...)...)
+		binary.LittleEndian.PutUint32(wav[4:8], uint32(len(wav)+len(data)-8))
+	}
+	return append(wav, data...)
+}
+
+func pcm16(data []byte) []int16 {
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[2*i:]))
+	}
+	return samples
+}
+
+// testOgg returns an Ogg Opus stream with the given serial number: the header
+// pages, then a page for each list of packets, the last of which has the
+// granule position granule. A packet of 255 bytes is continued on the next page.
+func testOgg(serial uint32, preSkip uint16, granule int64, pages [][][]byte) []byte {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+	tags := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	data := testOggPage(serial, 0, oggFirst, 0, [][]byte{head})
+	data = append(data, testOggPage(serial, 1, 0, 0, [][]byte{tags})...)
+	continued := false
+	for i, packets := range pages {
+		var flags byte
+		if continued {
+			flags |= oggContinued
+		}
+		pos := int64(-1)
+		if i == len(pages)-1 {
+			flags |= oggLast
+			pos = granule
+		}
+		data = append(data, testOggPage(serial, uint32(i+2), flags, pos, packets)...)
+		continued = len(packets[len(packets)-1]) == 255
+	}
+	return data
+}
+
+// testOggPage returns an Ogg page of packets with a valid CRC. A last packet of
+// 255 bytes is left open.
+func testOggPage(serial, sequence uint32, flags byte, granule int64, packets [][]byte) []byte {
+	var lacing, body []byte
+	for _, p := range packets {
+		for n := len(p); ; n -= 255 {
+			lacing = append(lacing, byte(min(n, 255)))
+			if n < 255 || (n == 255 && len(p) == 255) {
+				break
+			}
+		}
+		body = append(body, p...)
+	}
+	page := []byte("OggS\x00")
+	page = append(page, flags)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = binary.LittleEndian.AppendUint32(page, sequence)
+	page = append(page, 0, 0, 0, 0, byte(len(lacing)))
+	page = append(append(page, lacing...), body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+	return page
+}
+
+// opusPacket returns an Opus packet of size bytes starting with toc.
+func opusPacket(toc byte, size int) []byte {
+	p := make([]byte, size)
+	p[0] = toc
+	return p
+}
+
+func writeTemp(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "audio"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func readAll(t *testing.T, f *os.File) []byte {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
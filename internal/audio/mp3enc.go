@@ -0,0 +1,664 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// The MP3 encoder is a plain Layer III encoder in the spirit of shine: long
+// blocks only, no scalefactors and no bit reservoir, with each granule quantized
+// as finely as its share of the frame allows. That is plenty for speech and
+// keeps converting independent of ffmpeg or LAME. Audio at 32 to 48 kHz is
+// encoded as MPEG-1, at 16 to 24 kHz as MPEG-2; other rates are resampled.
+
+// mp3DefaultBitrate is the bitrate in kbit/s per channel when none is given.
+const mp3DefaultBitrate = 64
+
+// MP3 encodes p as a constant bitrate MP3 file of bitrate kbit/s, or 64 kbit/s
+// per channel if bitrate is 0. The nearest bitrate MPEG audio has at the sample
+// rate is used. Mono and stereo audio can be encoded.
+func (p *PCM) MP3(bitrate int) ([]byte, error) {
+	if p.Channels < 1 || p.Channels > 2 {
+		return nil, fmt.Errorf("encoding %d channels as MP3 is not supported", p.Channels)
+	}
+	rate := 48000
+	for _, r := range []int{16000, 22050, 24000, 32000, 44100, 48000} {
+		if r >= p.SampleRate {
+			rate = r
+			break
+		}
+	}
+	p = p.Resample(rate)
+	lsf := 0
+	if rate < 32000 {
+		lsf = 1
+	}
+	e := &mp3Encoder{
+		channels:   p.Channels,
+		sampleRate: rate,
+		lsf:        lsf,
+		granules:   2 - lsf,
+	}
+	for i, r := range mp3SampleRates[3-lsf] {
+		if r == rate {
+			e.rateIndex = i
+			e.sfb = &mp3LongBands[lsf][i]
+		}
+	}
+	if bitrate <= 0 {
+		bitrate = mp3DefaultBitrate * p.Channels
+	}
+	e.bitrateIndex = 1
+	for i, b := range mp3Bitrates[lsf][2] {
+		if b > 0 && b <= bitrate {
+			e.bitrateIndex = i
+		}
+	}
+	e.bitrate = mp3Bitrates[lsf][2][e.bitrateIndex]
+	// The filter bank and MDCT delay the audio by about a granule, so the
+	// samples are followed by enough silence to get all of them out
+	frameSize := e.granules * mp3GranuleSize
+	frames := (p.frames() + 2*mp3GranuleSize + frameSize - 1) / frameSize
+	var in [2][]float64
+	for c := 0; c < p.Channels; c++ {
+		in[c] = make([]float64, frames*frameSize)
+		for i := 0; i < p.frames(); i++ {
+			in[c][i] = float64(p.Samples[i*p.Channels+c]) / 32768
+		}
+	}
+	for f := 0; f < frames; f++ {
+		var grs [2][2]encGranule
+		for gr := 0; gr < e.granules; gr++ {
+			for c := 0; c < p.Channels; c++ {
+				off := (e.granules*f + gr) * mp3GranuleSize
+				var xr [mp3GranuleSize]float64
+				e.transform(c, in[c][off:off+mp3GranuleSize], &xr)
+				grs[gr][c].quantize(&xr, e.sfb, e.granuleBits())
+			}
+		}
+		e.writeFrame(&grs)
+	}
+	return e.w.data, nil
+}
+
+const (
+	mp3GranuleSize = 576
+	mp3MaxValue    = 15 + 1<<13 - 1 // the largest value table 31 can code
+	mp3MaxBits     = 1<<12 - 1      // the most part2_3_length can give
+)
+
+// mp3LongBands are the scalefactor band boundaries of long blocks, for MPEG-1
+// and MPEG-2 by sample rate index.
+var mp3LongBands = [2][3][23]int{
+	{
+		{0, 4, 8, 12, 16, 20, 24, 30, 36, 44, 52, 62, 74, 90, 110, 134, 162, 196, 238, 288, 342, 418, 576},
+		{0, 4, 8, 12, 16, 20, 24, 30, 36, 42, 50, 60, 72, 88, 106, 128, 156, 190, 230, 276, 330, 384, 576},
+		{0, 4, 8, 12, 16, 20, 24, 30, 36, 44, 54, 66, 82, 102, 126, 156, 194, 240, 296, 364, 448, 550, 576},
+	},
+	{
+		{0, 6, 12, 18, 24, 30, 36, 44, 54, 66, 80, 96, 116, 140, 168, 200, 238, 284, 336, 396, 464, 522, 576},
+		{0, 6, 12, 18, 24, 30, 36, 44, 54, 66, 80, 96, 114, 136, 162, 194, 232, 278, 332, 394, 464, 540, 576},
+		{0, 6, 12, 18, 24, 30, 36, 44, 54, 66, 80, 96, 116, 140, 168, 200, 238, 284, 336, 396, 464, 522, 576},
+	},
+}
+
+type mp3Encoder struct {
+	channels     int
+	sampleRate   int
+	lsf          int // 1 for MPEG-2, whose frames have a single granule
+	granules     int
+	rateIndex    int
+	bitrate      int
+	bitrateIndex int
+	sfb          *[23]int
+	history      [2][512]float64    // filter bank input, newest first
+	overlap      [2][32][18]float64 // subband samples of the previous granule
+	rest         int                // remainder of the frame lengths so far, for padding
+	w            bitWriter
+}
+
+// frameBytes returns the length of the next frame and whether it is padded.
+func (e *mp3Encoder) frameBytes() (int, bool) {
+	per := 72 * 1000 * e.bitrate * e.granules
+	e.rest += per % e.sampleRate
+	padded := e.rest >= e.sampleRate
+	if padded {
+		e.rest -= e.sampleRate
+	}
+	n := per / e.sampleRate
+	if padded {
+		n++
+	}
+	return n, padded
+}
+
+// sideInfoBytes returns the size of the side information of a frame.
+func (e *mp3Encoder) sideInfoBytes() int {
+	if e.channels == 1 {
+		return 17 - 8*e.lsf
+	}
+	return 32 - 15*e.lsf
+}
+
+// granuleBits returns the bits one channel of a granule may use, its share of
+// an unpadded frame.
+func (e *mp3Encoder) granuleBits() int {
+	frame := 72 * 1000 * e.bitrate * e.granules / e.sampleRate
+	return min((frame-4-e.sideInfoBytes())*8/(e.granules*e.channels), mp3MaxBits)
+}
+
+// transform turns 576 samples of channel c into 576 frequency lines: the
+// polyphase filter bank splits them into 32 subbands of 18 samples, which the
+// MDCT turns into 18 lines each, overlapping with the previous granule.
+func (e *mp3Encoder) transform(c int, samples []float64, xr *[mp3GranuleSize]float64) {
+	var sub [32][18]float64
+	x := &e.history[c]
+	for slot := 0; slot < 18; slot++ {
+		copy(x[32:], x[:480])
+		for i := 0; i < 32; i++ {
+			x[31-i] = samples[slot*32+i]
+		}
+		var y [64]float64
+		for i := range y {
+			for j := 0; j < 8; j++ {
+				y[i] += mp3Window[i+64*j] * x[i+64*j]
+			}
+		}
+		for sb := 0; sb < 32; sb++ {
+			var s float64
+			for i, v := range y {
+				s += mp3Matrix[sb][i] * v
+			}
+			if sb&1 == 1 && slot&1 == 1 {
+				s = -s // frequency inversion, undone by the decoder
+			}
+			sub[sb][slot] = s
+		}
+	}
+	for sb := 0; sb < 32; sb++ {
+		var in [36]float64
+		copy(in[:18], e.overlap[c][sb][:])
+		copy(in[18:], sub[sb][:])
+		e.overlap[c][sb] = sub[sb]
+		for k := 0; k < 18; k++ {
+			var s float64
+			for n, v := range in {
+				s += v * mp3MDCT[k][n]
+			}
+			xr[sb*18+k] = s
+		}
+	}
+	// Aliasing reduction, the inverse of the decoder's butterflies
+	for sb := 1; sb < 32; sb++ {
+		for i := 0; i < 8; i++ {
+			lo, hi := 18*sb-1-i, 18*sb+i
+			a, b := xr[lo], xr[hi]
+			xr[lo] = a*mp3AliasCS[i] + b*mp3AliasCA[i]
+			xr[hi] = b*mp3AliasCS[i] - a*mp3AliasCA[i]
+		}
+	}
+}
+
+// encGranule is one channel of a granule, quantized and ready to be written.
+type encGranule struct {
+	ix          [mp3GranuleSize]int
+	negative    [mp3GranuleSize]bool
+	bits        int // part2_3_length: the Huffman coded bits, there are no scalefactors
+	bigValues   int // pairs coded with the tables of the three regions
+	count1      int // quadruples of values up to 1 after them
+	globalGain  int
+	tables      [3]int
+	region0     int
+	region1     int
+	count1Table int
+}
+
+// quantize finds the finest quantization of xr whose code fits in budget bits.
+func (g *encGranule) quantize(xr *[mp3GranuleSize]float64, sfb *[23]int, budget int) {
+	var xr34 [mp3GranuleSize]float64
+	silent := true
+	for i, v := range xr {
+		g.negative[i] = v < 0
+		xr34[i] = math.Pow(math.Abs(v), 0.75)
+		silent = silent && xr34[i] < 1e-12
+	}
+	if silent {
+		*g = encGranule{globalGain: 210}
+		return
+	}
+	// The code gets shorter as the global gain, the quantizer step, grows
+	lo, hi := 0, 255
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if g.try(&xr34, sfb, mid) <= budget {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	g.try(&xr34, sfb, lo)
+}
+
+// try quantizes xr34, the magnitudes of the lines to the power of 0.75, with
+// global gain gain and returns the bits of the code, which are too many if a
+// value is too large for any table.
+func (g *encGranule) try(xr34 *[mp3GranuleSize]float64, sfb *[23]int, gain int) int {
+	g.globalGain = gain
+	scale := math.Pow(2, -0.1875*float64(gain-210))
+	for i, v := range xr34 {
+		q := v*scale + 0.4054
+		if q > mp3MaxValue {
+			g.bits = math.MaxInt32
+			return g.bits
+		}
+		g.ix[i] = int(q)
+	}
+	// Zeros at the end are not coded; before them quadruples of values up to 1
+	end := mp3GranuleSize
+	for end > 1 && g.ix[end-1] == 0 && g.ix[end-2] == 0 {
+		end -= 2
+	}
+	g.count1 = 0
+	for end > 3 && g.ix[end-1] <= 1 && g.ix[end-2] <= 1 && g.ix[end-3] <= 1 && g.ix[end-4] <= 1 {
+		end -= 4
+		g.count1++
+	}
+	g.bigValues = end / 2
+	g.region0, g.region1 = 0, 0
+	bands := 0
+	for bands < 22 && sfb[bands] < end {
+		bands++
+	}
+	if end > 0 {
+		g.region0, g.region1 = mp3RegionSplit[bands][0], mp3RegionSplit[bands][1]
+	}
+	bits := 0
+	for r, bounds := range g.regions(sfb) {
+		g.tables[r] = chooseTable(g.ix[bounds[0]:bounds[1]])
+		bits += pairBits(g.ix[bounds[0]:bounds[1]], g.tables[r])
+	}
+	quads := g.ix[end : end+4*g.count1]
+	a, b := quadBits(quads, 0), quadBits(quads, 1)
+	g.count1Table = 0
+	if b < a {
+		g.count1Table, a = 1, b
+	}
+	g.bits = bits + a
+	return g.bits
+}
+
+// regions returns the lines of the three big value regions.
+func (g *encGranule) regions(sfb *[23]int) [3][2]int {
+	end := 2 * g.bigValues
+	r0 := min(sfb[g.region0+1], end)
+	r1 := min(sfb[min(g.region0+g.region1+2, 22)], end)
+	return [3][2]int{{0, r0}, {r0, r1}, {r1, end}}
+}
+
+// mp3RegionSplit gives region0_count and region1_count by the number of
+// scalefactor bands holding big values, as in the ISO reference encoder.
+var mp3RegionSplit = [23][2]int{
+	{0, 0}, {0, 0}, {0, 0}, {0, 0}, {0, 0}, {0, 1}, {1, 1}, {1, 1}, {1, 2}, {2, 2}, {2, 3}, {2, 3},
+	{3, 4}, {3, 4}, {3, 4}, {4, 5}, {4, 5}, {4, 6}, {5, 6}, {5, 6}, {5, 7}, {6, 7}, {6, 7},
+}
+
+// chooseTable returns the Huffman table for a region with the values ix.
+func chooseTable(ix []int) int {
+	largest := 0
+	for _, v := range ix {
+		largest = max(largest, v)
+	}
+	switch {
+	case largest == 0:
+		return 0
+	case largest == 1:
+		return 1
+	case largest <= 15:
+		return 15
+	}
+	for t := 24; t < 31; t++ {
+		if largest <= 15+1<<mp3Linbits[t-24]-1 {
+			return t
+		}
+	}
+	return 31
+}
+
+// mp3Linbits are the extra bits of tables 24 to 31 for values of 15 and more.
+var mp3Linbits = [8]int{4, 5, 6, 7, 8, 9, 11, 13}
+
+// pairCode returns the code of the pair x, y (at most 15 each) in table.
+func pairCode(table, x, y int) (code uint32, length int) {
+	switch {
+	case table == 1:
+		return uint32(mp3Table1Codes[x*2+y]), int(mp3Table1Lens[x*2+y])
+	case table == 15:
+		return uint32(mp3Table15Codes[x*16+y]), int(mp3Table15Lens[x*16+y])
+	default:
+		return uint32(mp3Table24Codes[x*16+y]), int(mp3Table24Lens[x*16+y])
+	}
+}
+
+// pairBits returns the bits the values ix take in table.
+func pairBits(ix []int, table int) int {
+	if table == 0 {
+		return 0
+	}
+	bits := 0
+	for i := 0; i < len(ix); i += 2 {
+		x, y := ix[i], ix[i+1]
+		_, n := pairCode(table, min(x, 15), min(y, 15))
+		bits += n
+		for _, v := range [2]int{x, y} {
+			if v >= 15 && table >= 24 {
+				bits += mp3Linbits[table-24]
+			}
+			if v != 0 {
+				bits++
+			}
+		}
+	}
+	return bits
+}
+
+// quadBits returns the bits the quadruples quads take in count1 table A (0) or B (1).
+func quadBits(quads []int, table int) int {
+	bits := 0
+	for i := 0; i < len(quads); i += 4 {
+		q := quads[i]<<3 | quads[i+1]<<2 | quads[i+2]<<1 | quads[i+3]
+		if table == 0 {
+			bits += int(mp3QuadLensA[q])
+		} else {
+			bits += 4
+		}
+		bits += quads[i] + quads[i+1] + quads[i+2] + quads[i+3]
+	}
+	return bits
+}
+
+// writeFrame writes a frame of two granules.
+func (e *mp3Encoder) writeFrame(grs *[2][2]encGranule) {
+	length, padded := e.frameBytes()
+	start := len(e.w.data)
+	w := &e.w
+	mode := 0 // stereo
+	if e.channels == 1 {
+		mode = 3
+	}
+	w.write(0xFFFB&^uint32(e.lsf<<3), 16) // sync, MPEG-1 or 2, Layer III, no CRC
+	w.write(uint32(e.bitrateIndex), 4)
+	w.write(uint32(e.rateIndex), 2)
+	w.write(b2u(padded), 1)
+	w.write(0, 1)            // private
+	w.write(uint32(mode), 2) // mode
+	w.write(0, 2)            // mode extension
+	w.write(0, 1)            // copyright
+	w.write(1, 1)            // original
+	w.write(0, 2)            // emphasis
+
+	w.write(0, 9-e.lsf) // main_data_begin: no bit reservoir
+	if e.lsf == 1 {
+		w.write(0, e.channels) // private bits
+	} else {
+		w.write(0, 7-2*e.channels) // private bits
+		w.write(0, 4*e.channels)   // scfsi
+	}
+	for gr := 0; gr < e.granules; gr++ {
+		for c := 0; c < e.channels; c++ {
+			g := &grs[gr][c]
+			w.write(uint32(g.bits), 12)
+			w.write(uint32(g.bigValues), 9)
+			w.write(uint32(g.globalGain), 8)
+			w.write(0, 4+5*e.lsf) // scalefac_compress: no scalefactors
+			w.write(0, 1)         // window_switching_flag: long blocks
+			for _, t := range g.tables {
+				w.write(uint32(t), 5)
+			}
+			w.write(uint32(g.region0), 4)
+			w.write(uint32(g.region1), 3)
+			if e.lsf == 0 {
+				w.write(0, 1) // preflag
+			}
+			w.write(0, 1) // scalefac_scale
+			w.write(uint32(g.count1Table), 1)
+		}
+	}
+	for gr := 0; gr < e.granules; gr++ {
+		for c := 0; c < e.channels; c++ {
+			e.writeGranule(&grs[gr][c])
+		}
+	}
+	w.flush()
+	for len(w.data) < start+length {
+		w.data = append(w.data, 0)
+	}
+}
+
+// writeGranule writes the Huffman code of g.
+func (e *mp3Encoder) writeGranule(g *encGranule) {
+	w := &e.w
+	sign := func(i int) {
+		if g.ix[i] != 0 {
+			w.write(b2u(g.negative[i]), 1)
+		}
+	}
+	for r, bounds := range g.regions(e.sfb) {
+		table := g.tables[r]
+		if table == 0 {
+			continue
+		}
+		for i := bounds[0]; i < bounds[1]; i += 2 {
+			x, y := g.ix[i], g.ix[i+1]
+			code, n := pairCode(table, min(x, 15), min(y, 15))
+			w.write(code, n)
+			if x >= 15 && table >= 24 {
+				w.write(uint32(x-15), mp3Linbits[table-24])
+			}
+			sign(i)
+			if y >= 15 && table >= 24 {
+				w.write(uint32(y-15), mp3Linbits[table-24])
+			}
+			sign(i + 1)
+		}
+	}
+	for i := 2 * g.bigValues; i < 2*g.bigValues+4*g.count1; i += 4 {
+		q := g.ix[i]<<3 | g.ix[i+1]<<2 | g.ix[i+2]<<1 | g.ix[i+3]
+		if g.count1Table == 0 {
+			w.write(uint32(mp3QuadCodesA[q]), int(mp3QuadLensA[q]))
+		} else {
+			w.write(uint32(15-q), 4)
+		}
+		for k := 0; k < 4; k++ {
+			sign(i + k)
+		}
+	}
+}
+
+func b2u(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// bitWriter appends bits to data, most significant first.
+type bitWriter struct {
+	data []byte
+	acc  uint64
+	n    int
+}
+
+func (w *bitWriter) write(v uint32, bits int) {
+	w.acc = w.acc<<bits | uint64(v)&(1<<bits-1)
+	w.n += bits
+	for w.n >= 8 {
+		w.n -= 8
+		w.data = append(w.data, byte(w.acc>>w.n))
+	}
+}
+
+// flush pads the last byte with zeros.
+func (w *bitWriter) flush() {
+	if w.n > 0 {
+		w.write(0, 8-w.n)
+	}
+}
+
+// mp3Window is the analysis window C of the filter bank. Its coefficients are
+// multiples of 2^-21, given in the table below.
+var mp3Window = func() (c [512]float64) {
+	for i, n := range mp3WindowTable {
+		c[i] = float64(n) / (1 << 21)
+	}
+	return c
+}()
+
+// mp3Matrix is the cosine matrix of the filter bank.
+var mp3Matrix = func() (m [32][64]float64) {
+	for k := range m {
+		for i := range m[k] {
+			m[k][i] = math.Cos(float64((2*k+1)*(i-16)) * math.Pi / 64)
+		}
+	}
+	return m
+}()
+
+// mp3MDCT holds the windowed cosines of the long block MDCT.
+var mp3MDCT = func() (m [18][36]float64) {
+	for k := range m {
+		for n := range m[k] {
+			m[k][n] = mdctScale * math.Sin(math.Pi/36*(float64(n)+0.5)) * math.Cos(math.Pi/72*float64((2*n+19)*(2*k+1)))
+		}
+	}
+	return m
+}()
+
+// mdctScale makes the decoder's unscaled inverse MDCT return the input.
+const mdctScale = 1.0 / 9
+
+// Aliasing reduction butterflies.
+var (
+	mp3AliasCS = [8]float64{0.857493, 0.881742, 0.949629, 0.983315, 0.995518, 0.999161, 0.999899, 0.999993}
+	mp3AliasCA = [8]float64{-0.514496, -0.471732, -0.313377, -0.181913, -0.094574, -0.040966, -0.014199, -0.003700}
+)
+
+// Huffman tables 1, 15 and 24 (shared by 24 to 31) indexed by x*size+y, and
+// count1 table A indexed by the quadruple's bits vwxy.
+var (
+	mp3Table1Codes = [4]uint8{0x1, 0x1, 0x1, 0x0}
+	mp3Table1Lens  = [4]uint8{1, 3, 2, 3}
+	mp3QuadCodesA  = [16]uint8{0x1, 0x5, 0x4, 0x5, 0x6, 0x5, 0x4, 0x4, 0x7, 0x3, 0x6, 0x0, 0x7, 0x2, 0x3, 0x1}
+	mp3QuadLensA   = [16]uint8{1, 4, 4, 5, 4, 6, 5, 6, 4, 5, 5, 6, 5, 6, 6, 6}
+)
+
+var mp3Table15Codes = [256]uint16{
+	0x7, 0xc, 0x12, 0x35, 0x2f, 0x4c, 0x7c, 0x6c, 0x59, 0x7b, 0x6c, 0x77, 0x6b, 0x51, 0x7a, 0x3f,
+	0xd, 0x5, 0x10, 0x1b, 0x2e, 0x24, 0x3d, 0x33, 0x2a, 0x46, 0x34, 0x53, 0x41, 0x29, 0x3b, 0x24,
+	0x13, 0x11, 0xf, 0x18, 0x29, 0x22, 0x3b, 0x30, 0x28, 0x40, 0x32, 0x4e, 0x3e, 0x50, 0x38, 0x21,
+	0x1d, 0x1c, 0x19, 0x2b, 0x27, 0x3f, 0x37, 0x5d, 0x4c, 0x3b, 0x5d, 0x48, 0x36, 0x4b, 0x32, 0x1d,
+	0x34, 0x16, 0x2a, 0x28, 0x43, 0x39, 0x5f, 0x4f, 0x48, 0x39, 0x59, 0x45, 0x31, 0x42, 0x2e, 0x1b,
+	0x4d, 0x25, 0x23, 0x42, 0x3a, 0x34, 0x5b, 0x4a, 0x3e, 0x30, 0x4f, 0x3f, 0x5a, 0x3e, 0x28, 0x26,
+	0x7d, 0x20, 0x3c, 0x38, 0x32, 0x5c, 0x4e, 0x41, 0x37, 0x57, 0x47, 0x33, 0x49, 0x33, 0x46, 0x1e,
+	0x6d, 0x35, 0x31, 0x5e, 0x58, 0x4b, 0x42, 0x7a, 0x5b, 0x49, 0x38, 0x2a, 0x40, 0x2c, 0x15, 0x19,
+	0x5a, 0x2b, 0x29, 0x4d, 0x49, 0x3f, 0x38, 0x5c, 0x4d, 0x42, 0x2f, 0x43, 0x30, 0x35, 0x24, 0x14,
+	0x47, 0x22, 0x43, 0x3c, 0x3a, 0x31, 0x58, 0x4c, 0x43, 0x6a, 0x47, 0x36, 0x26, 0x27, 0x17, 0xf,
+	0x6d, 0x35, 0x33, 0x2f, 0x5a, 0x52, 0x3a, 0x39, 0x30, 0x48, 0x39, 0x29, 0x17, 0x1b, 0x3e, 0x9,
+	0x56, 0x2a, 0x28, 0x25, 0x46, 0x40, 0x34, 0x2b, 0x46, 0x37, 0x2a, 0x19, 0x1d, 0x12, 0xb, 0xb,
+	0x76, 0x44, 0x1e, 0x37, 0x32, 0x2e, 0x4a, 0x41, 0x31, 0x27, 0x18, 0x10, 0x16, 0xd, 0xe, 0x7,
+	0x5b, 0x2c, 0x27, 0x26, 0x22, 0x3f, 0x34, 0x2d, 0x1f, 0x34, 0x1c, 0x13, 0xe, 0x8, 0x9, 0x3,
+	0x7b, 0x3c, 0x3a, 0x35, 0x2f, 0x2b, 0x20, 0x16, 0x25, 0x18, 0x11, 0xc, 0xf, 0xa, 0x2, 0x1,
+	0x47, 0x25, 0x22, 0x1e, 0x1c, 0x14, 0x11, 0x1a, 0x15, 0x10, 0xa, 0x6, 0x8, 0x6, 0x2, 0x0,
+}
+
+var mp3Table15Lens = [256]uint8{
+	3, 4, 5, 7, 7, 8, 9, 9, 9, 10, 10, 11, 11, 11, 12, 13,
+	4, 3, 5, 6, 7, 7, 8, 8, 8, 9, 9, 10, 10, 10, 11, 11,
+	5, 5, 5, 6, 7, 7, 8, 8, 8, 9, 9, 10, 10, 11, 11, 11,
+	6, 6, 6, 7, 7, 8, 8, 9, 9, 9, 10, 10, 10, 11, 11, 11,
+	7, 6, 7, 7, 8, 8, 9, 9, 9, 9, 10, 10, 10, 11, 11, 11,
+	8, 7, 7, 8, 8, 8, 9, 9, 9, 9, 10, 10, 11, 11, 11, 12,
+	9, 7, 8, 8, 8, 9, 9, 9, 9, 10, 10, 10, 11, 11, 12, 12,
+	9, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 10, 11, 11, 11, 12,
+	9, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 11, 11, 12, 12, 12,
+	9, 8, 9, 9, 9, 9, 10, 10, 10, 11, 11, 11, 11, 12, 12, 12,
+	10, 9, 9, 9, 10, 10, 10, 10, 10, 11, 11, 11, 11, 12, 13, 12,
+	10, 9, 9, 9, 10, 10, 10, 10, 11, 11, 11, 11, 12, 12, 12, 13,
+	11, 10, 9, 10, 10, 10, 11, 11, 11, 11, 11, 11, 12, 12, 13, 13,
+	11, 10, 10, 10, 10, 11, 11, 11, 11, 12, 12, 12, 12, 12, 13, 13,
+	12, 11, 11, 11, 11, 11, 11, 11, 12, 12, 12, 12, 13, 13, 12, 13,
+	12, 11, 11, 11, 11, 11, 11, 12, 12, 12, 12, 12, 13, 13, 13, 13,
+}
+
+var mp3Table24Codes = [256]uint16{
+	0xf, 0xd, 0x2e, 0x50, 0x92, 0x106, 0xf8, 0x1b2, 0x1aa, 0x29d, 0x28d, 0x289, 0x26d, 0x205, 0x408, 0x58,
+	0xe, 0xc, 0x15, 0x26, 0x47, 0x82, 0x7a, 0xd8, 0xd1, 0xc6, 0x147, 0x159, 0x13f, 0x129, 0x117, 0x2a,
+	0x2f, 0x16, 0x29, 0x4a, 0x44, 0x80, 0x78, 0xdd, 0xcf, 0xc2, 0xb6, 0x154, 0x13b, 0x127, 0x21d, 0x12,
+	0x51, 0x27, 0x4b, 0x46, 0x86, 0x7d, 0x74, 0xdc, 0xcc, 0xbe, 0xb2, 0x145, 0x137, 0x125, 0x10f, 0x10,
+	0x93, 0x48, 0x45, 0x87, 0x7f, 0x76, 0x70, 0xd2, 0xc8, 0xbc, 0x160, 0x143, 0x132, 0x11d, 0x21c, 0xe,
+	0x107, 0x42, 0x81, 0x7e, 0x77, 0x72, 0xd6, 0xca, 0xc0, 0xb4, 0x155, 0x13d, 0x12d, 0x119, 0x106, 0xc,
+	0xf9, 0x7b, 0x79, 0x75, 0x71, 0xd7, 0xce, 0xc3, 0xb9, 0x15b, 0x14a, 0x134, 0x123, 0x110, 0x208, 0xa,
+	0x1b3, 0x73, 0x6f, 0x6d, 0xd3, 0xcb, 0xc4, 0xbb, 0x161, 0x14c, 0x139, 0x12a, 0x11b, 0x213, 0x17d, 0x11,
+	0x1ab, 0xd4, 0xd0, 0xcd, 0xc9, 0xc1, 0xba, 0xb1, 0xa9, 0x140, 0x12f, 0x11e, 0x10c, 0x202, 0x179, 0x10,
+	0x14f, 0xc7, 0xc5, 0xbf, 0xbd, 0xb5, 0xae, 0x14d, 0x141, 0x131, 0x121, 0x113, 0x209, 0x17b, 0x173, 0xb,
+	0x29c, 0xb8, 0xb7, 0xb3, 0xaf, 0x158, 0x14b, 0x13a, 0x130, 0x122, 0x115, 0x212, 0x17f, 0x175, 0x16e, 0xa,
+	0x28c, 0x15a, 0xab, 0xa8, 0xa4, 0x13e, 0x135, 0x12b, 0x11f, 0x114, 0x107, 0x201, 0x177, 0x170, 0x16a, 0x6,
+	0x288, 0x142, 0x13c, 0x138, 0x133, 0x12e, 0x124, 0x11c, 0x10d, 0x105, 0x200, 0x178, 0x172, 0x16c, 0x167, 0x4,
+	0x26c, 0x12c, 0x128, 0x126, 0x120, 0x11a, 0x111, 0x10a, 0x203, 0x17c, 0x176, 0x171, 0x16d, 0x169, 0x165, 0x2,
+	0x409, 0x118, 0x116, 0x112, 0x10b, 0x108, 0x103, 0x17e, 0x17a, 0x174, 0x16f, 0x16b, 0x168, 0x166, 0x164, 0x0,
+	0x2b, 0x14, 0x13, 0x11, 0xf, 0xd, 0xb, 0x9, 0x7, 0x6, 0x4, 0x7, 0x5, 0x3, 0x1, 0x3,
+}
+
+var mp3Table24Lens = [256]uint8{
+	4, 4, 6, 7, 8, 9, 9, 10, 10, 11, 11, 11, 11, 11, 12, 9,
+	4, 4, 5, 6, 7, 8, 8, 9, 9, 9, 10, 10, 10, 10, 10, 8,
+	6, 5, 6, 7, 7, 8, 8, 9, 9, 9, 9, 10, 10, 10, 11, 7,
+	7, 6, 7, 7, 8, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 7,
+	8, 7, 7, 8, 8, 8, 8, 9, 9, 9, 10, 10, 10, 10, 11, 7,
+	9, 7, 8, 8, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 10, 7,
+	9, 8, 8, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 10, 11, 7,
+	10, 8, 8, 8, 9, 9, 9, 9, 10, 10, 10, 10, 10, 11, 11, 8,
+	10, 9, 9, 9, 9, 9, 9, 9, 9, 10, 10, 10, 10, 11, 11, 8,
+	10, 9, 9, 9, 9, 9, 9, 10, 10, 10, 10, 10, 11, 11, 11, 8,
+	11, 9, 9, 9, 9, 10, 10, 10, 10, 10, 10, 11, 11, 11, 11, 8,
+	11, 10, 9, 9, 9, 10, 10, 10, 10, 10, 10, 11, 11, 11, 11, 8,
+	11, 10, 10, 10, 10, 10, 10, 10, 10, 10, 11, 11, 11, 11, 11, 8,
+	11, 10, 10, 10, 10, 10, 10, 10, 11, 11, 11, 11, 11, 11, 11, 8,
+	12, 10, 10, 10, 10, 10, 10, 11, 11, 11, 11, 11, 11, 11, 11, 8,
+	8, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 8, 8, 8, 8, 4,
+}
+
+// mp3WindowTable is the analysis window in units of 2^-21.
+var mp3WindowTable = [512]int32{
+	0, -1, -1, -1, -1, -1, -1, -2, -2, -2, -2, -3, -3, -4, -4, -5,
+	-5, -6, -7, -7, -8, -9, -10, -11, -13, -14, -16, -17, -19, -21, -24, -26,
+	-29, -31, -35, -38, -41, -45, -49, -53, -58, -63, -68, -73, -79, -85, -91, -97,
+	-104, -111, -117, -125, -132, -139, -147, -154, -161, -169, -176, -183, -190, -196, -202, -208,
+	213, 218, 222, 225, 227, 228, 228, 227, 224, 221, 215, 208, 200, 189, 177, 163,
+	146, 127, 106, 83, 57, 29, -2, -36, -72, -111, -153, -197, -244, -294, -347, -401,
+	-459, -519, -581, -645, -711, -779, -848, -919, -991, -1064, -1137, -1210, -1283, -1356, -1428, -1498,
+	-1567, -1634, -1698, -1759, -1817, -1870, -1919, -1962, -2001, -2032, -2057, -2075, -2085, -2087, -2080, -2063,
+	2037, 2000, 1952, 1893, 1822, 1739, 1644, 1535, 1414, 1280, 1131, 970, 794, 605, 402, 185,
+	-45, -288, -545, -814, -1095, -1388, -1692, -2006, -2330, -2663, -3004, -3351, -3705, -4063, -4425, -4788,
+	-5153, -5517, -5879, -6237, -6589, -6935, -7271, -7597, -7910, -8209, -8491, -8755, -8998, -9219, -9416, -9585,
+	-9727, -9838, -9916, -9959, -9966, -9935, -9863, -9750, -9592, -9389, -9139, -8840, -8492, -8092, -7640, -7134,
+	6574, 5959, 5288, 4561, 3776, 2935, 2037, 1082, 70, -998, -2122, -3300, -4533, -5818, -7154, -8540,
+	-9975, -11455, -12980, -14548, -16155, -17799, -19478, -21189, -22929, -24694, -26482, -28289, -30112, -31947, -33791, -35640,
+	-37489, -39336, -41176, -43006, -44821, -46617, -48390, -50137, -51853, -53534, -55178, -56778, -58333, -59838, -61289, -62684,
+	-64019, -65290, -66494, -67629, -68692, -69679, -70590, -71420, -72169, -72835, -73415, -73908, -74313, -74630, -74856, -74992,
+	75038, 74992, 74856, 74630, 74313, 73908, 73415, 72835, 72169, 71420, 70590, 69679, 68692, 67629, 66494, 65290,
+	64019, 62684, 61289, 59838, 58333, 56778, 55178, 53534, 51853, 50137, 48390, 46617, 44821, 43006, 41176, 39336,
+	37489, 35640, 33791, 31947, 30112, 28289, 26482, 24694, 22929, 21189, 19478, 17799, 16155, 14548, 12980, 11455,
+	9975, 8540, 7154, 5818, 4533, 3300, 2122, 998, -70, -1082, -2037, -2935, -3776, -4561, -5288, -5959,
+	6574, 7134, 7640, 8092, 8492, 8840, 9139, 9389, 9592, 9750, 9863, 9935, 9966, 9959, 9916, 9838,
+	9727, 9585, 9416, 9219, 8998, 8755, 8491, 8209, 7910, 7597, 7271, 6935, 6589, 6237, 5879, 5517,
+	5153, 4788, 4425, 4063, 3705, 3351, 3004, 2663, 2330, 2006, 1692, 1388, 1095, 814, 545, 288,
+	45, -185, -402, -605, -794, -970, -1131, -1280, -1414, -1535, -1644, -1739, -1822, -1893, -1952, -2000,
+	2037, 2063, 2080, 2087, 2085, 2075, 2057, 2032, 2001, 1962, 1919, 1870, 1817, 1759, 1698, 1634,
+	1567, 1498, 1428, 1356, 1283, 1210, 1137, 1064, 991, 919, 848, 779, 711, 645, 581, 519,
+	459, 401, 347, 294, 244, 197, 153, 111, 72, 36, 2, -29, -57, -83, -106, -127,
+	-146, -163, -177, -189, -200, -208, -215, -221, -224, -227, -228, -228, -227, -225, -222, -218,
+	213, 208, 202, 196, 190, 183, 176, 169, 161, 154, 147, 139, 132, 125, 117, 111,
+	104, 97, 91, 85, 79, 73, 68, 63, 58, 53, 49, 45, 41, 38, 35, 31,
+	29, 26, 24, 21, 19, 17, 16, 14, 13, 11, 10, 9, 8, 7, 7, 6,
+	5, 5, 4, 4, 3, 3, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1,
+}
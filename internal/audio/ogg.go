@@ -0,0 +1,280 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Ogg Opus chunks are complete streams, each with its own serial number, header
+// pages and granule positions. Assembled Ogg audio is kept as one logical stream
+// instead of a chain of them: Piece drops the header pages (OpusHead and
+// OpusTags) of every piece but the first, and fixOgg renumbers the pages of the
+// result as a single stream, deriving the granule positions from the packets.
+//
+// As RFC 7845 defines them, granule positions count the samples decoded from
+// the start of the stream, including the pre-skip of its OpusHead, which players
+// drop. The last page may end the stream earlier than its packets do, trimming
+// the padding of the encoder, and never before the pre-skip.
+
+// oggPageHeaderSize is the size of an Ogg page header without its segment table.
+const oggPageHeaderSize = 27
+
+// Ogg page header flags.
+const (
+	oggContinued = 0x01 // the page starts with the rest of a packet
+	oggFirst     = 0x02 // beginning of stream
+	oggLast      = 0x04 // end of stream
+)
+
+// oggPageLen returns the length of the Ogg page at the start of b, or 0 if b
+// does not start with a complete page.
+func oggPageLen(b []byte) int {
+	if len(b) < oggPageHeaderSize || !bytes.HasPrefix(b, []byte("OggS")) {
+		return 0
+	}
+	n := oggPageHeaderSize + int(b[26])
+	if n > len(b) {
+		return 0
+	}
+	for _, s := range b[oggPageHeaderSize:n] {
+		n += int(s)
+	}
+	if n > len(b) {
+		return 0
+	}
+	return n
+}
+
+// oggHeaderEnd returns the length of the header pages of Ogg Opus data, which
+// end with the page completing the OpusTags packet, or 0 if data is cut short.
+// Audio packets always start on a page of their own.
+func oggHeaderEnd(data []byte) int {
+	packets := 0
+	for pos := 0; pos < len(data); {
+		n := oggPageLen(data[pos:])
+		if n == 0 {
+			return 0
+		}
+		for _, s := range data[pos+oggPageHeaderSize : pos+oggPageHeaderSize+int(data[pos+26])] {
+			if s < 255 {
+				packets++
+			}
+		}
+		pos += n
+		if packets >= 2 {
+			return pos
+		}
+	}
+	return 0
+}
+
+// oggStream tracks the pages of assembled Ogg audio while fixOgg rewrites them.
+type oggStream struct {
+	serial  uint32
+	pages   uint32
+	packets int    // packets completed so far, the first two are the headers
+	granule int64  // samples of the audio packets completed so far
+	packet  []byte // the first bytes of an unfinished packet
+	preSkip int64  // samples the OpusHead tells players to drop
+
+	pieceStart int64 // granule where the current piece starts
+	pieceEnded bool  // whether the previous page ended a piece
+}
+
+// maxOggTrim bounds how many samples the last page may trim: the padding an
+// encoder adds, at most an unfilled 120 ms frame and its lookahead.
+const maxOggTrim = 5760 + 312
+
+// fix rewrites the header of page as the next page of the stream.
+func (s *oggStream) fix(page []byte, last bool) {
+	if s.pages == 0 {
+		s.serial = binary.LittleEndian.Uint32(page[14:18])
+	}
+	if s.pieceEnded {
+		// The pages of the next piece, whose granule positions start over
+		s.pieceStart = s.granule
+	}
+	s.pieceEnded = page[5]&oggLast != 0
+	own := int64(binary.LittleEndian.Uint64(page[6:14]))
+	segments := page[oggPageHeaderSize : oggPageHeaderSize+int(page[26])]
+	body := page[oggPageHeaderSize+len(segments):]
+	if s.pages == 0 && bytes.HasPrefix(body, []byte("OpusHead")) && len(body) >= 19 {
+		s.preSkip = int64(binary.LittleEndian.Uint16(body[10:12]))
+	}
+	granule := int64(-1) // no packet ends on the page
+	for _, n := range segments {
+		seg := body[:n]
+		body = body[n:]
+		if len(s.packet) < 2 {
+			s.packet = append(s.packet, seg[:min(len(seg), 2-len(s.packet))]...)
+		}
+		if n == 255 {
+			continue
+		}
+		if s.packets >= 2 {
+			s.granule += opusSamples(s.packet)
+			granule = s.granule
+		} else {
+			granule = 0 // header pages have a granule position of 0
+		}
+		s.packets++
+		s.packet = s.packet[:0]
+	}
+	flags := page[5] & oggContinued
+	if s.pages == 0 {
+		flags |= oggFirst
+	}
+	if last {
+		flags |= oggLast
+		if granule > 0 {
+			// Keep the padding of the last piece trimmed, as its own position did
+			if end := s.pieceStart + own; end > s.pieceStart && end < granule && granule-end <= maxOggTrim {
+				granule = end
+			}
+			granule = max(granule, s.preSkip)
+		}
+	}
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:18], s.serial)
+	binary.LittleEndian.PutUint32(page[18:22], s.pages)
+	binary.LittleEndian.PutUint32(page[22:26], 0)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+	s.pages++
+}
+
+// fixOgg rewrites the pages of assembled Ogg audio in place as a single stream.
+func fixOgg(data []byte) {
+	var s oggStream
+	for pos := 0; pos < len(data); {
+		n := oggPageLen(data[pos:])
+		if n == 0 {
+			return
+		}
+		s.fix(data[pos:pos+n], pos+n == len(data))
+		pos += n
+	}
+}
+
+// fixOggFile is fixOgg for assembled audio written to f, one page at a time.
+func fixOggFile(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var s oggStream
+	head := make([]byte, oggPageHeaderSize)
+	for pos := int64(0); pos < info.Size(); {
+		if _, err := f.ReadAt(head, pos); err != nil {
+			return nil // a truncated page is left alone
+		}
+		// A page is at most 27 + 255 + 255*255 bytes, so it is read whole
+		page := make([]byte, oggPageHeaderSize+int(head[26])+255*255)
+		n, _ := f.ReadAt(page, pos)
+		n = oggPageLen(page[:n])
+		if n == 0 {
+			return nil
+		}
+		page = page[:n]
+		s.fix(page, pos+int64(n) == info.Size())
+		if _, err := f.WriteAt(page[:oggPageHeaderSize], pos); err != nil {
+			return fmt.Errorf("failed to write an Ogg page header: %w", err)
+		}
+		pos += int64(n)
+	}
+	return nil
+}
+
+// opusFrameSamples gives the 48 kHz samples per frame of each Opus TOC
+// configuration: SILK 10-60 ms, hybrid 10-20 ms and CELT 2.5-20 ms.
+var opusFrameSamples = [32]int64{
+	480, 960, 1920, 2880, 480, 960, 1920, 2880, 480, 960, 1920, 2880,
+	480, 960, 480, 960,
+	120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960,
+}
+
+// opusSamples returns the 48 kHz samples of the Opus packet starting with p,
+// from its TOC byte and, for code 3 packets, its frame count byte.
+func opusSamples(p []byte) int64 {
+	if len(p) == 0 {
+		return 0
+	}
+	frame := opusFrameSamples[p[0]>>3]
+	switch p[0] & 3 {
+	case 0:
+		return frame
+	case 1, 2:
+		return 2 * frame
+	}
+	if len(p) < 2 {
+		return 0
+	}
+	return int64(p[1]&0x3F) * frame
+}
+
+// opusSilence is a 20 ms CELT frame flagged as silent, without its TOC byte.
+var opusSilence = []byte{0xFF, 0xFE}
+
+// oggSilence returns d of silence as an Ogg Opus stream with the headers of like.
+func oggSilence(like []byte, d time.Duration) ([]byte, error) {
+	h := oggHeaderEnd(like)
+	if h == 0 || !bytes.HasPrefix(like[oggPageHeaderSize+int(like[26]):], []byte("OpusHead")) {
+		return nil, fmt.Errorf("%w: missing Opus headers", ErrInvalidAudio)
+	}
+	head := like[oggPageHeaderSize+int(like[26]):]
+	if len(head) < 19 || head[9] > 2 {
+		return nil, fmt.Errorf("silence is only supported for mono and stereo Opus audio")
+	}
+	toc := byte(31 << 3) // CELT fullband, 20 ms, one frame
+	if head[9] == 2 {
+		toc |= 0x04
+	}
+	packet := append([]byte{toc}, opusSilence...)
+	frames := max(int(d/(20*time.Millisecond)), 1)
+	data := bytes.Clone(like[:h])
+	for frames > 0 {
+		n := min(frames, 50) // a second per page
+		page := make([]byte, oggPageHeaderSize, oggPageHeaderSize+n*(1+len(packet)))
+		copy(page, "OggS")
+		page[26] = byte(n)
+		for i := 0; i < n; i++ {
+			page = append(page, byte(len(packet)))
+		}
+		for i := 0; i < n; i++ {
+			page = append(page, packet...)
+		}
+		data = append(data, page...)
+		frames -= n
+	}
+	fixOgg(data)
+	return data, nil
+}
+
+// oggCRCTable is the lookup table of the CRC-32 used by Ogg: polynomial
+// 0x04C11DB7, not reflected, with an initial value of 0.
+var oggCRCTable = func() (t [256]uint32) {
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04C11DB7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+// oggCRC returns the checksum of page, whose checksum field must be zero.
+func oggCRC(page []byte) uint32 {
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,272 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// PCM is decoded audio: interleaved 16-bit samples. It is what converting between
+// formats goes through, without ffmpeg or any other tool installed.
+type PCM struct {
+	SampleRate int
+	Channels   int
+	Samples    []int16
+}
+
+// Decode decodes MP3 or WAV data. WAV may hold 8- or 16-bit PCM, µ-law or A-law.
+func Decode(data []byte) (*PCM, error) {
+	switch Sniff(data) {
+	case "mp3":
+		return decodeMP3(data)
+	case "wav":
+		return decodeWAV(data)
+	}
+	return nil, fmt.Errorf("decoding %q audio is not supported", Sniff(data))
+}
+
+// CanConvert reports whether Convert turns audio of format from into format to.
+func CanConvert(from, to string) bool {
+	from, to = NormalizeFormat(from), NormalizeFormat(to)
+	return from == to || (from == "mp3" || from == "wav") && (to == "mp3" || to == "wav")
+}
+
+// Convert returns data in format, decoding and encoding it if needed. MP3 and
+// WAV (16-bit PCM) can be converted into each other, MP3 is encoded at bitrate
+// kbit/s (see PCM.MP3); data already in format is returned as it is.
+func Convert(data []byte, format string, bitrate int) ([]byte, error) {
+	from, to := Sniff(data), NormalizeFormat(format)
+	if from == to {
+		return data, nil
+	}
+	if !CanConvert(from, to) {
+		return nil, fmt.Errorf("converting %q audio to %s is not supported", from, to)
+	}
+	pcm, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return pcm.Encode(to, bitrate)
+}
+
+// Match returns data, a piece of assembled audio, with the sample rate and
+// channels of like, the first piece, so chunks from different providers or
+// voices can be joined. Mismatching MP3 is re-encoded at bitrate kbit/s, WAV as
+// 16-bit PCM if like is. Ogg Opus always plays at 48 kHz and is returned as it
+// is, as is audio that already matches.
+func Match(data, like []byte, bitrate int) ([]byte, error) {
+	format := Sniff(data)
+	if format != Sniff(like) {
+		return nil, fmt.Errorf("cannot join %s audio to %s audio", format, Sniff(like))
+	}
+	var rate, channels int
+	switch format {
+	case "mp3":
+		want, ok := ParseMP3Frame(like[SkipID3v2(like):])
+		got, _ := ParseMP3Frame(data[SkipID3v2(data):])
+		if !ok || got.SampleRate == want.SampleRate && got.Channels == want.Channels {
+			return data, nil
+		}
+		rate, channels = want.SampleRate, want.Channels
+	case "wav":
+		want, _, err := ParseWAV(like)
+		if err != nil {
+			return data, nil
+		}
+		if got, _, err := ParseWAV(data); err != nil || got == want {
+			return data, nil
+		}
+		if want.AudioFormat != 1 || want.BitsPerSample != 16 {
+			return nil, fmt.Errorf("cannot convert audio to WAV format %d with %d bits per sample", want.AudioFormat, want.BitsPerSample)
+		}
+		rate, channels = int(want.SampleRate), int(want.Channels)
+	default:
+		return data, nil
+	}
+	pcm, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return pcm.Remix(channels).Resample(rate).Encode(format, bitrate)
+}
+
+// Resample returns p at rate, interpolating linearly between samples. It is
+// meant for speech, which has little above the lower of the two Nyquist
+// frequencies, so there is no filtering.
+func (p *PCM) Resample(rate int) *PCM {
+	if rate == p.SampleRate || p.SampleRate == 0 || p.Channels == 0 {
+		return p
+	}
+	n := int(int64(p.frames()) * int64(rate) / int64(p.SampleRate))
+	out := &PCM{SampleRate: rate, Channels: p.Channels, Samples: make([]int16, n*p.Channels)}
+	step := float64(p.SampleRate) / float64(rate)
+	for i := 0; i < n; i++ {
+		pos := float64(i) * step
+		j := int(pos)
+		frac := pos - float64(j)
+		for c := 0; c < p.Channels; c++ {
+			s := float64(p.Samples[j*p.Channels+c])
+			if j+1 < p.frames() {
+				s += frac * (float64(p.Samples[(j+1)*p.Channels+c]) - s)
+			}
+			out.Samples[i*p.Channels+c] = int16(math.Round(s))
+		}
+	}
+	return out
+}
+
+// Remix returns p with channels channels: mono is copied to both sides of
+// stereo, stereo is mixed down to mono.
+func (p *PCM) Remix(channels int) *PCM {
+	if channels == p.Channels || channels < 1 || channels > 2 || p.Channels < 1 || p.Channels > 2 {
+		return p
+	}
+	out := &PCM{SampleRate: p.SampleRate, Channels: channels, Samples: make([]int16, p.frames()*channels)}
+	for i := 0; i < p.frames(); i++ {
+		if channels == 2 {
+			out.Samples[2*i], out.Samples[2*i+1] = p.Samples[i], p.Samples[i]
+		} else {
+			out.Samples[i] = int16((int(p.Samples[2*i]) + int(p.Samples[2*i+1])) / 2)
+		}
+	}
+	return out
+}
+
+// Encode returns p as MP3, encoded at bitrate kbit/s, or as WAV.
+func (p *PCM) Encode(format string, bitrate int) ([]byte, error) {
+	switch NormalizeFormat(format) {
+	case "mp3":
+		return p.MP3(bitrate)
+	case "wav":
+		return p.WAV(), nil
+	}
+	return nil, fmt.Errorf("encoding %s audio is not supported", format)
+}
+
+// Duration returns how long p plays.
+func (p *PCM) Duration() time.Duration {
+	if p.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(p.frames()) * time.Second / time.Duration(p.SampleRate)
+}
+
+// WAV returns p as a 16-bit PCM WAV file.
+func (p *PCM) WAV() []byte {
+	format := WAVFormat{
+		AudioFormat:   1,
+		Channels:      uint16(p.Channels),
+		SampleRate:    uint32(p.SampleRate),
+		ByteRate:      uint32(p.SampleRate * p.Channels * 2),
+		BlockAlign:    uint16(p.Channels * 2),
+		BitsPerSample: 16,
+	}
+	data := WAVHeader(format, 2*len(p.Samples))
+	for _, s := range p.Samples {
+		data = binary.LittleEndian.AppendUint16(data, uint16(s))
+	}
+	return data
+}
+
+// frames returns the number of samples per channel.
+func (p *PCM) frames() int {
+	if p.Channels == 0 {
+		return 0
+	}
+	return len(p.Samples) / p.Channels
+}
+
+func decodeMP3(data []byte) (*PCM, error) {
+	frame, ok := ParseMP3Frame(data[SkipID3v2(data):])
+	if !ok {
+		return nil, fmt.Errorf("%w: no MPEG audio frame found", ErrInvalidAudio)
+	}
+	d, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAudio, err)
+	}
+	stereo, err := io.ReadAll(d)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAudio, err)
+	}
+	// The decoder always returns stereo; mono audio has the same samples twice
+	pcm := &PCM{SampleRate: d.SampleRate(), Channels: frame.Channels}
+	pcm.Samples = make([]int16, 0, len(stereo)/2/(3-frame.Channels))
+	for i := 0; i+4 <= len(stereo); i += 4 {
+		pcm.Samples = append(pcm.Samples, int16(binary.LittleEndian.Uint16(stereo[i:])))
+		if frame.Channels == 2 {
+			pcm.Samples = append(pcm.Samples, int16(binary.LittleEndian.Uint16(stereo[i+2:])))
+		}
+	}
+	return pcm, nil
+}
+
+func decodeWAV(data []byte) (*PCM, error) {
+	format, payload, err := ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if format.Channels == 0 || format.SampleRate == 0 {
+		return nil, fmt.Errorf("%w: no channels or sample rate in WAV header", ErrInvalidAudio)
+	}
+	pcm := &PCM{SampleRate: int(format.SampleRate), Channels: int(format.Channels)}
+	switch {
+	case format.AudioFormat == 1 && format.BitsPerSample == 16:
+		pcm.Samples = make([]int16, len(payload)/2)
+		for i := range pcm.Samples {
+			pcm.Samples[i] = int16(binary.LittleEndian.Uint16(payload[2*i:]))
+		}
+	case format.AudioFormat == 1 && format.BitsPerSample == 8:
+		pcm.Samples = make([]int16, len(payload))
+		for i, b := range payload {
+			pcm.Samples[i] = (int16(b) - 128) << 8
+		}
+	case format.AudioFormat == 7 && format.BitsPerSample == 8:
+		pcm.Samples = make([]int16, len(payload))
+		for i, b := range payload {
+			pcm.Samples[i] = ulaw(b)
+		}
+	case format.AudioFormat == 6 && format.BitsPerSample == 8:
+		pcm.Samples = make([]int16, len(payload))
+		for i, b := range payload {
+			pcm.Samples[i] = alaw(b)
+		}
+	default:
+		return nil, fmt.Errorf("decoding WAV format %d with %d bits per sample is not supported", format.AudioFormat, format.BitsPerSample)
+	}
+	return pcm, nil
+}
+
+// ulaw expands a G.711 µ-law sample.
+func ulaw(b byte) int16 {
+	b = ^b
+	t := (int16(b&0x0F)<<3 + 0x84) << (b & 0x70 >> 4)
+	if b&0x80 != 0 {
+		return 0x84 - t
+	}
+	return t - 0x84
+}
+
+// alaw expands a G.711 A-law sample.
+func alaw(b byte) int16 {
+	b ^= 0x55
+	t := int16(b&0x0F) << 4
+	switch seg := b & 0x70 >> 4; seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t = (t + 0x108) << (seg - 1)
+	}
+	if b&0x80 != 0 {
+		return t
+	}
+	return -t
+}
@@ -0,0 +1,461 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAudio is returned when a blob is not playable audio in the expected format.
+var ErrInvalidAudio = errors.New("invalid audio data")
+
+// Info describes a probed audio blob.
+type Info struct {
+	Format     string // Container detected from the data (mp3, wav, ogg, flac, aac)
+	Duration   time.Duration
+	SampleRate int
+	Channels   int
+}
+
+// NormalizeFormat maps provider format names onto the container they produce.
+// Google returns LINEAR16, MULAW and ALAW wrapped in a WAV header; OpenAI's opus is Ogg.
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "", "mp3":
+		return "mp3"
+	case "linear16", "wav", "mulaw", "alaw":
+		return "wav"
+	case "opus", "ogg_opus", "ogg":
+		return "ogg"
+	case "flac":
+		return "flac"
+	case "aac":
+		return "aac"
+	default:
+		return strings.ToLower(format)
+	}
+}
+
+// Validate checks that data is decodable audio of the requested format with a nonzero duration.
+func Validate(data []byte, format string) (*Info, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty response", ErrInvalidAudio)
+	}
+	if looksLikeText(data) {
+		return nil, fmt.Errorf("%w: response looks like a text body: %.80s", ErrInvalidAudio, strings.TrimSpace(string(data)))
+	}
+
+	want := NormalizeFormat(format)
+	got := Sniff(data)
+	if got == "" {
+		return nil, fmt.Errorf("%w: unrecognized data, expected %s", ErrInvalidAudio, want)
+	}
+	if got != want {
+		return nil, fmt.Errorf("%w: expected %s but received %s", ErrInvalidAudio, want, got)
+	}
+
+	info, err := Probe(data)
+	if err != nil {
+		return nil, err
+	}
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("%w: zero duration %s", ErrInvalidAudio, got)
+	}
+	return info, nil
+}
+
+// Sniff returns the container format identified by the magic bytes of data, or "" if unknown.
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("RIFF")) && len(data) >= 12 && string(data[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "ogg"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "flac"
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return "mp3"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xF6 == 0xF0:
+		return "aac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return "mp3"
+	}
+	return ""
+}
+
+// Probe parses the container headers of data and reports its duration.
+func Probe(data []byte) (*Info, error) {
+	switch Sniff(data) {
+	case "mp3":
+		return probeMP3(data)
+	case "wav":
+		return probeWAV(data)
+	case "ogg":
+		return probeOgg(data)
+	case "flac":
+		return probeFLAC(data)
+	case "aac":
+		return probeAAC(data)
+	}
+	return nil, fmt.Errorf("%w: unrecognized container", ErrInvalidAudio)
+}
+
+// looksLikeText reports whether data is a JSON, HTML or plain-text error body.
+func looksLikeText(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return true
+	}
+	switch trimmed[0] {
+	case '{', '[', '<':
+		return true
+	}
+	return false
+}
+
+// --- MP3 ---
+
+var (
+	mp3Bitrates = [2][3][16]int{
+		// MPEG-1: layer I, II, III
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, -1},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, -1},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, -1},
+		},
+		// MPEG-2 and 2.5: layer I, II, III
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, -1},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, -1},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, -1},
+		},
+	}
+	mp3SampleRates = map[int][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+)
+
+// MP3Frame describes a single MPEG audio frame header.
+type MP3Frame struct {
+	Length     int
+	Samples    int
+	SampleRate int
+	Channels   int
+	Bitrate    int // kbit/s
+}
+
+// ParseMP3Frame decodes the frame header at the start of b.
+func ParseMP3Frame(b []byte) (MP3Frame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return MP3Frame{}, false
+	}
+	version := int(b[1]>>3) & 3
+	layer := int(b[1]>>1) & 3
+	bitrateIdx := int(b[2] >> 4)
+	rateIdx := int(b[2]>>2) & 3
+	padding := int(b[2]>>1) & 1
+	if version == 1 || layer == 0 || rateIdx == 3 {
+		return MP3Frame{}, false
+	}
+
+	table := 1
+	if version == 3 {
+		table = 0
+	}
+	layerIdx := 3 - layer // layer bits: 3=I, 2=II, 1=III
+	bitrate := mp3Bitrates[table][layerIdx][bitrateIdx]
+	if bitrate <= 0 {
+		return MP3Frame{}, false
+	}
+	sampleRate := mp3SampleRates[version][rateIdx]
+
+	var samples, length int
+	switch layerIdx {
+	case 0:
+		samples = 384
+		length = (12*bitrate*1000/sampleRate + padding) * 4
+	case 1:
+		samples = 1152
+		length = 144*bitrate*1000/sampleRate + padding
+	default:
+		samples = 1152
+		if version != 3 {
+			samples = 576
+		}
+		length = samples/8*bitrate*1000/sampleRate + padding
+	}
+
+	channels := 2
+	if b[3]>>6 == 3 {
+		channels = 1
+	}
+	return MP3Frame{Length: length, Samples: samples, SampleRate: sampleRate, Channels: channels, Bitrate: bitrate}, true
+}
+
+// SkipID3v2 returns the offset of the first byte after a leading ID3v2 tag.
+func SkipID3v2(data []byte) int {
+	if len(data) < 10 || !bytes.HasPrefix(data, []byte("ID3")) {
+		return 0
+	}
+	size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+	offset := 10 + size
+	if data[5]&0x10 != 0 {
+		offset += 10 // footer present
+	}
+	if offset > len(data) {
+		return len(data)
+	}
+	return offset
+}
+
+func probeMP3(data []byte) (*Info, error) {
+	pos := SkipID3v2(data)
+	info := &Info{Format: "mp3"}
+	var samples int
+	frames := 0
+	for pos < len(data) {
+		frame, ok := ParseMP3Frame(data[pos:])
+		if !ok {
+			break
+		}
+		if pos+frame.Length > len(data) {
+			// A truncated final frame is tolerated; players skip it.
+			break
+		}
+		if frames == 0 {
+			info.SampleRate = frame.SampleRate
+			info.Channels = frame.Channels
+		}
+		samples += frame.Samples
+		frames++
+		pos += frame.Length
+	}
+	if frames == 0 {
+		return nil, fmt.Errorf("%w: no MPEG audio frames found", ErrInvalidAudio)
+	}
+
+	// Only an ID3v1 tag or a partial last frame may follow the frame stream.
+	rest := data[pos:]
+	if len(rest) > 0 && !bytes.HasPrefix(rest, []byte("TAG")) {
+		if _, ok := ParseMP3Frame(rest); !ok {
+			return nil, fmt.Errorf("%w: %d bytes of non-audio data after frame %d", ErrInvalidAudio, len(rest), frames)
+		}
+	}
+
+	info.Duration = time.Duration(float64(samples) / float64(info.SampleRate) * float64(time.Second))
+	return info, nil
+}
+
+// --- WAV ---
+
+// WAVFormat holds the fields of a WAV "fmt " chunk.
+type WAVFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// ParseWAV returns the format description and PCM payload of a RIFF/WAVE blob.
+func ParseWAV(data []byte) (WAVFormat, []byte, error) {
+	var format WAVFormat
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return format, nil, fmt.Errorf("%w: missing RIFF/WAVE header", ErrInvalidAudio)
+	}
+	var payload []byte
+	haveFmt := false
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		end := body + size
+		if end > len(data) || size < 0 {
+			end = len(data) // tolerate streaming headers with an unknown length
+		}
+		switch id {
+		case "fmt ":
+			if end-body < 16 {
+				return format, nil, fmt.Errorf("%w: short fmt chunk", ErrInvalidAudio)
+			}
+			chunk := data[body:end]
+			format = WAVFormat{
+				AudioFormat:   binary.LittleEndian.Uint16(chunk[0:2]),
+				Channels:      binary.LittleEndian.Uint16(chunk[2:4]),
+				SampleRate:    binary.LittleEndian.Uint32(chunk[4:8]),
+				ByteRate:      binary.LittleEndian.Uint32(chunk[8:12]),
+				BlockAlign:    binary.LittleEndian.Uint16(chunk[12:14]),
+				BitsPerSample: binary.LittleEndian.Uint16(chunk[14:16]),
+			}
+			haveFmt = true
+		case "data":
+			payload = data[body:end]
+		}
+		pos = end + size%2 // chunks are word aligned
+		if pos <= body {
+			break
+		}
+	}
+	if !haveFmt {
+		return format, nil, fmt.Errorf("%w: missing fmt chunk", ErrInvalidAudio)
+	}
+	if payload == nil {
+		return format, nil, fmt.Errorf("%w: missing data chunk", ErrInvalidAudio)
+	}
+	return format, payload, nil
+}
+
+func probeWAV(data []byte) (*Info, error) {
+	format, payload, err := ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if format.ByteRate == 0 {
+		return nil, fmt.Errorf("%w: zero byte rate in WAV header", ErrInvalidAudio)
+	}
+	return &Info{
+		Format:     "wav",
+		Duration:   time.Duration(float64(len(payload)) / float64(format.ByteRate) * float64(time.Second)),
+		SampleRate: int(format.SampleRate),
+		Channels:   int(format.Channels),
+	}, nil
+}
+
+// --- Ogg ---
+
+// OggPage is a single page of an Ogg bitstream.
+type OggPage struct {
+	HeaderType byte
+	Granule    int64
+	Serial     uint32
+	Sequence   uint32
+	Segments   []byte
+	Payload    []byte
+	Raw        []byte
+}
+
+// ParseOggPages splits data into its Ogg pages.
+func ParseOggPages(data []byte) ([]OggPage, error) {
+	var pages []OggPage
+	pos := 0
+	for pos < len(data) {
+		if len(data)-pos < 27 || string(data[pos:pos+4]) != "OggS" {
+			return pages, fmt.Errorf("%w: bad Ogg page at offset %d", ErrInvalidAudio, pos)
+		}
+		nsegs := int(data[pos+26])
+		headerLen := 27 + nsegs
+		if pos+headerLen > len(data) {
+			return pages, fmt.Errorf("%w: truncated Ogg page header", ErrInvalidAudio)
+		}
+		segments := data[pos+27 : pos+headerLen]
+		bodyLen := 0
+		for _, s := range segments {
+			bodyLen += int(s)
+		}
+		end := pos + headerLen + bodyLen
+		if end > len(data) {
+			return pages, fmt.Errorf("%w: truncated Ogg page body", ErrInvalidAudio)
+		}
+		pages = append(pages, OggPage{
+			HeaderType: data[pos+5],
+			Granule:    int64(binary.LittleEndian.Uint64(data[pos+6 : pos+14])),
+			Serial:     binary.LittleEndian.Uint32(data[pos+14 : pos+18]),
+			Sequence:   binary.LittleEndian.Uint32(data[pos+18 : pos+22]),
+			Segments:   segments,
+			Payload:    data[pos+headerLen : end],
+			Raw:        data[pos:end],
+		})
+		pos = end
+	}
+	return pages, nil
+}
+
+func probeOgg(data []byte) (*Info, error) {
+	pages, err := ParseOggPages(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: no Ogg pages", ErrInvalidAudio)
+	}
+	head := pages[0].Payload
+	if !bytes.HasPrefix(head, []byte("OpusHead")) || len(head) < 19 {
+		return nil, fmt.Errorf("%w: Ogg stream is not Opus", ErrInvalidAudio)
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:12]))
+	last := pages[len(pages)-1].Granule
+	samples := last - preSkip
+	if samples < 0 {
+		samples = 0
+	}
+	return &Info{
+		Format:     "ogg",
+		Duration:   time.Duration(float64(samples) / 48000 * float64(time.Second)),
+		SampleRate: 48000, // Opus granule positions always count 48 kHz samples
+		Channels:   int(head[9]),
+	}, nil
+}
+
+// --- FLAC ---
+
+func probeFLAC(data []byte) (*Info, error) {
+	// "fLaC" + 4-byte metadata block header + 34-byte STREAMINFO
+	if len(data) < 42 || data[4]&0x7F != 0 {
+		return nil, fmt.Errorf("%w: missing FLAC STREAMINFO", ErrInvalidAudio)
+	}
+	si := data[8:]
+	sampleRate := int(si[10])<<12 | int(si[11])<<4 | int(si[12])>>4
+	channels := int(si[12]>>1)&7 + 1
+	total := int64(si[13]&0x0F)<<32 | int64(si[14])<<24 | int64(si[15])<<16 | int64(si[16])<<8 | int64(si[17])
+	if sampleRate == 0 {
+		return nil, fmt.Errorf("%w: zero FLAC sample rate", ErrInvalidAudio)
+	}
+	return &Info{
+		Format:     "flac",
+		Duration:   time.Duration(float64(total) / float64(sampleRate) * float64(time.Second)),
+		SampleRate: sampleRate,
+		Channels:   channels,
+	}, nil
+}
+
+// --- AAC (ADTS) ---
+
+var aacSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+func probeAAC(data []byte) (*Info, error) {
+	info := &Info{Format: "aac"}
+	var samples int
+	pos := 0
+	for pos+7 <= len(data) {
+		b := data[pos:]
+		if b[0] != 0xFF || b[1]&0xF6 != 0xF0 {
+			break
+		}
+		rateIdx := int(b[2]>>2) & 0x0F
+		if rateIdx >= len(aacSampleRates) {
+			break
+		}
+		length := int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5])>>5
+		if length < 7 || pos+length > len(data) {
+			break
+		}
+		if info.SampleRate == 0 {
+			info.SampleRate = aacSampleRates[rateIdx]
+			info.Channels = int(b[2]&0x01)<<2 | int(b[3]>>6)
+		}
+		samples += 1024 * (int(b[6]&0x03) + 1)
+		pos += length
+	}
+	if samples == 0 {
+		return nil, fmt.Errorf("%w: no ADTS frames found", ErrInvalidAudio)
+	}
+	info.Duration = time.Duration(float64(samples) / float64(info.SampleRate) * float64(time.Second))
+	return info, nil
+}
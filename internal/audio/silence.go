@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Silence returns d of silence in the same format as like, so it can be joined
+// with neighbouring chunks. MP3 silence repeats the first frame's header with empty
+// side information; WAV silence is a complete file of silent samples, and Ogg
+// silence a complete stream of silent Opus frames.
+func Silence(like []byte, d time.Duration) ([]byte, error) {
+	if d <= 0 {
+		return nil, nil
+	}
+	switch Sniff(like) {
+	case "mp3":
+		return mp3Silence(like, d)
+	case "wav":
+		format, _, err := ParseWAV(like)
+		if err != nil {
+			return nil, err
+		}
+		return wavSilence(format, d), nil
+	case "ogg":
+		return oggSilence(like, d)
+	}
+	return nil, fmt.Errorf("silence is not supported for %q audio", Sniff(like))
+}
+
+// silentMP3Frame is an MPEG-1 Layer III frame header: 32 kbit/s, 44.1 kHz, mono.
+var silentMP3Frame = []byte{0xFF, 0xFB, 0x10, 0xC4}
+
+// SilentMP3 returns d of MP3 silence without a file to copy the format from.
+func SilentMP3(d time.Duration) []byte {
+	data, _ := mp3Silence(silentMP3Frame, max(d, time.Millisecond))
+	return data
+}
+
+func mp3Silence(like []byte, d time.Duration) ([]byte, error) {
+	pos := SkipID3v2(like)
+	if _, ok := ParseMP3Frame(like[pos:]); !ok {
+		return nil, fmt.Errorf("%w: no MPEG audio frame to copy", ErrInvalidAudio)
+	}
+	header := []byte{like[pos], like[pos+1] | 0x01, like[pos+2] &^ 0x02, like[pos+3]} // no CRC, no padding
+	frame, _ := ParseMP3Frame(header)
+	frameDuration := time.Duration(float64(frame.Samples) / float64(frame.SampleRate) * float64(time.Second))
+	count := int((d + frameDuration - 1) / frameDuration)
+
+	silent := make([]byte, frame.Length)
+	copy(silent, header)
+	return bytes.Repeat(silent, count), nil
+}
+
+func wavSilence(format WAVFormat, d time.Duration) []byte {
+	n := int(float64(format.ByteRate) * d.Seconds())
+	if format.BlockAlign > 0 {
+		n -= n % int(format.BlockAlign)
+	}
+	fill := byte(0)
+	switch format.AudioFormat {
+	case 6: // A-law
+		fill = 0xD5
+	case 7: // µ-law
+		fill = 0xFF
+	}
+	data := bytes.Repeat([]byte{fill}, n)
+	if format.AudioFormat == 1 && format.BitsPerSample == 8 {
+		data = bytes.Repeat([]byte{0x80}, n) // 8-bit PCM is unsigned
+	}
+	return append(WAVHeader(format, n), data...)
+}
+
+// WAVHeader returns a canonical 44-byte RIFF/WAVE header for dataLen bytes of samples.
+func WAVHeader(format WAVFormat, dataLen int) []byte {
+	h := make([]byte, 44)
+	copy(h[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:8], uint32(36+dataLen))
+	copy(h[8:12], "WAVE")
+	copy(h[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:20], 16)
+	binary.LittleEndian.PutUint16(h[20:22], format.AudioFormat)
+	binary.LittleEndian.PutUint16(h[22:24], format.Channels)
+	binary.LittleEndian.PutUint32(h[24:28], format.SampleRate)
+	binary.LittleEndian.PutUint32(h[28:32], format.ByteRate)
+	binary.LittleEndian.PutUint16(h[32:34], format.BlockAlign)
+	binary.LittleEndian.PutUint16(h[34:36], format.BitsPerSample)
+	copy(h[36:40], "data")
+	binary.LittleEndian.PutUint32(h[40:44], uint32(dataLen))
+	return h
+}
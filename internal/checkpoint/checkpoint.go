@@ -0,0 +1,187 @@
+// Package checkpoint persists the running job while it is synthesized: the job
+// itself once, and after each chunk the audio so far and the position reached.
+// After a crash, quit or outage the job can be resumed from there instead of
+// from the first chunk.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/tts"
+)
+
+const (
+	jobFileName      = "job.json"
+	progressFileName = "progress.json"
+	audioFileName    = "audio"
+)
+
+// progress is the position of the job after its last chunk.
+type progress struct {
+	AudioBytes int64         `json:"audio_bytes"`
+	Chunks     int           `json:"chunks"`
+	Chapters   []tts.Chapter `json:"chapters,omitempty"`
+	Next       int           `json:"next"`
+	Current    tts.Segment   `json:"current"`
+}
+
+// Store keeps the checkpoint of one job, the last one started.
+type Store struct {
+	dir string
+
+	mu       sync.Mutex
+	job      tts.ResumeState
+	progress progress
+	// The audio of an earlier run precedes this run's, which shifts its chapters
+	partialBytes    int
+	partialDuration time.Duration
+}
+
+// Open returns the store in dir. Nothing is written until a job begins.
+func Open(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Begin replaces the checkpoint by a new job. partial is audio synthesized for
+// the job before, when a resumed job is checkpointed again.
+func (s *Store) Begin(job tts.ResumeState, partial []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove the old checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+	job.PartialAudio = ""
+	s.job = job
+	s.partialBytes, s.partialDuration = len(partial), 0
+	if info, err := audio.Probe(partial); err == nil {
+		s.partialDuration = info.Duration
+	}
+	s.progress = progress{AudioBytes: int64(len(partial)), Chunks: job.Chunks, Chapters: job.Chapters}
+	if len(job.Segments) > 0 {
+		s.progress.Current = job.Segments[0]
+	}
+	if err := os.WriteFile(s.path(audioFileName), partial, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint audio: %w", err)
+	}
+	if err := writeJSON(s.path(jobFileName), job); err != nil {
+		return err
+	}
+	return writeJSON(s.path(progressFileName), s.progress)
+}
+
+// Save records the progress after a chunk. The audio is appended to the audio
+// so far, so each chunk is written only once.
+func (s *Store) Save(cp tts.Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress.AudioBytes > 0 && s.progress.AudioBytes == int64(s.partialBytes) && len(cp.Audio) > 0 {
+		// The first audio of a resumed run continues the partial audio; a WAV
+		// header of its own would end up amid the samples
+		piece := audio.Piece(cp.Audio, s.partialBytes)
+		s.partialBytes -= len(cp.Audio) - len(piece)
+		cp.Audio = piece
+	}
+	if len(cp.Audio) > 0 {
+		f, err := os.OpenFile(s.path(audioFileName), os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open checkpoint audio: %w", err)
+		}
+		_, err = f.Write(cp.Audio)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write checkpoint audio: %w", err)
+		}
+	}
+	chapters := append([]tts.Chapter{}, s.job.Chapters...)
+	for _, c := range cp.Chapters {
+		c.Offset += s.partialBytes
+		c.Start += s.partialDuration
+		chapters = append(chapters, c)
+	}
+	s.progress = progress{
+		AudioBytes: s.progress.AudioBytes + int64(len(cp.Audio)),
+		Chunks:     s.job.Chunks + cp.Chunks,
+		Chapters:   chapters,
+		Next:       cp.Next,
+		Current:    cp.Current,
+	}
+	return writeJSON(s.path(progressFileName), s.progress)
+}
+
+// Finish removes the checkpoint once the job's output is saved.
+func (s *Store) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	os.RemoveAll(s.dir)
+}
+
+// Last returns the interrupted job, if any, as a ResumeState whose segments are
+// those still to synthesize and whose PartialAudio holds the audio so far.
+// Audio written after the last recorded chunk, by a crash mid-write, is cut off.
+func (s *Store) Last() (*tts.ResumeState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var job tts.ResumeState
+	var p progress
+	if readJSON(s.path(jobFileName), &job) != nil || readJSON(s.path(progressFileName), &p) != nil {
+		return nil, false
+	}
+	audioPath := s.path(audioFileName)
+	info, err := os.Stat(audioPath)
+	if err != nil || info.Size() < p.AudioBytes {
+		return nil, false
+	}
+	if info.Size() > p.AudioBytes {
+		if err := os.Truncate(audioPath, p.AudioBytes); err != nil {
+			return nil, false
+		}
+	}
+	cp := tts.Checkpoint{Next: p.Next, Current: p.Current}
+	job.Segments = cp.Remaining(job.Segments)
+	job.Chunks = p.Chunks
+	job.Chapters = p.Chapters
+	if p.AudioBytes > 0 {
+		job.PartialAudio = audioPath
+	}
+	return &job, true
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// writeJSON replaces path atomically, so a crash leaves the previous version.
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("empty checkpoint file")
+	}
+	return json.Unmarshal(data, v)
+}
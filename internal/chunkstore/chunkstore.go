@@ -0,0 +1,159 @@
+// Package chunkstore keeps the audio of one job as numbered files, one per
+// chunk, pause or filler, in the order they are assembled. The output file is
+// built from them in a separate merge step, so a single chunk can be replaced
+// later and the audio so far exported without synthesizing anything again.
+package chunkstore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileExt marks the chunk files; temporary files start with a dot.
+const fileExt = ".chunk"
+
+// Store is a directory of chunk files.
+type Store struct {
+	dir string
+
+	mu   sync.Mutex
+	next int
+}
+
+// Open returns the store in dir, creating the directory if needed. Chunks
+// added to a store opened again follow those already there.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	names, err := files(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, next: len(names)}, nil
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add stores data as the next chunk and returns its file name. Empty data is
+// stored as an empty file, which keeps the place of a chunk left out.
+func (s *Store) Add(data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := fmt.Sprintf("%06d%s", s.next, fileExt)
+	if err := s.write(name, data); err != nil {
+		return "", err
+	}
+	s.next++
+	return name, nil
+}
+
+// Replace overwrites the chunk stored as name by Add.
+func (s *Store) Replace(name string, data []byte) error {
+	if name == "" || filepath.Base(name) != name || !strings.HasSuffix(name, fileExt) {
+		return fmt.Errorf("invalid chunk file %q", name)
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("chunk file %s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(name, data)
+}
+
+// Merge writes the chunks to w in order and returns the number of bytes written.
+func (s *Store) Merge(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, err := files(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(s.dir, name))
+		if err != nil {
+			return total, fmt.Errorf("failed to read chunk %s: %w", name, err)
+		}
+		n, err := io.Copy(w, f)
+		f.Close()
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("failed to merge chunk %s: %w", name, err)
+		}
+	}
+	return total, nil
+}
+
+// Head returns up to n bytes from the start of the merged chunks, or nil if
+// there are none yet.
+func (s *Store) Head(n int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, err := files(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var head []byte
+	for _, name := range names {
+		if len(head) >= n {
+			break
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk %s: %w", name, err)
+		}
+		head = append(head, data[:min(len(data), n-len(head))]...)
+	}
+	return head, nil
+}
+
+// Remove deletes the store directory and its chunks.
+func (s *Store) Remove() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.RemoveAll(s.dir)
+}
+
+// write stores data under name. The file is written under a temporary name
+// first, so a crash never leaves a truncated chunk behind.
+func (s *Store) write(name string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write chunk %s: %w", name, err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write chunk %s: %w", name, err)
+	}
+	return nil
+}
+
+// files lists the chunk files in dir in order.
+func files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasSuffix(e.Name(), fileExt) && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -25,24 +27,52 @@ const (
 	googleAuthMethodKeychainUser    = "auth_method"
 )
 
+// Keychain configuration for the Google service account key
+const (
+	googleCredentialsKeychainService = "Quacker_Google_Credentials"
+	googleCredentialsKeychainUser    = "credentials"
+)
+
+// Keychain configuration for the Google account signed in to, see package googleauth
+const (
+	googleAccountKeychainService = "Quacker_Google_Account"
+	googleAccountKeychainUser    = "authorized_user"
+)
+
 // Keychain configuration for default provider
 const (
 	defaultProviderKeychainService = "Quacker_DefaultProvider"
 	defaultProviderKeychainUser    = "default"
 )
 
+// Keychain configuration for the password of the minisign signing key
+const (
+	signingKeychainService = "Quacker_Signing"
+	signingKeychainUser    = "key_password"
+)
+
+// Keychain configuration for the password or secret key of the upload target
+const (
+	uploadKeychainService = "Quacker_Upload"
+	uploadKeychainUser    = "secret"
+)
+
 // Config holds configuration for all TTS providers.
 type Config struct {
 	// OpenAI configuration
 	OpenAIAPIKey string
 
 	// Google Cloud configuration
-	GoogleProjectID  string
-	GoogleAPIKey     string
-	GoogleAuthMethod string
+	GoogleProjectID   string
+	GoogleAPIKey      string
+	GoogleAuthMethod  string
+	GoogleCredentials string // Service account key file or its JSON; the signed-in account for "Google Account"
 
 	// Default provider
 	DefaultProvider string
+
+	// File is the config file, empty if there is none
+	File *File
 }
 
 // LoadEnvFiles loads environment variables from .env files in the current
@@ -56,9 +86,15 @@ func LoadEnvFiles() {
 	}
 }
 
-// LoadConfig loads configuration from environment variables and keychain.
+// LoadConfig loads configuration from environment variables, the config file
+// and keychain.
 func LoadConfig() (*Config, error) {
 	config := &Config{}
+	file, err := LoadFile()
+	if err != nil {
+		return nil, err
+	}
+	config.File = file
 
 	// Load OpenAI configuration
 	config.OpenAIAPIKey = getOpenAIAPIKey()
@@ -67,12 +103,25 @@ func LoadConfig() (*Config, error) {
 	config.GoogleProjectID = getGoogleProjectID()
 	config.GoogleAPIKey = getGoogleAPIKey()
 	config.GoogleAuthMethod = getGoogleAuthMethod()
+	config.GoogleCredentials = getGoogleCredentials()
+	if config.GoogleAuthMethod == "Google Account" {
+		config.GoogleCredentials = GetGoogleAccount()
+	}
+	if config.GoogleProjectID == "" && config.GoogleAuthMethod == "Service Account" {
+		config.GoogleProjectID, _ = GoogleCredentialsProjectID(config.GoogleCredentials)
+	}
 
-	// Set default provider from keychain, then env, then auto
-	config.DefaultProvider = GetDefaultProviderFromKeychain()
+	// Set default provider from env, then keychain, then the config file, then auto
+	config.DefaultProvider = os.Getenv("QUACKER_PROVIDER")
 	if config.DefaultProvider == "" {
 		config.DefaultProvider = os.Getenv("DEFAULT_TTS_PROVIDER")
 	}
+	if config.DefaultProvider == "" {
+		config.DefaultProvider = GetDefaultProviderFromKeychain()
+	}
+	if config.DefaultProvider == "" {
+		config.DefaultProvider = file.Provider
+	}
 	if config.DefaultProvider == "" {
 		// Auto-select based on available configuration
 		if config.OpenAIAPIKey != "" {
@@ -103,14 +152,14 @@ func getOpenAIAPIKey() string {
 	}
 
 	// Fall back to keychain
-	apiKey, err := keyring.Get(openAIKeychainService, openAIKeychainUser)
+	apiKey, err := secretGet(openAIKeychainService, openAIKeychainUser)
 	if err == nil && apiKey != "" {
 		return apiKey
 	}
 
 	// Log warning but don't block
 	if err != nil && err != keyring.ErrNotFound {
-		fmt.Printf("Warning: OpenAI keychain access error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: OpenAI keychain access error: %v\n", err)
 	}
 
 	return ""
@@ -137,14 +186,14 @@ func getGoogleProjectID() string {
 	}
 
 	// Fall back to keychain
-	projectID, err := keyring.Get(googleKeychainService, googleKeychainUser)
+	projectID, err := secretGet(googleKeychainService, googleKeychainUser)
 	if err == nil && projectID != "" {
 		return projectID
 	}
 
 	// Log warning but don't block
 	if err != nil && err != keyring.ErrNotFound {
-		fmt.Printf("Warning: Google Cloud keychain access error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Google Cloud keychain access error: %v\n", err)
 	}
 
 	return ""
@@ -164,22 +213,22 @@ func getGcloudProjectID() string {
 
 // SetOpenAIAPIKey stores the OpenAI API key in the keychain.
 func SetOpenAIAPIKey(apiKey string) error {
-	return keyring.Set(openAIKeychainService, openAIKeychainUser, apiKey)
+	return secretSet(openAIKeychainService, openAIKeychainUser, apiKey)
 }
 
 // SetGoogleProjectID stores the Google Cloud project ID in the keychain.
 func SetGoogleProjectID(projectID string) error {
-	return keyring.Set(googleKeychainService, googleKeychainUser, projectID)
+	return secretSet(googleKeychainService, googleKeychainUser, projectID)
 }
 
 // SetDefaultProvider stores the default provider in the keychain.
 func SetDefaultProvider(provider string) error {
-	return keyring.Set(defaultProviderKeychainService, defaultProviderKeychainUser, provider)
+	return secretSet(defaultProviderKeychainService, defaultProviderKeychainUser, provider)
 }
 
 // GetDefaultProviderFromKeychain retrieves the default provider from the keychain.
 func GetDefaultProviderFromKeychain() string {
-	val, err := keyring.Get(defaultProviderKeychainService, defaultProviderKeychainUser)
+	val, err := secretGet(defaultProviderKeychainService, defaultProviderKeychainUser)
 	if err == nil && val != "" {
 		return val
 	}
@@ -201,14 +250,14 @@ func getGoogleAPIKey() string {
 	}
 
 	// Fall back to keychain
-	apiKey, err := keyring.Get(googleAPIKeyKeychainService, googleAPIKeyKeychainUser)
+	apiKey, err := secretGet(googleAPIKeyKeychainService, googleAPIKeyKeychainUser)
 	if err == nil && apiKey != "" {
 		return apiKey
 	}
 
 	// Log warning but don't block
 	if err != nil && err != keyring.ErrNotFound {
-		fmt.Printf("Warning: Google API key keychain access error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Google API key keychain access error: %v\n", err)
 	}
 
 	return ""
@@ -223,14 +272,14 @@ func getGoogleAuthMethod() string {
 	}
 
 	// Fall back to keychain
-	method, err := keyring.Get(googleAuthMethodKeychainService, googleAuthMethodKeychainUser)
+	method, err := secretGet(googleAuthMethodKeychainService, googleAuthMethodKeychainUser)
 	if err == nil && method != "" {
 		return method
 	}
 
 	// Log warning but don't block
 	if err != nil && err != keyring.ErrNotFound {
-		fmt.Printf("Warning: Google auth method keychain access error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Google auth method keychain access error: %v\n", err)
 	}
 
 	// Default to gcloud auth
@@ -239,10 +288,99 @@ func getGoogleAuthMethod() string {
 
 // SetGoogleAPIKey stores the Google Cloud API key in the keychain.
 func SetGoogleAPIKey(apiKey string) error {
-	return keyring.Set(googleAPIKeyKeychainService, googleAPIKeyKeychainUser, apiKey)
+	return secretSet(googleAPIKeyKeychainService, googleAPIKeyKeychainUser, apiKey)
 }
 
 // SetGoogleAuthMethod stores the Google Cloud authentication method in the keychain.
 func SetGoogleAuthMethod(method string) error {
-	return keyring.Set(googleAuthMethodKeychainService, googleAuthMethodKeychainUser, method)
+	return secretSet(googleAuthMethodKeychainService, googleAuthMethodKeychainUser, method)
+}
+
+// GetSigningKeyPassword retrieves the password of the minisign signing key from
+// the keychain; keys without a password return "".
+func GetSigningKeyPassword() string {
+	val, err := secretGet(signingKeychainService, signingKeychainUser)
+	if err == nil {
+		return val
+	}
+	return ""
+}
+
+// SetSigningKeyPassword stores the password of the minisign signing key in the keychain.
+func SetSigningKeyPassword(password string) error {
+	return secretSet(signingKeychainService, signingKeychainUser, password)
+}
+
+// GetUploadSecret retrieves the WebDAV password or S3 secret access key of the
+// upload target from the keychain.
+func GetUploadSecret() string {
+	val, err := secretGet(uploadKeychainService, uploadKeychainUser)
+	if err == nil {
+		return val
+	}
+	return ""
+}
+
+// SetUploadSecret stores the password or secret key of the upload target in the keychain.
+func SetUploadSecret(secret string) error {
+	return secretSet(uploadKeychainService, uploadKeychainUser, secret)
+}
+
+// getGoogleCredentials retrieves the service account key of Google Cloud, a
+// file or its JSON, from environment or keychain.
+func getGoogleCredentials() string {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path
+	}
+	val, err := secretGet(googleCredentialsKeychainService, googleCredentialsKeychainUser)
+	if err != nil && err != keyring.ErrNotFound {
+		fmt.Fprintf(os.Stderr, "Warning: Google credentials keychain access error: %v\n", err)
+	}
+	return val
+}
+
+// SetGoogleCredentials stores the service account key of Google Cloud, a file
+// or its JSON, in the keychain.
+func SetGoogleCredentials(credentials string) error {
+	return secretSet(googleCredentialsKeychainService, googleCredentialsKeychainUser, credentials)
+}
+
+// GoogleCredentialsProjectID returns the project of a service account key,
+// given as a file or its JSON, after checking that it is one.
+func GoogleCredentialsProjectID(credentials string) (string, error) {
+	data := []byte(credentials)
+	if !strings.HasPrefix(strings.TrimSpace(credentials), "{") {
+		var err error
+		if data, err = os.ReadFile(credentials); err != nil {
+			return "", err
+		}
+	}
+	var key struct {
+		Type        string `json:"type"`
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", fmt.Errorf("invalid service account key: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" {
+		return "", errors.New("not a service account key")
+	}
+	return key.ProjectID, nil
+}
+
+// GetGoogleAccount retrieves the credentials of the Google account signed in
+// to, "" if none, from the keychain.
+func GetGoogleAccount() string {
+	val, err := secretGet(googleAccountKeychainService, googleAccountKeychainUser)
+	if err != nil && err != keyring.ErrNotFound {
+		fmt.Fprintf(os.Stderr, "Warning: Google account keychain access error: %v\n", err)
+	}
+	return val
+}
+
+// SetGoogleAccount stores the credentials of the Google account signed in to in
+// the keychain.
+func SetGoogleAccount(credentials string) error {
+	return secretSet(googleAccountKeychainService, googleAccountKeychainUser, credentials)
 }
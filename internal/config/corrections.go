@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const correctionsDirName = "corrections"
+
+// Corrections are the fixes made while reviewing one document. They are stored
+// by the document's content hash and applied whenever the same text is converted again.
+type Corrections struct {
+	// Acronyms maps a token to its rule for this document, see Settings.AcronymOverrides.
+	Acronyms map[string]string `json:"acronyms,omitempty"`
+	// Stages enables or disables preprocessing stages for this document only.
+	Stages map[string]bool `json:"stages,omitempty"`
+}
+
+// Empty reports whether c holds no corrections.
+func (c *Corrections) Empty() bool {
+	return len(c.Acronyms) == 0 && len(c.Stages) == 0
+}
+
+// correctionsPath returns the file holding the corrections for a document hash.
+func correctionsPath(hash string) (string, error) {
+	dir, err := AppDataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, correctionsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return filepath.Join(dir, hash+".json"), nil
+}
+
+// LoadCorrections returns the corrections stored for the document with the given
+// content hash, or empty corrections if there are none.
+func LoadCorrections(hash string) (*Corrections, error) {
+	c := &Corrections{}
+	path, err := correctionsPath(hash)
+	if err != nil {
+		return c, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return &Corrections{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// SaveCorrections stores the corrections for a document hash; empty corrections
+// remove the file.
+func SaveCorrections(hash string, c *Corrections) error {
+	path, err := correctionsPath(hash)
+	if err != nil {
+		return err
+	}
+	if c.Empty() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := writeJSONFile(path, c); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
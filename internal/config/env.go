@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envPrefix starts the environment variables that override settings.
+const envPrefix = "QUACKER_"
+
+// ApplyEnv overrides settings with environment variables for containers and
+// other headless setups. Each is named after the JSON key of its setting:
+// QUACKER_OUTPUT_DIR sets output_dir and QUACKER_RETRY_MAX_RETRIES max_retries
+// of retry. Lists and maps take JSON; maps of single values also
+// "key=value,key=value", e.g. QUACKER_CHUNK_LIMITS=openai=1500. QUACKER_SPEED
+// and QUACKER_VOICES set the defaults of the config file, see File. retry is the
+// policy that variables not set keep when s has none. Apply it through
+// Settings.Override, so the values are not saved.
+func ApplyEnv(s *Settings, retry RetrySettings) error {
+	if s.Retry == nil && hasEnvPrefix(envPrefix+"RETRY_") {
+		s.Retry = &retry
+	}
+	if err := applyEnvStruct(reflect.ValueOf(s).Elem(), envPrefix); err != nil {
+		return err
+	}
+	if v := os.Getenv(envPrefix + "SPEED"); v != "" {
+		speed, err := strconv.ParseFloat(v, 64)
+		if err != nil || speed < 0.25 || speed > 4 {
+			return fmt.Errorf("%sSPEED=%s: use a speed from 0.25 to 4", envPrefix, v)
+		}
+		s.DefaultSpeed = speed
+	}
+	if v := os.Getenv(envPrefix + "VOICES"); v != "" {
+		if err := setEnvValue(reflect.ValueOf(&s.DefaultVoices).Elem(), v); err != nil {
+			return fmt.Errorf("%sVOICES: %w", envPrefix, err)
+		}
+	}
+	return nil
+}
+
+// applyEnvStruct sets the fields of the struct v from the variables named
+// prefix and their JSON key.
+func applyEnvStruct(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(key)
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct {
+			field = field.Elem()
+			if !field.IsValid() {
+				continue // Set up by ApplyEnv where any variable asks for it
+			}
+		}
+		if field.Kind() == reflect.Struct {
+			if err := applyEnvStruct(field, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(field, val); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setEnvValue parses val into v.
+func setEnvValue(v reflect.Value, val string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", val)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", val)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", val)
+		}
+		v.SetFloat(f)
+	case reflect.Map:
+		if strings.HasPrefix(strings.TrimSpace(val), "{") {
+			return unmarshalEnv(v, val)
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, pair := range strings.Split(val, ",") {
+			k, elem, ok := strings.Cut(pair, "=")
+			if !ok || v.Type().Key().Kind() != reflect.String {
+				return fmt.Errorf("%q is neither JSON nor key=value pairs", val)
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setEnvValue(e, strings.TrimSpace(elem)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)), e)
+		}
+		v.Set(m)
+	default:
+		return unmarshalEnv(v, val)
+	}
+	return nil
+}
+
+func unmarshalEnv(v reflect.Value, val string) error {
+	p := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(val), p.Interface()); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	v.Set(p.Elem())
+	return nil
+}
+
+func hasEnvPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the names the config file is looked for under in the app
+// data directory, in this order.
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// File is the config file, e.g. ~/.config/quacker/config.toml, for defaults set
+// by hand or provisioned on a server:
+//
+//	provider = "google"
+//	speed = 1.1
+//	output_dir = "~/Audiobooks"
+//	output_format = "ogg"
+//
+//	[voices]
+//	google = "en-GB-Chirp3-HD-Charon"
+//
+//	[models]
+//	openai = "tts-1-hd"
+//
+//	[chunk_sizes]
+//	openai = 1500
+//
+//	[retry]
+//	max_retries = 5
+//	base_delay_s = 2
+//
+// A config.yaml with the same keys is read if there is no config.toml.
+// Environment variables take precedence over the settings made in the app and
+// the keychain, and those over the file: it only sets what was not set there.
+type File struct {
+	Provider     string            `toml:"provider" yaml:"provider"`
+	Voices       map[string]string `toml:"voices" yaml:"voices"` // Provider -> voice
+	Models       map[string]string `toml:"models" yaml:"models"` // Provider -> model, see Settings.Models
+	Speed        float64           `toml:"speed" yaml:"speed"`
+	OutputDir    string            `toml:"output_dir" yaml:"output_dir"`
+	OutputFormat string            `toml:"output_format" yaml:"output_format"`
+	ChunkSizes   map[string]int    `toml:"chunk_sizes" yaml:"chunk_sizes"` // Provider -> maximum chunk size, see Settings.ChunkLimits
+	Retry        FileRetry         `toml:"retry" yaml:"retry"`
+
+	// Path is where the file was read from; "" if there is none
+	Path string `toml:"-" yaml:"-"`
+}
+
+// FileRetry is the retry policy of the config file; unset keys keep the
+// default, see RetrySettings.
+type FileRetry struct {
+	MaxRetries *int     `toml:"max_retries" yaml:"max_retries"`
+	BaseDelay  *float64 `toml:"base_delay_s" yaml:"base_delay_s"`
+	Multiplier *float64 `toml:"multiplier" yaml:"multiplier"`
+	Jitter     *float64 `toml:"jitter" yaml:"jitter"`
+}
+
+// LoadFile reads the config file from the app data directory. Without one it
+// returns an empty File.
+func LoadFile() (*File, error) {
+	dir, err := AppDataDir()
+	if err != nil {
+		return &File{}, err
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return &File{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		f, err := parseFile(name, data)
+		if err != nil {
+			return &File{}, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		f.Path = path
+		return f, nil
+	}
+	return &File{}, nil
+}
+
+func parseFile(name string, data []byte) (*File, error) {
+	f := &File{}
+	if filepath.Ext(name) == ".toml" {
+		md, err := toml.Decode(string(data), f)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, k := range undecoded {
+				keys[i] = k.String()
+			}
+			sort.Strings(keys)
+			return nil, fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+	if f.Speed != 0 && (f.Speed < 0.25 || f.Speed > 4) {
+		return nil, fmt.Errorf("speed %g is out of range, use 0.25 to 4", f.Speed)
+	}
+	if f.OutputDir == "~" || strings.HasPrefix(f.OutputDir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		f.OutputDir = filepath.Join(home, f.OutputDir[1:])
+	}
+	return f, nil
+}
+
+// Apply sets the values of the file on s where s has none, so settings made in
+// the app win over the file. retry is the default policy, which keys missing
+// from the retry table keep.
+func (f *File) Apply(s *Settings, retry RetrySettings) {
+	if s.OutputDir == "" {
+		s.OutputDir = f.OutputDir
+	}
+	if s.OutputFormat == "" {
+		s.OutputFormat = f.OutputFormat
+	}
+	for provider, model := range f.Models {
+		if s.Models == nil {
+			s.Models = map[string]string{}
+		}
+		if s.Models[provider] == "" {
+			s.Models[provider] = model
+		}
+	}
+	for provider, size := range f.ChunkSizes {
+		if s.ChunkLimits == nil {
+			s.ChunkLimits = map[string]int{}
+		}
+		if s.ChunkLimits[provider] == 0 {
+			s.ChunkLimits[provider] = size
+		}
+	}
+	r := f.Retry
+	if s.Retry == nil && (r.MaxRetries != nil || r.BaseDelay != nil || r.Multiplier != nil || r.Jitter != nil) {
+		if r.MaxRetries != nil {
+			retry.MaxRetries = *r.MaxRetries
+		}
+		if r.BaseDelay != nil {
+			retry.BaseDelay = *r.BaseDelay
+		}
+		if r.Multiplier != nil {
+			retry.Multiplier = *r.Multiplier
+		}
+		if r.Jitter != nil {
+			retry.Jitter = *r.Jitter
+		}
+		s.Retry = &retry
+	}
+	s.DefaultVoices = f.Voices
+	s.DefaultSpeed = f.Speed
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keychainEntry is a value kept in the keychain under a name the command line uses.
+type keychainEntry struct {
+	service, user string
+	secret        bool
+}
+
+// keychainEntries are the keychain values that can be set by name, e.g. by
+// "quacker config set openai-api-key ...".
+var keychainEntries = map[string]keychainEntry{
+	"openai-api-key":       {openAIKeychainService, openAIKeychainUser, true},
+	"google-project-id":    {googleKeychainService, googleKeychainUser, false},
+	"google-api-key":       {googleAPIKeyKeychainService, googleAPIKeyKeychainUser, true},
+	"google-auth-method":   {googleAuthMethodKeychainService, googleAuthMethodKeychainUser, false},
+	"google-credentials":   {googleCredentialsKeychainService, googleCredentialsKeychainUser, true},
+	"google-account":       {googleAccountKeychainService, googleAccountKeychainUser, true},
+	"default-provider":     {defaultProviderKeychainService, defaultProviderKeychainUser, false},
+	"signing-key-password": {signingKeychainService, signingKeychainUser, true},
+	"upload-secret":        {uploadKeychainService, uploadKeychainUser, true},
+}
+
+// ErrUnknownKey is returned for a keychain value name that does not exist.
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeychainKeys returns the names of the keychain values, sorted.
+func KeychainKeys() []string {
+	names := make([]string, 0, len(keychainEntries))
+	for name := range keychainEntries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSecretKey reports whether the keychain value name holds a secret, which is
+// not shown in full.
+func IsSecretKey(name string) bool {
+	return keychainEntries[name].secret
+}
+
+// GetKeychainValue returns the keychain value name, "" if it is not set.
+// Environment variables that take precedence over it are not consulted.
+func GetKeychainValue(name string) (string, error) {
+	e, ok := keychainEntries[name]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, name)
+	}
+	val, err := secretGet(e.service, e.user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	return val, err
+}
+
+// SetKeychainValue stores the keychain value name.
+func SetKeychainValue(name, value string) error {
+	e, ok := keychainEntries[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, name)
+	}
+	return secretSet(e.service, e.user, value)
+}
+
+// DeleteKeychainValue removes the keychain value name; removing a value that is
+// not set succeeds.
+func DeleteKeychainValue(name string) error {
+	e, ok := keychainEntries[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, name)
+	}
+	if err := secretDelete(e.service, e.user); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+	return nil
+}
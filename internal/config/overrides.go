@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// overrides records what Override changed, so that SaveSettings and
+// SyncSettings can leave it out of what they write.
+type overrides struct {
+	apply  []func(*Settings) error
+	before map[string]any // the settings as stored, as a JSON object
+	after  map[string]any // the settings once overridden
+}
+
+// Override applies values that hold for this run only, such as those of the
+// config file or of environment variables. SaveSettings keeps writing the
+// values they replaced, unless they are changed again after the override.
+func (s *Settings) Override(apply func(*Settings) error) error {
+	o := &overrides{}
+	if s.overrides != nil {
+		o.apply = slices.Clone(s.overrides.apply)
+		o.before = s.overrides.before
+	} else {
+		before, err := toJSONMap(s)
+		if err != nil {
+			return err
+		}
+		o.before = before
+	}
+	if err := apply(s); err != nil {
+		return err
+	}
+	after, err := toJSONMap(s)
+	if err != nil {
+		return err
+	}
+	o.apply = append(o.apply, apply)
+	o.after = after
+	s.overrides = o
+	return nil
+}
+
+// reapply applies the overrides of from to s, whose stored values are new.
+func (s *Settings) reapply(from *Settings) error {
+	if from.overrides == nil {
+		return nil
+	}
+	for _, apply := range from.overrides.apply {
+		if err := s.Override(apply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stored returns the settings to write: s without the values overridden.
+func (s *Settings) stored() (*Settings, error) {
+	if s.overrides == nil {
+		return s, nil
+	}
+	m, err := toJSONMap(s)
+	if err != nil {
+		return nil, err
+	}
+	restoreOverridden(m, s.overrides.after, s.overrides.before)
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	stored := &Settings{}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return stored, nil
+}
+
+// restoreOverridden sets each value of cur that after changed from before back
+// to before, down to single map entries. Values changed since are left alone.
+func restoreOverridden(cur, after, before map[string]any) {
+	keys := map[string]bool{}
+	for k := range after {
+		keys[k] = true
+	}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range keys {
+		a, hadA := after[k]
+		b, hadB := before[k]
+		if hadA == hadB && reflect.DeepEqual(a, b) {
+			continue
+		}
+		c, hasC := cur[k]
+		cm, curIsMap := c.(map[string]any)
+		am, afterIsMap := a.(map[string]any)
+		bm, beforeIsMap := b.(map[string]any)
+		if curIsMap && afterIsMap && (beforeIsMap || !hadB) {
+			restoreOverridden(cm, am, bm)
+			if len(cm) == 0 && !hadB {
+				delete(cur, k)
+			}
+			continue
+		}
+		if hasC != hadA || !reflect.DeepEqual(c, a) {
+			continue
+		}
+		if hadB {
+			cur[k] = b
+		} else {
+			delete(cur, k)
+		}
+	}
+}
+
+// toJSONMap converts settings to a JSON object.
+func toJSONMap(settings *Settings) (map[string]any, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// secretsFileName holds the secrets when there is no keychain, e.g. on a Linux
+// server without Secret Service. It is encrypted with XChaCha20-Poly1305 under a
+// key derived with scrypt from $QUACKER_SECRETS_PASSPHRASE or, without one, from
+// the machine ID and user name. The latter keeps the file useless on another
+// machine, such as in a backup, but not from this user.
+const secretsFileName = "secrets.enc"
+
+// Key sources of the secrets file.
+const (
+	keyedByPassphrase = "passphrase"
+	keyedByMachine    = "machine"
+)
+
+// secretsFile is the JSON of the secrets file.
+type secretsFile struct {
+	KeyedBy string `json:"keyed_by"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"` // Sealed JSON of "service/user" -> value
+}
+
+var (
+	secretsOnce   sync.Once
+	secretsPath   string // "" while the keychain is used
+	secretsMu     sync.Mutex
+	secretsKeys   = map[string][]byte{} // Derived keys by source and salt, as scrypt is slow
+	errNoKeychain = errors.New("keychain disabled by QUACKER_SECRETS=file")
+)
+
+// SecretStore returns where secrets are kept: "keychain", or the path of the
+// encrypted file used instead.
+func SecretStore() string {
+	if path := secretsFilePath(); path != "" {
+		return path
+	}
+	return "keychain"
+}
+
+// secretsFilePath returns the path of the secrets file if secrets are kept in
+// it, "" if they are kept in the keychain. QUACKER_SECRETS set to "keychain"
+// or "file" chooses; by default the file is used if the keychain is unreachable.
+func secretsFilePath() string {
+	secretsOnce.Do(func() {
+		mode := strings.ToLower(os.Getenv("QUACKER_SECRETS"))
+		if mode == "keychain" {
+			return
+		}
+		var err error = errNoKeychain
+		if mode != "file" {
+			// A value that is not set only fails if the keychain cannot be reached
+			if _, err = keyring.Get("Quacker_Probe", "probe"); err == nil || errors.Is(err, keyring.ErrNotFound) {
+				return
+			}
+		}
+		dir, dirErr := AppDataDir()
+		if dirErr != nil {
+			slog.Error("No keychain and no app data directory for secrets", "err", dirErr)
+			return
+		}
+		secretsPath = filepath.Join(dir, secretsFileName)
+		slog.Info("Keychain unavailable, keeping secrets in a file", "path", secretsPath, "err", err)
+	})
+	return secretsPath
+}
+
+// secretGet is keyring.Get, or reads the secrets file without a keychain.
+func secretGet(service, user string) (string, error) {
+	path := secretsFilePath()
+	if path == "" {
+		return keyring.Get(service, user)
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	values, _, err := readSecrets(path)
+	if err != nil {
+		return "", err
+	}
+	val, ok := values[service+"/"+user]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return val, nil
+}
+
+// secretSet is keyring.Set, or writes the secrets file without a keychain.
+func secretSet(service, user, value string) error {
+	path := secretsFilePath()
+	if path == "" {
+		return keyring.Set(service, user, value)
+	}
+	return updateSecrets(path, func(values map[string]string) bool {
+		values[service+"/"+user] = value
+		return true
+	})
+}
+
+// secretDelete is keyring.Delete, or removes the value from the secrets file
+// without a keychain.
+func secretDelete(service, user string) error {
+	path := secretsFilePath()
+	if path == "" {
+		return keyring.Delete(service, user)
+	}
+	found := false
+	err := updateSecrets(path, func(values map[string]string) bool {
+		_, found = values[service+"/"+user]
+		delete(values, service+"/"+user)
+		return found
+	})
+	if err == nil && !found {
+		return keyring.ErrNotFound
+	}
+	return err
+}
+
+func updateSecrets(path string, update func(map[string]string) bool) error {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	values, f, err := readSecrets(path)
+	if err != nil {
+		return err
+	}
+	if !update(values) {
+		return nil
+	}
+	return writeSecrets(path, f, values)
+}
+
+// readSecrets decrypts the secrets file at path; a missing file has no values.
+func readSecrets(path string) (map[string]string, *secretsFile, error) {
+	values := map[string]string{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	f := &secretsFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if f.KeyedBy == keyedByPassphrase && os.Getenv("QUACKER_SECRETS_PASSPHRASE") == "" {
+		return nil, nil, fmt.Errorf("%s is encrypted with a passphrase, set QUACKER_SECRETS_PASSPHRASE", path)
+	}
+	key, err := secretsKey(f.KeyedBy, f.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, []byte(f.KeyedBy))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt %s: wrong passphrase or another machine", path)
+	}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, f, nil
+}
+
+// writeSecrets encrypts values into the file at path, with the passphrase if
+// one is set. prev is the file read before, nil for a new one, whose salt is
+// kept so the derived key can be reused.
+func writeSecrets(path string, prev *secretsFile, values map[string]string) error {
+	f := &secretsFile{KeyedBy: keyedByMachine}
+	if os.Getenv("QUACKER_SECRETS_PASSPHRASE") != "" {
+		f.KeyedBy = keyedByPassphrase
+	}
+	if prev != nil && prev.KeyedBy == f.KeyedBy {
+		f.Salt = prev.Salt
+	} else {
+		f.Salt = make([]byte, 16)
+		rand.Read(f.Salt)
+	}
+	key, err := secretsKey(f.KeyedBy, f.Salt)
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	rand.Read(f.Nonce)
+	f.Data = aead.Seal(nil, f.Nonce, plain, []byte(f.KeyedBy))
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// secretsKey derives the key of the secrets file from the passphrase or the
+// machine, and salt.
+func secretsKey(keyedBy string, salt []byte) ([]byte, error) {
+	var secret string
+	switch keyedBy {
+	case keyedByPassphrase:
+		secret = os.Getenv("QUACKER_SECRETS_PASSPHRASE")
+	case keyedByMachine:
+		secret = machineSecret()
+	default:
+		return nil, fmt.Errorf("unknown key source %q of the secrets file", keyedBy)
+	}
+	cacheKey := keyedBy + "\x00" + secret + "\x00" + string(salt)
+	if key, ok := secretsKeys[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key([]byte(secret), salt, 1<<15, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	secretsKeys[cacheKey] = key
+	return key, nil
+}
+
+// machineSecret identifies this machine and user: the machine ID where there
+// is one, else the host name, and the user name.
+func machineSecret() string {
+	var id string
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			id = strings.TrimSpace(string(data))
+			break
+		}
+	}
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return "quacker:" + id + ":" + name
+}
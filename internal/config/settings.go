@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"easy-tts/internal/preprocess"
+)
+
+const (
+	appDataDirName   = "quacker"
+	settingsFileName = "settings.json"
+)
+
+// RetrySettings is the retry schedule of failed chunks: after the first failed
+// attempt the job waits BaseDelay seconds, each further wait is Multiplier times
+// longer, and every wait varies randomly by up to Jitter (0.2 = ±20%).
+type RetrySettings struct {
+	MaxRetries int     `json:"max_retries"`
+	BaseDelay  float64 `json:"base_delay_s"`
+	Multiplier float64 `json:"multiplier"`
+	Jitter     float64 `json:"jitter"`
+}
+
+// UploadTarget is remote storage every output is copied to once saved; see
+// package upload. Its password or secret key is kept in the keychain.
+type UploadTarget struct {
+	Kind     string `json:"kind,omitempty"`     // "webdav", "s3" or "gdrive"; "" uploads nothing
+	URL      string `json:"url,omitempty"`      // WebDAV folder, or S3 endpoint other than AWS
+	Username string `json:"username,omitempty"` // WebDAV user or S3 access key ID
+	Bucket   string `json:"bucket,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	FolderID string `json:"folder_id,omitempty"` // Google Drive folder
+}
+
+// Settings holds non-secret application preferences. Secrets stay in the keychain.
+type Settings struct {
+	// PreprocessStages enables or disables preprocessing stages by name.
+	// Stages not listed use their default state.
+	PreprocessStages map[string]bool `json:"preprocess_stages,omitempty"`
+
+	// Replacements are custom regex find/replace rules applied during preprocessing.
+	Replacements []preprocess.ReplaceRule `json:"replacements,omitempty"`
+
+	// SayAsHints marks dates, times and ordinals with SSML <say-as> for Google voices.
+	SayAsHints bool `json:"say_as_hints,omitempty"`
+	// QuoteRate and DefinitionRate are SSML prosody rates ("slow", "90%") for
+	// blockquotes and definitions, applied by Google voices that accept SSML.
+	QuoteRate      string `json:"quote_rate,omitempty"`
+	DefinitionRate string `json:"definition_rate,omitempty"`
+	// TableHeaders is "each-row" (default) to repeat column headers in every row, or "once".
+	TableHeaders string `json:"table_headers,omitempty"`
+	// FootnoteLeadIn introduces inlined footnotes ("Footnote {id}:"); empty uses the language default.
+	FootnoteLeadIn string `json:"footnote_lead_in,omitempty"`
+	// FootnoteVoices maps provider -> voice for inlined footnotes; unset keeps the text's voice.
+	FootnoteVoices map[string]string `json:"footnote_voices,omitempty"`
+
+	// AcronymModes maps a base language to how all-caps tokens are read
+	// ("spell", "word" or "dictionary"); AcronymOverrides holds per-token corrections.
+	AcronymModes     map[string]string `json:"acronym_modes,omitempty"`
+	AcronymOverrides map[string]string `json:"acronym_overrides,omitempty"`
+
+	// OpenAIBaseURL is the OpenAI API or a gateway standing in for it, such as
+	// Azure OpenAI; empty uses api.openai.com. OpenAIOrganization and
+	// OpenAIProject select what requests are billed to. OPENAI_BASE_URL,
+	// OPENAI_ORG_ID and OPENAI_PROJECT_ID take precedence, see OpenAIEndpoint.
+	OpenAIBaseURL      string `json:"openai_base_url,omitempty"`
+	OpenAIOrganization string `json:"openai_organization,omitempty"`
+	OpenAIProject      string `json:"openai_project,omitempty"`
+
+	// Models maps provider -> the model synthesizing, e.g. "tts-1-hd" for OpenAI;
+	// unset uses the provider's default.
+	Models map[string]string `json:"models,omitempty"`
+
+	// ChunkLimits maps provider -> maximum chunk size (tokens, bytes for Google); unset uses the default.
+	ChunkLimits map[string]int `json:"chunk_limits,omitempty"`
+	// StitchContext passes the last sentence of the previous chunk as context to
+	// models that accept instructions, so intonation carries across chunk boundaries.
+	StitchContext bool `json:"stitch_context,omitempty"`
+	// ReviewChunks shows all chunks for editing, merging and splitting before synthesis starts.
+	ReviewChunks bool `json:"review_chunks,omitempty"`
+	// Retry tunes how failed chunks are retried; nil uses the defaults.
+	Retry *RetrySettings `json:"retry,omitempty"`
+	// FailedSection is what takes the place of a chunk that failed for good:
+	// "phrase" (default), "silence", "beep", "skip" or "abort". FailedPhrase is
+	// spoken for "phrase"; empty uses the default.
+	FailedSection string `json:"failed_section,omitempty"`
+	FailedPhrase  string `json:"failed_phrase,omitempty"`
+	// OutputFormat is the container of the output: "mp3" (default), "wav" or
+	// "ogg" (Opus). Each provider is asked for the format under its own name.
+	OutputFormat string `json:"output_format,omitempty"`
+	// SampleRate asks providers that support it (Google) for audio at this rate
+	// in Hz; 0 keeps each voice's own rate.
+	SampleRate int `json:"sample_rate,omitempty"`
+	// Bitrate in kbit/s of MP3 audio encoded by the app itself, when converting
+	// or resampling chunks; 0 uses 64 kbit/s per channel.
+	Bitrate int `json:"bitrate,omitempty"`
+	// OutputDir is the folder outputs are saved in; empty uses Downloads.
+	OutputDir string `json:"output_dir,omitempty"`
+	// FilenameTemplate names outputs, e.g. "{date}_{title}_{voice}.{ext}", see
+	// util.ExpandFilenameTemplate; empty uses util.DefaultFilenameTemplate.
+	FilenameTemplate string `json:"filename_template,omitempty"`
+	// NeverOverwrite saves a new output under a numbered name, "name (2).mp3",
+	// when its name is taken, instead of asking whether to overwrite.
+	NeverOverwrite bool `json:"never_overwrite,omitempty"`
+	// IntroFile and OutroFile are audio files put before and after every output,
+	// e.g. a jingle or a disclaimer. MP3 and WAV are converted to the output
+	// format; Ogg Opus only joins Ogg output.
+	IntroFile string `json:"intro_file,omitempty"`
+	OutroFile string `json:"outro_file,omitempty"`
+	// Subtitles writes "srt" or "vtt" subtitles of the sentences next to every
+	// output, timed by Google's marks or estimated for other voices; "" for none.
+	// Jobs resumed after an exhausted quota get none.
+	Subtitles string `json:"subtitles,omitempty"`
+	// TimingJSON writes name.timing.json next to every output with the start and
+	// end of each chunk and sentence, timed like the subtitles.
+	TimingJSON bool `json:"timing_json,omitempty"`
+	// Transcript writes name.transcript.txt with the preprocessed text and
+	// name.settings.json with the provider, voice, speed, chunk size and other
+	// settings next to every output, so it can be reproduced.
+	Transcript bool `json:"transcript,omitempty"`
+	// ChunkFiles also keeps the audio of every chunk as a file of its own in the
+	// folder name_chunks next to the output, for fixing a single sentence in an
+	// audio editor. Jobs resumed after an exhausted quota get none.
+	ChunkFiles bool `json:"chunk_files,omitempty"`
+
+	// HeadingStyles configures announcements, pauses and file splits per heading level (1-6).
+	HeadingStyles map[int]preprocess.HeadingStyle `json:"heading_styles,omitempty"`
+	// ChapterFilesOnly keeps only the files of headings that start a new file,
+	// deleting the whole document once no failed sections are left to retry.
+	ChapterFilesOnly bool `json:"chapter_files_only,omitempty"`
+	// Crossfade in seconds overlaps sections joined without a pause, such as a
+	// change of voice, instead of cutting hard; 0 for none. MP3 and WAV only.
+	Crossfade float64 `json:"crossfade,omitempty"`
+
+	// AutoLanguageVoices switches the voice per paragraph based on its detected language.
+	AutoLanguageVoices bool `json:"auto_language_voices,omitempty"`
+	// LanguageVoices maps provider -> base language ("de", "en") -> voice.
+	// Unmapped languages derive a voice from the selected one where possible.
+	LanguageVoices map[string]map[string]string `json:"language_voices,omitempty"`
+
+	// DialogueVoices reads "Name: text" dialogue scripts with one voice per speaker.
+	DialogueVoices bool `json:"dialogue_voices,omitempty"`
+	// SpeakerVoices maps provider -> speaker name -> voice.
+	SpeakerVoices map[string]map[string]string `json:"speaker_voices,omitempty"`
+
+	// FavoriteVoices maps provider -> voices bound to the keys 1-9 when auditioning in the preview.
+	FavoriteVoices map[string][]string `json:"favorite_voices,omitempty"`
+
+	// Script is a Starlark hook defining transform() and/or filename(); see package script.
+	Script string `json:"script,omitempty"`
+
+	// DisableCache turns off the synthesis cache, which reuses the audio of chunks
+	// synthesized before with the same text, voice and settings.
+	DisableCache bool `json:"disable_cache,omitempty"`
+	// CacheRetentionDays deletes cache entries older than this many days; 0 keeps them.
+	CacheRetentionDays int `json:"cache_retention_days,omitempty"`
+	// ArchiveAfterDays moves outputs older than this many days to ArchiveDir; 0 disables archiving.
+	ArchiveAfterDays int    `json:"archive_after_days,omitempty"`
+	ArchiveDir       string `json:"archive_dir,omitempty"`
+	CompressArchive  bool   `json:"compress_archive,omitempty"`
+
+	// Checksums writes a sidecar with the SHA-256 checksum next to every output.
+	Checksums bool `json:"checksums,omitempty"`
+	// SigningKey is a minisign secret key file; if set, outputs with checksums are
+	// also signed. Its password is kept in the keychain.
+	SigningKey string `json:"signing_key,omitempty"`
+	// Upload copies every saved output, its parts and the files written next to
+	// it to remote storage.
+	Upload UploadTarget `json:"upload,omitempty"`
+
+	// SyncDir is a user-chosen folder (e.g. in Dropbox) that keeps these settings
+	// consistent across machines; see SyncSettings.
+	SyncDir string `json:"sync_dir,omitempty"`
+
+	// DefaultVoices maps provider -> the voice used when none is chosen, and
+	// DefaultSpeed is the speed used when none is; both only come from the
+	// config file, see File, and are not saved here.
+	DefaultVoices map[string]string `json:"-"`
+	DefaultSpeed  float64           `json:"-"`
+
+	overrides *overrides // see Override
+}
+
+// OpenAIEndpoint returns the base URL, organization and project of the OpenAI
+// API: those of the environment, else those of s.
+func (s *Settings) OpenAIEndpoint() (baseURL, organization, project string) {
+	env := func(name, setting string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return setting
+	}
+	return env("OPENAI_BASE_URL", s.OpenAIBaseURL), env("OPENAI_ORG_ID", s.OpenAIOrganization), env("OPENAI_PROJECT_ID", s.OpenAIProject)
+}
+
+// AppDataDir returns the directory holding Quacker's own files, creating it if needed.
+func AppDataDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	dir := filepath.Join(base, appDataDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// settingsPath returns the location of the settings file.
+func settingsPath() (string, error) {
+	dir, err := AppDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsFileName), nil
+}
+
+// LoadSettings reads the settings file, returning empty settings if it does not exist yet.
+func LoadSettings() (*Settings, error) {
+	settings := &Settings{}
+	path, err := settingsPath()
+	if err != nil {
+		return settings, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return &Settings{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// SaveSettings writes the settings file, without the values of Override.
+func SaveSettings(settings *Settings) error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	stored, err := settings.stored()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	syncFileName     = "quacker-settings.json"
+	syncBaseFileName = "sync-base.json"
+)
+
+// localOnlyKeys are machine-specific settings that never leave this computer,
+// such as paths, which differ between machines or do not exist on others.
+var localOnlyKeys = []string{"sync_dir", "archive_dir", "signing_key", "intro_file", "outro_file", "output_dir"}
+
+// SyncSettings merges settings with the copy kept in settings.SyncDir, a folder the
+// user syncs with Dropbox, iCloud Drive or similar. It is a three-way merge against
+// the state of the last sync, so changes made on different machines to different
+// settings are combined. When both sides changed the same setting, this machine wins
+// (except on the first sync, where the shared copy wins) and the losing values are
+// written next to the shared copy as a conflict file. It returns the conflicting keys.
+func SyncSettings(settings *Settings) ([]string, error) {
+	if settings.SyncDir == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(settings.SyncDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("sync folder %s is not available", settings.SyncDir)
+	}
+	dataDir, err := AppDataDir()
+	if err != nil {
+		return nil, err
+	}
+	basePath := filepath.Join(dataDir, syncBaseFileName)
+	remotePath := filepath.Join(settings.SyncDir, syncFileName)
+
+	local, own, err := settingsToMap(settings)
+	if err != nil {
+		return nil, err
+	}
+	baseState, err := readJSONMap(basePath)
+	if err != nil {
+		return nil, err
+	}
+	// The recorded state only applies to the folder it was taken from.
+	base, _ := baseState["values"].(map[string]any)
+	if baseState["sync_dir"] != settings.SyncDir {
+		base = nil
+	}
+	remote, err := readJSONMap(remotePath)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSync := len(base) == 0
+	var conflicts []string
+	losers := map[string]any{}
+	merged := mergeMaps(base, local, remote, !firstSync, "", &conflicts, losers)
+
+	if len(conflicts) > 0 {
+		host, _ := os.Hostname()
+		name := fmt.Sprintf("quacker-settings.conflict-%s-%s.json", host, time.Now().Format("20060102-150405"))
+		if err := writeJSONFile(filepath.Join(settings.SyncDir, name), losers); err != nil {
+			slog.Warn("Failed to write sync conflict file", "err", err)
+		}
+		slog.Warn("Settings sync conflicts", "file", name, "keys", strings.Join(conflicts, ", "))
+	}
+
+	if err := writeJSONFile(remotePath, merged); err != nil {
+		return conflicts, fmt.Errorf("failed to write synced settings: %w", err)
+	}
+	if err := writeJSONFile(basePath, map[string]any{"sync_dir": settings.SyncDir, "values": merged}); err != nil {
+		return conflicts, fmt.Errorf("failed to record sync state: %w", err)
+	}
+
+	// Apply the merged values, keeping machine-specific settings.
+	applied := maps.Clone(merged)
+	maps.Copy(applied, own)
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return conflicts, fmt.Errorf("failed to encode synced settings: %w", err)
+	}
+	updated := &Settings{}
+	if err := json.Unmarshal(data, updated); err != nil {
+		return conflicts, fmt.Errorf("failed to parse synced settings: %w", err)
+	}
+	if err := updated.reapply(settings); err != nil {
+		return conflicts, err
+	}
+	*settings = *updated
+	return conflicts, SaveSettings(settings)
+}
+
+// mergeMaps performs a recursive three-way merge of JSON objects. On conflict the
+// local value wins if preferLocal is set; the losing value is stored in losers.
+func mergeMaps(base, local, remote map[string]any, preferLocal bool, prefix string, conflicts *[]string, losers map[string]any) map[string]any {
+	keys := map[string]bool{}
+	for _, m := range []map[string]any{base, local, remote} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	out := map[string]any{}
+	for _, k := range sorted {
+		b, inBase := base[k]
+		l, inLocal := local[k]
+		r, inRemote := remote[k]
+		lm, localIsMap := l.(map[string]any)
+		rm, remoteIsMap := r.(map[string]any)
+		if localIsMap && remoteIsMap {
+			bm, _ := b.(map[string]any)
+			sub := map[string]any{}
+			out[k] = mergeMaps(bm, lm, rm, preferLocal, prefix+k+".", conflicts, sub)
+			if len(sub) > 0 {
+				losers[k] = sub
+			}
+			continue
+		}
+		localChanged := inLocal != inBase || !reflect.DeepEqual(l, b)
+		remoteChanged := inRemote != inBase || !reflect.DeepEqual(r, b)
+		take, takeOK := l, inLocal
+		switch {
+		case !remoteChanged:
+		case !localChanged || (inLocal == inRemote && reflect.DeepEqual(l, r)):
+			take, takeOK = r, inRemote
+		default:
+			*conflicts = append(*conflicts, prefix+k)
+			if preferLocal {
+				losers[k] = r
+			} else {
+				losers[k] = l
+				take, takeOK = r, inRemote
+			}
+		}
+		if takeOK {
+			out[k] = take
+		}
+	}
+	return out
+}
+
+// settingsToMap converts the stored settings to a JSON object without
+// machine-specific keys, which it returns in an object of their own.
+func settingsToMap(settings *Settings) (shared, local map[string]any, err error) {
+	stored, err := settings.stored()
+	if err != nil {
+		return nil, nil, err
+	}
+	shared, err = toJSONMap(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	local = map[string]any{}
+	for _, k := range localOnlyKeys {
+		if v, ok := shared[k]; ok {
+			local[k] = v
+			delete(shared, k)
+		}
+	}
+	return shared, local, nil
+}
+
+// readJSONMap reads a JSON object, returning an empty map if the file does not exist.
+func readJSONMap(path string) (map[string]any, error) {
+	m := map[string]any{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// writeJSONFile writes v atomically so a syncing client never sees a partial file.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
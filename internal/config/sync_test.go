@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMergeMaps(t *testing.T) {
+	base := map[string]any{
+		"filename_template": "{title}",
+		"bitrate":           128.0,
+		"models":            map[string]any{"openai": "tts-1", "google": "standard"},
+	}
+	tests := []struct {
+		name          string
+		local, remote map[string]any
+		preferLocal   bool
+		want          map[string]any
+		conflicts     []string
+		losers        map[string]any
+	}{
+		{
+			name:        "edits to different keys",
+			local:       with(base, "bitrate", 192.0),
+			remote:      with(base, "filename_template", "{date} {title}"),
+			preferLocal: true,
+			want:        with(with(base, "bitrate", 192.0), "filename_template", "{date} {title}"),
+			losers:      map[string]any{},
+		},
+		{
+			name:        "the same edit on both sides",
+			local:       with(base, "bitrate", 192.0),
+			remote:      with(base, "bitrate", 192.0),
+			preferLocal: true,
+			want:        with(base, "bitrate", 192.0),
+			losers:      map[string]any{},
+		},
+		{
+			name:        "conflicting edits",
+			local:       with(base, "bitrate", 192.0),
+			remote:      with(base, "bitrate", 64.0),
+			preferLocal: true,
+			want:        with(base, "bitrate", 192.0),
+			conflicts:   []string{"bitrate"},
+			losers:      map[string]any{"bitrate": 64.0},
+		},
+		{
+			name:      "conflicting edits on the first sync",
+			local:     with(base, "bitrate", 192.0),
+			remote:    with(base, "bitrate", 64.0),
+			want:      with(base, "bitrate", 64.0),
+			conflicts: []string{"bitrate"},
+			losers:    map[string]any{"bitrate": 192.0},
+		},
+		{
+			name:        "conflicting edits of a nested key",
+			local:       with(base, "models", map[string]any{"openai": "tts-1-hd", "google": "standard"}),
+			remote:      with(base, "models", map[string]any{"openai": "gpt-4o-mini-tts", "google": "neural"}),
+			preferLocal: true,
+			want:        with(base, "models", map[string]any{"openai": "tts-1-hd", "google": "neural"}),
+			conflicts:   []string{"models.openai"},
+			losers:      map[string]any{"models": map[string]any{"openai": "gpt-4o-mini-tts"}},
+		},
+		{
+			name:        "local delete against a remote edit",
+			local:       without(base, "filename_template"),
+			remote:      with(base, "filename_template", "{date} {title}"),
+			preferLocal: true,
+			want:        without(base, "filename_template"),
+			conflicts:   []string{"filename_template"},
+			losers:      map[string]any{"filename_template": "{date} {title}"},
+		},
+		{
+			name:        "local edit against a remote delete",
+			local:       with(base, "filename_template", "{date} {title}"),
+			remote:      without(base, "filename_template"),
+			preferLocal: true,
+			want:        with(base, "filename_template", "{date} {title}"),
+			conflicts:   []string{"filename_template"},
+			losers:      map[string]any{"filename_template": nil},
+		},
+		{
+			name:        "delete without an edit",
+			local:       base,
+			remote:      without(base, "bitrate"),
+			preferLocal: true,
+			want:        without(base, "bitrate"),
+			losers:      map[string]any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conflicts []string
+			losers := map[string]any{}
+			got := mergeMaps(base, tt.local, tt.remote, tt.preferLocal, "", &conflicts, losers)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %v, want %v", conflicts, tt.conflicts)
+			}
+			if !reflect.DeepEqual(losers, tt.losers) {
+				t.Errorf("losers = %v, want %v", losers, tt.losers)
+			}
+		})
+	}
+}
+
+// TestSyncSettingsLocalOnly checks that machine-specific settings are neither
+// written to the shared copy nor replaced by it.
+func TestSyncSettingsLocalOnly(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	syncDir := filepath.Join(home, "Dropbox")
+	if err := os.Mkdir(syncDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The shared copy of another machine
+	remotePath := filepath.Join(syncDir, syncFileName)
+	if err := writeJSONFile(remotePath, map[string]any{"filename_template": "{date} {title}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &Settings{
+		SyncDir:    syncDir,
+		ArchiveDir: filepath.Join(home, "archive"),
+		SigningKey: filepath.Join(home, "key.pem"),
+		IntroFile:  filepath.Join(home, "intro.mp3"),
+		OutroFile:  filepath.Join(home, "outro.mp3"),
+		OutputDir:  filepath.Join(home, "Audio"),
+		Bitrate:    192,
+	}
+	want := *settings
+	want.FilenameTemplate = "{date} {title}"
+	for sync := range 2 {
+		if _, err := SyncSettings(settings); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*settings, want) {
+			t.Errorf("sync %d: settings = %+v, want %+v", sync, *settings, want)
+		}
+		remote, err := readJSONMap(remotePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if remote["filename_template"] != "{date} {title}" || remote["bitrate"] != 192.0 {
+			t.Errorf("sync %d: shared copy %v lacks the shared settings", sync, remote)
+		}
+		for _, k := range []string{"sync_dir", "archive_dir", "signing_key", "intro_file", "outro_file", "output_dir"} {
+			if v, ok := remote[k]; ok {
+				t.Errorf("sync %d: shared copy has %s = %v", sync, k, v)
+			}
+		}
+		settings.OutputDir = filepath.Join(home, "Podcasts")
+		want.OutputDir = settings.OutputDir
+	}
+}
+
+// with returns a copy of m with k set to v.
+func with(m map[string]any, k string, v any) map[string]any {
+	c := make(map[string]any, len(m)+1)
+	for key, value := range m {
+		c[key] = value
+	}
+	c[k] = v
+	return c
+}
+
+// without returns a copy of m without k.
+func without(m map[string]any, k string) map[string]any {
+	c := with(m, k, nil)
+	delete(c, k)
+	return c
+}
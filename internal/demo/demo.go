@@ -0,0 +1,53 @@
+// Package demo bundles the sample document and the steps of the guided tour
+// shown by "Try a demo", which runs without any provider credentials.
+package demo
+
+import _ "embed"
+
+// Sample is the bundled sample Markdown document.
+//
+//go:embed sample.md
+var Sample string
+
+// Actions a tour step asks the app to perform when its button is pressed.
+const (
+	ActionPreview    = "preview"
+	ActionSynthesize = "synthesize"
+)
+
+// Step is one page of the guided tour.
+type Step struct {
+	Title  string
+	Text   string
+	Button string // Label of the button that moves on, "Next" if empty
+	Action string // Performed when the button is pressed, if set
+}
+
+// Tour walks new users through preview, synthesis and playback.
+var Tour = []Step{
+	{
+		Title: "Try a demo",
+		Text: "A sample document has been loaded and the demo voice selected. The demo voice needs no account " +
+			"and produces silent audio as long as a real narration, so everything else works as usual.",
+	},
+	{
+		Title: "1. Preview",
+		Text: "The preview shows the text exactly as it will be sent, after preprocessing, split into chunks. " +
+			"Notice the removed Markdown, the written-out numbers and the inlined footnote.",
+		Button: "Open preview",
+		Action: ActionPreview,
+	},
+	{
+		Title: "2. Synthesis",
+		Text: "Synthesize the document, just as if you pressed Submit. " +
+			"The progress bar follows the chunks as they are processed.",
+		Button: "Synthesize",
+		Action: ActionSynthesize,
+	},
+	{
+		Title: "3. Playback",
+		Text: "When the progress bar is full, the file is saved to your Downloads folder and listed under " +
+			"Quacker → History, where it can be replayed. Add an OpenAI or Google Cloud key in Settings to hear real voices.",
+		Button: "Done",
+	},
+}
@@ -0,0 +1,77 @@
+// Package devstats collects live counters and states of the app's internals for
+// the developer panel. Subsystems publish values under dotted names such as
+// "requests.in_flight"; the panel shows whatever has been published.
+package devstats
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var (
+	mu       sync.Mutex
+	counters = map[string]int64{}
+	texts    = map[string]string{}
+)
+
+// Stat is one named value.
+type Stat struct {
+	Name  string
+	Value string
+}
+
+// Add changes the counter name by delta.
+func Add(name string, delta int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	counters[name] += delta
+}
+
+// Set sets the counter name to v.
+func Set(name string, v int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	counters[name] = v
+}
+
+// SetText sets a free-form state, e.g. "waiting until 14:02:10". An empty value removes it.
+func SetText(name, v string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if v == "" {
+		delete(texts, name)
+		return
+	}
+	texts[name] = v
+}
+
+// Get returns the counter name.
+func Get(name string) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return counters[name]
+}
+
+// Snapshot returns all published values plus runtime figures, sorted by name.
+func Snapshot() []Stat {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	mu.Lock()
+	stats := make([]Stat, 0, len(counters)+len(texts)+2)
+	for name, v := range counters {
+		stats = append(stats, Stat{name, strconv.FormatInt(v, 10)})
+	}
+	for name, v := range texts {
+		stats = append(stats, Stat{name, v})
+	}
+	mu.Unlock()
+	stats = append(stats,
+		Stat{"runtime.goroutines", strconv.Itoa(runtime.NumGoroutine())},
+		Stat{"runtime.heap", fmt.Sprintf("%.1f MB", float64(mem.HeapAlloc)/(1<<20))},
+	)
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,341 @@
+// Package epub reads the chapters of EPUB e-books as plain text, for synthesis
+// of whole books without copying them chapter by chapter.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Book is the readable content of an EPUB.
+type Book struct {
+	Title    string
+	Chapters []Chapter // In reading order
+}
+
+// Chapter is one document of the book's reading order.
+type Chapter struct {
+	Title string // From the table of contents or the first heading
+	Text  string // Paragraphs separated by blank lines, headings as Markdown
+}
+
+// Words returns the number of words of the chapter.
+func (c Chapter) Words() int {
+	return len(strings.Fields(c.Text))
+}
+
+// Markdown returns the chapters at indexes as one document, each under a
+// top-level heading, so the heading styles announce them and can start a new
+// output file per chapter.
+func (b *Book) Markdown(indexes []int) string {
+	var parts []string
+	for _, i := range indexes {
+		if i >= 0 && i < len(b.Chapters) {
+			parts = append(parts, "# "+b.Chapters[i].Title+"\n\n"+b.Chapters[i].Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Open reads the EPUB at path.
+func Open(path string) (*Book, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not an EPUB file: %w", err)
+	}
+	defer r.Close()
+	return read(&r.Reader)
+}
+
+// Read reads an EPUB of size bytes from r.
+func Read(r io.ReaderAt, size int64) (*Book, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an EPUB file: %w", err)
+	}
+	return read(z)
+}
+
+// maxDocumentBytes guards against documents in the archive that unpack to
+// absurd sizes.
+const maxDocumentBytes = 32 << 20
+
+type opfPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Toc   string `xml:"toc,attr"`
+		Items []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+func read(z *zip.Reader) (*Book, error) {
+	files := make(map[string]*zip.File)
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+	load := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is missing", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxDocumentBytes))
+	}
+
+	data, err := load("META-INF/container.xml")
+	if err != nil {
+		return nil, fmt.Errorf("not an EPUB file: %w", err)
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, errors.New("not an EPUB file: no package document")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	if data, err = load(opfPath); err != nil {
+		return nil, err
+	}
+	var opf opfPackage
+	if err := xml.Unmarshal(data, &opf); err != nil {
+		return nil, fmt.Errorf("invalid package document: %w", err)
+	}
+	dir := path.Dir(opfPath)
+	resolve := func(base, href string) string {
+		href, _, _ = strings.Cut(href, "#")
+		return path.Join(base, href)
+	}
+
+	// Titles from the table of contents, EPUB 3 navigation or EPUB 2 NCX
+	titles := make(map[string]string)
+	hrefs, types := make(map[string]string), make(map[string]string)
+	nav := ""
+	for _, item := range opf.Manifest {
+		hrefs[item.ID], types[item.ID] = resolve(dir, item.Href), item.MediaType
+		switch {
+		case strings.Contains(" "+item.Properties+" ", " nav "):
+			nav = hrefs[item.ID]
+			if data, err := load(nav); err == nil {
+				navTitles(data, path.Dir(nav), titles, resolve)
+			}
+		case item.ID == opf.Spine.Toc && len(titles) == 0:
+			if data, err := load(hrefs[item.ID]); err == nil {
+				ncxTitles(data, path.Dir(hrefs[item.ID]), titles, resolve)
+			}
+		}
+	}
+
+	book := &Book{}
+	if len(opf.Title) > 0 {
+		book.Title = strings.TrimSpace(opf.Title[0])
+	}
+	for _, ref := range opf.Spine.Items {
+		href, ok := hrefs[ref.IDRef]
+		if !ok || href == nav || !strings.Contains(types[ref.IDRef], "html") {
+			continue
+		}
+		data, err := load(href)
+		if err != nil {
+			return nil, err
+		}
+		heading, text := documentText(data)
+		title := titles[href]
+		switch {
+		case title == "":
+			title = heading
+		case heading != "" && !strings.EqualFold(heading, title):
+			text = strings.TrimSpace("## " + heading + "\n\n" + text)
+		}
+		if text == "" {
+			continue // cover images, blank pages
+		}
+		if title == "" {
+			title = "Chapter " + strconv.Itoa(len(book.Chapters)+1)
+		}
+		book.Chapters = append(book.Chapters, Chapter{Title: title, Text: text})
+	}
+	if len(book.Chapters) == 0 {
+		return nil, errors.New("the EPUB has no readable chapters")
+	}
+	return book, nil
+}
+
+// newDecoder returns a lenient decoder for XHTML that is not quite XML.
+func newDecoder(data []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil // EPUB requires UTF-8 or UTF-16; trust the bytes over the label
+	}
+	return d
+}
+
+// navTitles records the first title of each document linked from the "toc"
+// navigation of an EPUB 3 navigation document.
+func navTitles(data []byte, base string, titles map[string]string, resolve func(base, href string) string) {
+	d := newDecoder(data)
+	depth, inToc := 0, 0
+	href, text := "", ""
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if t.Name.Local == "nav" && inToc == 0 && attr(t, "type") == "toc" {
+				inToc = depth
+			}
+			if inToc > 0 && t.Name.Local == "a" {
+				href, text = attr(t, "href"), ""
+			}
+		case xml.CharData:
+			if href != "" {
+				text += string(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "a" && href != "" {
+				addTitle(titles, resolve(base, href), text)
+				href = ""
+			}
+			if depth == inToc {
+				return
+			}
+			depth--
+		}
+	}
+}
+
+// ncxTitles records the first title of each document in an EPUB 2 NCX table of contents.
+func ncxTitles(data []byte, base string, titles map[string]string, resolve func(base, href string) string) {
+	var ncx struct {
+		Points []ncxPoint `xml:"navMap>navPoint"`
+	}
+	if xml.Unmarshal(data, &ncx) != nil {
+		return
+	}
+	var walk func([]ncxPoint)
+	walk = func(points []ncxPoint) {
+		for _, p := range points {
+			addTitle(titles, resolve(base, p.Content.Src), p.Label)
+			walk(p.Points)
+		}
+	}
+	walk(ncx.Points)
+}
+
+type ncxPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+func addTitle(titles map[string]string, href, title string) {
+	title = strings.Join(strings.Fields(title), " ")
+	if _, ok := titles[href]; !ok && title != "" {
+		titles[href] = title
+	}
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// blockElements end a paragraph.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "blockquote": true, "li": true,
+	"tr": true, "br": true, "hr": true, "dt": true, "dd": true, "figcaption": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// documentText strips an XHTML document to its readable text, one paragraph
+// per block. A heading that opens the document is returned on its own, for the
+// chapter title; later headings are kept as Markdown one level below it.
+func documentText(data []byte) (heading, text string) {
+	d := newDecoder(data)
+	var paragraphs []string
+	var current strings.Builder
+	level, skip, inBody := 0, 0, false
+	flush := func() {
+		p := strings.Join(strings.Fields(current.String()), " ")
+		current.Reset()
+		if p == "" {
+			return
+		}
+		if level > 0 {
+			if heading == "" && len(paragraphs) == 0 {
+				heading = p
+				return
+			}
+			p = strings.Repeat("#", min(level+1, 6)) + " " + p
+		}
+		paragraphs = append(paragraphs, p)
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "body":
+				inBody = true
+			case name == "script" || name == "style" || name == "head":
+				skip++
+			case blockElements[name]:
+				flush()
+				if len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6' {
+					level = int(name[1] - '0')
+				}
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "script" || name == "style" || name == "head":
+				skip--
+			case blockElements[name]:
+				flush()
+				level = 0
+			}
+		case xml.CharData:
+			if inBody && skip == 0 {
+				current.Write(t)
+			}
+		}
+	}
+	flush()
+	return heading, strings.Join(paragraphs, "\n\n")
+}
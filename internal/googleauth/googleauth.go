@@ -0,0 +1,129 @@
+// Package googleauth signs in to a Google account in the browser, with the OAuth
+// flow for installed apps and a redirect to a port on localhost, so Google Cloud
+// can be used without the gcloud CLI or an API key.
+package googleauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+)
+
+// ClientID and ClientSecret are the OAuth client, of type "Desktop app", that
+// release builds sign in with, set by
+//
+//	-ldflags "-X easy-tts/internal/googleauth.ClientID=... -X easy-tts/internal/googleauth.ClientSecret=..."
+//
+// The secret of an installed app is not confidential (RFC 8252); the consent
+// page shows the name of the client's project.
+var ClientID, ClientSecret string
+
+// scope allows calling Cloud Text-to-Speech, which has no scope of its own.
+const scope = "https://www.googleapis.com/auth/cloud-platform"
+
+var endpoint = oauth2.Endpoint{
+	AuthURL:   "https://accounts.google.com/o/oauth2/auth",
+	TokenURL:  "https://oauth2.googleapis.com/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+// ErrNoClient is returned when there is no OAuth client to sign in with.
+var ErrNoClient = errors.New("no Google OAuth client in this build, set GOOGLE_OAUTH_CLIENT_ID and GOOGLE_OAUTH_CLIENT_SECRET")
+
+// Client is the OAuth client to sign in with: GOOGLE_OAUTH_CLIENT_ID and
+// GOOGLE_OAUTH_CLIENT_SECRET if set, else that of the build.
+func Client() (id, secret string, err error) {
+	if id := os.Getenv("GOOGLE_OAUTH_CLIENT_ID"); id != "" {
+		return id, os.Getenv("GOOGLE_OAUTH_CLIENT_SECRET"), nil
+	}
+	if ClientID == "" {
+		return "", "", ErrNoClient
+	}
+	return ClientID, ClientSecret, nil
+}
+
+// SignIn has the user sign in to Google on the page given to open, usually
+// opening it in the browser, and waits until Google redirects back or ctx is
+// done. It returns the credentials as "authorized_user" JSON, the format of
+// gcloud's application default credentials.
+func SignIn(ctx context.Context, open func(url string) error) ([]byte, error) {
+	id, secret, err := Client()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the sign-in: %w", err)
+	}
+	conf := &oauth2.Config{
+		ClientID:     id,
+		ClientSecret: secret,
+		Endpoint:     endpoint,
+		RedirectURL:  fmt.Sprintf("http://%s/", ln.Addr()),
+		Scopes:       []string{scope},
+	}
+	state, verifier := oauth2.GenerateVerifier(), oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "Unknown sign-in request.", http.StatusBadRequest)
+			return
+		}
+		res := result{code: q.Get("code")}
+		message := "Signed in to Quacker. You can close this tab."
+		if e := q.Get("error"); e != "" || res.code == "" {
+			res.err = fmt.Errorf("sign-in refused: %s", e)
+			message = "Sign-in refused: " + e
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!doctype html><title>Quacker</title><p>%s</p>", html.EscapeString(message))
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	// Offline access with consent asked again, so Google returns a refresh token
+	// even to an account that signed in before
+	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
+	if err := open(authURL); err != nil {
+		return nil, fmt.Errorf("failed to open the sign-in page: %w", err)
+	}
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	token, err := conf.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete the sign-in: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return nil, errors.New("Google returned no refresh token")
+	}
+	return json.Marshal(map[string]string{
+		"type":          "authorized_user",
+		"client_id":     id,
+		"client_secret": secret,
+		"refresh_token": token.RefreshToken,
+	})
+}
@@ -0,0 +1,121 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// EditableChunk is a chunk in the pre-flight chunk editor.
+type EditableChunk struct {
+	Text  string
+	Voice string
+	// Group identifies the segment the chunk belongs to; only chunks of the
+	// same group share voice and pauses and can be merged.
+	Group int
+}
+
+// ChunkLimits tells the chunk editor how chunks are measured and split.
+type ChunkLimits struct {
+	Limit   int                   // Maximum size of a chunk
+	Unit    string                // "tokens" or "bytes"
+	Measure func(string) int      // Size of a chunk in Unit
+	Split   func(string) []string // Splits a chunk in two at a sentence boundary
+}
+
+// EditChunks shows the chunks of a job with their sizes and lets the user edit,
+// merge and split them before synthesis. It blocks until the user starts or
+// cancels the job; on start it returns the edited chunks without empty ones.
+func (ui *UI) EditChunks(chunks []EditableChunk, limits ChunkLimits) ([]EditableChunk, bool) {
+	answer := make(chan bool, 1)
+	chunks = append([]EditableChunk(nil), chunks...)
+	fyne.Do(func() {
+		list := container.NewVBox()
+		summary := widget.NewLabel("")
+		var rebuild func()
+		updateSummary := func() {
+			size, over := 0, 0
+			for _, c := range chunks {
+				n := limits.Measure(c.Text)
+				size += n
+				if n > limits.Limit {
+					over++
+				}
+			}
+			msg := fmt.Sprintf("%d chunk(s), %d %s in total, at most %d %s each.", len(chunks), size, limits.Unit, limits.Limit, limits.Unit)
+			if over > 0 {
+				msg += fmt.Sprintf(" %d chunk(s) over the limit will be split again.", over)
+			}
+			summary.SetText(msg)
+		}
+		rebuild = func() {
+			list.Objects = nil
+			for i := range chunks {
+				header := widget.NewLabel("")
+				updateHeader := func() {
+					n := limits.Measure(chunks[i].Text)
+					info := fmt.Sprintf("Chunk %d/%d · %s · %d %s", i+1, len(chunks), chunks[i].Voice, n, limits.Unit)
+					if n > limits.Limit {
+						info += " · over the limit"
+					}
+					header.SetText(info)
+				}
+				entry := widget.NewMultiLineEntry()
+				entry.Wrapping = fyne.TextWrapWord
+				entry.SetText(chunks[i].Text)
+				entry.SetMinRowsVisible(3)
+				entry.OnChanged = func(s string) {
+					chunks[i].Text = s
+					updateHeader()
+					updateSummary()
+				}
+				updateHeader()
+
+				mergeBtn := widget.NewButton("Merge with next", func() {
+					chunks[i].Text = strings.TrimSpace(chunks[i].Text + " " + chunks[i+1].Text)
+					chunks = append(chunks[:i+1], chunks[i+2:]...)
+					rebuild()
+				})
+				if i+1 >= len(chunks) || chunks[i+1].Group != chunks[i].Group {
+					mergeBtn.Disable()
+				}
+				splitBtn := widget.NewButton("Split", func() {
+					parts := limits.Split(chunks[i].Text)
+					if len(parts) < 2 {
+						return
+					}
+					split := make([]EditableChunk, len(parts))
+					for j, p := range parts {
+						split[j] = EditableChunk{Text: p, Voice: chunks[i].Voice, Group: chunks[i].Group}
+					}
+					chunks = append(chunks[:i], append(split, chunks[i+1:]...)...)
+					rebuild()
+				})
+				list.Add(container.NewBorder(nil, nil, nil, container.NewHBox(splitBtn, mergeBtn), header))
+				list.Add(entry)
+			}
+			list.Refresh()
+			updateSummary()
+		}
+		rebuild()
+
+		content := container.NewBorder(summary, nil, nil, nil, container.NewVScroll(list))
+		d := dialog.NewCustomConfirm("Review chunks", "Start", "Cancel", content, func(ok bool) { answer <- ok }, ui.Window)
+		d.Resize(fyne.NewSize(760, 560))
+		d.Show()
+	})
+	if !<-answer {
+		return nil, false
+	}
+	var out []EditableChunk
+	for _, c := range chunks {
+		if c.Text = strings.TrimSpace(c.Text); c.Text != "" {
+			out = append(out, c)
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,58 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"easy-tts/internal/devstats"
+)
+
+// ShowDeveloperPanel opens a window with the live internals published through
+// package devstats, refreshed every second while the window is open.
+func ShowDeveloperPanel(app fyne.App) {
+	w := app.NewWindow("Developer")
+	w.Resize(fyne.NewSize(520, 420))
+
+	stats := devstats.Snapshot()
+	table := widget.NewTable(
+		func() (int, int) { return len(stats), 2 },
+		func() fyne.CanvasObject { return widget.NewLabel("requests.in_flight__") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			if id.Row >= len(stats) {
+				return
+			}
+			if id.Col == 0 {
+				obj.(*widget.Label).SetText(stats[id.Row].Name)
+			} else {
+				obj.(*widget.Label).SetText(stats[id.Row].Value)
+			}
+		},
+	)
+	table.SetColumnWidth(0, 220)
+	table.SetColumnWidth(1, 280)
+
+	done := make(chan struct{})
+	w.SetOnClosed(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				snapshot := devstats.Snapshot()
+				fyne.Do(func() {
+					stats = snapshot
+					table.Refresh()
+				})
+			}
+		}
+	}()
+
+	w.SetContent(container.NewBorder(widget.NewLabel("Live internals (refreshed every second)"), nil, nil, nil, table))
+	w.Show()
+}
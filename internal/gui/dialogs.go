@@ -0,0 +1,380 @@
+package gui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ReportExporter writes the job report in the given format ("html" or "md"), or
+// the failed chunks as "json" or "csv".
+type ReportExporter func(format string, w io.Writer) error
+
+// ShowQASummary displays the end-of-job quality report in a dialog. If export is
+// set, the dialog offers to save the full conversion report as HTML or Markdown;
+// baseName is the suggested file name without extension. If retry is set, some
+// sections failed: the dialog offers to retry them and to export them for scripts.
+// If timeline is set, it offers to show the chunks of the output on a timeline.
+func (ui *UI) ShowQASummary(status, details string, export ReportExporter, baseName string, retry, timeline func()) {
+	fyne.Do(func() {
+		label := widget.NewLabel(details)
+		label.TextStyle = fyne.TextStyle{Monospace: true}
+		content := container.NewVBox(label)
+		if export != nil {
+			content.Add(container.NewHBox(
+				widget.NewButton("Export HTML report...", func() { ui.saveReport(export, "html", baseName) }),
+				widget.NewButton("Export Markdown report...", func() { ui.saveReport(export, "md", baseName) }),
+			))
+		}
+		d := dialog.NewCustom("Quality check: "+status, "Close", content, ui.Window)
+		if timeline != nil {
+			content.Add(widget.NewButtonWithIcon("Show timeline...", theme.MediaPlayIcon(), timeline))
+		}
+		if retry != nil {
+			if export != nil {
+				content.Add(container.NewHBox(
+					widget.NewButton("Export failed sections (JSON)...", func() { ui.saveReport(export, "json", baseName) }),
+					widget.NewButton("Export failed sections (CSV)...", func() { ui.saveReport(export, "csv", baseName) }),
+				))
+			}
+			retryBtn := widget.NewButtonWithIcon("Retry failed sections...", theme.ViewRefreshIcon(), func() {
+				d.Hide()
+				retry()
+			})
+			retryBtn.Importance = widget.HighImportance
+			content.Add(retryBtn)
+		}
+		d.Show()
+	})
+}
+
+// RetryChoice is the provider and voice to retry failed sections with.
+type RetryChoice struct {
+	Provider string
+	Voice    string
+}
+
+// AskRetryFailed lists the failed sections and asks which provider and voice to
+// retry them with, starting from provider and voice. defaultVoice supplies the
+// voice when another provider is chosen. It blocks until the user answers.
+func (ui *UI) AskRetryFailed(sections, providers []string, provider, voice string, defaultVoice func(provider string) string) (RetryChoice, bool) {
+	answer := make(chan *RetryChoice, 1)
+	fyne.Do(func() {
+		list := widget.NewList(
+			func() int { return len(sections) },
+			func() fyne.CanvasObject { return widget.NewLabel("") },
+			func(id widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(sections[id]) },
+		)
+		voiceEntry := widget.NewEntry()
+		voiceEntry.SetText(voice)
+		providerSelect := widget.NewSelect(providers, func(p string) {
+			if p != provider {
+				voiceEntry.SetText(defaultVoice(p))
+			} else {
+				voiceEntry.SetText(voice)
+			}
+		})
+		providerSelect.SetSelected(provider)
+		form := container.New(layout.NewFormLayout(),
+			widget.NewLabel("Provider:"), providerSelect,
+			widget.NewLabel("Voice:"), voiceEntry,
+		)
+		content := container.NewBorder(
+			widget.NewLabel(fmt.Sprintf("%d section(s) could not be synthesized:", len(sections))),
+			form, nil, nil, list,
+		)
+		d := dialog.NewCustomConfirm("Retry failed sections", "Retry", "Cancel", content, func(ok bool) {
+			if !ok {
+				answer <- nil
+				return
+			}
+			answer <- &RetryChoice{Provider: providerSelect.Selected, Voice: strings.TrimSpace(voiceEntry.Text)}
+		}, ui.Window)
+		d.Resize(fyne.NewSize(640, 420))
+		d.Show()
+	})
+	choice := <-answer
+	if choice == nil {
+		return RetryChoice{}, false
+	}
+	return *choice, true
+}
+
+// FailingAction is the user's answer when a provider keeps failing during a job.
+type FailingAction int
+
+const (
+	FailingWait   FailingAction = iota // pause the job and try again on Resume
+	FailingSwitch                      // continue with another provider
+	FailingAbort                       // stop and keep the audio so far
+)
+
+// FailingChoice is the answer to AskProviderFailing. Provider and Voice are set
+// for FailingSwitch.
+type FailingChoice struct {
+	Action   FailingAction
+	Provider string
+	Voice    string
+}
+
+// AskProviderFailing tells the user that provider failed failures times in a row
+// with message, and asks whether to wait, switch to one of providers or abort.
+// defaultVoice supplies the voice of the provider chosen. It blocks until the user
+// answers; closing the dialog counts as Wait so no audio is lost.
+func (ui *UI) AskProviderFailing(provider string, failures int, message string, providers []string, defaultVoice func(provider string) string) FailingChoice {
+	answer := make(chan FailingChoice, 1)
+	fyne.Do(func() {
+		var others []string
+		for _, p := range providers {
+			if p != provider {
+				others = append(others, p)
+			}
+		}
+		voiceEntry := widget.NewEntry()
+		providerSelect := widget.NewSelect(others, func(p string) { voiceEntry.SetText(defaultVoice(p)) })
+		if len(others) > 0 {
+			providerSelect.SetSelected(others[0])
+		}
+
+		var d dialog.Dialog
+		choose := func(action FailingAction) func() {
+			return func() {
+				answer <- FailingChoice{Action: action, Provider: providerSelect.Selected, Voice: strings.TrimSpace(voiceEntry.Text)}
+				d.Hide()
+			}
+		}
+		label := widget.NewLabel(fmt.Sprintf("%s failed %d times in a row (%s). Waiting pauses the job until you press Resume; switching continues the remaining chunks with another provider.", provider, failures, message))
+		label.Wrapping = fyne.TextWrapWord
+		switchBtn := widget.NewButton("Switch provider", choose(FailingSwitch))
+		if len(others) == 0 {
+			switchBtn.Disable()
+		}
+		content := container.NewVBox(
+			label,
+			container.New(layout.NewFormLayout(),
+				widget.NewLabel("Provider:"), providerSelect,
+				widget.NewLabel("Voice:"), voiceEntry,
+			),
+			container.NewHBox(
+				widget.NewButton("Wait", choose(FailingWait)),
+				switchBtn,
+				widget.NewButton("Abort", choose(FailingAbort)),
+			),
+		)
+		d = dialog.NewCustomWithoutButtons("Provider keeps failing", content, ui.Window)
+		d.SetOnClosed(func() {
+			select {
+			case answer <- FailingChoice{Action: FailingWait}:
+			default:
+			}
+		})
+		d.Resize(fyne.NewSize(520, 0))
+		d.Show()
+	})
+	return <-answer
+}
+
+func (ui *UI) saveReport(export ReportExporter, format, baseName string) {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil || w == nil {
+			return
+		}
+		defer w.Close()
+		if err := export(format, w); err != nil {
+			dialog.ShowError(err, ui.Window)
+		}
+	}, ui.Window)
+	suffix := "_report."
+	if format == "json" || format == "csv" {
+		suffix = "_failed."
+	}
+	save.SetFileName(baseName + suffix + format)
+	save.Show()
+}
+
+// ConflictChoice is the user's answer when an output file already exists.
+type ConflictChoice int
+
+const (
+	ConflictRename    ConflictChoice = iota // save under a new, numbered name
+	ConflictSkip                            // keep the existing file and drop the new audio
+	ConflictOverwrite                       // replace the existing file
+)
+
+// AskOutputConflict asks what to do because path already exists and blocks until
+// the user answers. Closing the dialog counts as Rename so no audio is lost.
+func (ui *UI) AskOutputConflict(path string) ConflictChoice {
+	answer := make(chan ConflictChoice, 1)
+	fyne.Do(func() {
+		var d dialog.Dialog
+		choose := func(c ConflictChoice) func() {
+			return func() {
+				answer <- c
+				d.Hide()
+			}
+		}
+		content := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("%s already exists.", filepath.Base(path))),
+			container.NewHBox(
+				widget.NewButton("Rename", choose(ConflictRename)),
+				widget.NewButton("Skip", choose(ConflictSkip)),
+				widget.NewButton("Overwrite", choose(ConflictOverwrite)),
+			),
+		)
+		d = dialog.NewCustomWithoutButtons("File exists", content, ui.Window)
+		d.SetOnClosed(func() {
+			select {
+			case answer <- ConflictRename:
+			default:
+			}
+		})
+		d.Show()
+	})
+	return <-answer
+}
+
+// AskConfirm shows a yes/no question and blocks until the user answers.
+func (ui *UI) AskConfirm(title, message string) bool {
+	answer := make(chan bool, 1)
+	fyne.Do(func() {
+		label := widget.NewLabel(message)
+		label.Wrapping = fyne.TextWrapWord
+		d := dialog.NewCustomConfirm(title, "Yes", "No", label, func(ok bool) { answer <- ok }, ui.Window)
+		d.Resize(fyne.NewSize(480, 0))
+		d.Show()
+	})
+	return <-answer
+}
+
+// TourStep is one page of a guided tour. Button labels the button that moves on
+// ("Next" if empty) and OnNext, if set, runs on the UI thread when it is pressed,
+// e.g. to open the window or start the action the page talks about.
+type TourStep struct {
+	Title  string
+	Text   string
+	Button string
+	OnNext func()
+}
+
+// ShowTour shows steps one after another; "End tour" closes the tour early.
+func (ui *UI) ShowTour(steps []TourStep) {
+	var show func(i int)
+	show = func(i int) {
+		if i >= len(steps) {
+			return
+		}
+		step := steps[i]
+		button := step.Button
+		if button == "" {
+			button = "Next"
+		}
+		label := widget.NewLabel(step.Text)
+		label.Wrapping = fyne.TextWrapWord
+		d := dialog.NewCustomConfirm(step.Title, button, "End tour", label, func(ok bool) {
+			if !ok {
+				return
+			}
+			if step.OnNext != nil {
+				step.OnNext()
+			}
+			show(i + 1)
+		}, ui.Window)
+		d.Resize(fyne.NewSize(420, 0))
+		d.Show()
+	}
+	show(0)
+}
+
+// EstimateRow is the dry-run estimate of the current document for one provider.
+type EstimateRow struct {
+	Provider  string
+	Voice     string
+	Chunks    int
+	Tokens    int
+	Chars     int
+	Duration  time.Duration
+	Cost      float64 // estimated list price in USD
+	CostKnown bool
+	Err       string // set if the document could not be prepared for the provider
+}
+
+// ShowEstimate lists the dry-run estimate of the document per provider.
+func (ui *UI) ShowEstimate(rows []EstimateRow) {
+	fyne.Do(func() {
+		headers := []string{"Provider", "Voice", "Chunks", "Tokens", "Characters", "Duration", "Cost"}
+		grid := container.NewGridWithColumns(len(headers))
+		for _, h := range headers {
+			grid.Add(widget.NewLabelWithStyle(h, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		for _, r := range rows {
+			cells := []string{r.Provider, r.Voice, "–", "–", "–", "–", r.Err}
+			if r.Err == "" {
+				cost := "unknown"
+				if r.CostKnown {
+					cost = fmt.Sprintf("~$%.2f", r.Cost)
+				}
+				cells = []string{r.Provider, r.Voice, strconv.Itoa(r.Chunks), strconv.Itoa(r.Tokens), strconv.Itoa(r.Chars),
+					r.Duration.Round(time.Second).String(), cost}
+			}
+			for _, c := range cells {
+				grid.Add(widget.NewLabel(c))
+			}
+		}
+		note := widget.NewLabel("Dry run: the document was preprocessed and chunked, but nothing was sent to a provider. Durations assume a typical narration rate; costs are list prices.")
+		note.Wrapping = fyne.TextWrapWord
+		d := dialog.NewCustom("Estimate", "Close", container.NewVBox(grid, note), ui.Window)
+		d.Resize(fyne.NewSize(820, 0))
+		d.Show()
+	})
+}
+
+// ShowChapterPicker lists the chapters of the book title, all selected, and
+// calls onPick with the indexes of the chapters the user keeps.
+func (ui *UI) ShowChapterPicker(title string, chapters []string, onPick func(indexes []int)) {
+	fyne.Do(func() {
+		checks := make([]*widget.Check, len(chapters))
+		list := container.NewVBox()
+		for i, c := range chapters {
+			checks[i] = widget.NewCheck(c, nil)
+			checks[i].SetChecked(true)
+			list.Add(checks[i])
+		}
+		setAll := func(checked bool) {
+			for _, c := range checks {
+				c.SetChecked(checked)
+			}
+		}
+		buttons := container.NewHBox(
+			widget.NewButton("Select all", func() { setAll(true) }),
+			widget.NewButton("Select none", func() { setAll(false) }),
+		)
+		content := container.NewBorder(
+			widget.NewLabel("Chapters to load into the input, each under a heading of its own:"),
+			buttons, nil, nil, container.NewVScroll(list),
+		)
+		d := dialog.NewCustomConfirm(title, "Load", "Cancel", content, func(ok bool) {
+			if !ok {
+				return
+			}
+			var indexes []int
+			for i, c := range checks {
+				if c.Checked {
+					indexes = append(indexes, i)
+				}
+			}
+			onPick(indexes)
+		}, ui.Window)
+		d.Resize(fyne.NewSize(640, 520))
+		d.Show()
+	})
+}
@@ -0,0 +1,165 @@
+package gui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"easy-tts/internal/history"
+	"easy-tts/internal/util"
+)
+
+// ShowHistoryWindow opens a window listing finished jobs with searchable notes and tags.
+// onReopen is called with the cached input text when the user reopens an entry.
+func ShowHistoryWindow(app fyne.App, store *history.Store, onReopen func(text string)) {
+	w := app.NewWindow("History")
+	w.Resize(fyne.NewSize(900, 500))
+
+	entries := store.Entries()
+	var selected string
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search titles, texts, voices, notes and tags...")
+
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			e := entries[id]
+			label := fmt.Sprintf("%s  %s", e.CreatedAt.Format("2006-01-02 15:04"), e.Title)
+			if len(e.Tags) > 0 {
+				label += "  [" + strings.Join(e.Tags, ", ") + "]"
+			}
+			obj.(*widget.Label).SetText(label)
+		},
+	)
+
+	details := widget.NewLabel("Select an entry.")
+	details.Wrapping = fyne.TextWrapWord
+	notes := widget.NewMultiLineEntry()
+	notes.SetPlaceHolder("Notes, e.g. \"final version for episode 12\"")
+	notes.Wrapping = fyne.TextWrapWord
+	tags := widget.NewEntry()
+	tags.SetPlaceHolder("Comma-separated tags")
+	saveBtn := widget.NewButton("Save notes", func() {
+		if selected == "" {
+			return
+		}
+		if err := store.SetAnnotations(selected, notes.Text, history.ParseTags(tags.Text)); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		entries = store.Filter(search.Text)
+		list.Refresh()
+	})
+
+	reopenBtn := widget.NewButton("Reopen text", func() {
+		e, ok := store.Get(selected)
+		if !ok {
+			return
+		}
+		text, err := store.Text(e.TextHash)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		onReopen(text)
+	})
+	playBtn := widget.NewButton("Play", func() {
+		e, ok := store.Get(selected)
+		if !ok {
+			return
+		}
+		if err := OpenOutput(app, e); err != nil {
+			dialog.ShowError(err, w)
+		}
+	})
+
+	deleteBtn := widget.NewButton("Delete output", func() {
+		e, ok := store.Get(selected)
+		if !ok {
+			return
+		}
+		var files []string
+		for _, f := range e.Outputs() {
+			if _, err := os.Stat(f); err == nil {
+				files = append(files, f)
+			}
+		}
+		msg := fmt.Sprintf("Remove \"%s\" from the history", e.Title)
+		if len(files) > 0 {
+			msg += fmt.Sprintf(" and move %d file(s) to the trash", len(files))
+		}
+		dialog.ShowConfirm("Delete output", msg+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+			for _, f := range files {
+				if err := util.MoveToTrash(f); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				slog.Info("Moved to the trash", "path", f)
+			}
+			if err := store.Remove(e.ID); err != nil {
+				dialog.ShowError(err, w)
+			}
+			entries = store.Filter(search.Text)
+			selected = ""
+			list.UnselectAll()
+			list.Refresh()
+			details.SetText("Select an entry.")
+			notes.SetText("")
+			tags.SetText("")
+		}, w)
+	})
+
+	list.OnSelected = func(id widget.ListItemID) {
+		e := entries[id]
+		selected = e.ID
+		details.SetText(fmt.Sprintf("%s\n\nProvider: %s\nVoice: %s\nSpeed: %.2f\nOutput: %s",
+			e.Title, e.Provider, e.Voice, e.Speed, e.OutputPath))
+		notes.SetText(e.Notes)
+		tags.SetText(strings.Join(e.Tags, ", "))
+	}
+	search.OnChanged = func(q string) {
+		entries = store.Filter(q)
+		selected = ""
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	detailPane := container.NewBorder(details, container.NewVBox(widget.NewLabel("Tags:"), tags,
+		container.NewGridWithColumns(4, saveBtn, reopenBtn, playBtn, deleteBtn)), nil, nil,
+		container.NewBorder(widget.NewLabel("Notes:"), nil, nil, nil, notes))
+	split := container.NewHSplit(list, detailPane)
+	split.Offset = 0.55
+	w.SetContent(container.NewBorder(search, nil, nil, nil, split))
+	w.Show()
+}
+
+// OpenOutput opens the output of e in the default player; if only its chapter
+// files were kept, the first of them.
+func OpenOutput(app fyne.App, e history.Entry) error {
+	path := e.OutputPath
+	if _, err := os.Stat(path); err != nil {
+		for _, f := range e.Files {
+			if filepath.Ext(f) == filepath.Ext(path) {
+				path = f
+				break
+			}
+		}
+	}
+	if err := app.OpenURL(&url.URL{Scheme: "file", Path: path}); err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return nil
+}
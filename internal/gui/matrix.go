@@ -0,0 +1,171 @@
+package gui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// matrixParallel is the number of samples rendered at the same time.
+const matrixParallel = 4
+
+// MatrixVoice is one voice of the voice matrix.
+type MatrixVoice struct {
+	Provider string
+	Voice    string
+}
+
+// MatrixSample is a rendered sample of the voice matrix.
+type MatrixSample struct {
+	Path      string // Audio file
+	Duration  time.Duration
+	Cost      float64 // Estimated list price in USD
+	CostKnown bool
+}
+
+// MatrixRenderer synthesizes text with one voice.
+type MatrixRenderer func(v MatrixVoice, text string) (MatrixSample, error)
+
+// ParseMatrixVoices reads "provider: voice" lines; lines without a provider use defaultProvider.
+func ParseMatrixVoices(s, defaultProvider string) []MatrixVoice {
+	var voices []MatrixVoice
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		v := MatrixVoice{Provider: defaultProvider, Voice: line}
+		if provider, voice, ok := strings.Cut(line, ":"); ok {
+			v = MatrixVoice{Provider: strings.TrimSpace(provider), Voice: strings.TrimSpace(voice)}
+		}
+		if v.Voice != "" {
+			voices = append(voices, v)
+		}
+	}
+	return voices
+}
+
+// FormatMatrixVoices renders voices as "provider: voice" lines.
+func FormatMatrixVoices(voices []MatrixVoice) string {
+	var b strings.Builder
+	for _, v := range voices {
+		fmt.Fprintf(&b, "%s: %s\n", v.Provider, v.Voice)
+	}
+	return b.String()
+}
+
+// ShowVoiceMatrix opens a tool that renders one paragraph in several voices,
+// possibly of different providers, in parallel and lists the samples with their
+// duration and estimated cost for side-by-side listening.
+func ShowVoiceMatrix(app fyne.App, paragraph, defaultProvider string, voices []MatrixVoice, render MatrixRenderer) {
+	w := app.NewWindow("Voice matrix")
+	w.Resize(fyne.NewSize(820, 640))
+
+	textEntry := widget.NewMultiLineEntry()
+	textEntry.Wrapping = fyne.TextWrapWord
+	textEntry.SetText(paragraph)
+	textEntry.SetMinRowsVisible(4)
+	voicesEntry := widget.NewMultiLineEntry()
+	voicesEntry.SetPlaceHolder("openai: nova\ngoogle: de-DE-Chirp3-HD-Kore")
+	voicesEntry.SetText(FormatMatrixVoices(voices))
+	voicesEntry.SetMinRowsVisible(5)
+
+	header := func() []fyne.CanvasObject {
+		var cells []fyne.CanvasObject
+		for _, title := range []string{"Provider", "Voice", "Duration", "Cost", "", "Status"} {
+			cells = append(cells, widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		return cells
+	}
+	grid := container.NewGridWithColumns(6, header()...)
+	total := widget.NewLabel("")
+
+	var renderBtn *widget.Button
+	renderBtn = widget.NewButton("Render matrix", func() {
+		text := strings.TrimSpace(textEntry.Text)
+		list := ParseMatrixVoices(voicesEntry.Text, defaultProvider)
+		if text == "" || len(list) == 0 {
+			total.SetText("Enter a paragraph and at least one voice.")
+			return
+		}
+		renderBtn.Disable()
+		grid.Objects = header()
+		total.SetText(fmt.Sprintf("Rendering %d samples...", len(list)))
+
+		var mu sync.Mutex
+		var sum float64
+		unknown := false
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, matrixParallel)
+		for _, v := range list {
+			duration, cost, status := widget.NewLabel("–"), widget.NewLabel("–"), widget.NewLabel("Waiting")
+			var sample MatrixSample
+			playBtn := widget.NewButton("Play", func() {
+				_ = app.OpenURL(&url.URL{Scheme: "file", Path: sample.Path})
+			})
+			playBtn.Disable()
+			grid.Add(widget.NewLabel(v.Provider))
+			grid.Add(widget.NewLabel(v.Voice))
+			grid.Add(duration)
+			grid.Add(cost)
+			grid.Add(playBtn)
+			grid.Add(status)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				slots <- struct{}{}
+				fyne.Do(func() { status.SetText("Rendering...") })
+				s, err := render(v, text)
+				<-slots
+				mu.Lock()
+				if err == nil && s.CostKnown {
+					sum += s.Cost
+				} else if err == nil {
+					unknown = true
+				}
+				mu.Unlock()
+				fyne.Do(func() {
+					if err != nil {
+						status.SetText(err.Error())
+						return
+					}
+					sample = s
+					duration.SetText(s.Duration.Round(100 * time.Millisecond).String())
+					if s.CostKnown {
+						cost.SetText(fmt.Sprintf("$%.4f", s.Cost))
+					}
+					status.SetText("Done")
+					playBtn.Enable()
+				})
+			}()
+		}
+		grid.Refresh()
+		go func() {
+			wg.Wait()
+			fyne.Do(func() {
+				msg := fmt.Sprintf("Estimated total: $%.4f", sum)
+				if unknown {
+					msg += " (some prices unknown)"
+				}
+				total.SetText(msg)
+				renderBtn.Enable()
+			})
+		}()
+	})
+	renderBtn.Importance = widget.HighImportance
+
+	form := container.NewVBox(
+		widget.NewLabel("Paragraph:"), textEntry,
+		widget.NewLabel("Voices, one \"provider: voice\" per line:"), voicesEntry,
+		container.NewHBox(renderBtn, total),
+	)
+	w.SetContent(container.NewBorder(form, nil, nil, nil, container.NewVScroll(grid)))
+	w.Show()
+}
@@ -0,0 +1,130 @@
+package gui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PreviewChunk is one request as it will be sent to the provider.
+type PreviewChunk struct {
+	Text        string
+	Voice       string
+	Speaker     string
+	PauseBefore time.Duration
+	NewFile     bool
+	Heading     string
+}
+
+// Audition lets the preview window render a short sample in other voices.
+type Audition struct {
+	Favorites []string                           // Voices bound to the keys 1-9
+	Render    func(voice string) (string, error) // Synthesizes the sample and returns its audio file
+	UseVoice  func(voice string)                 // Makes voice the main window's voice
+}
+
+// ShowPreviewWindow opens a window showing the processed text with its chunk
+// boundaries, so problems can be spotted before any credits are spent. With an
+// audition, keys 1-9 render and play a sample in the matching favorite voice.
+func ShowPreviewWindow(app fyne.App, providerName string, chunks []PreviewChunk, audition *Audition) {
+	w := app.NewWindow("Preview – " + providerName)
+	w.Resize(fyne.NewSize(800, 600))
+
+	chars := 0
+	var b strings.Builder
+	for i, c := range chunks {
+		chars += len([]rune(c.Text))
+		info := []string{fmt.Sprintf("Chunk %d/%d", i+1, len(chunks)), c.Voice}
+		if c.Speaker != "" {
+			info = append(info, c.Speaker)
+		}
+		info = append(info, fmt.Sprintf("%d chars", len([]rune(c.Text))))
+		if c.NewFile {
+			info = append(info, "new file: "+c.Heading)
+		}
+		if c.PauseBefore > 0 {
+			info = append(info, fmt.Sprintf("pause %v", c.PauseBefore))
+		}
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "──── %s ────\n%s", strings.Join(info, " · "), c.Text)
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("%d chunk(s), %d characters will be sent to %s.", len(chunks), chars, providerName))
+	text := widget.NewLabel(b.String())
+	text.Wrapping = fyne.TextWrapWord
+	text.Selectable = true
+	var bottom fyne.CanvasObject
+	if audition != nil && len(audition.Favorites) > 0 {
+		bottom = auditionBar(app, w, audition)
+	}
+	w.SetContent(container.NewBorder(summary, bottom, nil, nil, container.NewVScroll(text)))
+	w.Show()
+}
+
+// auditionBar shows the favorite voices with their hotkeys and plays samples.
+// Samples are rendered once per voice and window.
+func auditionBar(app fyne.App, w fyne.Window, audition *Audition) fyne.CanvasObject {
+	favorites := audition.Favorites
+	if len(favorites) > 9 {
+		favorites = favorites[:9]
+	}
+	status := widget.NewLabel("Press 1-9 to hear a sample in a favorite voice.")
+	current := ""
+	useBtn := widget.NewButton("Use this voice", func() {
+		if current != "" {
+			audition.UseVoice(current)
+		}
+	})
+	useBtn.Disable()
+
+	samples := map[string]string{}
+	busy := false
+	play := func(voice string) {
+		if busy {
+			return
+		}
+		current = voice
+		useBtn.Enable()
+		if path, ok := samples[voice]; ok {
+			status.SetText("Playing " + voice)
+			_ = app.OpenURL(&url.URL{Scheme: "file", Path: path})
+			return
+		}
+		busy = true
+		status.SetText("Rendering sample in " + voice + "...")
+		go func() {
+			path, err := audition.Render(voice)
+			fyne.Do(func() {
+				busy = false
+				if err != nil {
+					status.SetText(fmt.Sprintf("%s: %v", voice, err))
+					return
+				}
+				samples[voice] = path
+				status.SetText("Playing " + voice)
+				_ = app.OpenURL(&url.URL{Scheme: "file", Path: path})
+			})
+		}()
+	}
+
+	voices := container.NewHBox()
+	for i, voice := range favorites {
+		voices.Add(widget.NewButton(fmt.Sprintf("%d  %s", i+1, voice), func() { play(voice) }))
+	}
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		name := string(ev.Name)
+		if len(name) == 1 && name[0] >= '1' && name[0] <= '9' {
+			if i := int(name[0] - '1'); i < len(favorites) {
+				play(favorites[i])
+			}
+		}
+	})
+	return container.NewVBox(widget.NewSeparator(), container.NewHScroll(voices), container.NewHBox(useBtn, status))
+}
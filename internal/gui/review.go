@@ -0,0 +1,142 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"easy-tts/internal/preprocess"
+)
+
+// Labels of the acronym review choices.
+const (
+	acronymDefault = "Language default"
+	acronymSpell   = "Spell letter by letter"
+	acronymWord    = "Read as a word"
+	acronymCustom  = "Custom pronunciation"
+)
+
+// Scopes a review can be remembered for.
+const (
+	scopeDocument = "This document"
+	scopeAll      = "All documents"
+)
+
+// DocumentReview is what the review window shows for the current text.
+type DocumentReview struct {
+	Acronyms []string          // Acronyms found in the text
+	Rules    map[string]string // Current rule per acronym, "" for the language default
+	// Spoken returns how token is read under rule.
+	Spoken func(token, rule string) string
+	// Stages are the preprocessing stages with their state for this document.
+	Stages  []preprocess.Stage
+	Enabled map[string]bool
+}
+
+// ReviewCorrections are the user's answers in the review window.
+type ReviewCorrections struct {
+	Rules        map[string]string // Rule per acronym, "" for the language default
+	Enabled      map[string]bool   // Stage states for this document
+	DocumentOnly bool              // Remember the acronym rules for this document only
+}
+
+// ShowDocumentReview lists the problem tokens of the current text (acronyms)
+// with how they will be read, and the preprocessing stages for this document.
+// onSave receives the corrections.
+func ShowDocumentReview(app fyne.App, review DocumentReview, onSave func(ReviewCorrections)) {
+	w := app.NewWindow("Review document")
+	w.Resize(fyne.NewSize(680, 520))
+
+	type row struct {
+		choice *widget.Select
+		custom *widget.Entry
+	}
+	rows := map[string]*row{}
+	ruleOf := func(r *row) string {
+		switch r.choice.Selected {
+		case acronymSpell:
+			return preprocess.AcronymSpell
+		case acronymWord:
+			return preprocess.AcronymWord
+		case acronymCustom:
+			return r.custom.Text
+		}
+		return ""
+	}
+	grid := container.NewGridWithColumns(4,
+		widget.NewLabelWithStyle("Acronym", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Rule", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Pronunciation", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Read as", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	for _, token := range review.Acronyms {
+		r := &row{custom: widget.NewEntry()}
+		result := widget.NewLabel("")
+		update := func() {
+			if r.choice.Selected == acronymCustom {
+				r.custom.Enable()
+			} else {
+				r.custom.Disable()
+			}
+			result.SetText(review.Spoken(token, ruleOf(r)))
+		}
+		r.choice = widget.NewSelect([]string{acronymDefault, acronymSpell, acronymWord, acronymCustom}, func(string) { update() })
+		r.custom.OnChanged = func(string) { update() }
+		switch current := review.Rules[token]; current {
+		case "":
+			r.choice.SetSelected(acronymDefault)
+		case preprocess.AcronymSpell:
+			r.choice.SetSelected(acronymSpell)
+		case preprocess.AcronymWord:
+			r.choice.SetSelected(acronymWord)
+		default:
+			r.custom.SetText(current)
+			r.choice.SetSelected(acronymCustom)
+		}
+		rows[token] = r
+		grid.Add(widget.NewLabel(token))
+		grid.Add(r.choice)
+		grid.Add(r.custom)
+		grid.Add(result)
+	}
+	var acronyms fyne.CanvasObject = grid
+	if len(review.Acronyms) == 0 {
+		acronyms = widget.NewLabel("The text contains no acronyms.")
+	}
+
+	enabled := map[string]bool{}
+	stageChecks := container.NewVBox()
+	for _, stage := range review.Stages {
+		name := stage.Name()
+		enabled[name] = review.Enabled[name]
+		check := widget.NewCheck(stage.Description(), func(on bool) { enabled[name] = on })
+		check.SetChecked(enabled[name])
+		stageChecks.Add(check)
+	}
+
+	scope := widget.NewRadioGroup([]string{scopeDocument, scopeAll}, nil)
+	scope.Horizontal = true
+	scope.SetSelected(scopeDocument)
+
+	saveBtn := widget.NewButton("Save corrections", func() {
+		c := ReviewCorrections{Rules: map[string]string{}, Enabled: enabled, DocumentOnly: scope.Selected != scopeAll}
+		for token, r := range rows {
+			c.Rules[token] = ruleOf(r)
+		}
+		onSave(c)
+		w.Close()
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	w.SetContent(container.NewBorder(nil,
+		container.NewVBox(
+			container.NewHBox(widget.NewLabel("Remember acronym corrections for:"), scope),
+			widget.NewLabel("Preprocessing choices always apply to this document only; regenerating it reuses all corrections."),
+			container.NewHBox(saveBtn),
+		), nil, nil,
+		container.NewAppTabs(
+			container.NewTabItem("Acronyms", container.NewVScroll(acronyms)),
+			container.NewTabItem("Preprocessing", container.NewVScroll(stageChecks)),
+		)))
+	w.Show()
+}
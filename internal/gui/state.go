@@ -0,0 +1,49 @@
+package gui
+
+import "fyne.io/fyne/v2"
+
+// Preference keys of the window state that is restored at the next launch.
+const (
+	prefWindowWidth  = "window.width"
+	prefWindowHeight = "window.height"
+	prefSplitOffset  = "window.split_offset"
+	prefProvider     = "last.provider"
+	prefVoice        = "last.voice" // Of the last provider
+	prefSpeed        = "last.speed"
+	prefInstructions = "last.instructions"
+)
+
+// RestoreState brings back the window size, the split between instructions
+// and input, the speed and the instructions saved by SaveState. It returns the
+// provider and voice selected last, "" if there are none.
+func (ui *UI) RestoreState(prefs fyne.Preferences) (provider, voice string) {
+	if w, h := prefs.Float(prefWindowWidth), prefs.Float(prefWindowHeight); w > 0 && h > 0 {
+		ui.Window.Resize(fyne.NewSize(float32(w), float32(h)))
+	}
+	if offset := prefs.FloatWithFallback(prefSplitOffset, -1); offset >= 0 && offset <= 1 {
+		ui.split.SetOffset(offset)
+	}
+	if speed := prefs.Float(prefSpeed); speed > 0 {
+		ui.Speed.SetValue(speed)
+	}
+	// Saved even when emptied, so cleared instructions stay cleared
+	if instructions := prefs.StringWithFallback(prefInstructions, "\x00"); instructions != "\x00" {
+		ui.Instructions.SetText(instructions)
+	}
+	return prefs.String(prefProvider), prefs.String(prefVoice)
+}
+
+// SaveState keeps the window size, split, speed and instructions, and provider
+// with the voice selected, for RestoreState at the next launch.
+func (ui *UI) SaveState(prefs fyne.Preferences, provider string) {
+	size := ui.Window.Canvas().Size()
+	if size.Width > 0 && size.Height > 0 {
+		prefs.SetFloat(prefWindowWidth, float64(size.Width))
+		prefs.SetFloat(prefWindowHeight, float64(size.Height))
+	}
+	prefs.SetFloat(prefSplitOffset, ui.split.Offset)
+	prefs.SetFloat(prefSpeed, ui.Speed.Value)
+	prefs.SetString(prefInstructions, ui.Instructions.Text)
+	prefs.SetString(prefProvider, provider)
+	prefs.SetString(prefVoice, ui.Voice.Text)
+}
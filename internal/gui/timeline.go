@@ -0,0 +1,179 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// TimelineChunk is one chunk of an output on the timeline.
+type TimelineChunk struct {
+	Text     string
+	Voice    string
+	Start    time.Duration
+	Duration time.Duration
+	Failed   bool // The chunk failed; a filler or nothing takes its place
+}
+
+// TimelinePlayer plays the audio of the chunk at index.
+type TimelinePlayer func(index int) error
+
+// ShowTimeline opens a window that lays out the chunks of an output along its
+// playback time. Clicking a chunk, on the timeline or in the list of texts below
+// it, plays its audio and highlights it in both.
+func ShowTimeline(app fyne.App, title string, chunks []TimelineChunk, play TimelinePlayer) {
+	w := app.NewWindow("Timeline: " + title)
+	w.Resize(fyne.NewSize(900, 560))
+
+	status := widget.NewLabel("Click a chunk to play it.")
+	list := widget.NewList(
+		func() int { return len(chunks) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Wrapping = fyne.TextWrapWord
+			return label
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			c := chunks[id]
+			text := fmt.Sprintf("%d. [%s] %s", id+1, c.Start.Round(time.Second), c.Text)
+			if c.Failed {
+				text += " (failed)"
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	bar := newTimelineBar(chunks, func(index int) {
+		list.Unselect(index) // plays it again if it is selected already
+		list.Select(index)
+		list.ScrollTo(index)
+	})
+	list.OnSelected = func(id widget.ListItemID) {
+		bar.setSelected(id)
+		c := chunks[id]
+		status.SetText(fmt.Sprintf("Chunk %d of %d, %s at %s (%s)", id+1, len(chunks), c.Voice,
+			c.Start.Round(time.Second), c.Duration.Round(100*time.Millisecond)))
+		go func() {
+			if err := play(id); err != nil {
+				fyne.Do(func() { status.SetText(fmt.Sprintf("Cannot play chunk %d: %v", id+1, err)) })
+			}
+		}()
+	}
+
+	w.SetContent(container.NewBorder(container.NewVBox(bar, status), nil, nil, nil, list))
+	w.Show()
+}
+
+// timelineMinWidth is the width of chunks too short to click otherwise, such as failed ones.
+const timelineMinWidth = 3
+
+// timelineBar draws the chunks of an output as blocks as wide as they play.
+type timelineBar struct {
+	widget.BaseWidget
+	chunks   []TimelineChunk
+	selected int
+	onTapped func(index int)
+}
+
+func newTimelineBar(chunks []TimelineChunk, onTapped func(int)) *timelineBar {
+	b := &timelineBar{chunks: chunks, selected: -1, onTapped: onTapped}
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+func (b *timelineBar) setSelected(index int) {
+	b.selected = index
+	b.Refresh()
+}
+
+// Tapped selects the chunk under the pointer.
+func (b *timelineBar) Tapped(e *fyne.PointEvent) {
+	for i, x := range b.edges(b.Size().Width) {
+		if e.Position.X < x {
+			b.onTapped(i)
+			return
+		}
+	}
+}
+
+// edges returns the right edge of each chunk's block in a bar width wide.
+func (b *timelineBar) edges(width float32) []float32 {
+	var total time.Duration
+	for _, c := range b.chunks {
+		total += c.Duration
+	}
+	// The blocks too short to click take their width from the rest
+	scale := width - timelineMinWidth*float32(len(b.chunks))
+	edges := make([]float32, len(b.chunks))
+	x := float32(0)
+	for i, c := range b.chunks {
+		x += timelineMinWidth
+		if total > 0 && scale > 0 {
+			x += scale * float32(c.Duration) / float32(total)
+		}
+		edges[i] = x
+	}
+	return edges
+}
+
+func (b *timelineBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &timelineRenderer{bar: b}
+	r.Refresh()
+	return r
+}
+
+type timelineRenderer struct {
+	bar    *timelineBar
+	blocks []*canvas.Rectangle
+}
+
+func (r *timelineRenderer) Layout(size fyne.Size) {
+	left := float32(0)
+	for i, x := range r.bar.edges(size.Width) {
+		if i < len(r.blocks) {
+			r.blocks[i].Move(fyne.NewPos(left, 0))
+			r.blocks[i].Resize(fyne.NewSize(max(x-left-1, 1), size.Height))
+		}
+		left = x
+	}
+}
+
+func (r *timelineRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(timelineMinWidth*float32(len(r.bar.chunks)), 48)
+}
+
+func (r *timelineRenderer) Refresh() {
+	for len(r.blocks) < len(r.bar.chunks) {
+		r.blocks = append(r.blocks, canvas.NewRectangle(color.Transparent))
+	}
+	for i, c := range r.bar.chunks {
+		fill := theme.Color(theme.ColorNameDisabled)
+		if i%2 == 1 {
+			fill = theme.Color(theme.ColorNamePlaceHolder)
+		}
+		switch {
+		case i == r.bar.selected:
+			fill = theme.Color(theme.ColorNamePrimary)
+		case c.Failed:
+			fill = theme.Color(theme.ColorNameError)
+		}
+		r.blocks[i].FillColor = fill
+		r.blocks[i].Refresh()
+	}
+	r.Layout(r.bar.Size())
+}
+
+func (r *timelineRenderer) Objects() []fyne.CanvasObject {
+	objects := make([]fyne.CanvasObject, len(r.blocks))
+	for i, b := range r.blocks {
+		objects[i] = b
+	}
+	return objects
+}
+
+func (r *timelineRenderer) Destroy() {}
@@ -41,6 +41,17 @@ func createVoiceEntry() *widget.Entry {
 	return voice
 }
 
+// noStyle is the style option that leaves the voice's default style.
+const noStyle = "Default"
+
+// createStyleSelect creates the speaking style selection; options are set per provider.
+func createStyleSelect() *widget.Select {
+	style := widget.NewSelect([]string{noStyle}, nil)
+	style.SetSelected(noStyle)
+	style.Disable()
+	return style
+}
+
 // createSpeedSlider creates the speed slider and its value label.
 func createSpeedSlider() (*widget.Slider, *canvas.Text) {
 	speed := widget.NewSlider(0.5, 2.0)
@@ -73,6 +84,25 @@ func createSubmitButton(onTapped func()) *widget.Button {
 	return submitBtn
 }
 
+// createPasteButton creates the Paste & Speak button next to the submit button.
+func createPasteButton() *widget.Button {
+	return widget.NewButtonWithIcon("Paste & Speak", theme.ContentPasteIcon(), nil)
+}
+
+// createPauseButton creates the Pause/Resume button, hidden until a job runs.
+func createPauseButton() *widget.Button {
+	pauseBtn := widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), nil)
+	pauseBtn.Hide()
+	return pauseBtn
+}
+
+// createEstimateText creates the text element for the predicted audio length.
+func createEstimateText() *canvas.Text {
+	estimateText := canvas.NewText("", theme.Color(theme.ColorNamePlaceHolder))
+	estimateText.Alignment = fyne.TextAlignTrailing
+	return estimateText
+}
+
 // createSuccessText creates the text element for success messages.
 func createSuccessText() *canvas.Text {
 	successText := canvas.NewText("", theme.Color(theme.ColorNamePrimary))
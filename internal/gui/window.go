@@ -1,12 +1,18 @@
 package gui
 
 import (
+	"path/filepath"
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"easy-tts/internal/history"
 )
 
 // UI holds all the UI elements and state.
@@ -15,15 +21,22 @@ type UI struct {
 	Instructions    *widget.Entry
 	ProviderSelect  *widget.Select
 	Voice           *widget.Entry
+	Style           *widget.Select
 	Speed           *widget.Slider
 	Input           *widget.Entry
 	SubmitBtn       *widget.Button
+	PasteBtn        *widget.Button // Paste & Speak, see SetPasteAndSpeak
+	EstimateText    *canvas.Text   // Predicted audio length, next to the submit button
+	PauseBtn        *widget.Button // Shown while a job that can be paused is running
 	SuccessText     *canvas.Text
 	ErrorText       *canvas.Text
 	ProcessingText  *canvas.Text
 	SpeedValueLabel *canvas.Text
 
 	ProgressBar *widget.ProgressBar // Progress bar for TTS progress
+
+	mainMenu *fyne.MainMenu
+	split    *container.Split // Between instructions and input
 }
 
 const (
@@ -65,7 +78,7 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 	)
 	w.SetMainMenu(menu)
 
-	ui := &UI{Window: w}
+	ui := &UI{Window: w, mainMenu: menu}
 
 	// Create Widgets (using functions from widgets.go)
 	ui.Instructions = createInstructionsEntry()
@@ -75,10 +88,14 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 	voiceMin := voiceEntry.MinSize()
 	voiceContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(300, voiceMin.Height)), voiceEntry)
 	ui.Voice = voiceEntry
+	ui.Style = createStyleSelect()
 	ui.Speed, ui.SpeedValueLabel = createSpeedSlider()
 	ui.Input = createInputEntry()
 	ui.SubmitBtn = createSubmitButton(onSubmit)
 	ui.SubmitBtn.Resize(fyne.NewSize(200, 40)) // Make submit button wider
+	ui.PasteBtn = createPasteButton()
+	ui.PauseBtn = createPauseButton()
+	ui.EstimateText = createEstimateText()
 	// Settings button in bottom left (commented out)
 	// settingsBtn := widget.NewButtonWithIcon("Settings", theme.SettingsIcon(), onSettings)
 	settingsBtnTopRight := widget.NewButtonWithIcon("Settings", theme.SettingsIcon(), onSettings)
@@ -95,6 +112,7 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 	instrLabel := createLabel("Instructions:", 18, true)
 	providerLabel := createLabel("Provider:", 18, true)
 	voiceLabel := createLabel("Voice:", 18, true)
+	styleLabel := createLabel("Style:", 18, true)
 	// speedTextLabel := createLabel("Speed:", 18, true) // COMMENTED OUT
 	inputLabel := createLabel("Input Text:", 18, true)
 
@@ -105,6 +123,8 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 		layout.NewSpacer(),
 		voiceLabel,
 		voiceContainer,
+		styleLabel,
+		ui.Style,
 		layout.NewSpacer(),
 		settingsBtnTopRight,
 	)
@@ -112,9 +132,9 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 	// Settings on left, submit button centered in window using 3-column layout
 	btnRow := container.NewGridWithColumns(3,
 		// settingsBtn, // COMMENTED OUT (bottom left)
-		layout.NewSpacer(), // visually balances the settings button
-		container.NewCenter(ui.SubmitBtn),
-		layout.NewSpacer(),
+		container.NewHBox(layout.NewSpacer(), ui.EstimateText), // next to the submit button
+		container.NewCenter(container.NewHBox(ui.SubmitBtn, ui.PasteBtn)),
+		container.NewHBox(ui.PauseBtn),
 	)
 
 	instrGroup := container.NewBorder(instrLabel, nil, nil, nil, instrCont)
@@ -136,6 +156,7 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 
 	textSplit := container.NewVSplit(instrGroup, inputGroup)
 	textSplit.Offset = 0.4
+	ui.split = textSplit
 
 	content := container.NewBorder(topSection, bottomSection, nil, nil, textSplit)
 
@@ -144,6 +165,32 @@ func NewUI(app fyne.App, providers []string, onSubmit func(), onSettings func(),
 	return ui
 }
 
+// SetStyles offers the speaking styles of the selected provider; without any the
+// style selection is disabled.
+func (ui *UI) SetStyles(styles []string) {
+	selected := ui.SelectedStyle()
+	ui.Style.Options = append([]string{noStyle}, styles...)
+	ui.Style.SetSelected(noStyle)
+	for _, s := range styles {
+		if s == selected {
+			ui.Style.SetSelected(s)
+		}
+	}
+	if len(styles) == 0 {
+		ui.Style.Disable()
+	} else {
+		ui.Style.Enable()
+	}
+}
+
+// SelectedStyle returns the chosen speaking style, or "" for the voice's default.
+func (ui *UI) SelectedStyle() string {
+	if ui.Style.Selected == noStyle {
+		return ""
+	}
+	return ui.Style.Selected
+}
+
 // ShowError displays an error message in the UI.
 func (ui *UI) ShowError(msg string) {
 	fyne.Do(func() {
@@ -191,14 +238,78 @@ func (ui *UI) SetProcessingMessage(msg string) {
 	})
 }
 
+// SetEstimator shows what estimate returns for the input text and speed next to
+// the submit button, updated whenever either changes.
+func (ui *UI) SetEstimator(estimate func(text string, speed float64) string) {
+	update := func() {
+		ui.EstimateText.Text = estimate(ui.Input.Text, ui.Speed.Value)
+		ui.EstimateText.Refresh()
+	}
+	onInput, onSpeed := ui.Input.OnChanged, ui.Speed.OnChanged
+	ui.Input.OnChanged = func(text string) {
+		if onInput != nil {
+			onInput(text)
+		}
+		update()
+	}
+	ui.Speed.OnChanged = func(speed float64) {
+		if onSpeed != nil {
+			onSpeed(speed)
+		}
+		update()
+	}
+	update()
+}
+
 // SetSubmitEnabled enables or disables the submit button.
 func (ui *UI) SetSubmitEnabled(enabled bool) {
 	fyne.Do(func() {
 		if enabled {
 			ui.SubmitBtn.Enable()
+			ui.PasteBtn.Enable()
 		} else {
 			ui.SubmitBtn.Disable()
+			ui.PasteBtn.Disable()
+		}
+	})
+}
+
+// Pausable is a running job that can be paused between chunks.
+type Pausable interface {
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// SetPausable shows the Pause button for job, or hides it if job is nil.
+func (ui *UI) SetPausable(job Pausable) {
+	fyne.Do(func() {
+		if job == nil {
+			ui.PauseBtn.OnTapped = nil
+			ui.PauseBtn.Hide()
+			return
+		}
+		update := func() {
+			if job.Paused() {
+				ui.PauseBtn.SetText("Resume")
+				ui.PauseBtn.SetIcon(theme.MediaPlayIcon())
+			} else {
+				ui.PauseBtn.SetText("Pause")
+				ui.PauseBtn.SetIcon(theme.MediaPauseIcon())
+			}
 		}
+		ui.PauseBtn.OnTapped = func() {
+			if job.Paused() {
+				job.Resume()
+				ui.SetProcessingMessage("Resuming...")
+			} else {
+				job.Pause()
+				ui.SetProcessingMessage("Pausing after the current chunk...")
+			}
+			update()
+		}
+		update()
+		ui.PauseBtn.Show()
 	})
 }
 
@@ -228,3 +339,98 @@ func (ui *UI) SetProgress(value float64) {
 		ui.ProgressBar.Refresh()
 	})
 }
+
+// AddMenuItem appends an item to the named main menu, creating the menu if needed.
+func (ui *UI) AddMenuItem(menuLabel, itemLabel string, action func()) {
+	ui.addMenuItem(menuLabel, fyne.NewMenuItem(itemLabel, action))
+}
+
+// SetPasteAndSpeak makes the Paste & Speak button, and the menu item of the same
+// name with Cmd/Ctrl+Shift+V, replace the input with the text on the clipboard
+// and call onSpeak to start synthesis right away. Nothing happens while a job
+// keeps the submit button disabled.
+func (ui *UI) SetPasteAndSpeak(onSpeak func()) {
+	paste := func() {
+		if ui.SubmitBtn.Disabled() {
+			return
+		}
+		text := strings.ReplaceAll(fyne.CurrentApp().Clipboard().Content(), "\r\n", "\n")
+		if strings.TrimSpace(text) == "" {
+			ui.ShowError("The clipboard holds no text to speak.")
+			return
+		}
+		ui.Input.SetText(text)
+		onSpeak()
+	}
+	ui.PasteBtn.OnTapped = paste
+	item := fyne.NewMenuItem("Paste & Speak", paste)
+	item.Shortcut = &desktop.CustomShortcut{KeyName: fyne.KeyV, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}
+	ui.addMenuItem("File", item)
+}
+
+// SetRecentMenus lists docs under File → Open Recent, opened by onOpen, and
+// the outputs of jobs under File → Recent Outputs, opened in the default
+// player. Called again, it replaces both lists.
+func (ui *UI) SetRecentMenus(docs []string, jobs []history.Entry, onOpen func(path string)) {
+	fyne.Do(func() {
+		names := make(map[string]int)
+		for _, p := range docs {
+			names[filepath.Base(p)]++
+		}
+		var docItems []*fyne.MenuItem
+		for _, p := range docs {
+			label := filepath.Base(p)
+			if names[label] > 1 {
+				label += " – " + filepath.Dir(p)
+			}
+			docItems = append(docItems, fyne.NewMenuItem(label, func() { onOpen(p) }))
+		}
+		var jobItems []*fyne.MenuItem
+		for _, e := range jobs {
+			jobItems = append(jobItems, fyne.NewMenuItem(filepath.Base(e.OutputPath), func() {
+				if err := OpenOutput(fyne.CurrentApp(), e); err != nil {
+					ui.ShowError(err.Error())
+				}
+			}))
+		}
+		ui.setSubmenu("File", "Open Recent", docItems, "No recent documents")
+		ui.setSubmenu("File", "Recent Outputs", jobItems, "No recent outputs")
+		ui.mainMenu.Refresh()
+	})
+}
+
+// setSubmenu replaces the items of the submenu itemLabel of the named menu,
+// adding it if needed; without items it shows a disabled placeholder.
+func (ui *UI) setSubmenu(menuLabel, itemLabel string, items []*fyne.MenuItem, placeholder string) {
+	if len(items) == 0 {
+		empty := fyne.NewMenuItem(placeholder, nil)
+		empty.Disabled = true
+		items = []*fyne.MenuItem{empty}
+	}
+	for _, m := range ui.mainMenu.Items {
+		if m.Label != menuLabel {
+			continue
+		}
+		for _, item := range m.Items {
+			if item.Label == itemLabel {
+				item.ChildMenu = fyne.NewMenu("", items...)
+				return
+			}
+		}
+	}
+	item := fyne.NewMenuItem(itemLabel, nil)
+	item.ChildMenu = fyne.NewMenu("", items...)
+	ui.addMenuItem(menuLabel, item)
+}
+
+func (ui *UI) addMenuItem(menuLabel string, item *fyne.MenuItem) {
+	for _, m := range ui.mainMenu.Items {
+		if m.Label == menuLabel {
+			m.Items = append(m.Items, item)
+			ui.mainMenu.Refresh()
+			return
+		}
+	}
+	ui.mainMenu.Items = append(ui.mainMenu.Items, fyne.NewMenu(menuLabel, item))
+	ui.mainMenu.Refresh()
+}
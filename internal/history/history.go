@@ -0,0 +1,333 @@
+package history
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	historyFileName = "history.json"
+	textsDirName    = "texts"
+)
+
+// Entry records one finished job.
+type Entry struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Title      string    `json:"title"`
+	TextHash   string    `json:"text_hash"`
+	Provider   string    `json:"provider"`
+	Voice      string    `json:"voice"`
+	Speed      float64   `json:"speed"`
+	OutputPath string    `json:"output_path"`
+	Files      []string  `json:"files,omitempty"` // Written along with the output: chapter files, sidecars, subtitles, transcript, chunk folder
+	Notes      string    `json:"notes,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+}
+
+// Outputs returns the output of e followed by the files written along with it.
+func (e Entry) Outputs() []string {
+	var files []string
+	if e.OutputPath != "" {
+		files = append(files, e.OutputPath)
+	}
+	return append(files, e.Files...)
+}
+
+// Store is the persistent list of finished jobs.
+type Store struct {
+	path     string
+	textsDir string
+	mu       sync.Mutex
+	entries  []Entry
+	texts    map[string]string // lower-cased cached texts by hash, loaded on first search
+	onChange func()
+}
+
+// Open loads the history stored in dir, starting empty if none exists.
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		path:     filepath.Join(dir, historyFileName),
+		textsDir: filepath.Join(dir, textsDirName),
+		texts:    map[string]string{},
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return s, fmt.Errorf("failed to parse history: %w", err)
+	}
+	return s, nil
+}
+
+// HashText returns the content hash used to identify a document.
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// TitleFromText derives a short title from the first words of text.
+func TitleFromText(text string) string {
+	words := strings.Fields(text)
+	if len(words) > 8 {
+		words = words[:8]
+	}
+	title := strings.Join(words, " ")
+	if title == "" {
+		return "Untitled"
+	}
+	return title
+}
+
+// OnChange sets a function called after entries are added, moved or removed,
+// e.g. to update a menu of recent outputs. It may be called from any goroutine.
+func (s *Store) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// changed calls the OnChange function; deferred before locking, it runs once
+// the lock is released.
+func (s *Store) changed() {
+	s.mu.Lock()
+	fn := s.onChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// Add stores a new entry, filling in its ID and timestamp.
+func (s *Store) Add(e Entry) (Entry, error) {
+	defer s.changed()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	s.entries = append(s.entries, e)
+	return e, s.saveLocked()
+}
+
+// Entries returns all entries, newest first.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Entry, len(s.entries))
+	copy(out, s.entries)
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns the entry with the given ID.
+func (s *Store) Get(id string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// SetAnnotations replaces the notes and tags of an entry.
+func (s *Store) SetAnnotations(id, notes string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID == id {
+			s.entries[i].Notes = notes
+			s.entries[i].Tags = normalizeTags(tags)
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("history entry %s not found", id)
+}
+
+// SetOutputPath records a new location for an entry's output, e.g. after archiving.
+func (s *Store) SetOutputPath(id, path string) error {
+	defer s.changed()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID == id {
+			s.entries[i].OutputPath = path
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("history entry %s not found", id)
+}
+
+// Remove deletes an entry. Its cached text is deleted too unless another entry
+// refers to the same text.
+func (s *Store) Remove(id string) error {
+	defer s.changed()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := -1
+	for i := range s.entries {
+		if s.entries[i].ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("history entry %s not found", id)
+	}
+	hash := s.entries[idx].TextHash
+	s.entries = append(s.entries[:idx], s.entries[idx+1:]...)
+	if err := s.saveLocked(); err != nil {
+		return err
+	}
+	for _, e := range s.entries {
+		if e.TextHash == hash {
+			return nil
+		}
+	}
+	delete(s.texts, hash)
+	err := os.Remove(filepath.Join(s.textsDir, hash+".txt"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cached text: %w", err)
+	}
+	return nil
+}
+
+// TextsDir returns the directory of the cached input texts.
+func (s *Store) TextsDir() string {
+	return s.textsDir
+}
+
+// SaveText caches the input text of a job so it can be searched and reopened later.
+// Texts are stored once per content hash.
+func (s *Store) SaveText(text string) error {
+	if err := os.MkdirAll(s.textsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create text cache: %w", err)
+	}
+	path := filepath.Join(s.textsDir, HashText(text)+".txt")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to cache text: %w", err)
+	}
+	return nil
+}
+
+// Text returns the cached input text with the given hash.
+func (s *Store) Text(hash string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.textsDir, hash+".txt"))
+	if err != nil {
+		return "", fmt.Errorf("text is no longer cached: %w", err)
+	}
+	return string(data), nil
+}
+
+// Filter returns entries matching every word of query (case-insensitive). Titles,
+// notes, tags, provider, voice, the month and date of the job and the cached text
+// are searched, so "transformers march" finds an article converted in March.
+func (s *Store) Filter(query string) []Entry {
+	terms := strings.Fields(strings.ToLower(query))
+	entries := s.Entries()
+	if len(terms) == 0 {
+		return entries
+	}
+	var out []Entry
+	for _, e := range entries {
+		fields := strings.ToLower(strings.Join([]string{
+			e.Title, e.Notes, strings.Join(e.Tags, " "), e.Provider, e.Voice,
+			e.CreatedAt.Format("January 2006 2006-01-02"),
+		}, "\n"))
+		text := ""
+		match := true
+		for _, t := range terms {
+			if strings.Contains(fields, t) {
+				continue
+			}
+			if text == "" {
+				text = s.searchText(e.TextHash)
+			}
+			if !strings.Contains(text, t) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// searchText returns the lower-cased cached text for hash, or "" if it is not cached.
+func (s *Store) searchText(hash string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.texts[hash]; ok {
+		return t
+	}
+	data, err := os.ReadFile(filepath.Join(s.textsDir, hash+".txt"))
+	if err != nil {
+		return ""
+	}
+	t := strings.ToLower(string(data))
+	s.texts[hash] = t
+	return t
+}
+
+// ParseTags splits a comma-separated tag list.
+func ParseTags(s string) []string {
+	return normalizeTags(strings.Split(s, ","))
+}
+
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
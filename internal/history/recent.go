@@ -0,0 +1,79 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+const (
+	recentFileName = "recent.json"
+	// MaxRecent is the number of documents Recent remembers.
+	MaxRecent = 10
+)
+
+// Recent is the persistent list of recently opened documents, newest first.
+type Recent struct {
+	path  string
+	mu    sync.Mutex
+	paths []string
+}
+
+// OpenRecent loads the recent documents stored in dir, starting empty if none exist.
+func OpenRecent(dir string) (*Recent, error) {
+	r := &Recent{path: filepath.Join(dir, recentFileName)}
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("failed to read recent documents: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.paths); err != nil {
+		return r, fmt.Errorf("failed to parse recent documents: %w", err)
+	}
+	return r, nil
+}
+
+// Add moves path to the top of the list, dropping the oldest beyond MaxRecent.
+func (r *Recent) Add(path string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = slices.DeleteFunc(r.paths, func(p string) bool { return p == path })
+	r.paths = append([]string{path}, r.paths[:min(len(r.paths), MaxRecent-1)]...)
+	return r.saveLocked()
+}
+
+// Remove forgets path, e.g. once it is gone.
+func (r *Recent) Remove(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = slices.DeleteFunc(r.paths, func(p string) bool { return p == path })
+	return r.saveLocked()
+}
+
+// Paths returns the recent documents, newest first.
+func (r *Recent) Paths() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.paths)
+}
+
+func (r *Recent) saveLocked() error {
+	data, err := json.MarshalIndent(r.paths, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recent documents: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recent documents: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
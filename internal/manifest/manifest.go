@@ -0,0 +1,198 @@
+// Package manifest reads build manifests: YAML or JSON files that describe
+// several conversions, such as the chapters of an audiobook, so they can be
+// built again the same way with one command.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"easy-tts/internal/util"
+)
+
+// Manifest is a list of jobs and the settings they share.
+//
+//	provider: google
+//	voice: en-GB-Chirp3-HD-Charon
+//	out_dir: build
+//	jobs:
+//	  - file: preface.md
+//	    voice: en-GB-Chirp3-HD-Kore
+//	  - output: book.mp3
+//	    split_chapters: true
+//	    chapters:
+//	      - chapter1.md
+//	      - file: chapter2.md
+//	        title: The Storm
+type Manifest struct {
+	Defaults `yaml:",inline"`
+	OutDir   string `json:"out_dir,omitempty" yaml:"out_dir,omitempty"` // Relative to the manifest; "" for the output folder of the settings
+	Jobs     []Job  `json:"jobs" yaml:"jobs"`
+}
+
+// Defaults are the settings of a job; those of a job override the manifest's.
+type Defaults struct {
+	Provider     string  `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Voice        string  `json:"voice,omitempty" yaml:"voice,omitempty"`
+	Speed        float64 `json:"speed,omitempty" yaml:"speed,omitempty"`
+	Style        string  `json:"style,omitempty" yaml:"style,omitempty"`
+	Instructions string  `json:"instructions,omitempty" yaml:"instructions,omitempty"`
+	Format       string  `json:"format,omitempty" yaml:"format,omitempty"` // "" for the extension of the output, or the setting
+}
+
+// Job converts one document, or several chapters, into one output file.
+type Job struct {
+	Defaults      `yaml:",inline"`
+	File          string    `json:"file,omitempty" yaml:"file,omitempty"`
+	Chapters      []Chapter `json:"chapters,omitempty" yaml:"chapters,omitempty"`
+	Output        string    `json:"output,omitempty" yaml:"output,omitempty"`                 // File name in OutDir; "" names it after the (first) document
+	SplitChapters bool      `json:"split_chapters,omitempty" yaml:"split_chapters,omitempty"` // Also save a file per chapter
+}
+
+// Chapter is a document of a job; a manifest may give just its file.
+type Chapter struct {
+	File  string `json:"file" yaml:"file"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"` // Read as a heading before the chapter
+}
+
+func (c *Chapter) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, &c.File)
+	}
+	type plain Chapter
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+func (c *Chapter) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&c.File)
+	}
+	type plain Chapter
+	return node.Decode((*plain)(c))
+}
+
+// Load reads the manifest at path: JSON for a .json file, YAML otherwise. The
+// paths of documents and OutDir are made absolute relative to the manifest.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(m)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	if m.OutDir != "" {
+		m.OutDir = resolve(m.OutDir)
+	}
+	if len(m.Jobs) == 0 {
+		return nil, errors.New("invalid manifest: no jobs")
+	}
+	outputs := map[string]int{}
+	for i := range m.Jobs {
+		j := &m.Jobs[i]
+		if (j.File == "") == (len(j.Chapters) == 0) {
+			return nil, fmt.Errorf("invalid manifest: job %d needs either a file or chapters", i+1)
+		}
+		j.File = resolve(j.File)
+		for k := range j.Chapters {
+			if j.Chapters[k].File == "" {
+				return nil, fmt.Errorf("invalid manifest: chapter %d of job %d has no file", k+1, i+1)
+			}
+			j.Chapters[k].File = resolve(j.Chapters[k].File)
+		}
+		if filepath.Base(j.Output) != j.Output && j.Output != "" {
+			return nil, fmt.Errorf("invalid manifest: output %q of job %d must be a file name, see out_dir", j.Output, i+1)
+		}
+		if prev, ok := outputs[j.Output]; ok && j.Output != "" {
+			return nil, fmt.Errorf("invalid manifest: jobs %d and %d both write %s", prev, i+1, j.Output)
+		}
+		outputs[j.Output] = i + 1
+	}
+	return m, nil
+}
+
+// Settings returns the settings of job i, those of the manifest filled in.
+func (m *Manifest) Settings(i int) Defaults {
+	d := m.Jobs[i].Defaults
+	if d.Provider == "" {
+		d.Provider = m.Provider
+	}
+	if d.Voice == "" {
+		d.Voice = m.Voice
+	}
+	if d.Speed == 0 {
+		d.Speed = m.Speed
+	}
+	if d.Style == "" {
+		d.Style = m.Style
+	}
+	if d.Instructions == "" {
+		d.Instructions = m.Instructions
+	}
+	if d.Format == "" {
+		d.Format = m.Format
+	}
+	return d
+}
+
+// Source returns the document a job is named after: its file or first chapter.
+func (j *Job) Source() string {
+	if j.File != "" {
+		return j.File
+	}
+	return j.Chapters[0].File
+}
+
+// Text reads the documents of the job. Chapters are joined in order, each
+// with its title as a top-level heading if it has one.
+func (j *Job) Text() (string, error) {
+	if j.File != "" {
+		return readFile(j.File)
+	}
+	parts := make([]string, 0, len(j.Chapters))
+	for _, c := range j.Chapters {
+		text, err := readFile(c.File)
+		if err != nil {
+			return "", err
+		}
+		if c.Title != "" {
+			text = "# " + c.Title + "\n\n" + text
+		}
+		parts = append(parts, strings.TrimSpace(text))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func readFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return util.ReadTextFile(f)
+}
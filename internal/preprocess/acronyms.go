@@ -0,0 +1,173 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Acronym modes decide how an all-caps token such as "USB" or "NASA" is read.
+const (
+	AcronymSpell      = "spell"      // Letter by letter: "U S B"
+	AcronymWord       = "word"       // As a word, left to the voice: "NASA"
+	AcronymDictionary = "dictionary" // Look up the pronunciation; unknown tokens are left to the voice
+)
+
+var (
+	acronymRegex      = regexp.MustCompile(`\b\p{Lu}{2,}\b`)
+	romanNumeralRegex = regexp.MustCompile(`^[IVX]+$`)
+)
+
+// acronymDictionary holds spoken forms of common acronyms per language.
+var acronymDictionary = map[string]map[string]string{
+	"de": {
+		"NATO": "Nato", "NASA": "Nasa", "UNESCO": "Unesco", "UNICEF": "Unicef", "DAX": "Dax",
+		"TÜV": "Tüv", "ISO": "Iso", "PIN": "Pin", "LED": "L E D", "WLAN": "W-Lan", "JPEG": "Jot-Peg",
+		"ADAC": "A D A C", "EU": "E U", "USA": "U S A", "PDF": "P D F", "USB": "U S B", "KI": "K I",
+	},
+	"en": {
+		"NATO": "Nato", "NASA": "Nasa", "UNESCO": "Unesco", "UNICEF": "Unicef", "SCUBA": "scuba",
+		"LASER": "laser", "RADAR": "radar", "PIN": "pin", "GIF": "gif", "JPEG": "jay-peg", "SQL": "sequel",
+		"FAQ": "F A Q", "EU": "E U", "USA": "U S A", "PDF": "P D F", "USB": "U S B", "AI": "A I",
+	},
+}
+
+// defaultAcronymModes is the mode per language when the user has not chosen one.
+var defaultAcronymModes = map[string]string{
+	"de": AcronymDictionary,
+	"en": AcronymDictionary,
+}
+
+// AcronymPolicy configures the "acronyms" stage.
+type AcronymPolicy struct {
+	// Modes maps a base language ("de") to its mode; missing languages use the built-in default.
+	Modes map[string]string
+	// Overrides maps a token to AcronymSpell, AcronymWord or a literal spoken form,
+	// taking precedence over the mode.
+	Overrides map[string]string
+}
+
+// Mode returns the acronym mode for a language.
+func (p AcronymPolicy) Mode(languageCode string) string {
+	lang := BaseLanguage(languageCode)
+	if mode := p.Modes[lang]; mode != "" {
+		return mode
+	}
+	if mode, ok := defaultAcronymModes[lang]; ok {
+		return mode
+	}
+	return AcronymDictionary
+}
+
+// Spoken returns how token is read in the given language.
+func (p AcronymPolicy) Spoken(token, languageCode string) string {
+	rule, ok := p.Overrides[token]
+	if !ok {
+		rule = p.Mode(languageCode)
+	}
+	switch rule {
+	case AcronymSpell:
+		return spellOut(token)
+	case AcronymWord:
+		return token
+	case AcronymDictionary:
+		if spoken, ok := acronymDictionary[BaseLanguage(languageCode)][token]; ok {
+			return spoken
+		}
+		return token
+	}
+	return rule
+}
+
+// ApplyAcronymPolicy rewrites all-caps tokens according to policy. Roman numerals
+// and lines written entirely in capitals (shouted headings) are left alone.
+func ApplyAcronymPolicy(text string, policy AcronymPolicy, languageCode string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if shouted(line) {
+			continue
+		}
+		lines[i] = acronymRegex.ReplaceAllStringFunc(line, func(token string) string {
+			if romanNumeralRegex.MatchString(token) {
+				return token
+			}
+			return policy.Spoken(token, languageCode)
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FindAcronyms returns the distinct acronyms in text, outside inline markers, sorted.
+func FindAcronyms(text string) []string {
+	seen := map[string]bool{}
+	var tokens []string
+	for _, line := range strings.Split(markerRegex.ReplaceAllString(text, " "), "\n") {
+		if shouted(line) {
+			continue
+		}
+		for _, token := range acronymRegex.FindAllString(line, -1) {
+			if !seen[token] && !romanNumeralRegex.MatchString(token) {
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// ParseAcronymOverrides reads "TOKEN = spell|word|spoken form" lines.
+func ParseAcronymOverrides(s string) map[string]string {
+	overrides := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		token, rule, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		token, rule = strings.TrimSpace(token), strings.TrimSpace(rule)
+		if token != "" && rule != "" {
+			overrides[token] = rule
+		}
+	}
+	return overrides
+}
+
+// FormatAcronymOverrides renders overrides as sorted "TOKEN = rule" lines.
+func FormatAcronymOverrides(overrides map[string]string) string {
+	tokens := make([]string, 0, len(overrides))
+	for token := range overrides {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	var b strings.Builder
+	for _, token := range tokens {
+		fmt.Fprintf(&b, "%s = %s\n", token, overrides[token])
+	}
+	return b.String()
+}
+
+// spellOut separates the letters of token: "USB" -> "U S B".
+func spellOut(token string) string {
+	letters := make([]string, 0, len(token))
+	for _, r := range token {
+		letters = append(letters, string(r))
+	}
+	return strings.Join(letters, " ")
+}
+
+// shouted reports whether a line of at least three words is mostly capitals.
+func shouted(line string) bool {
+	words := strings.FieldsFunc(line, func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(words) < 3 {
+		return false
+	}
+	caps := 0
+	for _, w := range words {
+		if strings.ToUpper(w) == w {
+			caps++
+		}
+	}
+	return caps*2 > len(words)
+}
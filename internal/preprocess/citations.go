@@ -0,0 +1,83 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	footnoteDefRegex     = regexp.MustCompile(`(?m)^\[\^[^\]]+\]:.*(?:\n(?:[ \t]+.*)?)*?(?:\n|$)`)
+	footnoteMarkerRegex  = regexp.MustCompile(`\[\^[^\]]+\]`)
+	superscriptRegex     = regexp.MustCompile(`[⁰¹²³⁴⁵⁶⁷⁸⁹]+`)
+	numericCitationRegex = regexp.MustCompile(`[ \t]?\[\d+(?:[ \t]*[-–,][ \t]*\d+)*\]`)
+	authorYearRegex      = regexp.MustCompile(`[ \t]?\((?:(?:see|cf\.|e\.g\.,?|vgl\.)[ \t]+)?` + citation + `(?:;[ \t]*` + citation + `)*\)`)
+	referenceTitleRegex  = regexp.MustCompile(`(?i)^(references|bibliography|works cited|sources|endnotes|footnotes|literatur|literaturverzeichnis|quellen|quellenverzeichnis|anmerkungen|fußnoten|endnoten)[ \t]*:?$`)
+	anyHeadingRegex      = regexp.MustCompile(`^[ \t]*(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+	setextUnderlineRegex = regexp.MustCompile(`^[ \t]*(=+|-+)[ \t]*$`)
+)
+
+// citation is one author-year citation: surnames, joined by "and", "&" or
+// commas and optionally followed by "et al.", then the year and a page, as in
+// "Smith & Jones, 2019, p. 5". Parentheses with a year but no such names, like
+// "(founded 1998)", are left alone.
+const (
+	surname  = `(?:(?:van|von|de|der|den|da|di|le|la)[ \t]+)?\p{Lu}[\p{Ll}'’\-]+`
+	authors  = surname + `(?:(?:,[ \t]*|[ \t]+(?:and|und|&)[ \t]+|,[ \t]*(?:and|und|&)[ \t]+)` + surname + `)*(?:[ \t]+et[ \t]+al\.)?`
+	citation = authors + `,?[ \t]+(?:1[5-9]|20)\d{2}[a-z]?(?:,[ \t]*(?:p|pp|S)\.[ \t]*\d+(?:[-–]\d+)?)?`
+)
+
+// StripCitations removes footnote markers and definitions, numeric and author-year
+// citations, and reference sections from academic texts.
+func StripCitations(text string) string {
+	text = removeReferenceSections(text)
+	text = footnoteDefRegex.ReplaceAllString(text, "")
+	text = footnoteMarkerRegex.ReplaceAllString(text, "")
+	text = superscriptRegex.ReplaceAllString(text, "")
+	text = numericCitationRegex.ReplaceAllString(text, "")
+	return authorYearRegex.ReplaceAllString(text, "")
+}
+
+// removeReferenceSections drops a "References"/"Literatur" heading and everything up
+// to the next heading of the same or a higher level (or the end of the text). Only
+// Markdown headings count, so a line reading "Sources" in the body stays.
+func removeReferenceSections(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	skipLevel := 0 // heading level of the section being skipped
+	for i := 0; i < len(lines); i++ {
+		title, level, n := headingAt(lines, i)
+		if skipLevel > 0 {
+			if level == 0 || level > skipLevel {
+				continue
+			}
+			skipLevel = 0
+		}
+		if level > 0 && referenceTitleRegex.MatchString(title) {
+			skipLevel = level
+			i += n - 1
+			continue
+		}
+		out = append(out, lines[i])
+	}
+	return strings.Join(out, "\n")
+}
+
+// headingAt returns the title and level of the Markdown heading starting at
+// lines[i], and the number of lines it spans: one for "# Title", two for a
+// title underlined with "=" or "-". level is 0 if there is no heading.
+func headingAt(lines []string, i int) (title string, level, n int) {
+	if m := anyHeadingRegex.FindStringSubmatch(lines[i]); m != nil {
+		return m[2], len(m[1]), 1
+	}
+	title = strings.TrimSpace(lines[i])
+	if title == "" || i+1 >= len(lines) {
+		return "", 0, 0
+	}
+	if m := setextUnderlineRegex.FindStringSubmatch(lines[i+1]); m != nil {
+		if m[1][0] == '=' {
+			return title, 1, 2
+		}
+		return title, 2, 2
+	}
+	return "", 0, 0
+}
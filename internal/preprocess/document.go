@@ -0,0 +1,105 @@
+package preprocess
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var documentHeadingRegex = regexp.MustCompile(`^[ \t]*(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+
+// BlockKind distinguishes headings from running text.
+type BlockKind int
+
+const (
+	BlockText BlockKind = iota
+	BlockHeading
+)
+
+// Block is a heading or the text between two headings.
+type Block struct {
+	Kind    BlockKind
+	Level   int    // Heading level 1-6, 0 for text
+	Ordinal int    // Position among headings of the same level within the parent heading
+	Number  string // Hierarchical number such as "2.1"
+	Text    string
+}
+
+// Document is the structure of a text: headings and the text below them.
+type Document struct {
+	Blocks []Block
+}
+
+// ParseDocument splits Markdown text at its ATX headings ("# Title") and numbers them.
+func ParseDocument(text string) *Document {
+	doc := &Document{}
+	counters := make([]int, 7)
+	var body []string
+	flush := func() {
+		if t := strings.TrimSpace(strings.Join(body, "\n")); t != "" {
+			doc.Blocks = append(doc.Blocks, Block{Kind: BlockText, Text: t})
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		m := documentHeadingRegex.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[2]) == "" {
+			body = append(body, line)
+			continue
+		}
+		flush()
+		level := len(m[1])
+		counters[level]++
+		for l := level + 1; l < len(counters); l++ {
+			counters[l] = 0
+		}
+		var parts []string
+		for l := 1; l <= level; l++ {
+			if counters[l] > 0 || len(parts) > 0 {
+				parts = append(parts, strconv.Itoa(counters[l]))
+			}
+		}
+		doc.Blocks = append(doc.Blocks, Block{
+			Kind:    BlockHeading,
+			Level:   level,
+			Ordinal: counters[level],
+			Number:  strings.Join(parts, "."),
+			Text:    strings.TrimSpace(m[2]),
+		})
+	}
+	flush()
+	return doc
+}
+
+// Heading announcement modes.
+const (
+	AnnounceTitle    = "title"    // Read the heading text
+	AnnounceTemplate = "template" // Read the heading through a template such as "Kapitel {n}: {title}"
+	AnnounceSilent   = "silent"   // Skip the heading
+)
+
+// HeadingStyle configures how headings of one level are read.
+type HeadingStyle struct {
+	Mode        string  `json:"mode"`
+	Template    string  `json:"template,omitempty"`     // Placeholders: {n}, {number}, {title}
+	PauseBefore float64 `json:"pause_before,omitempty"` // Seconds of silence before the heading
+	PauseAfter  float64 `json:"pause_after,omitempty"`  // Seconds of silence after the heading
+	Split       bool    `json:"split,omitempty"`        // Start a new output file at this heading
+}
+
+// Announcement returns the text spoken for heading b, or "" if it is silent.
+func (s HeadingStyle) Announcement(b Block) string {
+	switch s.Mode {
+	case AnnounceSilent:
+		return ""
+	case AnnounceTemplate:
+		if s.Template != "" {
+			return terminate(strings.NewReplacer(
+				"{n}", strconv.Itoa(b.Ordinal),
+				"{number}", b.Number,
+				"{title}", strings.TrimRight(b.Text, "."),
+			).Replace(s.Template))
+		}
+	}
+	return terminate(b.Text)
+}
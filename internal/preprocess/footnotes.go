@@ -0,0 +1,100 @@
+package preprocess
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	footnoteDefinitionRegex = regexp.MustCompile(`(?m)^[ \t]*\[\^([^\]\s]+)\]:[ \t]*(.*(?:\n[ \t]+\S.*)*)\n?`)
+	footnoteRefRegex        = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+	sentenceBoundaryRegex   = regexp.MustCompile(`([.!?…]+["'”»)]*)(?:\s|$)`)
+	sentenceEndedRegex      = regexp.MustCompile(`[.!?…]+["'”»)]*(?:\[\^[^\]\s]+\])*[ \t]*$`)
+)
+
+// footnoteLeadIns is the default lead-in per language; {id} is the footnote's label.
+var footnoteLeadIns = map[string]string{
+	"de": "Fußnote {id}:",
+	"en": "Footnote {id}:",
+}
+
+// ParseFootnotes removes Markdown footnote definitions ("[^1]: text", continued
+// on indented lines) from text and returns the remaining text and the footnote
+// texts by label.
+func ParseFootnotes(text string) (string, map[string]string) {
+	notes := map[string]string{}
+	body := footnoteDefinitionRegex.ReplaceAllStringFunc(text, func(def string) string {
+		m := footnoteDefinitionRegex.FindStringSubmatch(def)
+		notes[m[1]] = strings.Join(strings.Fields(m[2]), " ")
+		return ""
+	})
+	return body, notes
+}
+
+// InlineFootnotes reads every footnote right after the sentence that references
+// it, introduced by leadIn (default "Footnote {id}:" in the text's language) and,
+// if voice is set, spoken with that voice via a {{voice:...}} marker. References
+// without a definition are dropped, as are definitions nobody references.
+func InlineFootnotes(text, leadIn, voice, languageCode string) string {
+	body, notes := ParseFootnotes(text)
+	if strings.TrimSpace(leadIn) == "" {
+		var ok bool
+		if leadIn, ok = footnoteLeadIns[BaseLanguage(languageCode)]; !ok {
+			leadIn = footnoteLeadIns["en"]
+		}
+	}
+
+	// Edits replace body[start:end] with insert; references are removed and the
+	// footnote is inserted at the end of the referencing sentence
+	type edit struct {
+		start, end int
+		insert     string
+	}
+	var edits []edit
+	for _, m := range footnoteRefRegex.FindAllStringSubmatchIndex(body, -1) {
+		edits = append(edits, edit{m[0], m[1], ""})
+		note, ok := notes[body[m[2]:m[3]]]
+		if !ok || note == "" {
+			continue
+		}
+		spoken := strings.TrimSpace(strings.ReplaceAll(leadIn, "{id}", body[m[2]:m[3]]) + " " + terminate(note))
+		if voice != "" {
+			spoken = "{{voice:" + voice + "}}" + spoken + "{{/voice}}"
+		}
+		edits = append(edits, edit{sentenceEnd(body, m[0], m[1]), -1, " " + spoken})
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	pos := 0
+	for _, e := range edits {
+		if e.start > pos {
+			b.WriteString(body[pos:e.start])
+			pos = e.start
+		}
+		b.WriteString(e.insert)
+		if e.end > pos {
+			pos = e.end
+		}
+	}
+	b.WriteString(body[pos:])
+	return b.String()
+}
+
+// sentenceEnd returns the position after the sentence containing the footnote
+// reference body[start:end]: the reference itself if it follows the closing
+// punctuation, otherwise the next sentence end within the paragraph.
+func sentenceEnd(body string, start, end int) int {
+	if sentenceEndedRegex.MatchString(body[:start]) {
+		return start
+	}
+	limit := len(body)
+	if i := strings.Index(body[end:], "\n\n"); i >= 0 {
+		limit = end + i
+	}
+	if m := sentenceBoundaryRegex.FindStringSubmatchIndex(body[end:limit]); m != nil {
+		return end + m[3]
+	}
+	return end + len(strings.TrimRight(body[end:limit], " \t\n"))
+}
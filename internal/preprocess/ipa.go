@@ -0,0 +1,60 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ipaOnly lists characters that occur in IPA transcriptions but not in ordinary
+// Latin-script text.
+const ipaOnly = "ɐɑɒæɓʙβɔɕɗɖðʤəɘɚɛɜɝɞɟʄɡɠɢʛɦɧħɥʜɨɪʝɭɬɫɮʟɱɯɰŋɳɲɴɵɸθœɶʘɹɺɾɻʀʁɽʂʃʈʧʉʊʋⱱʌɣɤʍχʎʏʑʐʒʔʡʕʢˈˌːˑʰʲʷˠˤ˞"
+
+var (
+	// "(IPA: /ˈkwækɚ/)", "(/ˈkwækɚ/)", "([ˈkvakɐ])"
+	ipaParenRegex = regexp.MustCompile(`\s*\(\s*(?:(?:IPA|Lautschrift|pronounced|gesprochen)\s*:?\s*)?[/\[][^/\[\]()\n]{1,100}[/\]]\s*\)`)
+	// "IPA: /ˈkwækɚ/", "/ˈkwækɚ/", "[ˈkvakɐ]"
+	ipaBareRegex = regexp.MustCompile(`\s*(?:(?:IPA|Lautschrift)\s*:?\s*)?[/\[][^/\[\]\n]{1,100}[/\]]`)
+)
+
+// RemoveIPA removes phonetic transcriptions such as "(IPA: /ˈkwækɚ/)" and lines
+// written mostly in IPA. Bracketed text only counts as a transcription if it
+// contains IPA-specific characters, so "[12]" or "and/or" are kept.
+func RemoveIPA(text string) string {
+	isIPA := func(m string) bool { return strings.ContainsAny(m, ipaOnly) }
+	text = ipaParenRegex.ReplaceAllStringFunc(text, func(m string) string {
+		if isIPA(m) {
+			return ""
+		}
+		return m
+	})
+	text = ipaBareRegex.ReplaceAllStringFunc(text, func(m string) string {
+		if isIPA(m) {
+			return ""
+		}
+		return m
+	})
+
+	lines := strings.Split(text, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if !mostlyIPA(line) {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// mostlyIPA reports whether at least a third of the letters in line are IPA-specific.
+func mostlyIPA(line string) bool {
+	letters, ipa := 0, 0
+	for _, r := range line {
+		if strings.ContainsRune(ipaOnly, r) {
+			ipa++
+			letters++
+		} else if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return ipa > 0 && ipa*3 >= letters
+}
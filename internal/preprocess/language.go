@@ -0,0 +1,41 @@
+package preprocess
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent function words used to guess the language of a text.
+var stopwords = map[string][]string{
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "den", "zu", "von", "sich", "auch", "auf", "für", "wird", "werden", "dass", "oder"},
+	"en": {"the", "and", "is", "not", "a", "an", "with", "of", "to", "in", "that", "it", "for", "on", "are", "be", "this", "was", "or", "by"},
+}
+
+// DetectLanguage guesses the base language of text from stopword frequencies.
+// It returns "" when no supported language clearly dominates.
+func DetectLanguage(text string) string {
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, list := range stopwords {
+			for _, s := range list {
+				if word == s {
+					counts[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, bestCount, total := "", 0, 0
+	for lang, n := range counts {
+		total += n
+		if n > bestCount {
+			best, bestCount = lang, n
+		}
+	}
+	if bestCount == 0 || bestCount*3 < total*2 {
+		return ""
+	}
+	return best
+}
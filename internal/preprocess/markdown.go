@@ -0,0 +1,162 @@
+package preprocess
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	codeFenceRegex      = regexp.MustCompile("(?ms)^[ \\t]*(```|~~~).*?^[ \\t]*(```|~~~)[ \\t]*$\\n?")
+	imageRegex          = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	linkRegex           = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	refLinkRegex        = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	linkDefRegex        = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:\s+\S+.*$`)
+	autoLinkRegex       = regexp.MustCompile(`<https?://[^>]+>`)
+	htmlTagRegex        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	headingRegex        = regexp.MustCompile(`(?m)^[ \t]*(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+	blockquoteRegex     = regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`)
+	boldStarRegex       = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	boldUnderRegex      = regexp.MustCompile(`(^|[^\p{L}\p{N}])__(.+?)__`)
+	italicStarRegex     = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	italicUnderRegex    = regexp.MustCompile(`(^|[^\p{L}\p{N}])_([^_\s][^_]*?)_`)
+	strikeRegex         = regexp.MustCompile(`~~(.+?)~~`)
+	inlineCodeRegex     = regexp.MustCompile("`([^`]+)`")
+	hrLineRegex         = regexp.MustCompile(`^[ \t]*([-*_])([ \t]*[-*_]){2,}[ \t]*$`)
+	orderedItemRegex    = regexp.MustCompile(`^([ \t]*)\d+[.)][ \t]+(.*)$`)
+	bulletItemRegex     = regexp.MustCompile(`^([ \t]*)[-*+][ \t]+(.*)$`)
+	markdownSymbolRegex = regexp.MustCompile("[\\\\*_#\\[\\]()>~`]+")
+	spaceRunRegex       = regexp.MustCompile(`[ \t\f\v\x{00A0}\x{2007}\x{202F}]+`)
+	blankRunRegex       = regexp.MustCompile(`\n{3,}`)
+	yamlFrontRegex      = regexp.MustCompile(`(?s)\A\s*---[ \t]*\n([\w-]+[ \t]*:.*?)\n(?:---|\.\.\.)[ \t]*(?:\n|\z)`)
+	tomlFrontRegex      = regexp.MustCompile(`(?s)\A\s*\+\+\+[ \t]*\n([\w-]+[ \t]*=.*?)\n\+\+\+[ \t]*(?:\n|\z)`)
+)
+
+// RemoveCodeBlocks drops fenced code blocks, which are never meant to be read aloud.
+func RemoveCodeBlocks(text string) string {
+	return codeFenceRegex.ReplaceAllString(text, "")
+}
+
+// RemoveFrontMatter drops a YAML (---) or TOML (+++) metadata block at the very
+// top of a note, as written by Obsidian, Jekyll or Hugo. The block must start with
+// a key so that a leading horizontal rule is not mistaken for front-matter.
+func RemoveFrontMatter(text string) string {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	if loc := yamlFrontRegex.FindStringIndex(text); loc != nil {
+		return text[loc[1]:]
+	}
+	if loc := tomlFrontRegex.FindStringIndex(text); loc != nil {
+		return text[loc[1]:]
+	}
+	return text
+}
+
+// StripMarkdown removes Markdown formatting while keeping the readable text.
+// Horizontal rules are kept because the chunker uses them as section breaks.
+func StripMarkdown(text string) string {
+	return stripMarkdown(text, false)
+}
+
+// stripMarkdown is StripMarkdown, optionally keeping "# " heading markers so a
+// Document can still be parsed from the result.
+func stripMarkdown(text string, keepHeadings bool) string {
+	text = imageRegex.ReplaceAllString(text, "")
+	text = linkRegex.ReplaceAllString(text, "$1")
+	text = refLinkRegex.ReplaceAllString(text, "$1")
+	text = linkDefRegex.ReplaceAllString(text, "")
+	text = autoLinkRegex.ReplaceAllString(text, "")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = headingRegex.ReplaceAllStringFunc(text, func(m string) string {
+		h := headingRegex.FindStringSubmatch(m)
+		if keepHeadings {
+			return h[1] + " " + h[2]
+		}
+		return terminate(h[2])
+	})
+	text = blockquoteRegex.ReplaceAllString(text, "")
+	text = boldStarRegex.ReplaceAllString(text, "$1")
+	text = boldUnderRegex.ReplaceAllString(text, "${1}${2}")
+	text = italicStarRegex.ReplaceAllString(text, "$1")
+	text = italicUnderRegex.ReplaceAllString(text, "${1}${2}")
+	text = strikeRegex.ReplaceAllString(text, "$1")
+	return inlineCodeRegex.ReplaceAllString(text, "$1")
+}
+
+// StripMarkdownSymbols removes every Markdown control character. It is a blunt
+// last resort for chunks the provider keeps rejecting.
+func StripMarkdownSymbols(text string) string {
+	return markdownSymbolRegex.ReplaceAllString(text, "")
+}
+
+// RenumberLists numbers ordered list items sequentially (Markdown allows "1." for
+// every item), drops bullet markers and ends each item with a period so it is
+// spoken as a separate phrase.
+func RenumberLists(text string) string {
+	lines := strings.Split(text, "\n")
+	counters := map[string]int{} // next number per indentation
+	for i, line := range lines {
+		if hrLineRegex.MatchString(line) {
+			continue
+		}
+		if m := orderedItemRegex.FindStringSubmatch(line); m != nil {
+			indent := m[1]
+			counters[indent]++
+			lines[i] = indent + strconv.Itoa(counters[indent]) + ". " + terminate(m[2])
+			continue
+		}
+		if m := bulletItemRegex.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + terminate(m[2])
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue // blank lines may separate items of one loose list
+		}
+		// Any other text ends the lists at this or deeper indentation.
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for k := range counters {
+			if len(k) >= indent {
+				delete(counters, k)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NormalizeWhitespace collapses runs of spaces, trims lines and limits blank lines to one.
+func NormalizeWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = spaceRunRegex.ReplaceAllString(text, " ")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+	text = blankRunRegex.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// SanitizeWord keeps only letters, digits and spaces.
+func SanitizeWord(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// terminate appends a period to s unless it already ends with punctuation.
+func terminate(s string) string {
+	s = strings.TrimRight(s, " \t")
+	if s == "" {
+		return s
+	}
+	last := []rune(s)[len([]rune(s))-1]
+	if unicode.IsPunct(last) {
+		return s
+	}
+	return s + "."
+}
@@ -0,0 +1,187 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// abbreviation maps a written abbreviation to its spoken form.
+type abbreviation struct {
+	short  string
+	spoken string
+}
+
+var abbreviations = map[string][]abbreviation{
+	"de": {
+		{"z.B.", "zum Beispiel"},
+		{"d.h.", "das heißt"},
+		{"u.a.", "unter anderem"},
+		{"z.T.", "zum Teil"},
+		{"u.U.", "unter Umständen"},
+		{"o.Ä.", "oder Ähnliches"},
+		{"i.d.R.", "in der Regel"},
+		{"bzw.", "beziehungsweise"},
+		{"usw.", "und so weiter"},
+		{"etc.", "et cetera"},
+		{"ca.", "circa"},
+		{"vgl.", "vergleiche"},
+		{"ggf.", "gegebenenfalls"},
+		{"evtl.", "eventuell"},
+		{"inkl.", "inklusive"},
+		{"exkl.", "exklusive"},
+		{"bspw.", "beispielsweise"},
+		{"sog.", "sogenannte"},
+		{"Dr.", "Doktor"},
+		{"Prof.", "Professor"},
+		{"Hr.", "Herr"},
+		{"Nr.", "Nummer"},
+		{"Abb.", "Abbildung"},
+		{"Kap.", "Kapitel"},
+		{"Tab.", "Tabelle"},
+		{"Mio.", "Millionen"},
+		{"Mrd.", "Milliarden"},
+		{"Tsd.", "Tausend"},
+	},
+	"en": {
+		{"e.g.", "for example"},
+		{"i.e.", "that is"},
+		{"etc.", "et cetera"},
+		{"vs.", "versus"},
+		{"approx.", "approximately"},
+		{"Dr.", "Doctor"},
+		{"Prof.", "Professor"},
+		{"Mr.", "Mister"},
+		{"Mrs.", "Missus"},
+		{"Jr.", "Junior"},
+		{"Fig.", "Figure"},
+		{"Ch.", "Chapter"},
+	},
+}
+
+var monthNames = map[string][]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+var spokenWords = map[string]map[string]string{
+	"de": {"percent": "Prozent", "euro": "Euro", "dollar": "Dollar", "to": "bis", "section": "Paragraph"},
+	"en": {"percent": "percent", "euro": "euros", "dollar": "dollars", "to": "to", "section": "section"},
+}
+
+var (
+	isoDateRegex    = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	dottedDateRegex = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4})\b`)
+	percentRegex    = regexp.MustCompile(`(\d)\s?%`)
+	euroAfterRegex  = regexp.MustCompile(`(\d)\s?€`)
+	// Separators only count between digits, so a full stop after the amount
+	// ("costs €5.") ends the sentence instead of making it an ordinal
+	euroBeforeRegex  = regexp.MustCompile(`€\s?(\d+(?:[.,]\d+)*)`)
+	dollarRegex      = regexp.MustCompile(`\$\s?(\d+(?:[.,]\d+)*)`)
+	numberRangeRegex = regexp.MustCompile(`\b(\d+)\s?[-–—]\s?(\d+)\b`)
+	sectionRegex     = regexp.MustCompile(`§\s?(\d)`)
+)
+
+// abbreviationRegexes caches compiled patterns per language.
+var abbreviationRegexes = map[string][]*regexp.Regexp{}
+
+func init() {
+	for lang, list := range abbreviations {
+		for _, a := range list {
+			// Allow an optional space between the parts of multi-part abbreviations ("z. B.").
+			parts := strings.Split(strings.TrimSuffix(a.short, "."), ".")
+			for i, p := range parts {
+				parts[i] = regexp.QuoteMeta(p)
+			}
+			pattern := `(^|[^\p{L}\p{N}])` + strings.Join(parts, `\.\s?`) + `\.`
+			abbreviationRegexes[lang] = append(abbreviationRegexes[lang], regexp.MustCompile(pattern))
+		}
+	}
+}
+
+// BaseLanguage returns the primary subtag of a language code ("de-DE" -> "de").
+func BaseLanguage(languageCode string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
+
+// SupportsLanguage reports whether normalization rules exist for the language.
+func SupportsLanguage(languageCode string) bool {
+	_, ok := abbreviations[BaseLanguage(languageCode)]
+	return ok
+}
+
+// Normalize expands common abbreviations and formats numbers and dates so they are
+// spoken naturally in the given language. Unsupported languages are returned unchanged.
+func Normalize(text, languageCode string) string {
+	lang := BaseLanguage(languageCode)
+	if !SupportsLanguage(lang) {
+		return text
+	}
+	text = expandAbbreviations(text, lang)
+	text = formatDates(text, lang)
+	return formatNumbers(text, lang)
+}
+
+func expandAbbreviations(text, lang string) string {
+	for i, re := range abbreviationRegexes[lang] {
+		spoken := abbreviations[lang][i].spoken
+		text = re.ReplaceAllString(text, "${1}"+spoken)
+	}
+	return text
+}
+
+func formatDates(text, lang string) string {
+	text = isoDateRegex.ReplaceAllStringFunc(text, func(m string) string {
+		p := isoDateRegex.FindStringSubmatch(m)
+		return spokenDate(p[3], p[2], p[1], lang, m)
+	})
+	return dottedDateRegex.ReplaceAllStringFunc(text, func(m string) string {
+		p := dottedDateRegex.FindStringSubmatch(m)
+		return spokenDate(p[1], p[2], p[3], lang, m)
+	})
+}
+
+// spokenDate renders a day/month/year triple, or returns fallback if it is not a valid date.
+func spokenDate(day, month, year, lang, fallback string) string {
+	d, _ := strconv.Atoi(day)
+	m, _ := strconv.Atoi(month)
+	if d < 1 || d > 31 || m < 1 || m > 12 {
+		return fallback
+	}
+	name := monthNames[lang][m-1]
+	if lang == "de" {
+		return fmt.Sprintf("%d. %s %s", d, name, year)
+	}
+	return fmt.Sprintf("%s %d, %s", name, d, year)
+}
+
+func formatNumbers(text, lang string) string {
+	words := spokenWords[lang]
+	text = percentRegex.ReplaceAllString(text, "${1} "+words["percent"])
+	text = euroAfterRegex.ReplaceAllString(text, "${1} "+words["euro"])
+	text = euroBeforeRegex.ReplaceAllString(text, "${1} "+words["euro"])
+	text = dollarRegex.ReplaceAllString(text, "${1} "+words["dollar"])
+	text = replaceRanges(text, words["to"])
+	return sectionRegex.ReplaceAllString(text, words["section"]+" ${1}")
+}
+
+// replaceRanges reads number ranges such as "10-20" or "10–20" as "10 to 20".
+// Numbers chained by more hyphens, such as phone numbers or "2024-01" left
+// over from a date, are not ranges and stay as they are.
+func replaceRanges(text, to string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range numberRangeRegex.FindAllStringSubmatchIndex(text, -1) {
+		if strings.HasSuffix(text[:m[0]], "-") || strings.HasPrefix(text[m[1]:], "-") {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(text[m[2]:m[3]] + " " + to + " " + text[m[4]:m[5]])
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
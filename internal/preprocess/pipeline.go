@@ -0,0 +1,191 @@
+package preprocess
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"easy-tts/internal/script"
+)
+
+// Options carries per-document information available to every stage.
+type Options struct {
+	Language string // BCP-47 code or base language of the text, may be empty
+	// MixedLanguages makes language-specific stages detect the language of every
+	// paragraph, falling back to Language.
+	MixedLanguages bool
+	// KeepHeadings keeps "# " heading markers for ParseDocument instead of turning
+	// headings into sentences.
+	KeepHeadings bool
+	// Script is the user's scripting hook; its transform() runs as the "script" stage.
+	Script *script.Hook
+	// Replacements are the user's find/replace rules, applied by the "replacements" stage.
+	Replacements []ReplaceRule
+	// QuoteRate and DefinitionRate are SSML prosody rates for blockquotes and
+	// definitions, marked by the "prosody" stage.
+	QuoteRate, DefinitionRate string
+	// TableHeaders is TableHeadersEachRow (default) or TableHeadersOnce.
+	TableHeaders string
+	// FootnoteLeadIn and FootnoteVoice control how the "footnotes" stage reads
+	// footnotes; see InlineFootnotes.
+	FootnoteLeadIn, FootnoteVoice string
+	// Acronyms decides how the "acronyms" stage reads all-caps tokens.
+	Acronyms AcronymPolicy
+}
+
+// Stage is a single text transformation in the preprocessing pipeline.
+type Stage interface {
+	// Name returns the stable identifier used in settings.
+	Name() string
+	// Description returns a short, user-facing explanation.
+	Description() string
+	// Apply transforms text.
+	Apply(text string, opts Options) string
+}
+
+// stageFunc adapts a function to the Stage interface.
+type stageFunc struct {
+	name        string
+	description string
+	enabled     bool // default state
+	apply       func(text string, opts Options) string
+}
+
+func (s *stageFunc) Name() string                           { return s.name }
+func (s *stageFunc) Description() string                    { return s.description }
+func (s *stageFunc) Apply(text string, opts Options) string { return s.apply(text, opts) }
+
+// registry lists all known stages in execution order.
+var registry = []*stageFunc{
+	{"skip", "Drop <!-- tts:skip --> sections and HTML comments", true, func(t string, _ Options) string { return RemoveSkipSections(t) }},
+	{"front-matter", "Remove YAML/TOML front-matter", true, func(t string, _ Options) string { return RemoveFrontMatter(t) }},
+	{"code-blocks", "Remove fenced code blocks", true, func(t string, _ Options) string { return RemoveCodeBlocks(t) }},
+	{"replacements", "Apply custom find/replace rules", true, func(t string, o Options) string { return ApplyReplacements(t, o.Replacements) }},
+	{"ipa", "Skip phonetic (IPA) transcriptions", true, func(t string, _ Options) string { return RemoveIPA(t) }},
+	{"footnotes", "Read footnotes after the referencing sentence", false, func(t string, o Options) string {
+		return InlineFootnotes(t, o.FootnoteLeadIn, o.FootnoteVoice, o.Language)
+	}},
+	{"citations", "Strip footnotes, citations and reference sections", false, func(t string, _ Options) string { return StripCitations(t) }},
+	{"tables", "Read tables row by row", true, func(t string, o Options) string { return SpeakTables(t, o.TableHeaders, o.Language) }},
+	{"lists", "Renumber ordered lists and drop bullet markers", true, func(t string, _ Options) string { return RenumberLists(t) }},
+	{"prosody", "Mark quotes and definitions for their own speaking rate", true, func(t string, o Options) string {
+		return MarkProsody(t, o.QuoteRate, o.DefinitionRate)
+	}},
+	{"markdown", "Strip Markdown formatting", true, func(t string, o Options) string { return stripMarkdown(t, o.KeepHeadings) }},
+	{"acronyms", "Spell or pronounce all-caps acronyms", true, acronymStage},
+	{"normalize", "Expand abbreviations, numbers and dates", true, normalizeStage},
+	{"script", "Run the custom script's transform()", true, scriptStage},
+	{"whitespace", "Normalize whitespace", true, func(t string, _ Options) string { return NormalizeWhitespace(t) }},
+}
+
+// markerRegex matches "{{...}}" inline markers, which must reach the synthesizer unchanged.
+var markerRegex = regexp.MustCompile(`\{\{[^{}\n]*\}\}`)
+
+// normalizeStage normalizes the whole text, or paragraph by paragraph for mixed-language documents.
+func normalizeStage(text string, opts Options) string {
+	if !opts.MixedLanguages {
+		return outsideMarkers(text, func(t string) string { return Normalize(t, opts.Language) })
+	}
+	paras := strings.Split(text, "\n\n")
+	for i, para := range paras {
+		lang := DetectLanguage(para)
+		if lang == "" {
+			lang = opts.Language
+		}
+		paras[i] = outsideMarkers(para, func(t string) string { return Normalize(t, lang) })
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// acronymStage applies the acronym policy in the text's language, or paragraph by
+// paragraph for mixed-language documents.
+func acronymStage(text string, opts Options) string {
+	if !opts.MixedLanguages {
+		return outsideMarkers(text, func(t string) string { return ApplyAcronymPolicy(t, opts.Acronyms, opts.Language) })
+	}
+	paras := strings.Split(text, "\n\n")
+	for i, para := range paras {
+		lang := DetectLanguage(para)
+		if lang == "" {
+			lang = opts.Language
+		}
+		paras[i] = outsideMarkers(para, func(t string) string { return ApplyAcronymPolicy(t, opts.Acronyms, lang) })
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// outsideMarkers applies fn to the text between inline markers, keeping "{{rate:90%}}"
+// from becoming "{{rate:90 percent}}".
+func outsideMarkers(text string, fn func(string) string) string {
+	var b strings.Builder
+	pos := 0
+	for _, m := range markerRegex.FindAllStringIndex(text, -1) {
+		b.WriteString(fn(text[pos:m[0]]))
+		b.WriteString(text[m[0]:m[1]])
+		pos = m[1]
+	}
+	b.WriteString(fn(text[pos:]))
+	return b.String()
+}
+
+// scriptStage applies the user's transform() hook. A failing script leaves the text unchanged.
+func scriptStage(text string, opts Options) string {
+	if opts.Script == nil || !opts.Script.HasTransform() {
+		return text
+	}
+	out, err := opts.Script.Transform(text, opts.Language)
+	if err != nil {
+		slog.Warn("Preprocess stage skipped", "stage", "script", "err", err)
+		return text
+	}
+	return out
+}
+
+// Stages returns all known stages in execution order.
+func Stages() []Stage {
+	stages := make([]Stage, len(registry))
+	for i, s := range registry {
+		stages[i] = s
+	}
+	return stages
+}
+
+// DefaultEnabled returns the default on/off state of every stage.
+func DefaultEnabled() map[string]bool {
+	enabled := make(map[string]bool, len(registry))
+	for _, s := range registry {
+		enabled[s.name] = s.enabled
+	}
+	return enabled
+}
+
+// Pipeline applies an ordered list of stages.
+type Pipeline struct {
+	Stages []Stage
+}
+
+// NewPipeline builds a pipeline of the registered stages, honoring enabled.
+// Stages missing from enabled use their default state.
+func NewPipeline(enabled map[string]bool) *Pipeline {
+	p := &Pipeline{}
+	for _, s := range registry {
+		on, ok := enabled[s.name]
+		if !ok {
+			on = s.enabled
+		}
+		if on {
+			p.Stages = append(p.Stages, s)
+		}
+	}
+	return p
+}
+
+// Run applies every stage in order.
+func (p *Pipeline) Run(text string, opts Options) string {
+	for _, s := range p.Stages {
+		before := len(text)
+		text = s.Apply(text, opts)
+		slog.Debug("Preprocess stage applied", "stage", s.Name(), "bytes_before", before, "bytes_after", len(text))
+	}
+	return text
+}
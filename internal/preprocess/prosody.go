@@ -0,0 +1,68 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	quoteLineRegex      = regexp.MustCompile(`^[ \t]*>[ \t]?`)
+	boldDefinitionRegex = regexp.MustCompile(`^[ \t]*(\*\*[^*\n]+\*\*|__[^_\n]+__)[ \t]*:[ \t]+\S`)
+	pandocDefRegex      = regexp.MustCompile(`^[ \t]*:[ \t]+\S`)
+	prosodyRates        = map[string]bool{"x-slow": true, "slow": true, "medium": true, "fast": true, "x-fast": true}
+)
+
+// NormalizeRate validates an SSML prosody rate: "x-slow" … "x-fast", a percentage
+// such as "85%", or a factor such as "0.85" (returned as "85%").
+func NormalizeRate(rate string) (string, error) {
+	rate = strings.ToLower(strings.TrimSpace(rate))
+	if rate == "" || prosodyRates[rate] {
+		return rate, nil
+	}
+	if p, ok := strings.CutSuffix(rate, "%"); ok {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(p), 64); err == nil && v >= 20 && v <= 400 {
+			return strconv.FormatFloat(v, 'f', -1, 64) + "%", nil
+		}
+	} else if v, err := strconv.ParseFloat(strings.Replace(rate, ",", ".", 1), 64); err == nil && v >= 0.2 && v <= 4 {
+		return strconv.FormatFloat(v*100, 'f', -1, 64) + "%", nil
+	}
+	return "", fmt.Errorf("invalid rate %q: use x-slow, slow, medium, fast, x-fast, a percentage or a factor", rate)
+}
+
+// MarkProsody wraps blockquotes in {{rate:quoteRate}} … {{/rate}} markers and
+// definitions ("**Term**: …" lines and ": …" definition list items) in
+// {{rate:definitionRate}} markers. Empty or invalid rates leave the text unchanged.
+func MarkProsody(text, quoteRate, definitionRate string) string {
+	quoteRate, qErr := NormalizeRate(quoteRate)
+	definitionRate, dErr := NormalizeRate(definitionRate)
+	if qErr != nil {
+		quoteRate = ""
+	}
+	if dErr != nil {
+		definitionRate = ""
+	}
+	if quoteRate == "" && definitionRate == "" {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case quoteRate != "" && quoteLineRegex.MatchString(line):
+			end := i
+			for end+1 < len(lines) && quoteLineRegex.MatchString(lines[end+1]) {
+				end++
+			}
+			prefix := quoteLineRegex.FindString(line)
+			lines[i] = prefix + "{{rate:" + quoteRate + "}}" + line[len(prefix):]
+			lines[end] += "{{/rate}}"
+			i = end
+		case definitionRate != "" && (boldDefinitionRegex.MatchString(line) || pandocDefRegex.MatchString(line)):
+			lines[i] = "{{rate:" + definitionRate + "}}" + line + "{{/rate}}"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package preprocess
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+// ReplaceRule is a user-defined find/replace rule. Pattern is a Go regular
+// expression (RE2 syntax); Replacement may refer to groups as $1 or ${name}.
+type ReplaceRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// CompileRules checks that every rule's pattern is a valid regular expression.
+func CompileRules(rules []ReplaceRule) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, len(rules))
+	for i, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i+1, r.Pattern, err)
+		}
+		compiled[i] = re
+	}
+	return compiled, nil
+}
+
+// ApplyReplacements applies rules in order. Rules with an invalid pattern are skipped.
+func ApplyReplacements(text string, rules []ReplaceRule) string {
+	for i, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			slog.Warn("Skipping replacement rule", "rule", i+1, "err", err)
+			continue
+		}
+		text = re.ReplaceAllString(text, r.Replacement)
+	}
+	return text
+}
@@ -0,0 +1,32 @@
+package preprocess
+
+import (
+	"log/slog"
+	"regexp"
+)
+
+var (
+	skipStartRegex   = regexp.MustCompile(`(?i)<!--[ \t]*tts:skip[ \t]*-->`)
+	skipEndRegex     = regexp.MustCompile(`(?i)<!--[ \t]*/tts:skip[ \t]*-->`)
+	htmlCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// RemoveSkipSections drops everything between "<!-- tts:skip -->" and
+// "<!-- /tts:skip -->", e.g. code listings or footnote sections, and then any
+// remaining HTML comments. A section that is never closed runs to the end of the text.
+func RemoveSkipSections(text string) string {
+	for {
+		start := skipStartRegex.FindStringIndex(text)
+		if start == nil {
+			break
+		}
+		end := skipEndRegex.FindStringIndex(text[start[1]:])
+		if end == nil {
+			slog.Warn("Unclosed <!-- tts:skip --> section, skipping to the end of the text")
+			text = text[:start[0]]
+			break
+		}
+		text = text[:start[0]] + text[start[1]+end[1]:]
+	}
+	return htmlCommentRegex.ReplaceAllString(text, "")
+}
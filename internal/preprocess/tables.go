@@ -0,0 +1,122 @@
+package preprocess
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Table header modes for Options.TableHeaders.
+const (
+	TableHeadersEachRow = "each-row" // "Row 2: Name, Anna; Score, 87." (default)
+	TableHeadersOnce    = "once"     // "Table with columns Name, Score. Row 2: Anna; 87."
+)
+
+var (
+	tableDelimiterRegex = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	htmlTableRegex      = regexp.MustCompile(`(?is)<table[^>]*>.*?</table>`)
+	htmlRowRegex        = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	htmlCellRegex       = regexp.MustCompile(`(?is)<(t[hd])[^>]*>(.*?)</t[hd]>`)
+)
+
+// tableWords holds the spoken words per language.
+var tableWords = map[string]map[string]string{
+	"de": {"row": "Zeile", "columns": "Tabelle mit den Spalten"},
+	"en": {"row": "Row", "columns": "Table with columns"},
+}
+
+// SpeakTables rewrites Markdown pipe tables and HTML tables as sentences, one per
+// row, e.g. "Row 2: Name, Anna; Score, 87." headers selects whether column
+// headers are repeated in every row or read once before the rows.
+func SpeakTables(text, headers, languageCode string) string {
+	words, ok := tableWords[BaseLanguage(languageCode)]
+	if !ok {
+		words = tableWords["en"]
+	}
+	text = htmlTableRegex.ReplaceAllStringFunc(text, func(table string) string {
+		var head []string
+		var rows [][]string
+		for i, row := range htmlRowRegex.FindAllStringSubmatch(table, -1) {
+			var cells []string
+			isHeader := true
+			for _, c := range htmlCellRegex.FindAllStringSubmatch(row[1], -1) {
+				cells = append(cells, html.UnescapeString(strings.TrimSpace(htmlTagRegex.ReplaceAllString(c[2], ""))))
+				isHeader = isHeader && strings.EqualFold(c[1], "th")
+			}
+			if i == 0 && isHeader {
+				head = cells
+			} else if len(cells) > 0 {
+				rows = append(rows, cells)
+			}
+		}
+		return "\n\n" + speakTable(head, rows, headers, words) + "\n\n"
+	})
+
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		isTable := strings.Contains(lines[i], "|") && i+1 < len(lines) &&
+			strings.Contains(lines[i+1], "|") && tableDelimiterRegex.MatchString(lines[i+1])
+		if !isTable {
+			out = append(out, lines[i])
+			continue
+		}
+		head := splitTableRow(lines[i])
+		var rows [][]string
+		j := i + 2
+		for ; j < len(lines) && strings.Contains(lines[j], "|"); j++ {
+			rows = append(rows, splitTableRow(lines[j]))
+		}
+		out = append(out, "", speakTable(head, rows, headers, words), "")
+		i = j - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// splitTableRow splits a Markdown table row into trimmed cells.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// speakTable renders rows as one sentence each.
+func speakTable(head []string, rows [][]string, headers string, words map[string]string) string {
+	var lines []string
+	once := headers == TableHeadersOnce || len(head) == 0
+	if headers == TableHeadersOnce && len(head) > 0 {
+		lines = append(lines, terminate(words["columns"]+" "+strings.Join(nonEmpty(head), ", ")))
+	}
+	for n, row := range rows {
+		var parts []string
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			if !once && i < len(head) && head[i] != "" {
+				cell = head[i] + ", " + cell
+			}
+			parts = append(parts, cell)
+		}
+		if len(parts) > 0 {
+			lines = append(lines, terminate(fmt.Sprintf("%s %d: %s", words["row"], n+1, strings.Join(parts, "; "))))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func nonEmpty(cells []string) []string {
+	var out []string
+	for _, c := range cells {
+		if c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+// Package project reads and writes .quack project files, which bundle a
+// document with the settings it is converted with, so long projects such as
+// audiobooks can be reopened and partially regenerated.
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Extension is the file extension of project files.
+	Extension = ".quack"
+	// version is the file format written by Save; Load rejects newer ones.
+	version = 1
+)
+
+// Project is the content of a project file.
+type Project struct {
+	Version      int     `json:"version"`
+	Text         string  `json:"text"`
+	Instructions string  `json:"instructions,omitempty"`
+	Provider     string  `json:"provider,omitempty"`
+	Voice        string  `json:"voice,omitempty"`
+	Style        string  `json:"style,omitempty"`
+	Speed        float64 `json:"speed,omitempty"`
+	// ChunkLimit overrides the chunk size set for the provider; 0 keeps it.
+	ChunkLimit int `json:"chunk_limit,omitempty"`
+	// Script is the Starlark hook the project is converted with, see package
+	// script; "" for none.
+	Script string `json:"script,omitempty"`
+	// Chunks are the chunks of Text as edited in the chunk review. They are
+	// used instead of splitting Text again as long as it is unchanged, so only
+	// edited chunks miss the synthesis cache when the project is converted again.
+	Chunks []Chunk `json:"chunks,omitempty"`
+}
+
+// Chunk is one reviewed chunk of a project.
+type Chunk struct {
+	Text  string `json:"text"`
+	Voice string `json:"voice,omitempty"`
+	// Group is the index of the segment the chunk belongs to.
+	Group int `json:"group"`
+}
+
+// Load reads the project file at path.
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project: %w", err)
+	}
+	p := &Project{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse project: %w", err)
+	}
+	if p.Version > version {
+		return nil, fmt.Errorf("the project was saved by a newer version of Quacker (format %d)", p.Version)
+	}
+	return p, nil
+}
+
+// Save writes p to path, replacing the file only once it is complete.
+func Save(path string, p *Project) error {
+	p.Version = version
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode project: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".project-*")
+	if err != nil {
+		return fmt.Errorf("failed to write project: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write project: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package queue keeps the jobs of the server in the app data directory, so jobs
+// that were queued or running when it stopped are run after a restart.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	fileName = "queue.json"
+	// keepFinished is how many done and failed jobs are kept for their status.
+	keepFinished = 200
+)
+
+// Statuses of a job.
+const (
+	Queued  = "queued"
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+)
+
+// Job is a queued unit of work; Payload is its input, as JSON.
+type Job struct {
+	ID       string          `json:"id"`
+	Status   string          `json:"status"`
+	Title    string          `json:"title"`
+	Payload  json.RawMessage `json:"payload"`
+	Output   string          `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"` // Also set for a done job of which some sections failed
+	Created  time.Time       `json:"created"`
+	Finished *time.Time      `json:"finished,omitempty"`
+
+	// Progress of a running job; not saved, as it restarts from the beginning
+	Chunk  int `json:"-"`
+	Chunks int `json:"-"`
+}
+
+// IsFinished reports whether the job is done or failed.
+func (j Job) IsFinished() bool {
+	return j.Status == Done || j.Status == Failed
+}
+
+// Queue holds the jobs in the order they were added.
+type Queue struct {
+	path string
+
+	mu      sync.Mutex
+	jobs    []*Job
+	changed chan struct{} // closed and replaced whenever a job changes
+}
+
+// Open loads the jobs stored in dir. Jobs that were running are queued again.
+func Open(dir string) (*Queue, error) {
+	q := &Queue{path: filepath.Join(dir, fileName), changed: make(chan struct{})}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return q, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return q, fmt.Errorf("failed to read the job queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.jobs); err != nil {
+		return q, fmt.Errorf("failed to parse the job queue: %w", err)
+	}
+	for _, j := range q.jobs {
+		if j.Status == Running {
+			j.Status = Queued
+		}
+	}
+	return q, nil
+}
+
+// Add queues a job with payload, encoded as JSON.
+func (q *Queue) Add(title string, payload any) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode job: %w", err)
+	}
+	j := &Job{ID: newID(), Status: Queued, Title: title, Payload: data, Created: time.Now()}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, j)
+	if err := q.saveLocked(); err != nil {
+		q.jobs = q.jobs[:len(q.jobs)-1]
+		return Job{}, err
+	}
+	q.notifyLocked()
+	return *j, nil
+}
+
+// Next waits for the oldest queued job, marks it running and returns it.
+func (q *Queue) Next(ctx context.Context) (Job, error) {
+	for {
+		q.mu.Lock()
+		for _, j := range q.jobs {
+			if j.Status == Queued {
+				j.Status = Running
+				j.Chunk, j.Chunks = 0, 0
+				err := q.saveLocked()
+				q.notifyLocked()
+				q.mu.Unlock()
+				return *j, err
+			}
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Job{}, ctx.Err()
+		}
+	}
+}
+
+// SetProgress records how many of chunks a running job has done.
+func (q *Queue) SetProgress(id string, chunk, chunks int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j := q.findLocked(id); j != nil {
+		j.Chunk, j.Chunks = chunk, chunks
+		q.notifyLocked()
+	}
+}
+
+// Finish records the outcome of a job: done if there is an output, even with
+// an error, failed otherwise.
+func (q *Queue) Finish(id, output string, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j := q.findLocked(id)
+	if j == nil {
+		return fmt.Errorf("job %s not found", id)
+	}
+	now := time.Now()
+	j.Output, j.Finished, j.Error = output, &now, ""
+	j.Status = Done
+	if err != nil {
+		j.Error = err.Error()
+		if output == "" {
+			j.Status = Failed
+		}
+	}
+	q.pruneLocked()
+	q.notifyLocked()
+	return q.saveLocked()
+}
+
+// Get returns the job id.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j := q.findLocked(id); j != nil {
+		return *j, true
+	}
+	return Job{}, false
+}
+
+// Jobs returns all jobs, oldest first.
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Job, len(q.jobs))
+	for i, j := range q.jobs {
+		out[i] = *j
+	}
+	return out
+}
+
+// Changed returns a channel that is closed the next time a job changes.
+func (q *Queue) Changed() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.changed
+}
+
+func (q *Queue) findLocked(id string) *Job {
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (q *Queue) notifyLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// pruneLocked drops the oldest finished jobs beyond keepFinished.
+func (q *Queue) pruneLocked() {
+	finished := 0
+	for _, j := range q.jobs {
+		if j.IsFinished() {
+			finished++
+		}
+	}
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if j.IsFinished() && finished > keepFinished {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	q.jobs = kept
+}
+
+func (q *Queue) saveLocked() error {
+	data, err := json.Marshal(q.jobs)
+	if err != nil {
+		return fmt.Errorf("failed to encode the job queue: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write the job queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("failed to write the job queue: %w", err)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
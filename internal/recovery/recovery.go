@@ -0,0 +1,84 @@
+// Package recovery autosaves the editor's text to a recovery file, so it can be
+// restored on the next launch after a crash or an accidental quit.
+package recovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const fileName = "recovery.json"
+
+// Draft is the editor's content at one point in time.
+type Draft struct {
+	Input        string    `json:"input"`
+	Instructions string    `json:"instructions,omitempty"`
+	SavedAt      time.Time `json:"saved_at"`
+}
+
+// Store keeps the last draft in a file.
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	last Draft
+}
+
+// Open returns the store in dir. Nothing is written until a draft is saved.
+func Open(dir string) *Store {
+	return &Store{path: filepath.Join(dir, fileName)}
+}
+
+// Load returns the draft saved by an earlier run, if any.
+func (s *Store) Load() (Draft, bool) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return Draft{}, false
+	}
+	var d Draft
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Draft{}, false
+	}
+	return d, true
+}
+
+// Save writes d unless it has the same content as the draft saved last.
+func (s *Store) Save(d Draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d.Input == s.last.Input && d.Instructions == s.last.Instructions && !s.last.SavedAt.IsZero() {
+		return nil
+	}
+	if d.SavedAt.IsZero() {
+		d.SavedAt = time.Now()
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode the recovery file: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write the recovery file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write the recovery file: %w", err)
+	}
+	s.last = d
+	return nil
+}
+
+// Clear removes the recovery file.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = Draft{}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the recovery file: %w", err)
+	}
+	return nil
+}
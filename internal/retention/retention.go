@@ -0,0 +1,230 @@
+package retention
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"easy-tts/internal/history"
+)
+
+// Policy describes how long cached data and outputs are kept.
+type Policy struct {
+	CacheDays   int    // Delete cache files older than this; 0 keeps them forever
+	ArchiveDays int    // Archive outputs older than this; 0 leaves them in place
+	ArchiveDir  string // Destination for archived outputs
+	Compress    bool   // Gzip outputs while archiving
+}
+
+// Usage is the disk space taken by Quacker's files, in bytes.
+type Usage struct {
+	Cache   int64
+	Outputs int64
+	Archive int64
+}
+
+// Result summarizes a cleanup run.
+type Result struct {
+	CacheFilesRemoved int
+	OutputsArchived   int
+	BytesFreed        int64
+}
+
+// DefaultArchiveDir returns the archive folder used when none is configured.
+func DefaultArchiveDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Downloads", "Quacker Archive"), nil
+}
+
+// DiskUsage measures the cache directories, the outputs recorded in store that
+// are still in place, and the archive folder.
+func DiskUsage(policy Policy, cacheDirs []string, store *history.Store) Usage {
+	var u Usage
+	for _, dir := range cacheDirs {
+		u.Cache += dirSize(dir)
+	}
+	if policy.ArchiveDir != "" {
+		u.Archive = dirSize(policy.ArchiveDir)
+	}
+	if store != nil {
+		for _, e := range store.Entries() {
+			if e.OutputPath == "" || inDir(e.OutputPath, policy.ArchiveDir) {
+				continue
+			}
+			if info, err := os.Stat(e.OutputPath); err == nil {
+				u.Outputs += info.Size()
+			}
+		}
+	}
+	return u
+}
+
+// Apply enforces policy: cache files older than CacheDays are deleted and outputs
+// of history entries older than ArchiveDays are moved (or compressed) into the
+// archive folder. History entries are updated to point at the archived files.
+func Apply(policy Policy, cacheDirs []string, store *history.Store, now time.Time) (Result, error) {
+	var res Result
+	if policy.CacheDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.CacheDays)
+		for _, dir := range cacheDirs {
+			removed, freed := pruneDir(dir, cutoff)
+			res.CacheFilesRemoved += removed
+			res.BytesFreed += freed
+		}
+	}
+	if policy.ArchiveDays <= 0 || store == nil {
+		return res, nil
+	}
+	if policy.ArchiveDir == "" {
+		return res, fmt.Errorf("no archive folder configured")
+	}
+	if err := os.MkdirAll(policy.ArchiveDir, 0755); err != nil {
+		return res, fmt.Errorf("failed to create archive folder: %w", err)
+	}
+	cutoff := now.AddDate(0, 0, -policy.ArchiveDays)
+	for _, e := range store.Entries() {
+		if e.CreatedAt.After(cutoff) || e.OutputPath == "" || inDir(e.OutputPath, policy.ArchiveDir) {
+			continue
+		}
+		info, err := os.Stat(e.OutputPath)
+		if err != nil {
+			continue // already moved or deleted by the user
+		}
+		dest, err := archiveFile(e.OutputPath, policy.ArchiveDir, e.ID, policy.Compress)
+		if err != nil {
+			return res, err
+		}
+		if err := store.SetOutputPath(e.ID, dest); err != nil {
+			return res, err
+		}
+		if archived, err := os.Stat(dest); err == nil && policy.Compress {
+			res.BytesFreed += info.Size() - archived.Size()
+		}
+		res.OutputsArchived++
+		slog.Info("Archived output", "path", e.OutputPath, "dest", dest)
+	}
+	return res, nil
+}
+
+// pruneDir deletes regular files in dir last modified before cutoff.
+func pruneDir(dir string, cutoff time.Time) (int, int64) {
+	var removed int
+	var freed int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			slog.Warn("Failed to remove cache file", "path", path, "err", err)
+			return nil
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed
+}
+
+// archiveFile moves src into dir, gzipping it if compress is set, and returns the new path.
+func archiveFile(src, dir, id string, compress bool) (string, error) {
+	name := filepath.Base(src)
+	if compress {
+		name += ".gz"
+	}
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		ext := filepath.Ext(name)
+		dest = filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+id+ext)
+	}
+	if !compress {
+		if err := os.Rename(src, dest); err == nil {
+			return dest, nil
+		}
+		// Rename fails across volumes; fall back to copying.
+	}
+	if err := copyFile(src, dest, compress); err != nil {
+		os.Remove(dest)
+		return "", fmt.Errorf("failed to archive %s: %w", src, err)
+	}
+	if err := os.Remove(src); err != nil {
+		return "", fmt.Errorf("archived %s but failed to remove it: %w", src, err)
+	}
+	return dest, nil
+}
+
+func copyFile(src, dest string, compress bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	var w io.WriteCloser = out
+	if compress {
+		w = gzip.NewWriter(out)
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		out.Close()
+		return err
+	}
+	if compress {
+		if err := w.Close(); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// inDir reports whether path lies inside dir.
+func inDir(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// FormatBytes renders a byte count for display, e.g. "12.3 MB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,190 @@
+// Package scheduler runs deferred jobs at a later time. Jobs are persisted in the
+// app data directory, so they survive a restart of the app.
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"easy-tts/internal/devstats"
+)
+
+const (
+	jobsFileName = "jobs.json"
+	pollInterval = time.Minute
+)
+
+// Job is a deferred unit of work. Kind selects the Runner, Payload is its input.
+type Job struct {
+	ID      string          `json:"id"`
+	Kind    string          `json:"kind"`
+	Title   string          `json:"title"`
+	RunAt   time.Time       `json:"run_at"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Runner executes a due job. The job is removed afterwards whether or not it
+// succeeded; a runner that wants another attempt schedules a new job.
+type Runner func(job Job) error
+
+// Scheduler holds the deferred jobs and runs them when they are due.
+type Scheduler struct {
+	dir     string
+	mu      sync.Mutex
+	jobs    []Job
+	running map[string]bool
+	runners map[string]Runner
+	once    sync.Once
+}
+
+// Open loads the jobs stored in dir. Call Start once all runners are registered.
+func Open(dir string) (*Scheduler, error) {
+	s := &Scheduler{dir: dir, running: map[string]bool{}, runners: map[string]Runner{}}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return s, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, jobsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read deferred jobs: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.jobs); err != nil {
+		return s, fmt.Errorf("failed to parse deferred jobs: %w", err)
+	}
+	devstats.Set("scheduler.deferred_jobs", int64(len(s.jobs)))
+	return s, nil
+}
+
+// Dir returns the directory where runners may keep files belonging to a job.
+func (s *Scheduler) Dir() string {
+	return s.dir
+}
+
+// Handle registers the runner for jobs of the given kind.
+func (s *Scheduler) Handle(kind string, r Runner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runners[kind] = r
+}
+
+// Start runs overdue jobs and then checks for due jobs every minute. The wall
+// clock is polled rather than using timers, so jobs still run on time after the
+// computer slept.
+func (s *Scheduler) Start() {
+	s.once.Do(func() {
+		go func() {
+			for {
+				s.runDue(time.Now())
+				time.Sleep(pollInterval)
+			}
+		}()
+	})
+}
+
+// Schedule stores a job of the given kind to run at runAt. payload is encoded as JSON.
+func (s *Scheduler) Schedule(kind, title string, runAt time.Time, payload any) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode job: %w", err)
+	}
+	job := Job{ID: newID(), Kind: kind, Title: title, RunAt: runAt, Payload: data}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	slog.Info("Scheduled job", "kind", kind, "job", job.ID, "title", title, "run_at", runAt.Format(time.RFC1123))
+	return job, s.saveLocked()
+}
+
+// Cancel removes a job that has not run yet.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[id] {
+		return fmt.Errorf("job %s is already running", id)
+	}
+	return s.removeLocked(id)
+}
+
+// Jobs returns the pending jobs, earliest first.
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, len(s.jobs))
+	copy(out, s.jobs)
+	sort.Slice(out, func(i, j int) bool { return out[i].RunAt.Before(out[j].RunAt) })
+	return out
+}
+
+// runDue starts every job due at now that is not running yet.
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.RunAt.After(now) || s.running[job.ID] {
+			continue
+		}
+		r, ok := s.runners[job.Kind]
+		if !ok {
+			slog.Warn("No runner for deferred job, keeping it", "kind", job.Kind, "job", job.ID)
+			continue
+		}
+		s.running[job.ID] = true
+		go s.run(job, r)
+	}
+}
+
+func (s *Scheduler) run(job Job, r Runner) {
+	slog.Info("Running deferred job", "kind", job.Kind, "job", job.ID, "title", job.Title)
+	if err := r(job); err != nil {
+		slog.Error("Deferred job failed", "kind", job.Kind, "job", job.ID, "err", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, job.ID)
+	if err := s.removeLocked(job.ID); err != nil {
+		slog.Warn("Failed to remove deferred job", "job", job.ID, "err", err)
+	}
+}
+
+func (s *Scheduler) removeLocked(id string) error {
+	for i, job := range s.jobs {
+		if job.ID == id {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("job %s not found", id)
+}
+
+func (s *Scheduler) saveLocked() error {
+	devstats.Set("scheduler.deferred_jobs", int64(len(s.jobs)))
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode deferred jobs: %w", err)
+	}
+	path := filepath.Join(s.dir, jobsFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write deferred jobs: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
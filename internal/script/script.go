@@ -0,0 +1,100 @@
+package script
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"go.starlark.net/starlark"
+)
+
+// maxSteps bounds the work a single hook call may do, so a runaway loop cannot hang a job.
+const maxSteps = 10_000_000
+
+// Hook is a compiled Starlark script. It may define
+//
+//	def transform(text, language): return text   # preprocessing stage
+//	def filename(text, default): return default  # output file name without extension
+//
+// Scripts are sandboxed: there is no file, network or module access, only the
+// Starlark language itself plus re_sub(pattern, replacement, text).
+type Hook struct {
+	globals starlark.StringDict
+}
+
+// Compile parses and runs the top level of src.
+func Compile(src string) (*Hook, error) {
+	thread := newThread()
+	globals, err := starlark.ExecFile(thread, "hook.star", src, builtins)
+	if err != nil {
+		return nil, fmt.Errorf("script error: %w", err)
+	}
+	return &Hook{globals: globals}, nil
+}
+
+// HasTransform reports whether the script defines transform().
+func (h *Hook) HasTransform() bool { return h.has("transform") }
+
+// HasFilename reports whether the script defines filename().
+func (h *Hook) HasFilename() bool { return h.has("filename") }
+
+// Transform calls transform(text, language).
+func (h *Hook) Transform(text, language string) (string, error) {
+	return h.callString("transform", text, language)
+}
+
+// Filename calls filename(text, default).
+func (h *Hook) Filename(text, defaultName string) (string, error) {
+	return h.callString("filename", text, defaultName)
+}
+
+func (h *Hook) has(name string) bool {
+	_, ok := h.globals[name].(starlark.Callable)
+	return ok
+}
+
+func (h *Hook) callString(name string, args ...string) (string, error) {
+	fn, ok := h.globals[name].(starlark.Callable)
+	if !ok {
+		return "", fmt.Errorf("script does not define %s()", name)
+	}
+	tuple := make(starlark.Tuple, len(args))
+	for i, a := range args {
+		tuple[i] = starlark.String(a)
+	}
+	v, err := starlark.Call(newThread(), fn, tuple, nil)
+	if err != nil {
+		return "", fmt.Errorf("script %s() failed: %w", name, err)
+	}
+	s, ok := starlark.AsString(v)
+	if !ok {
+		return "", fmt.Errorf("script %s() returned %s, want string", name, v.Type())
+	}
+	return s, nil
+}
+
+func newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  "hook",
+		Print: func(_ *starlark.Thread, msg string) { slog.Info("Script output", "msg", msg) },
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+var builtins = starlark.StringDict{
+	"re_sub": starlark.NewBuiltin("re_sub", reSub),
+}
+
+// reSub implements re_sub(pattern, replacement, text) with Go regexp syntax.
+func reSub(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, repl, text string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &pattern, &repl, &text); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlark.String(re.ReplaceAllString(text, repl)), nil
+}
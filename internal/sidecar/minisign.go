@@ -0,0 +1,122 @@
+package sidecar
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Layout of a minisign secret key, see https://jedisct1.github.io/minisign/.
+const (
+	minisignKeyIDSize     = 8
+	minisignKeynumSize    = minisignKeyIDSize + ed25519.PrivateKeySize + blake2b.Size256
+	minisignSecretKeySize = 2 + 2 + 2 + 32 + 8 + 8 + minisignKeynumSize
+)
+
+// SigningKey is a decrypted minisign secret key.
+type SigningKey struct {
+	id  [minisignKeyIDSize]byte
+	key ed25519.PrivateKey
+}
+
+// LoadSigningKey reads a minisign secret key file (as created by "minisign -G")
+// and decrypts it with password. Keys created with "minisign -G -W" are not
+// encrypted and take an empty password.
+func LoadSigningKey(path, password string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "untrusted comment:") {
+		return nil, errors.New("not a minisign secret key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != minisignSecretKeySize {
+		return nil, errors.New("not a minisign secret key")
+	}
+	sigAlg, kdfAlg, chkAlg := raw[0:2], raw[2:4], raw[4:6]
+	salt := raw[6:38]
+	opsLimit := binary.LittleEndian.Uint64(raw[38:46])
+	memLimit := binary.LittleEndian.Uint64(raw[46:54])
+	keynum := bytes.Clone(raw[54:])
+	if string(sigAlg) != "Ed" || string(chkAlg) != "B2" {
+		return nil, errors.New("unsupported minisign key algorithm")
+	}
+
+	switch {
+	case string(kdfAlg) == "Sc":
+		n, r, p := scryptParams(opsLimit, memLimit)
+		stream, err := scrypt.Key([]byte(password), salt, n, r, p, minisignKeynumSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		subtle.XORBytes(keynum, keynum, stream)
+	case kdfAlg[0] != 0 || kdfAlg[1] != 0:
+		return nil, errors.New("unsupported minisign key encryption")
+	}
+
+	k := &SigningKey{key: ed25519.PrivateKey(keynum[minisignKeyIDSize : minisignKeyIDSize+ed25519.PrivateKeySize])}
+	copy(k.id[:], keynum[:minisignKeyIDSize])
+	h, _ := blake2b.New256(nil)
+	h.Write(sigAlg)
+	h.Write(k.id[:])
+	h.Write(k.key)
+	if subtle.ConstantTimeCompare(h.Sum(nil), keynum[minisignKeyIDSize+ed25519.PrivateKeySize:]) != 1 {
+		return nil, errors.New("wrong password for the signing key")
+	}
+	return k, nil
+}
+
+// Sign returns a minisign signature file for data, in the prehashed format of
+// minisign 0.11 and later. The trusted comment is covered by the signature.
+func (k *SigningKey) Sign(data []byte, trustedComment string) []byte {
+	return k.signDigest(blake2b.Sum512(data), trustedComment)
+}
+
+// signDigest signs the BLAKE2b-512 digest of the data, for data too large to hold in memory.
+func (k *SigningKey) signDigest(digest [blake2b.Size]byte, trustedComment string) []byte {
+	sig := ed25519.Sign(k.key, digest[:])
+	global := ed25519.Sign(k.key, append(bytes.Clone(sig), trustedComment...))
+
+	blob := append([]byte("ED"), k.id[:]...)
+	blob = append(blob, sig...)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "untrusted comment: signature from quacker secret key\n%s\n", base64.StdEncoding.EncodeToString(blob))
+	fmt.Fprintf(&b, "trusted comment: %s\n%s\n", trustedComment, base64.StdEncoding.EncodeToString(global))
+	return b.Bytes()
+}
+
+// scryptParams converts libsodium's opslimit and memlimit, which minisign
+// stores in its keys, into scrypt's N, r and p.
+func scryptParams(opsLimit, memLimit uint64) (n, r, p int) {
+	opsLimit = max(opsLimit, 32768)
+	r = 8
+	var maxN uint64
+	if opsLimit < memLimit/32 {
+		p = 1
+		maxN = opsLimit / (uint64(r) * 4)
+	} else {
+		maxN = memLimit / (uint64(r) * 128)
+	}
+	logN := uint(1)
+	for ; logN < 63; logN++ {
+		if uint64(1)<<logN > maxN/2 {
+			break
+		}
+	}
+	if opsLimit >= memLimit/32 {
+		maxRP := min((opsLimit/4)/(uint64(1)<<logN), 0x3fffffff)
+		p = int(maxRP) / r
+	}
+	return 1 << logN, r, p
+}
@@ -0,0 +1,83 @@
+// Package sidecar writes a JSON file next to each output that records its
+// SHA-256 checksum and, optionally, a minisign signature, so published audio
+// can be verified for integrity later ("sha256sum", "minisign -V").
+package sidecar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Sidecar describes an output file.
+type Sidecar struct {
+	File    string    `json:"file"`
+	Created time.Time `json:"created"`
+	SHA256  string    `json:"sha256"`
+	// Signature is the minisign signature file next to the output, if signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Path returns the sidecar path of an output file: "book.mp3" -> "book.mp3.json".
+func Path(outputPath string) string {
+	return outputPath + ".json"
+}
+
+// SignaturePath returns where the signature of an output file is stored,
+// following minisign's convention: "book.mp3" -> "book.mp3.minisig".
+func SignaturePath(outputPath string) string {
+	return outputPath + ".minisig"
+}
+
+// Write records the checksum of data, the content of outputPath, in its sidecar.
+// If key is not nil it also signs data into the signature file.
+func Write(outputPath string, data []byte, key *SigningKey) (*Sidecar, error) {
+	return write(outputPath, sha256.Sum256(data), blake2b.Sum512(data), key)
+}
+
+// WriteFile is Write for the content of outputPath, which is read as a stream
+// rather than loaded into memory.
+func WriteFile(outputPath string, key *SigningKey) (*Sidecar, error) {
+	f, err := os.Open(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sha := sha256.New()
+	blake, _ := blake2b.New512(nil) // fails only for keys over 64 bytes
+	if _, err := io.Copy(io.MultiWriter(sha, blake), f); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(outputPath), err)
+	}
+	return write(outputPath, [sha256.Size]byte(sha.Sum(nil)), [blake2b.Size]byte(blake.Sum(nil)), key)
+}
+
+func write(outputPath string, sum [sha256.Size]byte, digest [blake2b.Size]byte, key *SigningKey) (*Sidecar, error) {
+	s := &Sidecar{
+		File:    filepath.Base(outputPath),
+		Created: time.Now(),
+		SHA256:  hex.EncodeToString(sum[:]),
+	}
+	if key != nil {
+		comment := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", s.Created.Unix(), s.File)
+		sigPath := SignaturePath(outputPath)
+		if err := os.WriteFile(sigPath, key.signDigest(digest, comment), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write signature: %w", err)
+		}
+		s.Signature = filepath.Base(sigPath)
+	}
+	encoded, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(Path(outputPath), encoded, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write sidecar: %w", err)
+	}
+	return s, nil
+}
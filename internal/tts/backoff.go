@@ -0,0 +1,70 @@
+package tts
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Defaults of the retry policy: 5s, 15s, 45s... capped at two minutes, each
+// varied by up to 20% so parallel jobs do not retry in lockstep.
+const (
+	DefaultMaxRetries      = 3
+	DefaultRetryBaseDelay  = 5 * time.Second
+	DefaultRetryMultiplier = 3.0
+	DefaultRetryJitter     = 0.2
+	DefaultRetryMaxDelay   = 2 * time.Minute
+)
+
+// retryPolicy is the retry schedule of a ProcessorConfig, and whom to tell
+// about each retry.
+type retryPolicy struct {
+	maxRetries int
+	base       time.Duration
+	multiplier float64
+	jitter     float64
+	max        time.Duration
+	jobID      string
+	onRetry    func(RetryEvent)
+}
+
+func (cfg *ProcessorConfig) retryPolicy() retryPolicy {
+	return retryPolicy{
+		maxRetries: max(cfg.MaxRetries, 1), // every chunk is tried at least once
+		base:       cfg.RetryBaseDelay,
+		multiplier: max(cfg.RetryMultiplier, 1),
+		jitter:     min(max(cfg.RetryJitter, 0), 1),
+		max:        cfg.RetryMaxDelay,
+		jobID:      cfg.JobID,
+		onRetry:    cfg.Hooks.OnRetry,
+	}
+}
+
+// delay returns how long to wait after the given failed attempt (1-based):
+// base * multiplier^(attempt-1), at most max, varied by ±jitter.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := float64(p.base) * math.Pow(p.multiplier, float64(attempt-1))
+	if p.max > 0 && d > float64(p.max) {
+		d = float64(p.max)
+	}
+	if p.jitter > 0 {
+		d *= 1 + p.jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// ValidateRetryPolicy checks user-entered retry settings.
+func ValidateRetryPolicy(maxRetries int, base time.Duration, multiplier, jitter float64) error {
+	switch {
+	case maxRetries < 1 || maxRetries > 10:
+		return fmt.Errorf("attempts must be between 1 and 10, got %d", maxRetries)
+	case base < 0 || base > 10*time.Minute:
+		return fmt.Errorf("base delay must be between 0 and 600 seconds, got %v", base)
+	case multiplier < 1 || multiplier > 10:
+		return fmt.Errorf("multiplier must be between 1 and 10, got %g", multiplier)
+	case jitter < 0 || jitter > 1:
+		return fmt.Errorf("jitter must be between 0 and 1, got %g", jitter)
+	}
+	return nil
+}
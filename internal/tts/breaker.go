@@ -0,0 +1,140 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"easy-tts/internal/devstats"
+)
+
+// ErrProviderFailing is returned by ProcessSegments when the circuit breaker
+// tripped and the user chose to abort the job.
+var ErrProviderFailing = errors.New("provider keeps failing")
+
+// breakerCooldown is how long BreakerWait waits when the job has no Pauser.
+const breakerCooldown = 5 * time.Minute
+
+// BreakerAction is what the job does after the circuit breaker tripped.
+type BreakerAction int
+
+const (
+	BreakerWait   BreakerAction = iota // pause the job, then try the provider again
+	BreakerSwitch                      // continue the job with another provider
+	BreakerAbort                       // stop the job, keeping the audio so far
+)
+
+// BreakerTrip describes why the circuit breaker tripped.
+type BreakerTrip struct {
+	Provider string
+	Failures int   // consecutive failed requests
+	Err      error // the last of them
+}
+
+// BreakerDecision is the answer to a BreakerTrip. For BreakerSwitch, Provider
+// synthesizes the rest of the job with Model, and with Voice instead of the
+// segments' voices if set, since those usually belong to the failing provider.
+// Instructions are dropped, as they are specific to a model.
+type BreakerDecision struct {
+	Action   BreakerAction
+	Provider Provider
+	Voice    string
+	Model    string
+}
+
+// breakerProvider counts consecutive hard failures of the wrapped provider.
+// At the threshold it asks onTrip what to do instead of letting the processor
+// back off and retry every remaining chunk. Requests of a job are sequential,
+// so it is not safe for concurrent use.
+type breakerProvider struct {
+	Provider
+	threshold int
+	onTrip    func(BreakerTrip) BreakerDecision
+	pause     *Pauser
+	timeout   time.Duration // per-request timeout for a provider switched to
+
+	failures int
+	switched *BreakerDecision // overrides the request after a switch
+	aborted  error
+}
+
+func (p *breakerProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	for {
+		if p.aborted != nil {
+			return nil, p.aborted
+		}
+		if p.switched != nil {
+			override := *req
+			if p.switched.Voice != "" {
+				override.Voice = p.switched.Voice
+			}
+			override.Model, override.Instructions = p.switched.Model, ""
+			override.Format = FormatFor(p.Provider, req.Format)
+			req = &override
+		}
+		data, err := p.Provider.GenerateSpeech(ctx, req)
+		if err == nil || !isHardFailure(ctx, err) {
+			if err == nil {
+				p.failures = 0
+			}
+			return data, err
+		}
+		p.failures++
+		if p.failures < p.threshold {
+			return nil, err
+		}
+		devstats.Add("breaker.trips", 1)
+		logger(ctx).Warn("Circuit breaker tripped", "failures", p.failures, "err", err)
+		decision := p.onTrip(BreakerTrip{Provider: p.GetName(), Failures: p.failures, Err: err})
+		p.failures = 0
+		switch decision.Action {
+		case BreakerSwitch:
+			if decision.Provider == nil {
+				return nil, err
+			}
+			logger(ctx).Info("Switching provider", "from", p.GetName(), "to", decision.Provider.GetName())
+			p.Provider = &transcodingProvider{Provider: decision.Provider}
+			if p.timeout > 0 {
+				p.Provider = &timeoutProvider{Provider: p.Provider, timeout: p.timeout}
+			}
+			p.switched = &decision
+		case BreakerWait:
+			if err := p.wait(ctx); err != nil {
+				return nil, err
+			}
+		default:
+			p.aborted = fmt.Errorf("%w: %v", ErrProviderFailing, err)
+			return nil, p.aborted
+		}
+	}
+}
+
+// wait pauses the job until the user resumes it, or for breakerCooldown if the
+// job cannot be paused.
+func (p *breakerProvider) wait(ctx context.Context) error {
+	if p.pause != nil {
+		p.pause.Pause()
+		return p.pause.Wait(ctx)
+	}
+	select {
+	case <-time.After(breakerCooldown):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isHardFailure reports whether err means the provider itself is failing, as
+// opposed to rejecting one chunk's text or audio. Errors after the job itself
+// was cancelled do not count.
+func isHardFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch ErrorType(err) {
+	case ErrorTypeRequest, ErrorTypeInvalidAudio, ErrorTypeQuota:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package tts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/cache"
+	"easy-tts/internal/devstats"
+)
+
+// cachedProvider answers requests from the synthesis cache where it can and
+// stores new audio in it, so unchanged chunks of a re-run document are free.
+type cachedProvider struct {
+	Provider
+	cache *cache.Cache
+}
+
+func (p *cachedProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	key := cacheKey(p.GetName(), req)
+	if data, ok := p.cache.Get(key); ok {
+		devstats.Add("cache.hits", 1)
+		return data, nil
+	}
+	devstats.Add("cache.misses", 1)
+	data, err := p.Provider.GenerateSpeech(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Only keep audio that passes validation; suspiciously short audio is
+	// re-requested, which must not be answered from the cache
+	if info, err := audio.Validate(data, req.Format); err == nil && !isSuspiciouslyShort(req.Text, req.Speed, info.Duration) {
+		if err := p.cache.Put(key, data); err != nil {
+			logger(ctx).Warn("Failed to cache chunk", "err", err)
+		}
+	}
+	return data, nil
+}
+
+// cacheKey identifies the audio of a request: the provider and every request
+// field that changes the result, including the text.
+func cacheKey(providerName string, req *UnifiedRequest) string {
+	encoded, _ := json.Marshal(req)
+	sum := sha256.Sum256(append([]byte(providerName+"\x00"), encoded...))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,18 +1,19 @@
 package tts
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkoukk/tiktoken-go"
 )
 
 var (
-	sentenceEndRegex            = regexp.MustCompile(`([.!?])`)
-	hrSeparatorRegex            = regexp.MustCompile(`\n(?:-{3,}|_{3,})\n`)
-	multiNewlineSeparatorRegex  = regexp.MustCompile(`\n\s*\n`)
-	sentenceEndNewlineRegex     = regexp.MustCompile(`([.!?])\s*\n`)
+	hrSeparatorRegex           = regexp.MustCompile(`\n(?:-{3,}|_{3,})\n`)
+	multiNewlineSeparatorRegex = regexp.MustCompile(`\n\s*\n`)
 )
 
 // Default chunking limits
@@ -21,295 +22,318 @@ const (
 	DefaultByteLimit  = 4500 // Google: bytes per chunk
 )
 
-// getInitialChunks splits text by major separators like horizontal rules or multiple newlines.
-func GetInitialChunks(text string) []string {
-	hrParts := hrSeparatorRegex.Split(text, -1)
-	var filteredHrParts []string
-	for _, p := range hrParts {
-		if strings.TrimSpace(p) != "" {
-			filteredHrParts = append(filteredHrParts, p)
-		}
-	}
-	if len(filteredHrParts) > 1 {
-		log.Printf("Split text by horizontal rule into %d major chunks.", len(filteredHrParts))
-		return filteredHrParts
-	}
-
-	multiNewlineParts := multiNewlineSeparatorRegex.Split(text, -1)
-	var filteredMultiNewlineParts []string
-	for _, p := range multiNewlineParts {
-		if strings.TrimSpace(p) != "" {
-			filteredMultiNewlineParts = append(filteredMultiNewlineParts, p)
-		}
-	}
-	if len(filteredMultiNewlineParts) > 1 {
-		log.Printf("Split text by multiple newlines into %d major chunks.", len(filteredMultiNewlineParts))
-		return filteredMultiNewlineParts
-	}
-
-	log.Println("No major separators found; treating text as a single chunk.")
-	trimmedText := strings.TrimSpace(text)
-	if trimmedText == "" {
-		return []string{}
-	}
-	return []string{trimmedText}
-}
+// Bounds for user-chosen chunk limits. Google rejects requests over 5000 bytes.
+const (
+	MinTokenLimit = 50
+	MinByteLimit  = 200
+	MaxByteLimit  = 5000
+)
 
-// ----------- TOKEN-BASED CHUNKING (OpenAI) -----------
+// Measure returns the size of a text in the unit a chunk limit is given in,
+// e.g. bytes for Google or tokens for OpenAI.
+type Measure func(text string) int
 
-// splitTextTokenLimit splits text into chunks based on token limits.
-func SplitTextTokenLimit(text, model string, maxTokens int) []string {
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return []string{}
-	}
+// ByteMeasure measures text in UTF-8 bytes.
+func ByteMeasure(text string) int { return len(text) }
 
+// TokenMeasure returns a Measure counting cl100k_base tokens. If the tokenizer
+// is unavailable it estimates three runes per token.
+func TokenMeasure() Measure {
 	enc, err := tiktoken.GetEncoding("cl100k_base")
 	if err != nil {
-		log.Printf("Error getting tokenizer encoding, falling back to rune splitting: %v", err)
-		return splitByRune(text, maxTokens*3)
+		slog.Warn("Tokenizer unavailable, estimating tokens from runes", "err", err)
+		return func(text string) int { return (utf8.RuneCountInString(text) + 2) / 3 }
 	}
+	return func(text string) int { return len(enc.Encode(text, nil, nil)) }
+}
 
-	initialChunks := GetInitialChunks(text)
-	var finalChunks []string
-	for _, chunk := range initialChunks {
-		if len(enc.Encode(chunk, nil, nil)) <= maxTokens {
-			finalChunks = append(finalChunks, chunk)
-		} else {
-			log.Printf("Major chunk exceeds token limit, applying recursive splitting...")
-			finalChunks = append(finalChunks, splitChunkRecursively(chunk, enc, maxTokens, 0)...)
-		}
+// SplitText splits text into chunks no larger than limit as measured by measure.
+// It prefers major separators, then sentence ends outside quotes and parentheses,
+// then any sentence end, then words, and only splits inside a word as a last resort.
+func SplitText(text string, limit int, measure Measure) []string {
+	finalChunks := []string{}
+	c := NewChunker(text, limit, measure)
+	for chunk, ok := c.NextChunk(); ok; chunk, ok = c.NextChunk() {
+		finalChunks = append(finalChunks, chunk)
 	}
 	return finalChunks
 }
 
-func splitChunkRecursively(chunk string, enc *tiktoken.Tiktoken, maxTokens int, level int) []string {
-	chunk = strings.TrimSpace(chunk)
-	if chunk == "" {
-		return nil
-	}
-
-	var regex *regexp.Regexp
-	switch level {
-	case 0:
-		regex = sentenceEndNewlineRegex
-	case 1:
-		regex = sentenceEndRegex
-	case 2:
-		return splitByWord(chunk, enc, maxTokens)
-	default:
-		return splitByRune(chunk, maxTokens*3)
-	}
+// Chunker yields the chunks of a text one at a time, in the same order as
+// SplitText, so synthesis can start before a long document is fully split.
+// Only the major chunk (section or paragraph) being split is held in memory.
+type Chunker struct {
+	limit     int
+	measure   Measure
+	separator *regexp.Regexp // major separator, nil if the text is a single major chunk
+	rest      string         // text not yet split into chunks
+	ready     []string       // chunks of the current major chunk not yet returned
+}
 
-	indices := regex.FindAllStringIndex(chunk, -1)
-	if len(indices) == 0 {
-		return splitChunkRecursively(chunk, enc, maxTokens, level+1)
+// NewChunker prepares to split text into chunks no larger than limit.
+func NewChunker(text string, limit int, measure Measure) *Chunker {
+	c := &Chunker{limit: limit, measure: measure, rest: strings.TrimSpace(text)}
+	switch {
+	case splitsInParts(c.rest, hrSeparatorRegex):
+		c.separator = hrSeparatorRegex
+	case splitsInParts(c.rest, multiNewlineSeparatorRegex):
+		c.separator = multiNewlineSeparatorRegex
 	}
+	return c
+}
 
-	var resultChunks []string
-	lastPos := 0
-	var currentChunk strings.Builder
+// NewProviderChunker returns a Chunker using the chunk limit of provider, see ChunkLimit.
+func NewProviderChunker(provider Provider, text string, override int) *Chunker {
+	limit, measure := ChunkLimit(provider, override)
+	return NewChunker(text, limit, measure)
+}
 
-	for _, idx := range indices {
-		segment := chunk[lastPos:idx[1]]
-		segment = strings.TrimSpace(segment)
-		if segment == "" {
-			lastPos = idx[1]
-			continue
+// NextChunk returns the next chunk, or false once the text is exhausted.
+func (c *Chunker) NextChunk() (string, bool) {
+	for len(c.ready) == 0 {
+		if c.rest == "" {
+			return "", false
 		}
-		if len(enc.Encode(currentChunk.String()+segment, nil, nil)) > maxTokens {
-			if currentChunk.Len() > 0 {
-				resultChunks = append(resultChunks, currentChunk.String())
-				currentChunk.Reset()
+		major := c.rest
+		c.rest = ""
+		if c.separator != nil {
+			if loc := c.separator.FindStringIndex(major); loc != nil {
+				major, c.rest = major[:loc[0]], major[loc[1]:]
 			}
 		}
-		currentChunk.WriteString(segment)
-		lastPos = idx[1]
-	}
-	// Add any trailing text
-	if lastPos < len(chunk) {
-		tail := chunk[lastPos:]
-		if len(enc.Encode(currentChunk.String()+tail, nil, nil)) > maxTokens {
-			if currentChunk.Len() > 0 {
-				resultChunks = append(resultChunks, currentChunk.String())
-				currentChunk.Reset()
-			}
+		if strings.TrimSpace(major) == "" {
+			continue
+		}
+		if c.measure(major) <= c.limit {
+			c.ready = []string{major}
+		} else {
+			slog.Debug("Major chunk exceeds limit, applying recursive splitting")
+			c.ready = splitChunkRecursively(major, c.limit, c.measure, 0)
 		}
-		currentChunk.WriteString(tail)
-	}
-	if currentChunk.Len() > 0 {
-		resultChunks = append(resultChunks, currentChunk.String())
 	}
-	return resultChunks
+	chunk := c.ready[0]
+	c.ready = c.ready[1:]
+	return chunk, true
 }
 
-func splitByWord(text string, enc *tiktoken.Tiktoken, maxTokens int) []string {
-	var chunks []string
-	var currentChunk strings.Builder
-	words := strings.Fields(text)
+// Rest returns the text of all chunks not yet returned by NextChunk.
+func (c *Chunker) Rest() string {
+	parts := append([]string{}, c.ready...)
+	if t := strings.TrimSpace(c.rest); t != "" {
+		parts = append(parts, t)
+	}
+	return strings.Join(parts, " ")
+}
 
-	for _, word := range words {
-		if len(enc.Encode(word, nil, nil)) > maxTokens {
-			if currentChunk.Len() > 0 {
-				chunks = append(chunks, currentChunk.String())
-				currentChunk.Reset()
+// Stream splits the text in the background and delivers the chunks over a
+// channel, which is closed when the text is exhausted or ctx is done.
+func (c *Chunker) Stream(ctx context.Context) <-chan string {
+	ch := make(chan string, 1)
+	go func() {
+		defer close(ch)
+		for chunk, ok := c.NextChunk(); ok; chunk, ok = c.NextChunk() {
+			select {
+			case ch <- chunk:
+			case <-ctx.Done():
+				return
 			}
-			chunks = append(chunks, splitByRune(word, maxTokens*3)...)
-			continue
-		}
-		if len(enc.Encode(currentChunk.String()+" "+word, nil, nil)) > maxTokens {
-			chunks = append(chunks, currentChunk.String())
-			currentChunk.Reset()
 		}
-		if currentChunk.Len() > 0 {
-			currentChunk.WriteString(" ")
-		}
-		currentChunk.WriteString(word)
-	}
-	if currentChunk.Len() > 0 {
-		chunks = append(chunks, currentChunk.String())
-	}
-	return chunks
+	}()
+	return ch
 }
 
-func splitByRune(text string, maxRunes int) []string {
-	var chunks []string
-	runes := []rune(text)
-	for i := 0; i < len(runes); i += maxRunes {
-		end := i + maxRunes
-		if end > len(runes) {
-			end = len(runes)
+// splitsInParts reports whether separator splits text into at least two
+// non-blank parts, without splitting the whole text.
+func splitsInParts(text string, separator *regexp.Regexp) bool {
+	nonBlank, pos := 0, 0
+	for pos <= len(text) {
+		loc := separator.FindStringIndex(text[pos:])
+		end := len(text)
+		if loc != nil {
+			end = pos + loc[0]
+		}
+		if strings.TrimSpace(text[pos:end]) != "" {
+			if nonBlank++; nonBlank > 1 {
+				return true
+			}
+		}
+		if loc == nil {
+			break
 		}
-		chunks = append(chunks, string(runes[i:end]))
+		pos += loc[1]
 	}
-	return chunks
+	return false
 }
 
-// ----------- BYTE-BASED CHUNKING (Google) -----------
+// SplitTextTokenLimit splits text into chunks of at most maxTokens cl100k_base tokens (OpenAI).
+func SplitTextTokenLimit(text string, maxTokens int) []string {
+	return SplitText(text, maxTokens, TokenMeasure())
+}
 
-// splitTextByteLimit splits text into chunks based on byte limits.
+// SplitTextByteLimit splits text into chunks of at most maxBytes bytes (Google).
 func SplitTextByteLimit(text string, maxBytes int) []string {
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return []string{}
-	}
-
-	initialChunks := GetInitialChunks(text)
-	var finalChunks []string
-	for _, chunk := range initialChunks {
-		if len([]byte(chunk)) <= maxBytes {
-			finalChunks = append(finalChunks, chunk)
-		} else {
-			log.Printf("Major chunk exceeds byte limit, applying recursive splitting...")
-			finalChunks = append(finalChunks, splitChunkRecursivelyBytes(chunk, maxBytes, 0)...)
-		}
-	}
-	return finalChunks
+	return SplitText(text, maxBytes, ByteMeasure)
 }
 
-func splitChunkRecursivelyBytes(chunk string, maxBytes int, level int) []string {
+func splitChunkRecursively(chunk string, limit int, measure Measure, level int) []string {
 	chunk = strings.TrimSpace(chunk)
 	if chunk == "" {
 		return nil
 	}
 
-	var regex *regexp.Regexp
+	var ends []int
 	switch level {
-	case 0:
-		regex = sentenceEndNewlineRegex
-	case 1:
-		regex = sentenceEndRegex
+	case 0, 1:
+		// Sentences ending a line, then all sentences, outside quotes and parentheses
+		spans := phraseSpans(chunk)
+		for _, end := range sentenceEnds(chunk) {
+			if insideSpan(spans, end) {
+				continue
+			}
+			if rest := strings.TrimLeft(chunk[end:], " \t"); level == 0 && !strings.HasPrefix(rest, "\n") {
+				continue
+			}
+			ends = append(ends, end)
+		}
 	case 2:
-		return splitByWordBytes(chunk, maxBytes)
+		ends = sentenceEnds(chunk)
+	case 3:
+		return splitByWord(chunk, limit, measure)
 	default:
-		return splitByRuneBytes(chunk, maxBytes)
+		return splitByRune(chunk, limit, measure)
 	}
 
-	indices := regex.FindAllStringIndex(chunk, -1)
-	if len(indices) == 0 {
-		return splitChunkRecursivelyBytes(chunk, maxBytes, level+1)
+	if len(ends) == 0 {
+		return splitChunkRecursively(chunk, limit, measure, level+1)
 	}
 
-	var resultChunks []string
+	b := chunkBuilder{limit: limit, measure: measure, separator: " "}
 	lastPos := 0
-	var currentChunk strings.Builder
-
-	for _, idx := range indices {
-		segment := chunk[lastPos:idx[1]]
-		segment = strings.TrimSpace(segment)
+	for i := 0; i <= len(ends); i++ {
+		var segment string
+		if i < len(ends) {
+			segment, lastPos = strings.TrimSpace(chunk[lastPos:ends[i]]), ends[i]
+		} else {
+			segment = strings.TrimSpace(chunk[lastPos:]) // trailing text
+		}
 		if segment == "" {
-			lastPos = idx[1]
 			continue
 		}
-		if len([]byte(currentChunk.String()+segment)) > maxBytes {
-			if currentChunk.Len() > 0 {
-				resultChunks = append(resultChunks, currentChunk.String())
-				currentChunk.Reset()
-			}
+		if size := measure(segment); size <= limit {
+			b.add(segment, size)
+		} else {
+			// A single sentence (or line) over the limit is split at the next level
+			b.flush()
+			b.chunks = append(b.chunks, splitChunkRecursively(segment, limit, measure, level+1)...)
 		}
-		currentChunk.WriteString(segment)
-		lastPos = idx[1]
 	}
-	// Add any trailing text
-	if lastPos < len(chunk) {
-		tail := chunk[lastPos:]
-		if len([]byte(currentChunk.String()+tail)) > maxBytes {
-			if currentChunk.Len() > 0 {
-				resultChunks = append(resultChunks, currentChunk.String())
-				currentChunk.Reset()
-			}
+	b.flush()
+	return b.chunks
+}
+
+// chunkBuilder joins segments into chunks of at most limit. Each segment is
+// measured once, with its separator, and the sizes are summed, so building a
+// chunk takes linear time instead of re-measuring the growing chunk for every
+// segment. Tokenizers split text at spaces before encoding, so the sum matches
+// the size of the joined chunk.
+type chunkBuilder struct {
+	limit     int
+	measure   Measure
+	separator string
+	chunks    []string
+	current   strings.Builder
+	size      int // size of current
+}
+
+// add appends segment, of the given size, to the current chunk, starting a new
+// chunk if it does not fit.
+func (b *chunkBuilder) add(segment string, size int) {
+	if b.current.Len() > 0 {
+		joined := size
+		if b.separator != "" {
+			joined = b.measure(b.separator + segment)
 		}
-		currentChunk.WriteString(tail)
-	}
-	if currentChunk.Len() > 0 {
-		resultChunks = append(resultChunks, currentChunk.String())
+		if b.size+joined <= b.limit {
+			b.current.WriteString(b.separator)
+			b.current.WriteString(segment)
+			b.size += joined
+			return
+		}
+		b.flush()
 	}
-	return resultChunks
+	b.current.WriteString(segment)
+	b.size = size
 }
 
-func splitByWordBytes(text string, maxBytes int) []string {
-	var chunks []string
-	var currentChunk strings.Builder
-	words := strings.Fields(text)
+// flush ends the current chunk.
+func (b *chunkBuilder) flush() {
+	if b.current.Len() > 0 {
+		b.chunks = append(b.chunks, b.current.String())
+		b.current.Reset()
+		b.size = 0
+	}
+}
 
-	for _, word := range words {
-		if len([]byte(word)) > maxBytes {
-			if currentChunk.Len() > 0 {
-				chunks = append(chunks, currentChunk.String())
-				currentChunk.Reset()
-			}
-			chunks = append(chunks, splitByRuneBytes(word, maxBytes)...)
+func splitByWord(text string, limit int, measure Measure) []string {
+	b := chunkBuilder{limit: limit, measure: measure, separator: " "}
+	for _, unit := range wordUnits(text, limit, measure) {
+		if size := measure(unit); size <= limit {
+			b.add(unit, size)
 			continue
 		}
-		if len([]byte(currentChunk.String()+" "+word)) > maxBytes {
-			chunks = append(chunks, currentChunk.String())
-			currentChunk.Reset()
+		for _, word := range strings.Fields(unit) {
+			if size := measure(word); size <= limit {
+				b.add(word, size)
+			} else {
+				b.flush()
+				b.chunks = append(b.chunks, splitByRune(word, limit, measure)...)
+			}
+		}
+	}
+	b.flush()
+	return b.chunks
+}
+
+// wordUnits splits text at spaces into the pieces a chunk may end between:
+// single words, except that short quotes and asides (up to half the limit) and
+// numbers with the following word ("1 000", "3,5 km") stay together.
+func wordUnits(text string, limit int, measure Measure) []string {
+	var keep []span
+	for _, s := range phraseSpans(text) {
+		if measure(text[s.start:s.end]) <= limit/2 {
+			keep = append(keep, s)
 		}
-		if currentChunk.Len() > 0 {
-			currentChunk.WriteString(" ")
+	}
+	var words []span
+	start := -1
+	for i, r := range text + " " {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			words = append(words, span{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
 		}
-		currentChunk.WriteString(word)
 	}
-	if currentChunk.Len() > 0 {
-		chunks = append(chunks, currentChunk.String())
+	var units []string
+	for k := 0; k < len(words); {
+		first := k
+		for k+1 < len(words) && (insideSpan(keep, words[k].end) || numeric(text[words[k].start:words[k].end])) {
+			k++
+		}
+		units = append(units, strings.Join(strings.Fields(text[words[first].start:words[k].end]), " "))
+		k++
 	}
-	return chunks
+	return units
 }
 
-func splitByRuneBytes(text string, maxBytes int) []string {
-	var chunks []string
-	var currentChunk strings.Builder
+// splitByRune splits text between runes. Runes are measured one by one, which
+// overestimates tokens, so the chunks may stay a little short of the limit.
+func splitByRune(text string, limit int, measure Measure) []string {
+	b := chunkBuilder{limit: limit, measure: measure}
 	for _, r := range text {
-		if len([]byte(currentChunk.String()+string(r))) > maxBytes {
-			if currentChunk.Len() > 0 {
-				chunks = append(chunks, currentChunk.String())
-				currentChunk.Reset()
-			}
-		}
-		currentChunk.WriteRune(r)
-	}
-	if currentChunk.Len() > 0 {
-		chunks = append(chunks, currentChunk.String())
+		b.add(string(r), measure(string(r)))
 	}
-	return chunks
+	b.flush()
+	return b.chunks
 }
@@ -0,0 +1,252 @@
+package tts
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "fits",
+			text:  "  One short sentence.\n",
+			limit: 100,
+			want:  []string{"One short sentence."},
+		},
+		{
+			name:  "horizontal rule",
+			text:  "Part one.\n\nStill part one.\n---\nPart two.",
+			limit: 100,
+			want:  []string{"Part one.\n\nStill part one.", "Part two."},
+		},
+		{
+			name:  "blank line",
+			text:  "Paragraph one.\n\n\nParagraph two.\n \nParagraph three.",
+			limit: 100,
+			want:  []string{"Paragraph one.", "Paragraph two.", "Paragraph three."},
+		},
+		{
+			name:  "line ends first",
+			text:  "One two. Three four.\nFive six. Seven eight.",
+			limit: 30,
+			want:  []string{"One two. Three four.", "Five six. Seven eight."},
+		},
+		{
+			name:  "sentences",
+			text:  "One two three. Four five six. Seven eight nine.",
+			limit: 30,
+			want:  []string{"One two three. Four five six.", "Seven eight nine."},
+		},
+		{
+			name:  "not inside quotes",
+			text:  `He said "Stop. Wait here." and left. Then it rained.`,
+			limit: 40,
+			want:  []string{`He said "Stop. Wait here." and left.`, "Then it rained."},
+		},
+		{
+			name:  "words",
+			text:  "alpha beta gamma delta epsilon",
+			limit: 12,
+			want:  []string{"alpha beta", "gamma delta", "epsilon"},
+		},
+		{
+			name:  "runes",
+			text:  "abcdefghij",
+			limit: 4,
+			want:  []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:  "multibyte runes",
+			text:  "äöüäöü",
+			limit: 4,
+			want:  []string{"äö", "üä", "öü"},
+		},
+		{
+			name:  "empty",
+			text:  " \n\n ",
+			limit: 10,
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.limit, ByteMeasure)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SplitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextLimits(t *testing.T) {
+	text := testDocument(30_000)
+	tokens := TokenMeasure()
+	tests := []struct {
+		name    string
+		limit   int
+		measure Measure
+		split   func(string, int) []string
+	}{
+		{"google default", DefaultByteLimit, ByteMeasure, SplitTextByteLimit},
+		{"google maximum", MaxByteLimit, ByteMeasure, SplitTextByteLimit},
+		{"google minimum", MinByteLimit, ByteMeasure, SplitTextByteLimit},
+		{"openai default", DefaultTokenLimit, tokens, SplitTextTokenLimit},
+		{"openai minimum", MinTokenLimit, tokens, SplitTextTokenLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := tt.split(text, tt.limit)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunk(s), want the text split", len(chunks))
+			}
+			for i, chunk := range chunks {
+				if size := tt.measure(chunk); size > tt.limit {
+					t.Errorf("chunk %d has size %d, over the limit of %d", i, size, tt.limit)
+				}
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %d is not valid UTF-8", i)
+				}
+			}
+			if got, want := strings.Fields(strings.Join(chunks, " ")), strings.Fields(text); !slices.Equal(got, want) {
+				t.Errorf("chunks hold %d words, want the %d of the text in order", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestChunkerMatchesSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+	}{
+		{"single chunk", "Short text.", 100},
+		{"sections", "A.\n---\nB.\n___\nC.", 100},
+		{"paragraphs", testDocument(5_000), 300},
+		{"long paragraph", strings.ReplaceAll(testDocument(5_000), "\n\n", " "), 300},
+		{"long word", strings.Repeat("x", 1_000), 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := SplitText(tt.text, tt.limit, ByteMeasure)
+
+			var got []string
+			c := NewChunker(tt.text, tt.limit, ByteMeasure)
+			for chunk, ok := c.NextChunk(); ok; chunk, ok = c.NextChunk() {
+				got = append(got, chunk)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("NextChunk returned %q, want %q", got, want)
+			}
+
+			got = nil
+			for chunk := range NewChunker(tt.text, tt.limit, ByteMeasure).Stream(context.Background()) {
+				got = append(got, chunk)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("Stream delivered %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestChunkerRest(t *testing.T) {
+	c := NewChunker("One.\n\nTwo.\n\nThree.", 100, ByteMeasure)
+	if chunk, _ := c.NextChunk(); chunk != "One." {
+		t.Fatalf("first chunk = %q, want %q", chunk, "One.")
+	}
+	if rest := c.Rest(); !strings.HasPrefix(rest, "Two.") || !strings.HasSuffix(rest, "Three.") {
+		t.Errorf("Rest() = %q, want the text from %q on", rest, "Two.")
+	}
+}
+
+// testDocument returns paragraphs of sentences of about size bytes, with
+// quotes, numbers and non-ASCII letters.
+func testDocument(size int) string {
+	sentences := []string{
+		"The quick brown fox jumps over the lazy dog.",
+		`She said "Wait. Not yet." and closed the door.`,
+		"Über 1 000 Bücher (darunter 3,5 km Akten) lagen im Archiv.",
+		"Prices rose by 12% in 2023; nobody was surprised.",
+		"Is this the end? No, it is not!",
+	}
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		b.WriteString(sentences[i%len(sentences)])
+		switch {
+		case i%7 == 6:
+			b.WriteString("\n\n")
+		case i%3 == 2:
+			b.WriteString("\n")
+		default:
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+func TestChunkBuilderSizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		measure func(testing.TB) Measure
+	}{
+		{"bytes", func(testing.TB) Measure { return ByteMeasure }},
+		{"tokens", tokenizer},
+	}
+	segments := strings.FieldsFunc(testDocument(20_000), func(r rune) bool { return r == '\n' })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			measure := tt.measure(t)
+			b := chunkBuilder{limit: 500, measure: measure, separator: " "}
+			for _, segment := range segments {
+				b.add(segment, measure(segment))
+				if want := measure(b.current.String()); b.size != want {
+					t.Fatalf("summed size %d, but the chunk %q measures %d", b.size, b.current.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSplitText(b *testing.B) {
+	text := testDocument(1 << 20)
+	measures := []struct {
+		name    string
+		limit   int
+		measure func(testing.TB) Measure
+	}{
+		{"bytes", DefaultByteLimit, func(testing.TB) Measure { return ByteMeasure }},
+		{"tokens", DefaultTokenLimit, tokenizer},
+	}
+	for _, m := range measures {
+		b.Run(m.name, func(b *testing.B) {
+			measure := m.measure(b)
+			b.SetBytes(int64(len(text)))
+			b.ResetTimer()
+			for range b.N {
+				SplitText(text, m.limit, measure)
+			}
+		})
+	}
+}
+
+// tokenizer returns the cl100k_base measure, skipping if the encoding cannot
+// be loaded, e.g. without network access.
+func tokenizer(tb testing.TB) Measure {
+	tb.Helper()
+	enc, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		tb.Skipf("tokenizer unavailable: %v", err)
+	}
+	return func(text string) int { return len(enc.Encode(text, nil, nil)) }
+}
@@ -0,0 +1,51 @@
+package tts
+
+import (
+	"strings"
+	"time"
+)
+
+// Published list prices in USD, used for rough cost estimates only.
+const (
+	openAIPerMinute        = 0.015 // gpt-4o-mini-tts, per minute of audio
+	openAITTS1PerMillion   = 15.0  // tts-1, per million characters
+	openAITTS1HDPerMillion = 30.0  // tts-1-hd
+)
+
+// googlePerMillion maps Google voice families to their price per million characters.
+var googlePerMillion = []struct {
+	family string
+	price  float64
+}{
+	{"Chirp3-HD", 30},
+	{"Chirp-HD", 30},
+	{"Studio", 160},
+	{"Neural2", 16},
+	{"Wavenet", 16},
+	{"Standard", 4},
+}
+
+// EstimateCost returns the approximate list price of synthesizing chars characters
+// into audio of the given duration. ok is false when the price is unknown.
+func EstimateCost(providerName, model, voice string, chars int, duration time.Duration) (usd float64, ok bool) {
+	switch providerName {
+	case "openai":
+		switch model {
+		case "tts-1":
+			return float64(chars) / 1e6 * openAITTS1PerMillion, true
+		case "tts-1-hd":
+			return float64(chars) / 1e6 * openAITTS1HDPerMillion, true
+		case "", "gpt-4o-mini-tts":
+			return duration.Minutes() * openAIPerMinute, true
+		}
+	case "demo":
+		return 0, true
+	case "google":
+		for _, p := range googlePerMillion {
+			if strings.Contains(voice, "-"+p.family+"-") {
+				return float64(chars) / 1e6 * p.price, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package tts
+
+import (
+	"context"
+	"time"
+
+	"easy-tts/internal/audio"
+)
+
+// DemoProvider synthesizes silent MP3 audio as long as the text would take to
+// read. It needs no credentials or network, so the whole app can be tried out:
+// preprocessing, chunking, progress, saving and the history work as with a real voice.
+type DemoProvider struct {
+	// Delay simulates the latency of a real provider per request.
+	Delay time.Duration
+}
+
+// NewDemoProvider creates a demo provider with a short simulated latency.
+func NewDemoProvider() *DemoProvider {
+	return &DemoProvider{Delay: 400 * time.Millisecond}
+}
+
+// GetName returns the provider's name.
+func (p *DemoProvider) GetName() string {
+	return "demo"
+}
+
+// GetDefaultVoice returns the provider's only voice.
+func (p *DemoProvider) GetDefaultVoice() string {
+	return "demo"
+}
+
+// GetSupportedFormats returns the audio formats supported by this provider.
+func (p *DemoProvider) GetSupportedFormats() []string {
+	return []string{"mp3"}
+}
+
+// ValidateConfig always succeeds, the demo provider needs no configuration.
+func (p *DemoProvider) ValidateConfig() error {
+	return nil
+}
+
+// GetSpeechMarkTypes returns nil, the demo provider reports no timing.
+func (p *DemoProvider) GetSpeechMarkTypes() []string {
+	return nil
+}
+
+// ListVoices returns the demo provider's only voice.
+func (p *DemoProvider) ListVoices(ctx context.Context) ([]VoiceInfo, error) {
+	return []VoiceInfo{{Name: "demo", DisplayName: "Demo (silent)", Provider: "demo"}}, nil
+}
+
+// GetMaxTokensPerChunk returns the maximum tokens per request for this provider.
+func (p *DemoProvider) GetMaxTokensPerChunk() int {
+	return DefaultTokenLimit
+}
+
+// CheckAuth always succeeds.
+func (p *DemoProvider) CheckAuth(ctx context.Context) error {
+	return nil
+}
+
+// GenerateSpeech returns silence of the estimated reading time of the text.
+func (p *DemoProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	select {
+	case <-time.After(p.Delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return audio.SilentMP3(EstimateDuration(PlainText(req.Text), req.Speed)), nil
+}
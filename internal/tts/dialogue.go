@@ -0,0 +1,170 @@
+package tts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// speakerLineRegex matches a dialogue turn such as "Anna: Hallo!" or "Dr. Ben Weber: Hi".
+var speakerLineRegex = regexp.MustCompile(`^[ \t]*([\p{Lu}][\p{L}\p{N}.'\- ]{0,30}?)[ \t]*:[ \t]+(\S.*)$`)
+
+// openAIDialogueVoices are assigned in order to speakers without a configured voice.
+var openAIDialogueVoices = []string{"alloy", "onyx", "nova", "echo", "shimmer", "fable", "coral", "ash", "sage", "ballad"}
+
+// googleDialogueVoiceNames are Chirp3-HD voice names combined with the selected voice's locale.
+var googleDialogueVoiceNames = []string{"Kore", "Charon", "Aoede", "Puck", "Leda", "Fenrir"}
+
+// Turn is one speaker's contribution to a dialogue script.
+type Turn struct {
+	Speaker string
+	Text    string
+}
+
+// ParseDialogue splits a script of "Name: text" lines into turns (see SplitTurns).
+// It returns nil unless the text has at least two speakers, so ordinary prose is
+// left alone.
+func ParseDialogue(text string) []Turn {
+	turns := SplitTurns(text)
+	speakers := map[string]bool{}
+	for _, t := range turns {
+		if t.Speaker != "" {
+			speakers[t.Speaker] = true
+		}
+	}
+	if len(speakers) < 2 {
+		return nil
+	}
+	return turns
+}
+
+// SplitTurns splits text at "Name: text" lines. Lines without a speaker continue
+// the previous turn; lines before the first turn (a title or introduction) become
+// a turn without speaker.
+func SplitTurns(text string) []Turn {
+	var turns []Turn
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if m := speakerLineRegex.FindStringSubmatch(line); m != nil {
+			turns = append(turns, Turn{Speaker: strings.TrimSpace(m[1]), Text: strings.TrimSpace(m[2])})
+			continue
+		}
+		if len(turns) == 0 {
+			turns = append(turns, Turn{Text: strings.TrimSpace(line)})
+			continue
+		}
+		turns[len(turns)-1].Text += "\n" + strings.TrimSpace(line)
+	}
+	return turns
+}
+
+// SpeakerVoices hands out one voice per speaker. Speakers with a configured voice
+// use it; the first other speaker keeps the default voice and the rest get unused
+// voices from the pool. Narration without a speaker uses the default voice.
+type SpeakerVoices struct {
+	configured   map[string]string
+	defaultVoice string
+	pool         []string
+	assigned     map[string]string
+	used         map[string]bool
+	next         int
+}
+
+// NewSpeakerVoices creates an assigner from configured speaker voices and a pool.
+func NewSpeakerVoices(configured map[string]string, defaultVoice string, pool []string) *SpeakerVoices {
+	a := &SpeakerVoices{
+		configured:   configured,
+		defaultVoice: defaultVoice,
+		pool:         pool,
+		assigned:     map[string]string{},
+		used:         map[string]bool{},
+	}
+	for _, v := range configured {
+		a.used[v] = true
+	}
+	return a
+}
+
+// Voice returns the voice of speaker, assigning one on first use.
+func (a *SpeakerVoices) Voice(speaker string) string {
+	if speaker == "" {
+		return a.defaultVoice
+	}
+	if v, ok := a.assigned[speaker]; ok {
+		return v
+	}
+	v := a.configured[speaker]
+	if v == "" && !a.used[a.defaultVoice] {
+		v = a.defaultVoice
+	}
+	for v == "" && a.next < len(a.pool) {
+		if !a.used[a.pool[a.next]] {
+			v = a.pool[a.next]
+		}
+		a.next++
+	}
+	if v == "" {
+		v = a.defaultVoice // more speakers than voices
+	}
+	a.used[v] = true
+	a.assigned[speaker] = v
+	return v
+}
+
+// DialogueSegments returns one segment per turn, voiced by voices.
+func DialogueSegments(turns []Turn, voices *SpeakerVoices) []Segment {
+	segments := make([]Segment, 0, len(turns))
+	for _, t := range turns {
+		segments = append(segments, Segment{Text: t.Text, Voice: voices.Voice(t.Speaker), Speaker: t.Speaker})
+	}
+	return segments
+}
+
+// DialogueVoicePool returns the voices handed out to unmapped speakers for provider.
+func DialogueVoicePool(providerName, selectedVoice string) []string {
+	if providerName != "google" {
+		return openAIDialogueVoices
+	}
+	locale := LanguageCodeForVoice(selectedVoice)
+	if locale == "" {
+		locale = "en-US"
+	}
+	pool := make([]string, len(googleDialogueVoiceNames))
+	for i, name := range googleDialogueVoiceNames {
+		pool[i] = locale + "-Chirp3-HD-" + name
+	}
+	return pool
+}
+
+// ParseSpeakerVoices reads "Name = voice" lines.
+func ParseSpeakerVoices(s string) map[string]string {
+	voices := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		name, voice, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name, voice = strings.TrimSpace(name), strings.TrimSpace(voice)
+		if name != "" && voice != "" {
+			voices[name] = voice
+		}
+	}
+	return voices
+}
+
+// FormatSpeakerVoices renders voices as sorted "Name = voice" lines.
+func FormatSpeakerVoices(voices map[string]string) string {
+	names := make([]string, 0, len(voices))
+	for name := range voices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s = %s\n", name, voices[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package tts
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"easy-tts/internal/preprocess"
+)
+
+// SpokenCharsPerSecond is the typical narration rate at speed 1.0, used for duration estimates.
+const SpokenCharsPerSecond = 14.0
+
+// languageCharsPerSecond are the narration rates of languages that differ from
+// SpokenCharsPerSecond: German packs more letters into each syllable.
+var languageCharsPerSecond = map[string]float64{"de": 15.5}
+
+// detectSampleSize is how much of a text EstimateSpokenDuration detects its language from.
+const detectSampleSize = 16 << 10
+
+// EstimateDuration predicts how long text takes to speak at the given speed.
+func EstimateDuration(text string, speed float64) time.Duration {
+	if speed <= 0 {
+		speed = 1.0
+	}
+	seconds := float64(utf8.RuneCountInString(text)) / SpokenCharsPerSecond / speed
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// EstimateSpokenDuration is EstimateDuration for text in language, a base
+// language such as "de", at its own narration rate. An empty language is
+// detected from the start of the text.
+func EstimateSpokenDuration(text, language string, speed float64) time.Duration {
+	if language == "" {
+		language = preprocess.DetectLanguage(text[:min(len(text), detectSampleSize)])
+	}
+	rate, ok := languageCharsPerSecond[language]
+	if !ok {
+		return EstimateDuration(text, speed)
+	}
+	if speed <= 0 {
+		speed = 1.0
+	}
+	seconds := float64(utf8.RuneCountInString(text)) / rate / speed
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// isSuspiciouslyShort reports whether audio of the given duration is implausibly short for text,
+// which usually means the provider silently truncated its output.
+func isSuspiciouslyShort(text string, speed float64, duration time.Duration) bool {
+	if utf8.RuneCountInString(text) < 40 {
+		return false // Short snippets vary too much to judge
+	}
+	return duration < EstimateDuration(text, speed)/4
+}
+
+// JobEstimate is the result of a dry run: how a job would be chunked for a
+// provider and roughly how long and expensive it would be.
+type JobEstimate struct {
+	Chunks    int
+	Tokens    int // cl100k_base tokens, as OpenAI counts them
+	Chars     int
+	Duration  time.Duration // predicted audio length, pauses included
+	Cost      float64       // estimated list price in USD
+	CostKnown bool          // false if the price of any voice is unknown
+}
+
+// EstimateJob chunks segments as the processor would for provider and predicts
+// the size, audio duration and cost of the job. It makes no provider requests.
+// request supplies the voice, model and speed of segments that do not set them.
+func EstimateJob(provider Provider, request *UnifiedRequest, segments []Segment, chunkLimit int) JobEstimate {
+	limit, measure := ChunkLimit(provider, chunkLimit)
+	tokens := TokenMeasure()
+	e := JobEstimate{CostKnown: true}
+	for _, seg := range segments {
+		voice, speed := request.Voice, request.Speed
+		if seg.Voice != "" {
+			voice = seg.Voice
+		}
+		if seg.Speed > 0 {
+			speed = seg.Speed
+		}
+		chars := utf8.RuneCountInString(seg.Text)
+		duration := EstimateSpokenDuration(seg.Text, seg.Language, speed)
+		e.Chunks += len(SplitText(seg.Text, limit, measure))
+		e.Tokens += tokens(seg.Text)
+		e.Chars += chars
+		e.Duration += duration + seg.PauseBefore + seg.PauseAfter
+		usd, ok := EstimateCost(provider.GetName(), request.Model, voice, chars, duration)
+		e.Cost += usd
+		e.CostKnown = e.CostKnown && ok
+	}
+	return e
+}
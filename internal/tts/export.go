@@ -0,0 +1,196 @@
+package tts
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JobSummary describes a finished job for exported reports.
+type JobSummary struct {
+	Title      string
+	Provider   string
+	Voice      string
+	Model      string
+	Speed      float64
+	Format     string
+	OutputPath string
+	Started    time.Time
+	Finished   time.Time
+	Settings   map[string]string // Other settings worth recording, e.g. enabled preprocessing stages
+}
+
+// reportData is the view model shared by the Markdown and HTML reports.
+type reportData struct {
+	Job      JobSummary
+	Duration string
+	Elapsed  string
+	Chars    int
+	Cost     string
+	QA       *QASummary
+	Chunks   []reportChunk
+	Failed   int
+	Flagged  int
+	Settings [][2]string
+}
+
+type reportChunk struct {
+	Number   int
+	Voice    string
+	Duration string
+	Attempts int
+	Status   string
+	Excerpt  string
+}
+
+func newReportData(job JobSummary, report *Report, qa *QASummary) reportData {
+	d := reportData{Job: job, QA: qa, Elapsed: formatDuration(job.Finished.Sub(job.Started))}
+	var total time.Duration
+	for _, c := range report.Chunks {
+		total += c.Duration
+		d.Chars += utf8.RuneCountInString(c.Text)
+		status := "ok"
+		switch {
+		case c.Error != "":
+			status = "failed: " + c.Error
+			d.Failed++
+		case len(c.Flags) > 0:
+			status = strings.Join(c.Flags, ", ")
+			d.Flagged++
+		}
+		d.Chunks = append(d.Chunks, reportChunk{
+			Number:   c.Index + 1,
+			Voice:    c.Voice,
+			Duration: formatDuration(c.Duration),
+			Attempts: c.Attempts,
+			Status:   status,
+			Excerpt:  excerpt(c.Text, 60),
+		})
+	}
+	d.Duration = formatDuration(total)
+	d.Cost = "unknown"
+	if usd, ok := EstimateCost(job.Provider, job.Model, job.Voice, d.Chars, total); ok {
+		d.Cost = fmt.Sprintf("~$%.2f", usd)
+		if usd < 0.01 {
+			d.Cost = "<$0.01"
+		}
+	}
+	keys := make([]string, 0, len(job.Settings))
+	for k := range job.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		d.Settings = append(d.Settings, [2]string{k, job.Settings[k]})
+	}
+	return d
+}
+
+// WriteMarkdownReport writes a human-readable Markdown report of a job.
+func WriteMarkdownReport(w io.Writer, job JobSummary, report *Report, qa *QASummary) error {
+	d := newReportData(job, report, qa)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversion report: %s\n\n", d.Job.Title)
+	fmt.Fprintf(&b, "| | |\n|---|---|\n")
+	for _, row := range d.summaryRows() {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], mdCell(row[1]))
+	}
+	if d.QA != nil {
+		fmt.Fprintf(&b, "\n## Quality check: %s\n\n", d.QA.Status)
+		for _, c := range d.QA.Checks {
+			fmt.Fprintf(&b, "- **%s** %s: %s\n", c.Status, c.Name, c.Detail)
+		}
+	}
+	fmt.Fprintf(&b, "\n## Chunks\n\n| # | Voice | Duration | Attempts | Status | Text |\n|---|---|---|---|---|---|\n")
+	for _, c := range d.Chunks {
+		fmt.Fprintf(&b, "| %d | %s | %s | %d | %s | %s |\n", c.Number, mdCell(c.Voice), c.Duration, c.Attempts, mdCell(c.Status), mdCell(c.Excerpt))
+	}
+	if len(d.Settings) > 0 {
+		fmt.Fprintf(&b, "\n## Settings\n\n")
+		for _, s := range d.Settings {
+			fmt.Fprintf(&b, "- %s: %s\n", s[0], s[1])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTMLReport writes a self-contained HTML report of a job.
+func WriteHTMLReport(w io.Writer, job JobSummary, report *Report, qa *QASummary) error {
+	d := newReportData(job, report, qa)
+	return htmlReportTemplate.Execute(w, struct {
+		reportData
+		Summary [][2]string
+	}{d, d.summaryRows()})
+}
+
+func (d reportData) summaryRows() [][2]string {
+	return [][2]string{
+		{"Provider", d.Job.Provider},
+		{"Voice", d.Job.Voice},
+		{"Model", d.Job.Model},
+		{"Speed", fmt.Sprintf("%.2f", d.Job.Speed)},
+		{"Format", d.Job.Format},
+		{"Output", d.Job.OutputPath},
+		{"Started", d.Job.Started.Format("2006-01-02 15:04:05")},
+		{"Processing time", d.Elapsed},
+		{"Audio duration", d.Duration},
+		{"Characters", fmt.Sprintf("%d", d.Chars)},
+		{"Chunks", fmt.Sprintf("%d (%d failed, %d flagged)", len(d.Chunks), d.Failed, d.Flagged)},
+		{"Estimated cost", d.Cost},
+	}
+}
+
+// mdCell escapes text for a Markdown table cell.
+func mdCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+// excerpt shortens text to at most n runes on one line.
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n]) + "…"
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Conversion report: {{.Job.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+.WARN { color: #b35c00; font-weight: bold; }
+.PASS { color: #2a7a2a; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Conversion report: {{.Job.Title}}</h1>
+<table>
+{{range .Summary}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{with .QA}}<h2>Quality check: <span class="{{.Status}}">{{.Status}}</span></h2>
+<ul>
+{{range .Checks}}<li><span class="{{.Status}}">{{.Status}}</span> {{.Name}}: {{.Detail}}</li>
+{{end}}</ul>
+{{end}}<h2>Chunks</h2>
+<table>
+<tr><th>#</th><th>Voice</th><th>Duration</th><th>Attempts</th><th>Status</th><th>Text</th></tr>
+{{range .Chunks}}<tr><td>{{.Number}}</td><td>{{.Voice}}</td><td>{{.Duration}}</td><td>{{.Attempts}}</td><td>{{.Status}}</td><td>{{.Excerpt}}</td></tr>
+{{end}}</table>
+{{if .Settings}}<h2>Settings</h2>
+<ul>
+{{range .Settings}}<li>{{index . 0}}: {{index . 1}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,110 @@
+package tts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"easy-tts/internal/audio"
+)
+
+// Error types of failed chunks in the failed-chunk export.
+const (
+	ErrorTypeQuota        = "quota"
+	ErrorTypeRateLimit    = "rate-limit"
+	ErrorTypeTimeout      = "timeout"
+	ErrorTypeInvalidAudio = "invalid-audio"
+	ErrorTypeAuth         = "auth"
+	ErrorTypeRequest      = "request"
+	ErrorTypeOther        = "other"
+)
+
+// FailedChunk is one failed chunk in the failed-chunk export, with everything a
+// script needs to synthesize it again.
+type FailedChunk struct {
+	Index     int     `json:"index"` // 0-based position in the job
+	Text      string  `json:"text"`
+	Voice     string  `json:"voice"`
+	Speaker   string  `json:"speaker,omitempty"`
+	Error     string  `json:"error"`
+	ErrorType string  `json:"error_type"`
+	Attempts  int     `json:"attempts"`
+	Offset    int     `json:"offset"`                 // Byte offset in the output where the audio belongs
+	Filler    int     `json:"filler_bytes,omitempty"` // Bytes of substitute audio at Offset to replace
+	Start     float64 `json:"start_s"`                // Approximate playback position in seconds
+}
+
+// FailedChunks lists the failed chunks of report for export.
+func FailedChunks(report *Report) []FailedChunk {
+	var out []FailedChunk
+	for _, c := range report.Failed() {
+		out = append(out, FailedChunk{
+			Index:     c.Index,
+			Text:      c.Text,
+			Voice:     c.Voice,
+			Speaker:   c.Speaker,
+			Error:     c.Error,
+			ErrorType: ErrorType(errors.New(c.Error)),
+			Attempts:  c.Attempts,
+			Offset:    c.Offset,
+			Filler:    c.Filler,
+			Start:     c.Start.Seconds(),
+		})
+	}
+	return out
+}
+
+// ErrorType classifies a synthesis error into one of the ErrorType constants.
+func ErrorType(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, ErrQuotaExhausted) || isDailyQuotaError(err):
+		return ErrorTypeQuota
+	case errors.Is(err, audio.ErrInvalidAudio) || strings.Contains(msg, "invalid audio"):
+		return ErrorTypeInvalidAudio
+	case strings.Contains(msg, "deadline") || strings.Contains(msg, "timeout"):
+		return ErrorTypeTimeout
+	case strings.Contains(msg, "429") || strings.Contains(msg, "rate limit") || strings.Contains(msg, "rate_limit") ||
+		strings.Contains(msg, "quota") || strings.Contains(msg, "resource_exhausted") || strings.Contains(msg, "resourceexhausted"):
+		// Not just "rate", which "generated" contains
+		return ErrorTypeRateLimit
+	case strings.Contains(msg, "401") || strings.Contains(msg, "403") || strings.Contains(msg, "unauthenticated") || strings.Contains(msg, "permission"):
+		return ErrorTypeAuth
+	case strings.Contains(msg, "400") || strings.Contains(msg, "invalid_argument") || strings.Contains(msg, "invalid argument"):
+		return ErrorTypeRequest
+	}
+	return ErrorTypeOther
+}
+
+// WriteFailedJSON writes the failed chunks of a job as JSON.
+func WriteFailedJSON(w io.Writer, job JobSummary, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Title    string        `json:"title"`
+		Provider string        `json:"provider"`
+		Model    string        `json:"model,omitempty"`
+		Speed    float64       `json:"speed"`
+		Format   string        `json:"format"`
+		Output   string        `json:"output"`
+		Chunks   int           `json:"chunks"`
+		Failed   []FailedChunk `json:"failed"`
+	}{job.Title, job.Provider, job.Model, job.Speed, job.Format, job.OutputPath, len(report.Chunks), FailedChunks(report)})
+}
+
+// WriteFailedCSV writes the failed chunks of a job as CSV with a header row.
+func WriteFailedCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"index", "voice", "speaker", "error_type", "attempts", "offset", "filler_bytes", "start_s", "error", "text"})
+	for _, c := range FailedChunks(report) {
+		cw.Write([]string{
+			strconv.Itoa(c.Index), c.Voice, c.Speaker, c.ErrorType, strconv.Itoa(c.Attempts),
+			strconv.Itoa(c.Offset), strconv.Itoa(c.Filler), strconv.FormatFloat(c.Start, 'f', 3, 64), c.Error, c.Text,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
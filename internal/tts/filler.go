@@ -0,0 +1,75 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"easy-tts/internal/audio"
+)
+
+// Failed-section policies: what takes the place of a chunk that could not be
+// synthesized.
+const (
+	FailedPhrase  = "phrase"  // speak ProcessorConfig.FailedPhrase in the chunk's voice
+	FailedSilence = "silence" // a second of silence
+	FailedBeep    = "beep"    // a short tone, for WAV output; silence otherwise
+	FailedSkip    = "skip"    // leave the chunk out
+	FailedAbort   = "abort"   // stop the job, keeping the audio so far
+)
+
+// FailedPolicies lists the failed-section policies in the order offered to the user.
+var FailedPolicies = []string{FailedPhrase, FailedSilence, FailedBeep, FailedSkip, FailedAbort}
+
+// DefaultFailedPhrase is spoken in place of a failed section unless configured otherwise.
+const DefaultFailedPhrase = "Error converting text. Continuing."
+
+// fillerDuration is the length of the silence or beep in place of a failed section.
+const fillerDuration = time.Second
+
+// ErrSectionFailed is returned by ProcessSegments when a section could not be
+// synthesized and the policy is FailedAbort.
+var ErrSectionFailed = errors.New("a section could not be synthesized")
+
+// failedSection applies cfg's policy to a chunk that failed with err. It returns
+// the audio to put in its place, nil to leave it out, or an error to stop the
+// job. like is the audio of the job so far, whose format the filler copies. The
+// filler's duration is recorded in result and the chunk is flagged as substituted.
+func failedSection(ctx context.Context, provider Provider, request *UnifiedRequest, cfg *ProcessorConfig, like []byte, result *ChunkResult, err error) ([]byte, error) {
+	var data []byte
+	switch cfg.FailedSection {
+	case FailedAbort:
+		return nil, fmt.Errorf("%w: %v", ErrSectionFailed, err)
+	case FailedSkip:
+		return nil, nil
+	case FailedBeep:
+		if data, err = audio.Beep(like, fillerDuration); err != nil {
+			logger(ctx).Debug("No beep for the failed chunk, inserting silence", "err", err)
+		}
+	case FailedPhrase, "":
+		phrase := cfg.FailedPhrase
+		if phrase == "" {
+			phrase = DefaultFailedPhrase
+		}
+		if data, err = generateChunk(ctx, provider, request, result, phrase, request.Voice); err != nil {
+			logger(ctx).Warn("Failed to speak the substitute phrase, inserting silence", "err", err)
+		}
+	}
+	if data == nil {
+		if len(like) > 0 {
+			data, _ = audio.Silence(like, fillerDuration)
+		} else if audio.NormalizeFormat(request.Format) == "mp3" {
+			data = audio.SilentMP3(fillerDuration)
+		}
+		if len(data) == 0 {
+			logger(ctx).Warn("Leaving out the failed chunk, there is no silence for its format", "format", request.Format)
+			return nil, nil
+		}
+	}
+	if result.Duration == 0 {
+		result.Duration = fillerDuration
+	}
+	result.AddFlag(FlagSubstituted)
+	return data, nil
+}
@@ -3,21 +3,26 @@ package tts
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/googleapis/gax-go/v2/apierror"
 	"google.golang.org/api/option"
 
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	texttospeechpb "google.golang.org/genproto/googleapis/cloud/texttospeech/v1"
+	texttospeechbeta "google.golang.org/genproto/googleapis/cloud/texttospeech/v1beta1"
 )
 
 // GoogleProvider handles communication with the Google Cloud TTS API using the Go SDK.
 type GoogleProvider struct {
-	ProjectID  string
-	APIKey     string
-	AuthMethod string // "gcloud auth" or "API Key"
+	ProjectID   string
+	APIKey      string
+	AuthMethod  string // "gcloud auth", "API Key", "Service Account" or "Google Account"
+	Credentials string // Service account key file or its JSON; the signed-in account for "Google Account"
 
 	// Caches the client to avoid re-initializing on every request.
 	ttsClient  *texttospeech.Client
@@ -26,11 +31,12 @@ type GoogleProvider struct {
 }
 
 // NewGoogleProvider creates a new Google TTS provider.
-func NewGoogleProvider(projectID, apiKey, authMethod string) *GoogleProvider {
+func NewGoogleProvider(projectID, apiKey, authMethod, credentials string) *GoogleProvider {
 	return &GoogleProvider{
-		ProjectID:  projectID,
-		APIKey:     apiKey,
-		AuthMethod: authMethod,
+		ProjectID:   projectID,
+		APIKey:      apiKey,
+		AuthMethod:  authMethod,
+		Credentials: credentials,
 	}
 }
 
@@ -59,9 +65,21 @@ func (g *GoogleProvider) ValidateConfig() error {
 	if g.AuthMethod == "API Key" && g.APIKey == "" {
 		return fmt.Errorf("Google Cloud API key is required for API Key authentication")
 	}
+	if g.AuthMethod == "Service Account" && g.Credentials == "" {
+		return fmt.Errorf("Google Cloud service account key is required for Service Account authentication")
+	}
+	if g.AuthMethod == "Google Account" && g.Credentials == "" {
+		return fmt.Errorf("sign in to a Google account under Settings → Google Cloud for Google Account authentication")
+	}
 	return nil
 }
 
+// GetSpeechMarkTypes returns the speech marks Google reports: sentences, marked
+// in SSML. Chirp voices take no SSML and report none.
+func (g *GoogleProvider) GetSpeechMarkTypes() []string {
+	return []string{SpeechMarkSentence}
+}
+
 // GetMaxTokensPerChunk returns a value based on the byte limit.
 // Note: Google uses a byte/character limit, not tokens. This is an approximation.
 func (g *GoogleProvider) GetMaxTokensPerChunk() int {
@@ -71,14 +89,25 @@ func (g *GoogleProvider) GetMaxTokensPerChunk() int {
 // getClient initializes and returns a thread-safe, cached TTS client.
 func (g *GoogleProvider) getClient(ctx context.Context) (*texttospeech.Client, error) {
 	g.clientOnce.Do(func() {
-		log.Println("Initializing Google TTS client...")
+		logger(ctx).Info("Initializing Google TTS client")
 		var opts []option.ClientOption
 
 		if g.AuthMethod == "API Key" {
-			log.Println("Using API Key authentication.")
+			logger(ctx).Info("Using API key authentication")
 			opts = append(opts, option.WithAPIKey(g.APIKey))
+		} else if g.AuthMethod == "Service Account" {
+			logger(ctx).Info("Using service account authentication")
+			if strings.HasPrefix(strings.TrimSpace(g.Credentials), "{") {
+				opts = append(opts, option.WithCredentialsJSON([]byte(g.Credentials)))
+			} else {
+				opts = append(opts, option.WithCredentialsFile(g.Credentials))
+			}
+		} else if g.AuthMethod == "Google Account" {
+			// Requests of a user are billed to the project, which must allow it
+			logger(ctx).Info("Using the signed-in Google account")
+			opts = append(opts, option.WithCredentialsJSON([]byte(g.Credentials)), option.WithQuotaProject(g.ProjectID))
 		} else {
-			log.Println("Using Application Default Credentials (gcloud auth).")
+			logger(ctx).Info("Using Application Default Credentials (gcloud auth)")
 			// The SDK automatically uses ADC when no explicit credentials are provided.
 			// The project ID is not passed as an option here but is used in headers if needed.
 		}
@@ -86,11 +115,11 @@ func (g *GoogleProvider) getClient(ctx context.Context) (*texttospeech.Client, e
 		client, err := texttospeech.NewClient(ctx, opts...)
 		if err != nil {
 			g.clientErr = fmt.Errorf("failed to create Google TTS client: %w", err)
-			log.Printf("Google TTS client initialization failed: %v", g.clientErr)
+			logger(ctx).Error("Google TTS client initialization failed", "err", g.clientErr)
 			return
 		}
 		g.ttsClient = client
-		log.Println("Google TTS client initialized successfully.")
+		logger(ctx).Info("Google TTS client initialized")
 	})
 
 	return g.ttsClient, g.clientErr
@@ -121,6 +150,36 @@ func (g *GoogleProvider) CheckAuth(ctx context.Context) error {
 	return nil
 }
 
+// ListVoices returns the voices of Google Cloud Text-to-Speech, one entry per
+// voice under its first language.
+func (g *GoogleProvider) ListVoices(ctx context.Context) ([]VoiceInfo, error) {
+	client, err := g.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListVoices(ctx, &texttospeechpb.ListVoicesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	voices := make([]VoiceInfo, 0, len(resp.Voices))
+	for _, v := range resp.Voices {
+		info := VoiceInfo{Name: v.Name, DisplayName: v.Name, Provider: "google"}
+		if len(v.LanguageCodes) > 0 {
+			info.LanguageCode = v.LanguageCodes[0]
+		}
+		switch v.SsmlGender {
+		case texttospeechpb.SsmlVoiceGender_FEMALE:
+			info.Gender = "female"
+		case texttospeechpb.SsmlVoiceGender_MALE:
+			info.Gender = "male"
+		case texttospeechpb.SsmlVoiceGender_NEUTRAL:
+			info.Gender = "neutral"
+		}
+		voices = append(voices, info)
+	}
+	return voices, nil
+}
+
 // GenerateSpeech generates speech using the unified request format.
 func (g *GoogleProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
 	if err := g.ValidateConfig(); err != nil {
@@ -138,20 +197,40 @@ func (g *GoogleProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest
 	// Prepare the SDK-specific request.
 	ttsReq := &texttospeechpb.SynthesizeSpeechRequest{
 		Input: &texttospeechpb.SynthesisInput{
-			InputSource: &texttospeechpb.SynthesisInput_Text{Text: req.Text},
+			InputSource: &texttospeechpb.SynthesisInput_Text{Text: PlainText(req.Text)},
 		},
 		Voice: &texttospeechpb.VoiceSelectionParams{
 			LanguageCode: languageCode,
 			Name:         voiceName,
 		},
 		AudioConfig: &texttospeechpb.AudioConfig{
-			AudioEncoding: g.convertFormat(req.Format),
-			SpeakingRate:  req.Speed,
+			AudioEncoding:   g.convertFormat(req.Format),
+			SpeakingRate:    req.Speed,
+			SampleRateHertz: int32(req.SampleRate),
 		},
 	}
 
-	log.Printf("Sending request to Google TTS API for text: '%.30s...'", req.Text)
-	resp, err := client.SynthesizeSpeech(ctx, ttsReq)
+	// Chirp voices do not accept SSML, so they cannot mark sentences either
+	marked := false
+	if !strings.Contains(voiceName, "Chirp") {
+		if ssml := MarkedSSML(req.Text, languageCode, req.SayAs); req.SpeechMarks.Wants(SpeechMarkSentence) && len(ssml) <= maxSSMLBytes {
+			ttsReq.Input.InputSource = &texttospeechpb.SynthesisInput_Ssml{Ssml: ssml}
+			marked = true
+		} else if ssml, ok := SSML(req.Text, languageCode, req.SayAs); ok && len(ssml) <= maxSSMLBytes {
+			ttsReq.Input.InputSource = &texttospeechpb.SynthesisInput_Ssml{Ssml: ssml}
+		}
+	}
+
+	logger(ctx).Debug("Sending request to Google TTS API", "text", excerpt(req.Text, 30))
+	var audioContent []byte
+	if marked {
+		audioContent, err = g.synthesizeMarked(ctx, client, ttsReq, req)
+	} else {
+		var resp *texttospeechpb.SynthesizeSpeechResponse
+		if resp, err = client.SynthesizeSpeech(ctx, ttsReq); err == nil {
+			audioContent = resp.AudioContent
+		}
+	}
 	if err != nil {
 		// Try to log full error details if available
 		type causer interface{ Unwrap() error }
@@ -160,21 +239,73 @@ func (g *GoogleProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest
 			if unwrapped == nil {
 				break
 			}
-			log.Printf("Google TTS error (unwrap %d): %v", i, unwrapped)
+			logger(ctx).Debug("Google TTS error", "unwrap", i, "err", unwrapped)
 			if c, ok := unwrapped.(causer); ok {
 				unwrapped = c.Unwrap()
 			} else {
 				break
 			}
 		}
-		log.Printf("Google TTS SynthesizeSpeech failed: %v", err)
+		logger(ctx).Warn("Google TTS SynthesizeSpeech failed", "err", err)
+		if delay, ok := googleRetryDelay(err); ok {
+			return nil, &RetryAfterError{Err: fmt.Errorf("Google TTS API error: %w", err), Delay: delay}
+		}
 		return nil, fmt.Errorf("Google TTS API error: %w", err)
 	}
-	log.Printf("Successfully received audio data (len=%d)", len(resp.AudioContent))
+	logger(ctx).Debug("Received audio data", "bytes", len(audioContent))
+
+	return audioContent, nil
+}
 
+// synthesizeMarked sends ttsReq, whose SSML marks the sentences of req.Text,
+// through the v1beta1 API, the only one that reports when each mark is reached,
+// and records them as sentence marks in req.SpeechMarks.
+func (g *GoogleProvider) synthesizeMarked(ctx context.Context, client *texttospeech.Client, ttsReq *texttospeechpb.SynthesizeSpeechRequest, req *UnifiedRequest) ([]byte, error) {
+	beta := texttospeechbeta.NewTextToSpeechClient(client.Connection())
+	resp, err := beta.SynthesizeSpeech(ctx, &texttospeechbeta.SynthesizeSpeechRequest{
+		Input: &texttospeechbeta.SynthesisInput{
+			InputSource: &texttospeechbeta.SynthesisInput_Ssml{Ssml: ttsReq.Input.GetSsml()},
+		},
+		Voice: &texttospeechbeta.VoiceSelectionParams{
+			LanguageCode: ttsReq.Voice.LanguageCode,
+			Name:         ttsReq.Voice.Name,
+		},
+		AudioConfig: &texttospeechbeta.AudioConfig{
+			AudioEncoding:   texttospeechbeta.AudioEncoding(ttsReq.AudioConfig.AudioEncoding),
+			SpeakingRate:    ttsReq.AudioConfig.SpeakingRate,
+			SampleRateHertz: ttsReq.AudioConfig.SampleRateHertz,
+		},
+		EnableTimePointing: []texttospeechbeta.SynthesizeSpeechRequest_TimepointType{texttospeechbeta.SynthesizeSpeechRequest_SSML_MARK},
+	})
+	if err != nil {
+		return nil, err
+	}
+	spans := SentenceSpans(req.Text)
+	var marks []SpeechMark
+	for _, tp := range resp.Timepoints {
+		if i, err := strconv.Atoi(tp.MarkName); err == nil && i >= 0 && i < len(spans) {
+			marks = append(marks, SpeechMark{Type: SpeechMarkSentence, Start: spans[i][0], End: spans[i][1],
+				Time: time.Duration(tp.TimeSeconds * float64(time.Second))})
+		}
+	}
+	req.SpeechMarks.Text, req.SpeechMarks.Marks = req.Text, marks
 	return resp.AudioContent, nil
 }
 
+// googleRetryDelay returns the delay from the RetryInfo detail that Google attaches
+// to RESOURCE_EXHAUSTED errors.
+func googleRetryDelay(err error) (time.Duration, bool) {
+	apiErr, ok := apierror.FromError(err)
+	if !ok {
+		return 0, false
+	}
+	info := apiErr.Details().RetryInfo
+	if info == nil || info.GetRetryDelay() == nil {
+		return 0, false
+	}
+	return info.GetRetryDelay().AsDuration(), true
+}
+
 // parseVoice extracts language code and voice name from the voice string.
 // Example: "de-DE-Wavenet-F" -> "de-DE", "de-DE-Wavenet-F"
 func (g *GoogleProvider) parseVoice(voice string) (languageCode, voiceName string) {
@@ -206,7 +337,7 @@ func (g *GoogleProvider) convertFormat(format string) texttospeechpb.AudioEncodi
 	case "ALAW":
 		return texttospeechpb.AudioEncoding_ALAW
 	default:
-		log.Printf("Unsupported format '%s', defaulting to MP3.", format)
+		slog.Warn("Unsupported format, defaulting to MP3", "format", format)
 		return texttospeechpb.AudioEncoding_MP3
 	}
 }
@@ -0,0 +1,84 @@
+package tts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// Hooks let the GUI, a CLI or telemetry follow a job as it runs. Each is
+// optional and called on the job's goroutine, so it should return quickly.
+type Hooks struct {
+	OnChunkStart func(ChunkEvent) // before the first request for a chunk
+	OnChunkDone  func(ChunkEvent) // once the chunk has audio, a filler or an error
+	OnRetry      func(RetryEvent) // before waiting to repeat a failed request
+}
+
+// ChunkEvent describes a chunk of a job.
+type ChunkEvent struct {
+	JobID    string
+	Provider string
+	Index    int // the chunk's index in the report
+	Total    int // the job's chunks, an estimate until the last segment
+	Voice    string
+	Text     string
+	// Set for OnChunkDone only
+	Result  *ChunkResult  // the outcome; Error is set if the chunk failed
+	Elapsed time.Duration // time spent on the chunk, including retries
+}
+
+// RetryEvent describes a failed request that is about to be repeated.
+type RetryEvent struct {
+	JobID    string
+	Provider string
+	Index    int           // the chunk's index in the report
+	Attempt  int           // the attempt that failed, from 1
+	Delay    time.Duration // the wait before the next attempt
+	Err      error
+}
+
+// NewJobID returns a short random identifier that tells the log records and
+// events of concurrent jobs apart.
+func NewJobID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type loggerKey struct{}
+
+// withLogger returns ctx carrying l, the logger of the job or chunk ctx belongs to.
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// logger returns the logger carried by ctx, whose records have the fields of
+// its job (job_id, provider) and chunk (chunk_index), or slog.Default().
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// jobContext returns ctx carrying the job's logger. A job without an ID is
+// given one in a copy of cfg, so hook events and log records agree.
+func jobContext(ctx context.Context, provider Provider, cfg *ProcessorConfig) (context.Context, *ProcessorConfig) {
+	if cfg.JobID == "" {
+		c := *cfg
+		c.JobID = NewJobID()
+		cfg = &c
+	}
+	l := cfg.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	return withLogger(ctx, l.With("job_id", cfg.JobID, "provider", provider.GetName())), cfg
+}
+
+// chunkContext returns ctx carrying a logger for chunk index of its job.
+func chunkContext(ctx context.Context, index int) context.Context {
+	return withLogger(ctx, logger(ctx).With("chunk_index", index))
+}
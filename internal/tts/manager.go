@@ -3,7 +3,10 @@ package tts
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+
+	"easy-tts/internal/audio"
 )
 
 // Manager handles multiple TTS providers and provides a unified interface.
@@ -31,6 +34,9 @@ func (m *Manager) initializeProviders() {
 	// Initialize OpenAI provider if API key is available
 	if m.config.OpenAIAPIKey != "" {
 		openaiProvider := NewOpenAIProvider(m.config.OpenAIAPIKey)
+		openaiProvider.BaseURL = m.config.OpenAIBaseURL
+		openaiProvider.Organization = m.config.OpenAIOrganization
+		openaiProvider.Project = m.config.OpenAIProject
 		m.providers["openai"] = openaiProvider
 	}
 
@@ -40,7 +46,7 @@ func (m *Manager) initializeProviders() {
 		if authMethod == "" {
 			authMethod = "gcloud auth" // Default to gcloud auth
 		}
-		googleProvider := NewGoogleProvider(m.config.GoogleProjectID, m.config.GoogleAPIKey, authMethod)
+		googleProvider := NewGoogleProvider(m.config.GoogleProjectID, m.config.GoogleAPIKey, authMethod, m.config.GoogleCredentials)
 		m.providers["google"] = googleProvider
 	}
 
@@ -56,6 +62,15 @@ func (m *Manager) initializeProviders() {
 	}
 }
 
+// LimitRate spaces the requests to each configured provider to perMinute,
+// counted over all jobs together, see Limiter. Call it before the providers
+// are used.
+func (m *Manager) LimitRate(perMinute int) {
+	for name, provider := range m.providers {
+		m.providers[name] = RateLimited(provider, NewLimiter(perMinute))
+	}
+}
+
 // GetProvider returns a specific provider by name.
 func (m *Manager) GetProvider(name string) (Provider, error) {
 	provider, exists := m.providers[name]
@@ -68,11 +83,7 @@ func (m *Manager) GetProvider(name string) (Provider, error) {
 
 // ChunkText splits the input text into chunks based on the provider's token limit.
 func (m *Manager) ChunkText(text string, provider Provider) []string {
-	if provider.GetName() == "google" {
-		return SplitTextByteLimit(text, DefaultByteLimit)
-	}
-	maxTokens := provider.GetMaxTokensPerChunk()
-	return SplitTextTokenLimit(text, "cl100k_base", maxTokens)
+	return SplitIntoChunks(provider, text)
 }
 
 // GetDefaultProvider returns the default provider.
@@ -83,6 +94,11 @@ func (m *Manager) GetDefaultProvider() (Provider, error) {
 	return m.GetProvider(m.defaultProvider)
 }
 
+// EnableDemo adds the offline demo provider, which needs no credentials.
+func (m *Manager) EnableDemo() {
+	m.providers["demo"] = NewDemoProvider()
+}
+
 // SetDefaultProvider sets the default provider.
 func (m *Manager) SetDefaultProvider(name string) error {
 	if _, exists := m.providers[name]; !exists {
@@ -158,11 +174,15 @@ func (m *Manager) GenerateSpeech(ctx context.Context, req *UnifiedRequest, provi
 		return nil, err
 	}
 
-	return &UnifiedResponse{
+	response := &UnifiedResponse{
 		AudioData: audioData,
 		Format:    req.Format,
 		Provider:  provider.GetName(),
-	}, nil
+	}
+	if req.SpeechMarks != nil && req.SpeechMarks.Text == req.Text {
+		response.SpeechMarks = req.SpeechMarks.Marks
+	}
+	return response, nil
 }
 
 // ValidateProvider checks if a provider is properly configured.
@@ -174,25 +194,42 @@ func (m *Manager) ValidateProvider(name string) error {
 	return provider.ValidateConfig()
 }
 
-// GetVoicesForProvider returns available voices for a specific provider.
-// This is a placeholder for future implementation when we add voice discovery.
-func (m *Manager) GetVoicesForProvider(providerName string) ([]VoiceInfo, error) {
+// ListVoices returns the voices of a provider, sorted by language and name.
+func (m *Manager) ListVoices(ctx context.Context, providerName string) ([]VoiceInfo, error) {
 	provider, err := m.GetProvider(providerName)
 	if err != nil {
 		return nil, err
 	}
-
-	// For now, return the default voice
-	// In the future, we can implement API calls to get available voices
-	defaultVoice := VoiceInfo{
-		Name:         provider.GetDefaultVoice(),
-		DisplayName:  provider.GetDefaultVoice(),
-		LanguageCode: "en-US", // Default, should be provider-specific
-		Gender:       "neutral",
-		Provider:     provider.GetName(),
+	voices, err := provider.ListVoices(ctx)
+	if err != nil {
+		return nil, err
 	}
+	sort.SliceStable(voices, func(i, j int) bool {
+		if voices[i].LanguageCode != voices[j].LanguageCode {
+			return voices[i].LanguageCode < voices[j].LanguageCode
+		}
+		return voices[i].Name < voices[j].Name
+	})
+	return voices, nil
+}
 
-	return []VoiceInfo{defaultVoice}, nil
+// FilterVoices returns the voices speaking language, a code such as "de" or
+// "de-DE", and of gender; empty arguments match all. Voices that speak any
+// language match every language.
+func FilterVoices(voices []VoiceInfo, language, gender string) []VoiceInfo {
+	var matched []VoiceInfo
+	for _, v := range voices {
+		code := strings.ToLower(v.LanguageCode)
+		lang := strings.ToLower(language)
+		if language != "" && code != "" && code != lang && !strings.HasPrefix(code, lang+"-") {
+			continue
+		}
+		if gender != "" && !strings.EqualFold(v.Gender, gender) {
+			continue
+		}
+		matched = append(matched, v)
+	}
+	return matched
 }
 
 // UpdateConfig updates the provider configuration and reinitializes providers.
@@ -206,3 +243,16 @@ func (m *Manager) UpdateConfig(config *ProviderConfig) {
 func (m *Manager) GetConfig() *ProviderConfig {
 	return m.config
 }
+
+// FormatFor returns the name provider uses for the container of format, e.g.
+// "opus" for OpenAI and "ogg_opus" for Google when asked for Ogg. A format the
+// provider cannot produce is returned as it is.
+func FormatFor(provider Provider, format string) string {
+	container := audio.NormalizeFormat(format)
+	for _, f := range provider.GetSupportedFormats() {
+		if audio.NormalizeFormat(f) == container {
+			return f
+		}
+	}
+	return format
+}
@@ -0,0 +1,119 @@
+package tts
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inlineMarkerRegex matches "[pause 2s]", "[pause 500ms]", "[pause]" and
+// "{{key:value}}" / "{{/key}}" request overrides.
+var inlineMarkerRegex = regexp.MustCompile(`(?i)\[pause(?:[ \t]+(\d+(?:[.,]\d+)?)[ \t]*(ms|s)?)?\]|\{\{[ \t]*(/?)([a-z]+)[ \t]*(?::[ \t]*([^}]*?))?[ \t]*\}\}`)
+
+// defaultInlinePause is used for a bare "[pause]".
+const defaultInlinePause = time.Second
+
+// ApplyInlineMarkers translates inline markers into segment boundaries:
+//
+//	[pause 2s], [pause 500ms], [pause]   insert silence
+//	{{voice:en-US-Chirp3-HD-Kore}}       read the following text with another voice
+//	{{speed:1.25}}                       read the following text at another speed
+//	{{/voice}}, {{/speed}}               return to the segment's own setting
+//	{{ipa:Quacker|ˈkwækɚ}}               pronounce a word as written in IPA (kept for the provider)
+//	{{rate:90%}} … {{/rate}}             soften the speaking rate within a request (kept for the provider)
+//
+// Overrides last until they are reset, across segment boundaries. Unknown markers
+// are removed so they are not read aloud.
+func ApplyInlineMarkers(segments []Segment) []Segment {
+	var voice string
+	var speed float64
+	return ExpandSegments(segments, func(seg Segment) []Segment {
+		var out []Segment
+		var pending time.Duration
+		var text strings.Builder
+		flush := func() {
+			t := strings.TrimSpace(text.String())
+			text.Reset()
+			if t == "" {
+				return
+			}
+			part := seg
+			part.Text, part.PauseBefore, part.PauseAfter, part.NewFile, part.Heading = t, pending, 0, false, ""
+			if voice != "" {
+				part.Voice = voice
+			}
+			if speed > 0 {
+				part.Speed = speed
+			}
+			out = append(out, part)
+			pending = 0
+		}
+
+		pos := 0
+		for _, m := range inlineMarkerRegex.FindAllStringSubmatchIndex(seg.Text, -1) {
+			text.WriteString(seg.Text[pos:m[0]])
+			pos = m[1]
+			group := func(i int) string {
+				if m[2*i] < 0 {
+					return ""
+				}
+				return seg.Text[m[2*i]:m[2*i+1]]
+			}
+			if strings.HasPrefix(strings.ToLower(seg.Text[m[0]:m[1]]), "[pause") {
+				flush()
+				pending += parsePause(group(1), group(2))
+				continue
+			}
+			reset, key, value := group(3) == "/", strings.ToLower(group(4)), strings.TrimSpace(group(5))
+			switch key {
+			case "voice":
+				flush()
+				voice = ""
+				if !reset && !strings.EqualFold(value, "default") {
+					voice = value
+				}
+			case "speed":
+				flush()
+				speed = 0
+				if !reset {
+					if v, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64); err == nil && v > 0 {
+						speed = v
+					}
+				}
+			case "ipa", "rate":
+				// Phoneme and prosody hints are rendered by the provider, see SSML
+				text.WriteString(seg.Text[m[0]:m[1]])
+			default:
+				slog.Warn("Ignoring unknown inline marker", "marker", seg.Text[m[0]:m[1]])
+			}
+		}
+		text.WriteString(seg.Text[pos:])
+		flush()
+
+		if pending > 0 {
+			if len(out) > 0 {
+				out[len(out)-1].PauseAfter += pending
+			} else {
+				out = append(out, Segment{Voice: seg.Voice, PauseBefore: pending})
+			}
+		}
+		return out
+	})
+}
+
+// parsePause converts the number and unit of a pause marker into a duration.
+func parsePause(number, unit string) time.Duration {
+	if number == "" {
+		return defaultInlinePause
+	}
+	v, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil {
+		return defaultInlinePause
+	}
+	if strings.EqualFold(unit, "ms") {
+		return time.Duration(v * float64(time.Millisecond))
+	}
+	return time.Duration(v * float64(time.Second))
+}
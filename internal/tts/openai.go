@@ -7,14 +7,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
-const openAIAPIURL = "https://api.openai.com/v1/audio/speech"
+// DefaultOpenAIBaseURL is the OpenAI API, which gateways may stand in for.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
 
 // OpenAIProvider handles communication with the OpenAI TTS API.
 type OpenAIProvider struct {
 	APIKey     string
 	HTTPClient *http.Client
+
+	// BaseURL is the API, DefaultOpenAIBaseURL if empty, or a gateway speaking
+	// it. A URL with an api-version parameter, as Azure OpenAI has, gets the
+	// key in an "api-key" header.
+	BaseURL string
+	// Organization and Project select what requests are billed to.
+	Organization string
+	Project      string
 }
 
 // NewOpenAIProvider creates a new OpenAI TTS provider.
@@ -45,9 +57,73 @@ func (p *OpenAIProvider) ValidateConfig() error {
 	if p.APIKey == "" {
 		return fmt.Errorf("OpenAI API key is required")
 	}
+	if p.BaseURL != "" {
+		if err := ValidateOpenAIBaseURL(p.BaseURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateOpenAIBaseURL checks a base URL of the OpenAI API.
+func ValidateOpenAIBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("OpenAI base URL %q must be an http or https URL", baseURL)
+	}
 	return nil
 }
 
+// newRequest returns a request to the API endpoint path, e.g. "/models",
+// with the credentials and billing headers set.
+func (p *OpenAIProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = DefaultOpenAIBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	// The path goes before the query of the base URL, e.g. an Azure api-version
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if u.Query().Has("api-version") {
+		req.Header.Set("api-key", p.APIKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	if p.Organization != "" {
+		req.Header.Set("OpenAI-Organization", p.Organization)
+	}
+	if p.Project != "" {
+		req.Header.Set("OpenAI-Project", p.Project)
+	}
+	return req, nil
+}
+
+// GetSpeechMarkTypes returns nil, the OpenAI API reports no timing.
+func (p *OpenAIProvider) GetSpeechMarkTypes() []string {
+	return nil
+}
+
+// openAIVoices are the voices of the OpenAI speech API. All of them speak every
+// supported language.
+var openAIVoices = []string{"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse"}
+
+// ListVoices returns the OpenAI voices. The API has no endpoint listing them, so
+// the list is built in.
+func (p *OpenAIProvider) ListVoices(ctx context.Context) ([]VoiceInfo, error) {
+	voices := make([]VoiceInfo, len(openAIVoices))
+	for i, name := range openAIVoices {
+		voices[i] = VoiceInfo{Name: name, DisplayName: strings.ToUpper(name[:1]) + name[1:], Provider: "openai"}
+	}
+	return voices, nil
+}
+
 // GetMaxTokensPerChunk returns the maximum tokens per request for this provider.
 func (p *OpenAIProvider) GetMaxTokensPerChunk() int {
 	return DefaultTokenLimit
@@ -55,11 +131,10 @@ func (p *OpenAIProvider) GetMaxTokensPerChunk() int {
 
 // CheckAuth verifies that the OpenAI API key is valid by making a lightweight request.
 func (p *OpenAIProvider) CheckAuth(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.openai.com/v1/models", nil)
+	req, err := p.newRequest(ctx, "GET", "/models", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create auth request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
 
 	resp, err := p.HTTPClient.Do(req)
 	if err != nil {
@@ -83,26 +158,28 @@ func (p *OpenAIProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest
 		"model":           req.Model,
 		"voice":           req.Voice,
 		"speed":           req.Speed,
-		"input":           req.Text,
+		"input":           PlainText(req.Text),
 		"response_format": req.Format,
 	}
 	if payload["model"] == "" {
-		payload["model"] = "gpt-4o-mini-tts"
+		payload["model"] = DefaultModel("openai")
 	}
 	if payload["response_format"] == "" {
 		payload["response_format"] = "mp3"
 	}
+	if instructions := styledInstructions(req.Instructions, req.Style); instructions != "" && acceptsInstructions(payload["model"].(string)) {
+		payload["instructions"] = instructions
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request payload: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", openAIAPIURL, bytes.NewReader(body))
+	httpReq, err := p.newRequest(ctx, "POST", "/audio/speech", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.HTTPClient.Do(httpReq)
@@ -126,6 +203,11 @@ func (p *OpenAIProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest
 				errMsg += "\n" + string(respBody)
 			}
 		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if delay, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+				return nil, &RetryAfterError{Err: fmt.Errorf(errMsg), Delay: delay}
+			}
+		}
 		return nil, fmt.Errorf(errMsg)
 	}
 
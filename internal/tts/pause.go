@@ -0,0 +1,121 @@
+package tts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pauser pauses a running job between chunks: the chunk in flight is finished,
+// then the processor idles until Resume. Audio synthesized so far is kept.
+type Pauser struct {
+	// OnPaused, if set, is called when the processor starts idling.
+	OnPaused func()
+
+	mu      sync.Mutex
+	paused  bool
+	changed chan struct{} // closed and replaced on every Pause and Resume
+}
+
+// NewPauser creates a Pauser that is running.
+func NewPauser() *Pauser {
+	return &Pauser{changed: make(chan struct{})}
+}
+
+// Pause asks the job to idle after the current chunk.
+func (p *Pauser) Pause() {
+	p.set(true)
+}
+
+// Resume continues a paused job.
+func (p *Pauser) Resume() {
+	p.set(false)
+}
+
+// Paused reports whether the job is paused or about to pause.
+func (p *Pauser) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+func (p *Pauser) set(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// state returns whether the job is paused and a channel closed on the next change.
+func (p *Pauser) state() (bool, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused, p.changed
+}
+
+// Wait blocks while the job is paused. It returns ctx's error if ctx is done
+// before the job is resumed. A nil Pauser never pauses.
+func (p *Pauser) Wait(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	notified := false
+	for {
+		paused, changed := p.state()
+		if !paused {
+			return nil
+		}
+		if !notified && p.OnPaused != nil {
+			p.OnPaused()
+			notified = true
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WithTimeout returns a context that is cancelled once the job has been running
+// for timeout, not counting the time spent paused, so a job paused overnight can
+// still be resumed. context.Cause reports context.DeadlineExceeded then. With a
+// nil Pauser this is context.WithTimeout.
+func (p *Pauser) WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if p == nil {
+		return context.WithTimeout(parent, timeout)
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		remaining := timeout
+		for {
+			paused, changed := p.state()
+			if paused {
+				select {
+				case <-changed:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			start := time.Now()
+			timer := time.NewTimer(remaining)
+			select {
+			case <-changed:
+				timer.Stop()
+				remaining -= time.Since(start)
+			case <-timer.C:
+				cancel(context.DeadlineExceeded)
+				return
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
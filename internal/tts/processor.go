@@ -1,12 +1,20 @@
 package tts
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"regexp"
+	"io"
+	"log/slog"
 	"strings"
 	"time"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/cache"
+	"easy-tts/internal/chunkstore"
+	"easy-tts/internal/devstats"
+	"easy-tts/internal/preprocess"
 )
 
 // ProgressCallback is called after each successful chunk or sub-chunk.
@@ -19,8 +27,50 @@ type ErrorCallback func(msg string)
 type ProcessorConfig struct {
 	MinChunkBytes      int           // Minimum chunk size for fallback (bytes)
 	ChunkDelay         time.Duration // Delay between chunk requests
-	MaxRetries         int           // Retries per chunk
+	MaxRetries         int           // Attempts per chunk before it is split or given up
+	RetryBaseDelay     time.Duration // Wait after the first failed attempt
+	RetryMultiplier    float64       // Growth of the wait with each further attempt
+	RetryJitter        float64       // Random variation of each wait, as a fraction (0.2 = ±20%)
+	RetryMaxDelay      time.Duration // Upper bound of each wait, 0 for none
 	GoogleFallbackVoices []string    // Optional: override fallback voices for Google
+	ChunkLimit         int           // Optional: overrides the provider's chunk limit (tokens, bytes for Google)
+	StitchContext      bool          // Pass the end of the previous chunk as context where the model accepts instructions
+	Pause              *Pauser       // Optional: lets the job be paused between chunks
+	ChunkTimeout       time.Duration // Deadline of each provider request, 0 for none
+	JobTimeout         time.Duration // Budget of the whole job, not counting pauses, 0 for none
+	Checkpoint         func(Checkpoint) // Optional: called after each chunk to persist the progress
+	Cache              *cache.Cache  // Optional: reuses audio of chunks synthesized before
+	FailedSection      string        // What takes the place of a chunk that failed, one of FailedPolicies
+	FailedPhrase       string        // Spoken for FailedPhrase, DefaultFailedPhrase if empty
+	Output             io.Writer     // Optional: receives the audio as it is assembled instead of memory
+	Chunks             *chunkstore.Store // Optional: keeps the audio as one file per chunk instead of memory, for a merge step
+	BreakerThreshold   int           // Consecutive failed requests that trip the circuit breaker, 0 for none
+	OnBreakerTrip      func(BreakerTrip) BreakerDecision // Optional: asked when the breaker trips; without it the breaker is off
+	JobID              string        // Identifies the job in log records and hook events; generated if empty
+	Logger             *slog.Logger  // Optional: receives the job's log records instead of slog.Default()
+	Hooks              Hooks         // Optional: called as chunks start, finish and are retried
+	Intro              []byte        // Optional: audio assembled before the first chunk, e.g. a jingle
+	Outro              []byte        // Optional: audio assembled after the last chunk, e.g. a disclaimer
+	Crossfade          time.Duration // Overlap of sections joined without a pause (MP3 and WAV), 0 for a hard cut
+	SpeechMarks        bool          // Ask providers that can for sentence marks, see ChunkResult.Marks
+}
+
+// Checkpoint is the progress of a job after a chunk, enough to resume it after a
+// crash or quit without starting from the first chunk.
+type Checkpoint struct {
+	Audio     []byte    // Audio appended since the previous checkpoint
+	Chapters  []Chapter // Chapters so far
+	Chunks    int       // Chunks synthesized so far
+	Next      int       // Index of the first unfinished segment
+	Current   Segment   // That segment, with only the text still to synthesize
+}
+
+// Remaining returns the segments of a job still to synthesize at cp.
+func (cp Checkpoint) Remaining(segments []Segment) []Segment {
+	if cp.Next >= len(segments) {
+		return nil
+	}
+	return append([]Segment{cp.Current}, segments[cp.Next+1:]...)
 }
 
 // DefaultProcessorConfig returns a sensible default config.
@@ -28,13 +78,22 @@ func DefaultProcessorConfig() *ProcessorConfig {
 	return &ProcessorConfig{
 		MinChunkBytes:      1, // one word
 		ChunkDelay:         2 * time.Second,
-		MaxRetries:         3,
+		MaxRetries:         DefaultMaxRetries,
+		RetryBaseDelay:     DefaultRetryBaseDelay,
+		RetryMultiplier:    DefaultRetryMultiplier,
+		RetryJitter:        DefaultRetryJitter,
+		RetryMaxDelay:      DefaultRetryMaxDelay,
 		GoogleFallbackVoices: nil, // use dynamic logic
+		ChunkTimeout:       2 * time.Minute,
+		JobTimeout:         6 * time.Hour,
+		BreakerThreshold:   5,
+		FailedSection:      FailedPhrase,
+		FailedPhrase:       DefaultFailedPhrase,
 	}
 }
 
 // ProcessTextToSpeech handles chunking, retry, fallback, and error logic for TTS.
-// Returns the concatenated audio or error.
+// Returns the concatenated audio and a per-chunk report, or an error.
 func ProcessTextToSpeech(
 	ctx context.Context,
 	provider Provider,
@@ -42,44 +101,383 @@ func ProcessTextToSpeech(
 	progressCb ProgressCallback,
 	errorCb ErrorCallback,
 	cfg *ProcessorConfig,
-) ([]byte, error) {
+) ([]byte, *Report, error) {
+	segments := []Segment{{Text: request.Text, Voice: request.Voice}}
+	return ProcessSegments(ctx, provider, request, segments, progressCb, errorCb, cfg)
+}
+
+// ProcessSegments synthesizes segments in order, each with its own voice, and
+// concatenates the audio. Other request fields apply to every segment. With
+// cfg.Output or cfg.Chunks the audio is written there chunk by chunk and none is
+// returned, so memory use does not grow with the length of the document.
+func ProcessSegments(
+	ctx context.Context,
+	provider Provider,
+	request *UnifiedRequest,
+	segments []Segment,
+	progressCb ProgressCallback,
+	errorCb ErrorCallback,
+	cfg *ProcessorConfig,
+) ([]byte, *Report, error) {
 	if cfg == nil {
 		cfg = DefaultProcessorConfig()
 	}
+	if cfg.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = cfg.Pause.WithTimeout(ctx, cfg.JobTimeout)
+		defer cancel()
+	}
+	ctx, cfg = jobContext(ctx, provider, cfg)
+	provider = wrapProvider(provider, cfg)
 	isGoogle := provider.GetName() == "google"
-	var chunks []string
-	if isGoogle {
-		chunks = SplitTextByteLimit(request.Text, DefaultByteLimit)
-	} else {
-		chunks = SplitTextTokenLimit(request.Text, "cl100k_base", provider.GetMaxTokensPerChunk())
-	}
-	totalChunks := len(chunks)
-	var audioData []byte
+	// Chunks are split while synthesizing; the total starts as an estimate and
+	// is corrected as each segment is finished
+	limit, measure := ChunkLimit(provider, cfg.ChunkLimit)
+	estimates := make([]int, len(segments))
+	totalChunks := 0
+	for i, seg := range segments {
+		estimates[i] = estimateChunks(seg.Text, limit, measure)
+		totalChunks += estimates[i]
+	}
+	devstats.Add("jobs.active", 1)
+	queued := int64(totalChunks)
+	devstats.Add("chunks.queued", queued)
+	defer func() {
+		devstats.Add("jobs.active", -1)
+		devstats.Add("chunks.queued", -queued)
+		devstats.SetText("chunks.current", "")
+	}()
+	var audioData []byte // the assembled audio, unless cfg.Output or cfg.Chunks receive it
+	size := 0            // bytes assembled so far
+	var last []byte      // the latest chunk's audio, whose format fillers copy
+	var unsaved []byte   // audio not yet passed to cfg.Checkpoint
+	var like []byte      // the start of the first piece, whose sample rate the others get
+	// write assembles data and returns its chunk file, if cfg.Chunks keeps them.
+	// Only the first piece of WAV or Ogg audio keeps its header, see audio.Piece.
+	write := func(data []byte) (string, error) {
+		data = matchFirst(ctx, data, &like, request.Bitrate)
+		data = audio.Piece(data, size)
+		var file string
+		if cfg.Chunks != nil {
+			var err error
+			if file, err = cfg.Chunks.Add(data); err != nil {
+				return "", err
+			}
+		}
+		if cfg.Output != nil {
+			if _, err := cfg.Output.Write(data); err != nil {
+				return "", fmt.Errorf("failed to write audio: %w", err)
+			}
+		} else if cfg.Chunks == nil {
+			audioData = append(audioData, data...)
+		}
+		if cfg.Checkpoint != nil {
+			unsaved = append(unsaved, data...)
+		}
+		size += len(data)
+		return file, nil
+	}
+	// assembled returns the audio kept in memory, with the sizes in its header set
+	assembled := func() []byte {
+		audio.FixHeaders(audioData)
+		return audioData
+	}
+	report := &Report{}
 	completed := 0
+	stitch := cfg.StitchContext && stitchesContext(provider, request)
+	previous := "" // text of the previous chunk in the same file, for stitching
 
-	for _, chunk := range chunks {
-		data, err := processChunkRecursively(
-			ctx, provider, request, chunk, isGoogle,
-			cfg.MinChunkBytes, cfg.MaxRetries, cfg.GoogleFallbackVoices,
-			func() {
-				completed++
-				if progressCb != nil {
-					progressCb(completed, totalChunks)
+	checkpoint := func(next int, current Segment) {
+		if cfg.Checkpoint == nil {
+			return
+		}
+		cfg.Checkpoint(Checkpoint{
+			Audio:    unsaved,
+			Chapters: report.Chapters,
+			Chunks:   len(report.Succeeded()),
+			Next:     next,
+			Current:  current,
+		})
+		unsaved = nil
+	}
+
+	var elapsed time.Duration // playback position of the assembled audio
+	var pendingPause time.Duration
+	// addClip assembles clip, the intro or outro, converted to the format, sample
+	// rate and channels of next, the job's audio beside it. A clip that cannot be
+	// converted is logged and left out rather than failing the job.
+	addClip := func(name string, clip, next []byte) error {
+		converted, err := audio.Convert(clip, audio.Sniff(next), request.Bitrate)
+		if err == nil {
+			converted, err = audio.Match(converted, next, request.Bitrate)
+		}
+		if err != nil {
+			logger(ctx).Warn("Leaving out the "+name, "err", err)
+			return nil
+		}
+		if _, err := write(converted); err != nil {
+			return err
+		}
+		if info, err := audio.Probe(converted); err == nil {
+			elapsed += info.Duration
+		}
+		return nil
+	}
+	intro := cfg.Intro // assembled with the first audio, whose format it takes
+	var tail *audio.PCM // the end of the previous section, held back to crossfade
+	// flushTail assembles the held back end of a section on its own
+	flushTail := func() error {
+		if tail == nil {
+			return nil
+		}
+		data, err := tail.Encode(audio.Sniff(last), request.Bitrate)
+		if err != nil {
+			return err
+		}
+		elapsed += tail.Duration()
+		tail = nil
+		_, err = write(data)
+		return err
+	}
+	// appendAudio assembles the audio of result and records where it went. With
+	// fadeOut its end is held back and crossfaded into the next audio.
+	appendAudio := func(data []byte, result *ChunkResult, fadeOut bool) error {
+		if len(intro) > 0 {
+			clip := intro
+			intro = nil
+			if err := addClip("intro", clip, data); err != nil {
+				return err
+			}
+		}
+		if tail != nil && pendingPause == 0 {
+			if mixed, err := audio.Crossfade(tail, data, request.Bitrate); err != nil {
+				logger(ctx).Debug("Skipping crossfade", "err", err)
+			} else {
+				data, tail = mixed, nil
+			}
+		}
+		if err := flushTail(); err != nil {
+			return err
+		}
+		if pendingPause > 0 {
+			silence, err := audio.Silence(data, pendingPause)
+			if err != nil {
+				logger(ctx).Debug("Skipping pause", "pause", pendingPause, "err", err)
+			} else {
+				if _, err := write(silence); err != nil {
+					return err
 				}
-			},
-			errorCb,
-		)
+				elapsed += pendingPause
+			}
+			pendingPause = 0
+		}
+		result.Offset, result.Start = size, elapsed
+		if fadeOut {
+			head, rest, err := audio.SplitTail(data, cfg.Crossfade)
+			if err != nil {
+				logger(ctx).Debug("Skipping crossfade", "err", err)
+			} else {
+				data, tail = head, rest
+				elapsed -= tail.Duration()
+			}
+		}
+		file, err := write(data)
 		if err != nil {
-			// Error already reported via errorCb, continue to next chunk
-			continue
+			return err
 		}
-		audioData = append(audioData, data...)
+		result.File = file
+		last = data
+		elapsed += result.Duration
+		return nil
 	}
-	return audioData, nil
+	// done flushes the held back end of a section and returns the job's result
+	done := func(err error) ([]byte, *Report, error) {
+		if flushErr := flushTail(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+		return assembled(), report, err
+	}
+
+	// finish records the result of a chunk started at started
+	finish := func(ctx context.Context, result ChunkResult, started time.Time) {
+		report.Chunks = append(report.Chunks, result)
+		elapsed := time.Since(started)
+		if result.Error != "" {
+			logger(ctx).Warn("Chunk finished with an error", "elapsed", elapsed, "attempts", result.Attempts, "filler_bytes", result.Filler, "err", result.Error)
+		} else {
+			logger(ctx).Debug("Chunk finished", "elapsed", elapsed, "attempts", result.Attempts, "duration", result.Duration)
+		}
+		if cfg.Hooks.OnChunkDone != nil {
+			cfg.Hooks.OnChunkDone(ChunkEvent{
+				JobID: cfg.JobID, Provider: provider.GetName(), Index: result.Index, Total: totalChunks,
+				Voice: result.Voice, Text: result.Text, Result: &report.Chunks[len(report.Chunks)-1], Elapsed: elapsed,
+			})
+		}
+	}
+
+	for segIndex, seg := range segments {
+		segRequest := *request
+		segRequest.Text = seg.Text
+		if seg.Voice != "" {
+			segRequest.Voice = seg.Voice
+		}
+		if seg.Speed > 0 {
+			segRequest.Speed = seg.Speed
+		}
+		if seg.NewFile && size == 0 && seg.Heading != "" {
+			// The first file has no boundary but is named after its heading
+			report.Chapters = append(report.Chapters, Chapter{Title: seg.Heading, Chunk: len(report.Chunks)})
+		}
+		if seg.NewFile && size > 0 {
+			report.Chapters = append(report.Chapters, Chapter{Title: seg.Heading, Offset: size, Start: elapsed, Chunk: len(report.Chunks)})
+			pendingPause = 0 // a pause at the start of a file is pointless
+			previous = ""
+		} else {
+			pendingPause += seg.PauseBefore
+		}
+		chunker := NewChunker(seg.Text, limit, measure)
+		chunkIndex := 0
+		for chunk, ok := chunker.NextChunk(); ok; chunk, ok = chunker.NextChunk() {
+			if err := cfg.Pause.Wait(ctx); err != nil {
+				return done(err)
+			}
+			if chunkIndex >= estimates[segIndex] {
+				// More chunks than estimated
+				totalChunks++
+				queued++
+				devstats.Add("chunks.queued", 1)
+			}
+			result := ChunkResult{Index: len(report.Chunks), Text: chunk, Voice: segRequest.Voice, Speaker: seg.Speaker}
+			chunkCtx, started := chunkContext(ctx, result.Index), time.Now()
+			if cfg.Hooks.OnChunkStart != nil {
+				cfg.Hooks.OnChunkStart(ChunkEvent{JobID: cfg.JobID, Provider: provider.GetName(), Index: result.Index, Total: totalChunks, Voice: result.Voice, Text: chunk})
+			}
+			devstats.SetText("chunks.current", fmt.Sprintf("%d of %d (%s): %.40s", len(report.Chunks)+1, totalChunks, segRequest.Voice, chunk))
+			chunkRequest := segRequest
+			if stitch && previous != "" {
+				chunkRequest.Instructions = contextInstructions(segRequest.Instructions, previous)
+			}
+			previous = chunk
+			marks := SpeechMarks{Types: []string{SpeechMarkSentence}}
+			if cfg.SpeechMarks {
+				chunkRequest.SpeechMarks = &marks
+			}
+			data, err := processChunkRecursively(
+				chunkCtx, provider, &chunkRequest, chunk, isGoogle,
+				cfg.MinChunkBytes, cfg.retryPolicy(), cfg.GoogleFallbackVoices,
+				func() {
+					completed++
+					if progressCb != nil {
+						progressCb(completed, totalChunks)
+					}
+				},
+				errorCb,
+				&result,
+			)
+			queued--
+			devstats.Add("chunks.queued", -1)
+			if err != nil {
+				// The section before ends without a crossfade, ahead of the failed chunk's place
+				if flushErr := flushTail(); flushErr != nil {
+					return done(flushErr)
+				}
+			}
+			if errors.Is(err, ErrQuotaExhausted) {
+				devstats.SetText("quota.exhausted", fmt.Sprintf("%s at %s", provider.GetName(), time.Now().Format("2006-01-02 15:04")))
+				result.Error = err.Error()
+				result.Offset, result.Start = size, elapsed
+				finish(chunkCtx, result, started)
+				remaining := remainingSegments(segments, segIndex, chunk+" "+chunker.Rest(), chunkIndex > 0)
+				return done(&QuotaExhaustedError{Err: err, Remaining: remaining})
+			}
+			chunkIndex++
+			if errors.Is(err, ErrProviderFailing) {
+				result.Error = err.Error()
+				result.Offset, result.Start = size, elapsed
+				finish(chunkCtx, result, started)
+				return done(err)
+			}
+			if err != nil {
+				// Error already reported via errorCb; the policy decides what takes its place
+				result.Error = err.Error()
+				fill, policyErr := failedSection(chunkCtx, provider, &chunkRequest, cfg, last, &result, err)
+				if len(fill) > 0 {
+					if policyErr = appendAudio(fill, &result, false); policyErr == nil {
+						result.Filler = size - result.Offset
+					}
+				} else {
+					result.Offset, result.Start = size, elapsed
+					if policyErr == nil && cfg.Chunks != nil {
+						// An empty chunk file keeps the place of the skipped chunk for a retry
+						result.File, policyErr = cfg.Chunks.Add(nil)
+					}
+				}
+				finish(chunkCtx, result, started)
+				if policyErr != nil {
+					return done(policyErr)
+				}
+			} else {
+				if marks.Text == chunk {
+					result.Marks = marks.Marks // not if the chunk was split or came from the cache
+				}
+				fadeOut := cfg.Crossfade > 0 && chunker.Rest() == "" && crossfades(segments, segIndex)
+				if err := appendAudio(data, &result, fadeOut); err != nil {
+					result.Error = err.Error()
+					finish(chunkCtx, result, started)
+					return done(err)
+				}
+				finish(chunkCtx, result, started)
+			}
+			checkpoint(segIndex, resumeSegment(seg, chunker.Rest(), true))
+		}
+		if unused := estimates[segIndex] - chunkIndex; unused > 0 {
+			// Fewer chunks than estimated
+			totalChunks -= unused
+			queued -= int64(unused)
+			devstats.Add("chunks.queued", -int64(unused))
+		}
+		pendingPause += seg.PauseAfter
+	}
+	if err := flushTail(); err != nil {
+		return assembled(), report, err
+	}
+	if len(cfg.Outro) > 0 && len(last) > 0 {
+		if err := addClip("outro", cfg.Outro, last); err != nil {
+			return assembled(), report, err
+		}
+		checkpoint(len(segments), Segment{})
+	}
+	return assembled(), report, nil
+}
+
+// crossfades reports whether segments[i] is crossfaded into the next segment:
+// sections joined without a pause in the same file.
+func crossfades(segments []Segment, i int) bool {
+	if i+1 >= len(segments) {
+		return false
+	}
+	next := segments[i+1]
+	return segments[i].PauseAfter == 0 && next.PauseBefore == 0 && !next.NewFile
 }
 
 // --- Internal helpers ---
 
+// remainingSegments returns the segments from segIndex on, with the text of the
+// first replaced by rest.
+func remainingSegments(segments []Segment, segIndex int, rest string, started bool) []Segment {
+	return append([]Segment{resumeSegment(segments[segIndex], rest, started)}, segments[segIndex+1:]...)
+}
+
+// resumeSegment returns seg with only the text rest left to synthesize. If the
+// segment has started, its pause and file split before it were already applied.
+func resumeSegment(seg Segment, rest string, started bool) Segment {
+	seg.Text = strings.TrimSpace(rest)
+	if started {
+		seg.PauseBefore, seg.NewFile = 0, false
+	}
+	return seg
+}
+
 // processChunkRecursively handles chunking, retry, fallback, and error chunk insertion for a single chunk.
 func processChunkRecursively(
 	ctx context.Context,
@@ -88,12 +486,13 @@ func processChunkRecursively(
 	chunk string,
 	isGoogle bool,
 	minLimit int,
-	maxRetries int,
+	retry retryPolicy,
 	googleFallbackVoices []string,
 	progressCb func(),
 	errorCb ErrorCallback,
+	result *ChunkResult,
 ) ([]byte, error) {
-	return processChunkRecursivelyWithDepth(ctx, provider, request, chunk, isGoogle, minLimit, maxRetries, googleFallbackVoices, progressCb, errorCb, 0, len([]byte(chunk)))
+	return processChunkRecursivelyWithDepth(ctx, provider, request, chunk, isGoogle, minLimit, retry, googleFallbackVoices, progressCb, errorCb, result, 0, len([]byte(chunk)))
 }
 
 // Helper with recursion depth and previous chunk size tracking
@@ -104,10 +503,11 @@ func processChunkRecursivelyWithDepth(
 	chunk string,
 	isGoogle bool,
 	minLimit int,
-	maxRetries int,
+	retry retryPolicy,
 	googleFallbackVoices []string,
 	progressCb func(),
 	errorCb ErrorCallback,
+	result *ChunkResult,
 	recursionLevel int,
 	prevChunkBytes int,
 ) ([]byte, error) {
@@ -118,15 +518,14 @@ func processChunkRecursivelyWithDepth(
 	words := strings.Fields(chunk)
 	chunkBytes := len([]byte(chunk))
 
-	// --- DEBUG LOGGING ---
-	log.Printf("[TTS DEBUG] processChunkRecursively: chunkBytes=%d, len(words)=%d, chunk='%.60s...', minLimit=%d, recursionLevel=%d", chunkBytes, len(words), chunk, minLimit, recursionLevel)
+	logger(ctx).Debug("Processing chunk", "bytes", chunkBytes, "words", len(words), "text", excerpt(chunk, 60), "min_bytes", minLimit, "depth", recursionLevel)
 	if ctx.Err() != nil {
-		log.Printf("[TTS DEBUG] Context done in processChunkRecursively: %v", ctx.Err())
+		logger(ctx).Debug("Context done", "err", ctx.Err())
 		return nil, ctx.Err()
 	}
 	// Recursion depth guard
 	if recursionLevel > 20 {
-		log.Printf("[TTS DEBUG] Recursion depth exceeded for chunk (len=%d): %.60s...", chunkBytes, chunk)
+		logger(ctx).Warn("Recursion depth exceeded", "bytes", chunkBytes, "text", excerpt(chunk, 60))
 		if errorCb != nil {
 			errorCb(fmt.Sprintf("Chunk recursion depth exceeded (%.40s...). Aborting this section.", chunk))
 		}
@@ -134,30 +533,58 @@ func processChunkRecursivelyWithDepth(
 	}
 
 	// 1. Normal attempts with exponential backoff on error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		log.Printf("[TTS DEBUG] Attempt %d/%d for chunk (len=%d): %.60s...", attempt, maxRetries, chunkBytes, chunk)
-		data, err = provider.GenerateSpeech(ctx, &UnifiedRequest{
-			Text:   chunk,
-			Voice:  request.Voice,
-			Speed:  request.Speed,
-			Format: request.Format,
-			Model:  request.Model,
-		})
+	for attempt := 1; attempt <= retry.maxRetries; attempt++ {
+		logger(ctx).Debug("Requesting chunk", "attempt", attempt, "max_attempts", retry.maxRetries, "bytes", chunkBytes)
+		data, err = generateChunk(ctx, provider, request, result, chunk, request.Voice)
 		if err == nil {
 			if progressCb != nil {
 				progressCb()
 			}
-			log.Printf("[TTS DEBUG] Success for chunk (len=%d): %.60s...", chunkBytes, chunk)
+			logger(ctx).Debug("Chunk succeeded", "attempt", attempt, "bytes", chunkBytes)
 			return data, nil
 		}
-		log.Printf("[TTS DEBUG] Error on attempt %d: %v", attempt, err)
-		if attempt < maxRetries && isRetryableTTS(err) {
-			if isQuotaOrRateError(err) && errorCb != nil {
-				errorCb("Google TTS may be rate-limiting or throttling your requests. Waiting before retrying...")
+		logger(ctx).Warn("Request failed", "attempt", attempt, "err", err)
+		if isDailyQuotaError(err) {
+			return nil, fmt.Errorf("%w: %v", ErrQuotaExhausted, err)
+		}
+		if errors.Is(err, ErrProviderFailing) {
+			return nil, err
+		}
+		if attempt < retry.maxRetries && isRetryableTTS(err) {
+			delay, requested := retryAfter(err)
+			if requested && retry.max > 0 {
+				// A server asking for longer must not stall the job
+				delay = min(delay, retry.max)
 			}
-			delay := getBackoffDelay(attempt)
-			log.Printf("[TTS DEBUG] Waiting %v before retrying...", delay)
-			time.Sleep(delay)
+			if requested {
+				devstats.Add("ratelimit.throttled", 1)
+				if errorCb != nil {
+					errorCb(fmt.Sprintf("%s is rate-limiting your requests. Retrying in %v as requested...", provider.GetName(), delay.Round(time.Second)))
+				}
+			} else if isQuotaOrRateError(err) {
+				devstats.Add("ratelimit.throttled", 1)
+				if errorCb != nil {
+					errorCb("Google TTS may be rate-limiting or throttling your requests. Waiting before retrying...")
+				}
+			}
+			if !requested {
+				delay = retry.delay(attempt)
+			}
+			logger(ctx).Info("Waiting before retrying", "attempt", attempt, "delay", delay)
+			if retry.onRetry != nil {
+				retry.onRetry(RetryEvent{JobID: retry.jobID, Provider: provider.GetName(), Index: result.Index, Attempt: attempt, Delay: delay, Err: err})
+			}
+			devstats.Add("requests.retries", 1)
+			devstats.SetText("ratelimit.backoff_until", time.Now().Add(delay).Format("15:04:05"))
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				devstats.SetText("ratelimit.backoff_until", "")
+				return nil, ctx.Err()
+			}
+			devstats.SetText("ratelimit.backoff_until", "")
 			continue
 		}
 		break
@@ -165,81 +592,74 @@ func processChunkRecursivelyWithDepth(
 
 	// 2. Sub-chunking if possible
 	if chunkBytes > minLimit && len(words) > 1 {
-		log.Printf("[TTS DEBUG] Sub-chunking chunk (len=%d): %.60s...", chunkBytes, chunk)
+		logger(ctx).Debug("Sub-chunking", "bytes", chunkBytes)
 		var subChunks []string
 		if isGoogle {
 			subChunks = SplitTextByteLimit(chunk, chunkBytes/2)
 		} else {
-			subChunks = SplitTextTokenLimit(chunk, "cl100k_base", provider.GetMaxTokensPerChunk()/2)
+			// Half the failed chunk, which the user's chunk size may keep far below the provider's limit
+			measure := TokenMeasure()
+			subChunks = SplitText(chunk, max(measure(chunk)/2, 1), measure)
 		}
-		log.Printf("[TTS DEBUG] Sub-chunked into %d sub-chunks", len(subChunks))
+		logger(ctx).Debug("Sub-chunked", "parts", len(subChunks))
 
 		// If chunk cannot be split further (only one sub-chunk, same size), treat as minimum-size chunk
 		if len(subChunks) == 1 && len([]byte(subChunks[0])) == chunkBytes {
-			log.Printf("[TTS DEBUG] Sub-chunking did not reduce chunk size. Treating as minimum-size chunk.")
+			logger(ctx).Debug("Sub-chunking did not reduce the chunk, treating it as minimum-size")
 			goto MIN_CHUNK_LOGIC
 		}
 
-		var audio []byte
+		var joined []byte
 		for i, sub := range subChunks {
-			log.Printf("[TTS DEBUG] Processing sub-chunk %d/%d (len=%d): %.60s...", i+1, len(subChunks), len([]byte(sub)), sub)
-			subData, subErr := processChunkRecursivelyWithDepth(ctx, provider, request, sub, isGoogle, minLimit, maxRetries, googleFallbackVoices, progressCb, errorCb, recursionLevel+1, chunkBytes)
+			logger(ctx).Debug("Processing sub-chunk", "part", i+1, "parts", len(subChunks), "bytes", len([]byte(sub)))
+			subData, subErr := processChunkRecursivelyWithDepth(ctx, provider, request, sub, isGoogle, minLimit, retry, googleFallbackVoices, progressCb, errorCb, result, recursionLevel+1, chunkBytes)
+			if errors.Is(subErr, ErrQuotaExhausted) || errors.Is(subErr, ErrProviderFailing) {
+				return nil, subErr
+			}
 			if subErr != nil {
-				log.Printf("[TTS DEBUG] Error in sub-chunk %d/%d: %v", i+1, len(subChunks), subErr)
+				logger(ctx).Warn("Sub-chunk failed", "part", i+1, "parts", len(subChunks), "err", subErr)
 				// Error already reported, continue to next sub-chunk
 				continue
 			}
-			audio = append(audio, subData...)
+			joined = audio.Join(joined, subData)
 		}
-		if len(audio) > 0 {
-			log.Printf("[TTS DEBUG] Returning audio from sub-chunks for parent chunk (len=%d)", chunkBytes)
-			return audio, nil
+		if len(joined) > 0 {
+			logger(ctx).Debug("Joined the audio of sub-chunks", "bytes", chunkBytes)
+			return joined, nil
 		}
-		log.Printf("[TTS DEBUG] All sub-chunks failed for parent chunk (len=%d)", chunkBytes)
+		logger(ctx).Warn("All sub-chunks failed", "bytes", chunkBytes)
 	}
 
 MIN_CHUNK_LOGIC:
 	// 3. If chunk is a single word and <200 bytes, or chunk cannot be split further, treat as minimum-size chunk
 	if len(words) == 1 && chunkBytes < 200 || chunkBytes <= minLimit {
-		log.Printf("[TTS DEBUG] Minimum-size chunk logic triggered (len=%d): %.60s...", chunkBytes, chunk)
-		sanitized := sanitizeWordForTTS(chunk)
+		logger(ctx).Debug("Trying minimum-size fallbacks", "bytes", chunkBytes, "text", excerpt(chunk, 60))
+		sanitized := preprocess.SanitizeWord(chunk)
 		if sanitized != chunk && sanitized != "" {
-			log.Printf("[TTS DEBUG] Trying sanitized word: %s", sanitized)
-			data, err = provider.GenerateSpeech(ctx, &UnifiedRequest{
-				Text:   sanitized,
-				Voice:  request.Voice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
+			logger(ctx).Debug("Trying sanitized word", "word", sanitized)
+			data, err = generateChunk(ctx, provider, request, result, sanitized, request.Voice)
 			if err == nil {
 				if progressCb != nil {
 					progressCb()
 				}
-				log.Printf("[TTS DEBUG] Success with sanitized word.")
+				logger(ctx).Debug("Sanitized word succeeded")
 				return data, nil
 			}
-			log.Printf("[TTS DEBUG] Sanitized word failed: %v", err)
+			logger(ctx).Debug("Sanitized word failed", "err", err)
 		}
 		// Try stripping Markdown and retry once more
-		mdStripped := stripMarkdown(chunk)
+		mdStripped := preprocess.StripMarkdownSymbols(chunk)
 		if mdStripped != chunk && mdStripped != "" {
-			log.Printf("[TTS DEBUG] Trying Markdown-stripped word: %s", mdStripped)
-			data, err = provider.GenerateSpeech(ctx, &UnifiedRequest{
-				Text:   mdStripped,
-				Voice:  request.Voice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
+			logger(ctx).Debug("Trying Markdown-stripped word", "word", mdStripped)
+			data, err = generateChunk(ctx, provider, request, result, mdStripped, request.Voice)
 			if err == nil {
 				if progressCb != nil {
 					progressCb()
 				}
-				log.Printf("[TTS DEBUG] Success with Markdown-stripped word.")
+				logger(ctx).Debug("Markdown-stripped word succeeded")
 				return data, nil
 			}
-			log.Printf("[TTS DEBUG] Markdown-stripped word failed: %v", err)
+			logger(ctx).Debug("Markdown-stripped word failed", "err", err)
 		}
 		// Fallback voices for Google
 		if isGoogle {
@@ -248,49 +668,27 @@ MIN_CHUNK_LOGIC:
 				fallbackVoices = buildFallbackVoices(origLang, origVoice)
 			}
 			for _, fallbackVoice := range fallbackVoices {
-				log.Printf("[TTS DEBUG] Trying fallback voice: %s", fallbackVoice)
-				data, err = provider.GenerateSpeech(ctx, &UnifiedRequest{
-					Text:   chunk,
-					Voice:  fallbackVoice,
-					Speed:  request.Speed,
-					Format: request.Format,
-					Model:  request.Model,
-				})
+				logger(ctx).Debug("Trying fallback voice", "voice", fallbackVoice)
+				data, err = generateChunk(ctx, provider, request, result, chunk, fallbackVoice)
 				if err == nil {
 					if progressCb != nil {
 						progressCb()
 					}
-					log.Printf("[TTS DEBUG] Fallback voice succeeded: %s", fallbackVoice)
+					logger(ctx).Info("Fallback voice succeeded", "voice", fallbackVoice)
+					result.AddFlag(FlagFallbackVoice)
 					return data, nil
 				}
-				log.Printf("[TTS DEBUG] Fallback voice failed: %v", err)
-			}
-			// If all fallback voices fail, try error message chunk in en-US
-			log.Printf("[TTS DEBUG] All fallback voices failed for chunk (len=%d): %.100s", chunkBytes, chunk)
-			if errorCb != nil {
-				errorCb(fmt.Sprintf(
-					"A section could not be processed (%.40s...). Substituting error message and continuing.", chunk))
+				logger(ctx).Debug("Fallback voice failed", "voice", fallbackVoice, "err", err)
 			}
-			data, err = provider.GenerateSpeech(ctx, &UnifiedRequest{
-				Text:   "Error converting Text. Continuing.",
-				Voice:  "en-US-" + origVoice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
-			if err == nil {
-				if progressCb != nil {
-					progressCb()
-				}
-				log.Printf("[TTS DEBUG] Error message chunk succeeded.")
-				return data, nil
-			}
-			log.Printf("[TTS DEBUG] Error message chunk failed: %v", err)
+			logger(ctx).Warn("All fallback voices failed", "bytes", chunkBytes, "text", excerpt(chunk, 100))
 		}
 	}
 
+	if errors.Is(err, ErrProviderFailing) {
+		return nil, err
+	}
 	// Log and show user-friendly error
-	log.Printf("[TTS DEBUG] Final failed chunk (len=%d): %.100s", chunkBytes, chunk)
+	logger(ctx).Error("Chunk failed", "bytes", chunkBytes, "text", excerpt(chunk, 100), "err", err)
 	if errorCb != nil {
 		errorCb(fmt.Sprintf(
 			"A section could not be processed (%.40s...). Try rephrasing or splitting it manually.", chunk))
@@ -298,19 +696,123 @@ MIN_CHUNK_LOGIC:
 	return nil, err
 }
 
-// --- Utility functions ---
+// generateChunk synthesizes text with the request's settings and rejects responses
+// that are not valid audio in the requested format (empty blobs, JSON error bodies).
+// Audio that is implausibly short for its text is re-requested once and flagged in result.
+func generateChunk(ctx context.Context, provider Provider, request *UnifiedRequest, result *ChunkResult, text, voice string) ([]byte, error) {
+	data, info, err := requestAudio(ctx, provider, request, result, text, voice)
+	if err != nil {
+		return nil, err
+	}
+	if isSuspiciouslyShort(text, request.Speed, info.Duration) {
+		logger(ctx).Info("Suspiciously short audio, re-requesting", "duration", info.Duration, "chars", len(text))
+		retryData, retryInfo, retryErr := requestAudio(ctx, provider, request, result, text, voice)
+		if retryErr == nil && retryInfo.Duration > info.Duration {
+			data, info = retryData, retryInfo
+		}
+		if isSuspiciouslyShort(text, request.Speed, info.Duration) {
+			result.AddFlag(FlagShortAudio)
+		} else {
+			result.AddFlag(FlagShortAudioRetried)
+		}
+	}
+	result.Duration += info.Duration
+	return data, nil
+}
+
+// requestAudio performs a single provider request and validates the returned audio.
+func requestAudio(ctx context.Context, provider Provider, request *UnifiedRequest, result *ChunkResult, text, voice string) ([]byte, *audio.Info, error) {
+	result.Attempts++
+	devstats.Add("requests.total", 1)
+	devstats.Add("requests.in_flight", 1)
+	data, err := provider.GenerateSpeech(ctx, &UnifiedRequest{
+		Text:   text,
+		Voice:  voice,
+		Speed:  request.Speed,
+		Format: request.Format,
+		Model:  request.Model,
+		SayAs:  request.SayAs,
+
+		Instructions: request.Instructions,
+		Style:        request.Style,
+		SampleRate:   request.SampleRate,
+		Bitrate:      request.Bitrate,
+		SpeechMarks:  request.SpeechMarks,
+	})
+	devstats.Add("requests.in_flight", -1)
+	if err != nil {
+		devstats.Add("requests.failed", 1)
+		return nil, nil, err
+	}
+	info, err := audio.Validate(data, request.Format)
+	if err != nil {
+		logger(ctx).Warn("Rejected audio", "bytes", len(data), "err", err)
+		return nil, nil, err
+	}
+	logger(ctx).Debug("Validated audio", "format", info.Format, "bytes", len(data), "duration", info.Duration)
+	return data, info, nil
+}
+
+// wrapProvider adds format conversion, the per-request timeout, the circuit
+// breaker and the cache of cfg to provider.
+func wrapProvider(provider Provider, cfg *ProcessorConfig) Provider {
+	provider = &transcodingProvider{Provider: provider}
+	if cfg.ChunkTimeout > 0 {
+		provider = &timeoutProvider{Provider: provider, timeout: cfg.ChunkTimeout}
+	}
+	if cfg.BreakerThreshold > 0 && cfg.OnBreakerTrip != nil {
+		provider = &breakerProvider{
+			Provider:  provider,
+			threshold: cfg.BreakerThreshold,
+			onTrip:    cfg.OnBreakerTrip,
+			pause:     cfg.Pause,
+			timeout:   cfg.ChunkTimeout,
+		}
+	}
+	if cfg.Cache != nil {
+		provider = &cachedProvider{Provider: provider, cache: cfg.Cache}
+	}
+	return provider
+}
+
+// matchHeadSize is how much of the first piece of a job matchFirst keeps.
+const matchHeadSize = 4 << 10
 
-func getBackoffDelay(attempt int) time.Duration {
-	switch attempt {
-	case 1:
-		return 30 * time.Second
-	case 2:
-		return 60 * time.Second
-	default:
-		return 120 * time.Second
+// matchFirst converts data to the sample rate and channels of the first piece of
+// the job's audio, whose start is kept in like, so chunks from a provider
+// switched to or a voice with another rate can be joined. Audio that cannot be
+// converted is logged and left as it is.
+func matchFirst(ctx context.Context, data []byte, like *[]byte, bitrate int) []byte {
+	if len(*like) == 0 {
+		*like = bytes.Clone(data[:min(len(data), matchHeadSize)])
+		return data
+	}
+	if len(data) == 0 {
+		return data
+	}
+	matched, err := audio.Match(data, *like, bitrate)
+	if err != nil {
+		logger(ctx).Warn("Failed to convert audio to the format of the first chunk", "err", err)
+		return data
 	}
+	return matched
 }
 
+// timeoutProvider gives each request of the wrapped provider its own deadline,
+// so a stalled request fails and is retried instead of holding up the job.
+type timeoutProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+func (p *timeoutProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.GenerateSpeech(ctx, req)
+}
+
+// --- Utility functions ---
+
 func isQuotaOrRateError(err error) bool {
 	msg := strings.ToLower(err.Error())
 	return strings.Contains(msg, "quota") ||
@@ -320,6 +822,12 @@ func isQuotaOrRateError(err error) bool {
 }
 
 func isRetryableTTS(err error) bool {
+	if _, ok := retryAfter(err); ok {
+		return true
+	}
+	if errors.Is(err, audio.ErrInvalidAudio) {
+		return true
+	}
 	msg := strings.ToLower(err.Error())
 	return strings.Contains(msg, "502") ||
 		strings.Contains(msg, "context deadline exceeded") ||
@@ -328,24 +836,6 @@ func isRetryableTTS(err error) bool {
 		strings.Contains(msg, "rate")
 }
 
-// Remove special characters, keep only letters, numbers, and spaces
-func sanitizeWordForTTS(s string) string {
-	var b strings.Builder
-	for _, r := range s {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == ' ' {
-			b.WriteRune(r)
-		}
-	}
-	return b.String()
-}
-
-// Remove Markdown formatting (common symbols)
-func stripMarkdown(s string) string {
-	reg := regexp.MustCompile(`[\\*_#\\[\\]()>~\` + "`" + `]+`)
-	return reg.ReplaceAllString(s, "")
-}
-
 // Extract language code from a voice string (e.g. de-DE-Chirp3-HD-Sulafat -> de-DE)
 func extractLangCode(voice string) string {
 	parts := strings.Split(voice, "-")
@@ -355,6 +845,16 @@ func extractLangCode(voice string) string {
 	return "en-US"
 }
 
+// LanguageCodeForVoice returns the locale prefix of a voice name ("de-DE-Chirp3-HD-Kore" -> "de-DE"),
+// or "" for voices that do not encode a language, such as OpenAI's.
+func LanguageCodeForVoice(voice string) string {
+	parts := strings.Split(voice, "-")
+	if len(parts) >= 3 && len(parts[0]) >= 2 && len(parts[0]) <= 3 && len(parts[1]) == 2 {
+		return parts[0] + "-" + parts[1]
+	}
+	return ""
+}
+
 // Build fallback voices list for Google
 func buildFallbackVoices(origLang, origVoice string) []string {
 	// Use the last part of the original voice as the suffix
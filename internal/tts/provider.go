@@ -24,6 +24,13 @@ type Provider interface {
 
 	// GetMaxTokensPerChunk returns the maximum tokens per request for this provider
 	GetMaxTokensPerChunk() int
+
+	// GetSpeechMarkTypes returns the speech marks the provider reports when
+	// UnifiedRequest.SpeechMarks asks for them, nil for none
+	GetSpeechMarkTypes() []string
+
+	// ListVoices returns the voices the provider offers
+	ListVoices(ctx context.Context) ([]VoiceInfo, error)
 }
 
 // UnifiedRequest represents a unified TTS request that works across providers
@@ -37,7 +44,12 @@ type UnifiedRequest struct {
 	// Provider-specific fields (optional)
 	Model        string `json:"model,omitempty"`        // OpenAI specific
 	LanguageCode string `json:"language_code,omitempty"` // Google specific
-	Instructions string `json:"instructions,omitempty"`  // For future use
+	SayAs        bool   `json:"say_as,omitempty"`        // Google specific: mark dates, times and ordinals in SSML
+	Instructions string `json:"instructions,omitempty"`  // OpenAI specific: speaking style, gpt-4o-mini-tts only
+	Style        string `json:"style,omitempty"`         // Speaking style, see ProviderCapabilities; ignored where unsupported
+	SampleRate   int    `json:"sample_rate,omitempty"`   // Google specific: sampleRateHertz; 0 keeps the voice's own rate
+	Bitrate      int    `json:"bitrate,omitempty"`       // kbit/s of MP3 audio encoded when converting or resampling, see audio.PCM.MP3
+	SpeechMarks  *SpeechMarks `json:"-"`                 // Optional: receives sentence or word timing, see Provider.GetSpeechMarkTypes
 }
 
 // UnifiedResponse represents a unified TTS response
@@ -45,18 +57,22 @@ type UnifiedResponse struct {
 	AudioData []byte
 	Format    string
 	Provider  string
+	SpeechMarks []SpeechMark // Marks requested in UnifiedRequest.SpeechMarks, if the provider reports them
 }
 
 // ProviderConfig holds configuration for all providers
 type ProviderConfig struct {
 	// OpenAI configuration
-	OpenAIAPIKey string
+	OpenAIAPIKey       string
+	OpenAIBaseURL      string // "" for DefaultOpenAIBaseURL
+	OpenAIOrganization string // OpenAI-Organization header, if set
+	OpenAIProject      string // OpenAI-Project header, if set
 
 	// Google Cloud configuration
 	GoogleProjectID   string
 	GoogleAPIKey      string // Google Cloud API key
-	GoogleAuthMethod  string // "gcloud auth" or "API Key"
-	GoogleCredentials string // Path to service account JSON or JSON content
+	GoogleAuthMethod  string // "gcloud auth", "API Key", "Service Account" or "Google Account"
+	GoogleCredentials string // Path to service account JSON or JSON content; the signed-in account for "Google Account"
 
 	// Default provider
 	DefaultProvider string
@@ -64,11 +80,11 @@ type ProviderConfig struct {
 
 // VoiceInfo represents information about a voice
 type VoiceInfo struct {
-	Name         string
-	DisplayName  string
-	LanguageCode string
-	Gender       string
-	Provider     string
+	Name         string `json:"name"`
+	DisplayName  string `json:"display_name,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"` // "" for voices that speak any language
+	Gender       string `json:"gender,omitempty"`        // "female", "male", "neutral" or "" if unknown
+	Provider     string `json:"provider"`
 }
 
 // ProviderInfo represents information about a TTS provider
@@ -0,0 +1,128 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-tts/internal/audio"
+)
+
+// QAStatus is the verdict of a quality check.
+type QAStatus string
+
+const (
+	QAPass QAStatus = "PASS"
+	QAWarn QAStatus = "WARN"
+)
+
+// QA thresholds for BuildQASummary.
+const (
+	qaMinDurationRatio = 0.6
+	qaMaxDurationRatio = 1.6
+	qaMaxSilence       = 5 * time.Second
+	qaMaxLoudnessRange = 20.0 // dB
+)
+
+// QACheck is a single line of the QA summary.
+type QACheck struct {
+	Name   string
+	Status QAStatus
+	Detail string
+}
+
+// QASummary is the end-of-job acoustic quality report.
+type QASummary struct {
+	Status   QAStatus
+	Checks   []QACheck
+	Analysis *audio.Analysis
+}
+
+// BuildQASummary computes duration, silence, loudness and substitution checks
+// for the assembled audio of a job.
+func BuildQASummary(audioData []byte, report *Report, text string, speed float64) *QASummary {
+	s := &QASummary{Status: QAPass}
+
+	analysis, err := audio.Analyze(audioData)
+	if err != nil {
+		s.add("Audio", QAWarn, fmt.Sprintf("could not analyze output: %v", err))
+		return s
+	}
+	s.Analysis = analysis
+
+	estimate := EstimateDuration(text, speed)
+	status := QAPass
+	if estimate > 0 {
+		ratio := float64(analysis.Duration) / float64(estimate)
+		if ratio < qaMinDurationRatio || ratio > qaMaxDurationRatio {
+			status = QAWarn
+		}
+	}
+	s.add("Duration", status, fmt.Sprintf("%s (estimated %s)", formatDuration(analysis.Duration), formatDuration(estimate)))
+
+	if analysis.HasLevels {
+		status = QAPass
+		if analysis.LongestSilence > qaMaxSilence {
+			status = QAWarn
+		}
+		s.add("Longest silence", status, formatDuration(analysis.LongestSilence))
+
+		status = QAPass
+		if analysis.LoudnessRange > qaMaxLoudnessRange {
+			status = QAWarn
+		}
+		s.add("Loudness range", status, fmt.Sprintf("%.1f dB", analysis.LoudnessRange))
+	} else {
+		s.add("Silence/loudness", QAPass, "not measured for this format")
+	}
+
+	if report != nil {
+		substituted := len(report.Flagged(FlagSubstituted))
+		failed := len(report.Failed())
+		status = QAPass
+		if failed > 0 {
+			status = QAWarn
+		}
+		s.add("Error segments", status, fmt.Sprintf("%d substituted, %d skipped", substituted, failed-substituted))
+
+		short := len(report.Flagged(FlagShortAudio))
+		status = QAPass
+		if short > 0 {
+			status = QAWarn
+		}
+		s.add("Truncation", status, fmt.Sprintf("%d suspiciously short section(s)", short))
+
+		if fallback := len(report.Flagged(FlagFallbackVoice)); fallback > 0 {
+			s.add("Fallback voices", QAWarn, fmt.Sprintf("%d section(s) read with a fallback voice", fallback))
+		}
+	}
+	return s
+}
+
+func (s *QASummary) add(name string, status QAStatus, detail string) {
+	s.Checks = append(s.Checks, QACheck{Name: name, Status: status, Detail: detail})
+	if status == QAWarn {
+		s.Status = QAWarn
+	}
+}
+
+// String renders the summary as one line per check.
+func (s *QASummary) String() string {
+	var b strings.Builder
+	for _, c := range s.Checks {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", c.Status, c.Name, c.Detail)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatDuration renders d as h:mm:ss or m:ss.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
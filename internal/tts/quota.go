@@ -0,0 +1,70 @@
+package tts
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrQuotaExhausted marks errors caused by an exhausted daily quota. Retrying
+// before the quota resets is pointless, so processing stops at the first one.
+var ErrQuotaExhausted = errors.New("daily quota exhausted")
+
+// QuotaExhaustedError is returned by ProcessSegments when the provider's daily
+// quota ran out. Remaining holds the segments that were not synthesized yet, so
+// the job can be resumed once the quota resets.
+type QuotaExhaustedError struct {
+	Err       error
+	Remaining []Segment
+}
+
+func (e *QuotaExhaustedError) Error() string { return e.Err.Error() }
+
+func (e *QuotaExhaustedError) Unwrap() error { return e.Err }
+
+// isDailyQuotaError reports whether err is a per-day quota error. Per-minute
+// rate limits are retried instead; they clear long before the next day.
+func isDailyQuotaError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "quota") && !strings.Contains(msg, "rate limit") && !strings.Contains(msg, "resource_exhausted") {
+		return false
+	}
+	return strings.Contains(msg, "per day") ||
+		strings.Contains(msg, "daily") ||
+		strings.Contains(msg, "(rpd)")
+}
+
+// pacific is the time zone the providers reset daily quotas in.
+var pacific = func() *time.Location {
+	if loc, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		return loc
+	}
+	return time.FixedZone("PST", -8*60*60)
+}()
+
+// QuotaResetTime returns when daily quotas reset after now: midnight Pacific time,
+// plus a few minutes of margin.
+func QuotaResetTime(now time.Time) time.Time {
+	t := now.In(pacific)
+	midnight := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, pacific)
+	return midnight.Add(5 * time.Minute)
+}
+
+// ResumeState is everything needed to finish a job stopped by an exhausted quota.
+type ResumeState struct {
+	Provider  string         `json:"provider"`
+	Request   UnifiedRequest `json:"request"`
+	Segments  []Segment      `json:"segments"`
+	InputText string         `json:"input_text"`
+	Filename  string         `json:"filename"`
+	// PartialAudio is the file holding the audio synthesized before the quota ran out.
+	PartialAudio string    `json:"partial_audio,omitempty"`
+	Chapters     []Chapter `json:"chapters,omitempty"`
+	Chunks       int       `json:"chunks"` // chunks already synthesized
+	// ChunkLimit is the user's chunk size for the provider, 0 for its default.
+	ChunkLimit int `json:"chunk_limit,omitempty"`
+	// StitchContext passes the end of the previous chunk as context, see ProcessorConfig.
+	StitchContext bool `json:"stitch_context,omitempty"`
+	// CustomScript reports whether a script hook preprocessed the text.
+	CustomScript bool `json:"custom_script,omitempty"`
+}
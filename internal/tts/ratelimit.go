@@ -0,0 +1,128 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryAfterError is a rate-limit error for which the provider said how long
+// to wait before the next request.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// retryAfter returns the delay a provider asked for in err, if any.
+func retryAfter(err error) (time.Duration, bool) {
+	var ra *RetryAfterError
+	if errors.As(err, &ra) {
+		return ra.Delay, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads the delay from the headers of a 429 response: OpenAI's
+// retry-after-ms, or the standard Retry-After in seconds or as an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(header.Get("Retry-After-Ms")), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// Limiter spaces the requests of several jobs to one provider, such as those of
+// the server, so together they stay under its rate limit. A delay the provider
+// asked for in a RetryAfterError holds back the requests of every job.
+type Limiter struct {
+	interval time.Duration // between requests; 0 only waits for Retry-After
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+}
+
+// NewLimiter returns a limiter for perMinute requests a minute; 0 or less only
+// shares Retry-After delays.
+func NewLimiter(perMinute int) *Limiter {
+	l := &Limiter{}
+	if perMinute > 0 {
+		l.interval = time.Minute / time.Duration(perMinute)
+	}
+	return l
+}
+
+// Wait blocks until the next request may start or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+	if at.Equal(now) {
+		return nil
+	}
+	t := time.NewTimer(at.Sub(now))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// holdOff makes the next request wait at least d.
+func (l *Limiter) holdOff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at := time.Now().Add(d); at.After(l.next) {
+		l.next = at
+	}
+}
+
+// limitedProvider waits for its limiter before every request.
+type limitedProvider struct {
+	Provider
+	limiter *Limiter
+}
+
+// RateLimited returns p with its requests spaced by l.
+func RateLimited(p Provider, l *Limiter) Provider {
+	return &limitedProvider{Provider: p, limiter: l}
+}
+
+func (p *limitedProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	data, err := p.Provider.GenerateSpeech(ctx, req)
+	if delay, ok := retryAfter(err); ok {
+		p.limiter.holdOff(delay)
+	}
+	return data, err
+}
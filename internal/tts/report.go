@@ -0,0 +1,154 @@
+package tts
+
+import (
+	"time"
+
+	"easy-tts/internal/audio"
+)
+
+// Chunk flags recorded in a Report.
+const (
+	// FlagShortAudio marks a chunk whose audio stayed implausibly short after a re-request.
+	FlagShortAudio = "short-audio"
+	// FlagShortAudioRetried marks a chunk that came back too short once and was re-requested successfully.
+	FlagShortAudioRetried = "short-audio-retried"
+	// FlagSubstituted marks a failed chunk whose place is taken by a filler: the
+	// substitute phrase, silence or a beep, see ProcessorConfig.FailedSection.
+	FlagSubstituted = "substituted"
+	// FlagFallbackVoice marks a chunk read with a fallback voice.
+	FlagFallbackVoice = "fallback-voice"
+)
+
+// ChunkResult records the outcome of one top-level chunk.
+type ChunkResult struct {
+	Index    int
+	Text     string
+	Voice    string
+	Speaker  string        // Dialogue speaker, if any
+	Duration time.Duration // Decoded duration of the accepted audio, or of the filler of a failed chunk
+	Attempts int           // Provider requests made for this chunk, including sub-chunks and fallbacks
+	Flags    []string
+	Error    string // Empty when the chunk produced audio
+	// Offset is the byte offset of the chunk's audio in the assembled audio; for
+	// a failed chunk, where its audio belongs.
+	Offset int
+	Start  time.Duration // Approximate playback position of Offset
+	Filler int           // Bytes of filler at Offset standing in for a failed chunk
+	File   string        // The chunk's file in ProcessorConfig.Chunks, if kept there
+	Marks  []SpeechMark  // Speech marks of Text, timed from Start; nil if the provider did not report them
+}
+
+// AddFlag records flag once.
+func (c *ChunkResult) AddFlag(flag string) {
+	for _, f := range c.Flags {
+		if f == flag {
+			return
+		}
+	}
+	c.Flags = append(c.Flags, flag)
+}
+
+// HasFlag reports whether the chunk carries flag.
+func (c *ChunkResult) HasFlag(flag string) bool {
+	for _, f := range c.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Report summarizes a ProcessTextToSpeech run chunk by chunk.
+type Report struct {
+	Chunks   []ChunkResult
+	Chapters []Chapter // Output file boundaries requested by heading styles
+}
+
+// Chapter marks where a new output file starts in the assembled audio.
+type Chapter struct {
+	Title  string
+	Offset int           // Byte offset in the audio
+	Start  time.Duration // Approximate playback position
+	Chunk  int           // Index of the first chunk of the chapter
+}
+
+// SplitChapters cuts audio at the chapter offsets into files of their own, see
+// audio.Slice. Audio before the first chapter becomes its own part. Without
+// chapters the audio is returned whole.
+func SplitChapters(data []byte, chapters []Chapter) [][]byte {
+	var parts [][]byte
+	for _, r := range ChapterRanges(len(data), chapters) {
+		parts = append(parts, audio.Slice(data, r[0], r[1]))
+	}
+	return parts
+}
+
+// ChapterRanges returns the byte ranges [start, end) SplitChapters cuts audio of
+// the given size into, for audio that is not held in memory.
+func ChapterRanges(size int, chapters []Chapter) [][2]int {
+	var ranges [][2]int
+	start := 0
+	for _, c := range chapters {
+		if c.Offset > start && c.Offset <= size {
+			ranges = append(ranges, [2]int{start, c.Offset})
+			start = c.Offset
+		}
+	}
+	if start < size {
+		ranges = append(ranges, [2]int{start, size})
+	}
+	return ranges
+}
+
+// ChapterTitles returns the title of each range ChapterRanges returns: that of
+// the chapter it starts with, or "" for audio before the first chapter.
+func ChapterTitles(size int, chapters []Chapter) []string {
+	var titles []string
+	start, title := 0, ""
+	for _, c := range chapters {
+		if c.Offset > start && c.Offset <= size {
+			titles = append(titles, title)
+			start = c.Offset
+		}
+		if c.Offset == start {
+			title = c.Title
+		}
+	}
+	if start < size {
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+// Flagged returns the chunks that carry flag.
+func (r *Report) Flagged(flag string) []ChunkResult {
+	var out []ChunkResult
+	for _, c := range r.Chunks {
+		if c.HasFlag(flag) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Failed returns the chunks that produced no audio.
+func (r *Report) Failed() []ChunkResult {
+	var out []ChunkResult
+	for _, c := range r.Chunks {
+		if c.Error != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Succeeded returns the chunks that produced audio.
+func (r *Report) Succeeded() []ChunkResult {
+	var out []ChunkResult
+	for _, c := range r.Chunks {
+		if c.Error == "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/chunkstore"
+)
+
+// RetryFailed synthesizes the failed chunks of report again and splices their
+// audio into data where each chunk belongs. provider and voice may differ from
+// the original job; an empty voice keeps each chunk's own voice. Chunks that fail
+// again keep an error and their filler. The filler of a chunk that succeeds is
+// replaced. The offsets in report are moved accordingly, and the new audio is
+// returned.
+func RetryFailed(
+	ctx context.Context,
+	provider Provider,
+	request *UnifiedRequest,
+	data []byte,
+	report *Report,
+	voice string,
+	progressCb ProgressCallback,
+	errorCb ErrorCallback,
+	cfg *ProcessorConfig,
+) []byte {
+	retryFailed(ctx, provider, request, report, voice, progressCb, errorCb, cfg, data, func(f ChunkResult, chunkAudio []byte) error {
+		data = slices.Replace(data, f.Offset, f.Offset+f.Filler, chunkAudio...)
+		return nil
+	})
+	audio.FixHeaders(data)
+	return data
+}
+
+// RetryFailedChunks is RetryFailed for a job whose audio was kept in chunks: the
+// file of each chunk that succeeds is replaced, and the output is merged from
+// chunks again afterwards. Chunks without a file, such as the one a job stopped
+// at, keep their error.
+func RetryFailedChunks(
+	ctx context.Context,
+	provider Provider,
+	request *UnifiedRequest,
+	chunks *chunkstore.Store,
+	report *Report,
+	voice string,
+	progressCb ProgressCallback,
+	errorCb ErrorCallback,
+	cfg *ProcessorConfig,
+) {
+	like, err := chunks.Head(matchHeadSize)
+	if err != nil {
+		logger(ctx).Warn("Failed to read the first chunk", "err", err)
+	}
+	retryFailed(ctx, provider, request, report, voice, progressCb, errorCb, cfg, like, func(f ChunkResult, chunkAudio []byte) error {
+		return chunks.Replace(f.File, chunkAudio)
+	})
+}
+
+// retryFailed synthesizes the failed chunks of report again and passes the audio
+// of each that succeeds to place, which puts it in place of the chunk's filler.
+// The audio is passed as an audio.Piece of the assembled audio, starting with
+// like, with its sample rate and channels.
+func retryFailed(
+	ctx context.Context,
+	provider Provider,
+	request *UnifiedRequest,
+	report *Report,
+	voice string,
+	progressCb ProgressCallback,
+	errorCb ErrorCallback,
+	cfg *ProcessorConfig,
+	like []byte,
+	place func(f ChunkResult, chunkAudio []byte) error,
+) {
+	if cfg == nil {
+		cfg = DefaultProcessorConfig()
+	}
+	ctx, cfg = jobContext(ctx, provider, cfg)
+	provider = wrapProvider(provider, cfg)
+	isGoogle := provider.GetName() == "google"
+	failed := report.Failed()
+	for n, f := range failed {
+		chunkRequest := *request
+		chunkRequest.Voice = f.Voice
+		if voice != "" {
+			chunkRequest.Voice = voice
+		}
+		result := ChunkResult{Index: f.Index, Text: f.Text, Voice: chunkRequest.Voice, Speaker: f.Speaker, Offset: f.Offset, Start: f.Start, File: f.File}
+		chunkAudio, err := processChunkRecursively(
+			chunkContext(ctx, f.Index), provider, &chunkRequest, f.Text, isGoogle,
+			cfg.MinChunkBytes, cfg.retryPolicy(), cfg.GoogleFallbackVoices,
+			nil, errorCb, &result,
+		)
+		if err == nil {
+			chunkAudio = matchFirst(ctx, chunkAudio, &like, request.Bitrate)
+		}
+		piece := audio.Piece(chunkAudio, f.Offset)
+		if err == nil && f.Offset == 0 && f.Filler == 0 && audio.HasHeader(chunkAudio) && report.audioAfter(f.Index) {
+			// Its samples would go before the header of the audio after it
+			err = errors.New("a skipped WAV or Ogg section at the very start cannot be inserted, run the job again")
+		}
+		if err == nil {
+			err = place(f, piece)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			result.Duration, result.Filler, result.Flags = f.Duration, f.Filler, f.Flags
+		} else {
+			report.shift(f.Index, len(piece)-f.Filler, result.Duration-f.Duration)
+		}
+		report.Chunks[f.Index] = result
+		if progressCb != nil {
+			progressCb(n+1, len(failed))
+		}
+	}
+}
+
+// audioAfter reports whether any chunk after chunk index has audio or a filler.
+func (r *Report) audioAfter(index int) bool {
+	for _, c := range r.Chunks[index+1:] {
+		if c.Error == "" || c.Filler > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// shift moves the chunks after chunk index and the chapters starting after it
+// by the size and duration of audio added at that chunk.
+func (r *Report) shift(index, size int, duration time.Duration) {
+	for i := index + 1; i < len(r.Chunks); i++ {
+		r.Chunks[i].Offset += size
+		r.Chunks[i].Start += duration
+	}
+	for i := range r.Chapters {
+		if r.Chapters[i].Chunk > index {
+			r.Chapters[i].Offset += size
+			r.Chapters[i].Start += duration
+		}
+	}
+}
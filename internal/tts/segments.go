@@ -0,0 +1,201 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-tts/internal/preprocess"
+)
+
+// Segment is a run of text synthesized with its own voice.
+type Segment struct {
+	Text     string
+	Voice    string
+	Language string  // Base language of the text ("de", "en"), empty if unknown
+	Speaker  string  // Dialogue speaker, empty outside dialogue scripts
+	Speed    float64 // Overrides the request speed when > 0
+
+	PauseBefore time.Duration // Silence inserted before the segment
+	PauseAfter  time.Duration // Silence inserted after the segment
+	NewFile     bool          // The segment starts a new output file
+	Heading     string        // Heading that starts the segment, if any
+}
+
+// DocumentSegments renders doc into segments read with voice. Headings are
+// announced according to the style for their level; headings with pauses or
+// file splits start their own segment.
+func DocumentSegments(doc *preprocess.Document, voice string, styles map[int]preprocess.HeadingStyle) []Segment {
+	var segments []Segment
+	current := Segment{Voice: voice}
+	flush := func() {
+		if current.Text != "" || current.PauseBefore > 0 || current.PauseAfter > 0 || current.NewFile {
+			segments = append(segments, current)
+		}
+		current = Segment{Voice: voice}
+	}
+	appendText := func(text string) {
+		if text == "" {
+			return
+		}
+		if current.Text != "" {
+			current.Text += "\n\n"
+		}
+		current.Text += text
+	}
+	for _, b := range doc.Blocks {
+		if b.Kind != preprocess.BlockHeading {
+			appendText(b.Text)
+			continue
+		}
+		style := styles[b.Level]
+		standalone := style.PauseBefore > 0 || style.PauseAfter > 0 || style.Split
+		if standalone {
+			flush()
+			current.PauseBefore = seconds(style.PauseBefore)
+			current.NewFile = style.Split
+			current.Heading = b.Text
+		}
+		appendText(style.Announcement(b))
+		if style.PauseAfter > 0 {
+			current.PauseAfter = seconds(style.PauseAfter)
+			flush()
+		}
+	}
+	flush()
+	return segments
+}
+
+// ExpandSegments replaces every segment by the segments split returns for it,
+// adding the segment's pauses to the first and last of them and keeping its file split.
+func ExpandSegments(segments []Segment, split func(Segment) []Segment) []Segment {
+	var out []Segment
+	for _, seg := range segments {
+		parts := split(seg)
+		if len(parts) == 0 {
+			parts = []Segment{{Voice: seg.Voice}}
+		}
+		parts[0].PauseBefore += seg.PauseBefore
+		parts[0].NewFile = seg.NewFile
+		parts[0].Heading = seg.Heading
+		parts[len(parts)-1].PauseAfter += seg.PauseAfter
+		out = append(out, parts...)
+	}
+	return out
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// defaultLocales maps a base language to the locale used when deriving a voice name.
+var defaultLocales = map[string]string{
+	"de": "de-DE",
+	"en": "en-US",
+}
+
+// SplitByLanguage detects the language of every paragraph and assigns the voice that
+// voiceFor returns for it. Paragraphs whose language is unclear (headings, short
+// lines) keep the voice of the paragraph before them. Adjacent paragraphs with the
+// same voice are merged into one segment.
+func SplitByLanguage(text, defaultVoice string, voiceFor func(lang string) string) []Segment {
+	var segments []Segment
+	current := Segment{Voice: defaultVoice}
+	for _, para := range strings.Split(text, "\n\n") {
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		lang := preprocess.DetectLanguage(para)
+		voice := current.Voice
+		if lang != "" {
+			if v := voiceFor(lang); v != "" {
+				voice = v
+			}
+		}
+		if voice != current.Voice && current.Text != "" {
+			segments = append(segments, current)
+			current = Segment{}
+		}
+		current.Voice = voice
+		if lang != "" && current.Language == "" {
+			current.Language = lang
+		}
+		if current.Text != "" {
+			current.Text += "\n\n"
+		}
+		current.Text += para
+	}
+	if current.Text != "" {
+		segments = append(segments, current)
+	}
+	return segments
+}
+
+// VoiceForLanguage picks the voice for lang: an explicit mapping wins, otherwise a
+// locale-prefixed voice is moved to the language's default locale
+// ("de-DE-Chirp3-HD-Kore" -> "en-US-Chirp3-HD-Kore"). Voices without a locale,
+// such as OpenAI's multilingual voices, are kept.
+func VoiceForLanguage(voice, lang string, mapping map[string]string) string {
+	if v := mapping[lang]; v != "" {
+		return v
+	}
+	current := LanguageCodeForVoice(voice)
+	locale, ok := defaultLocales[lang]
+	if current == "" || !ok || preprocess.BaseLanguage(current) == lang {
+		return voice
+	}
+	return locale + strings.TrimPrefix(voice, current)
+}
+
+// SplitIntoChunks splits text into request-sized chunks for provider, using its default limit.
+func SplitIntoChunks(provider Provider, text string) []string {
+	limit, measure := ChunkLimit(provider, 0)
+	return SplitText(text, limit, measure)
+}
+
+// ChunkLimit returns the per-request limit of provider and how text is measured
+// against it: bytes for Google, tokens for everything else. A positive override
+// replaces the default limit.
+func ChunkLimit(provider Provider, override int) (int, Measure) {
+	limit, measure := provider.GetMaxTokensPerChunk(), TokenMeasure()
+	if provider.GetName() == "google" {
+		limit, measure = DefaultByteLimit, ByteMeasure
+	}
+	if override > 0 {
+		limit = override
+	}
+	return limit, measure
+}
+
+// ValidateChunkLimit checks a user-chosen chunk limit for a provider; 0 selects the default.
+func ValidateChunkLimit(providerName string, limit int) error {
+	switch {
+	case limit == 0:
+		return nil
+	case providerName == "google" && (limit < MinByteLimit || limit > MaxByteLimit):
+		return fmt.Errorf("chunk size for google must be between %d and %d bytes", MinByteLimit, MaxByteLimit)
+	case providerName != "google" && (limit < MinTokenLimit || limit > DefaultTokenLimit):
+		return fmt.Errorf("chunk size for %s must be between %d and %d tokens", providerName, MinTokenLimit, DefaultTokenLimit)
+	}
+	return nil
+}
+
+// EstimateChunks guesses the number of chunks from the size of each segment,
+// without splitting. Chunk boundaries usually leave chunks a little short of the
+// limit, so the real count can be somewhat higher. override is as for ChunkLimit.
+func EstimateChunks(provider Provider, segments []Segment, override int) int {
+	limit, measure := ChunkLimit(provider, override)
+	total := 0
+	for _, seg := range segments {
+		total += estimateChunks(seg.Text, limit, measure)
+	}
+	return total
+}
+
+// estimateChunks guesses the number of chunks of one text.
+func estimateChunks(text string, limit int, measure Measure) int {
+	if strings.TrimSpace(text) == "" || limit <= 0 {
+		return 0
+	}
+	return max(1, (measure(text)+limit-1)/limit)
+}
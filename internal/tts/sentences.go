@@ -0,0 +1,159 @@
+package tts
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceAbbreviations end with a period without ending the sentence.
+var sentenceAbbreviations = map[string]bool{
+	// German
+	"bzw": true, "ca": true, "vgl": true, "ggf": true, "evtl": true, "inkl": true, "exkl": true,
+	"bspw": true, "sog": true, "Dr": true, "Prof": true, "Hr": true, "Fr": true, "Nr": true,
+	"Abb": true, "Kap": true, "Tab": true, "Mio": true, "Mrd": true, "Tsd": true, "St": true,
+	"Str": true, "Jh": true, "Bd": true, "Hrsg": true, "Anm": true, "geb": true, "gest": true,
+	// English
+	"vs": true, "approx": true, "Mr": true, "Mrs": true, "Ms": true, "Jr": true, "Sr": true,
+	"Fig": true, "Ch": true, "No": true, "Vol": true, "pp": true, "cf": true, "Inc": true,
+	"Ltd": true, "Co": true, "Corp": true, "Gen": true, "Sen": true, "Rev": true, "Mt": true,
+}
+
+// sentenceClosers may follow the final punctuation of a sentence.
+const sentenceClosers = "\"'“”‘’»«)]」』）"
+
+// isCJKTerminator reports whether r ends a sentence without a following space.
+func isCJKTerminator(r rune) bool {
+	return r == '。' || r == '！' || r == '？' || r == '｡'
+}
+
+// sentenceEnds returns the byte offset after each sentence in text, including
+// its closing quotes or brackets. It does not split after abbreviations ("z.B.",
+// "Dr."), initials, ordinals ("3. Mai"), decimals, ellipses continuing in lower
+// case, or before text starting in lower case; CJK full stops always end a sentence.
+func sentenceEnds(text string) []int {
+	var ends []int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isCJKTerminator(r) && !strings.ContainsRune(".!?…", r) {
+			i += size
+			continue
+		}
+
+		// Consume the punctuation run and closing quotes/brackets
+		start, j := i, i
+		cjk, strong := false, false
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if isCJKTerminator(r) {
+				cjk = true
+			} else if r == '!' || r == '?' {
+				strong = true
+			} else if r != '.' && r != '…' {
+				break
+			}
+			j += size
+		}
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !strings.ContainsRune(sentenceClosers, r) {
+				break
+			}
+			j += size
+		}
+		i = j
+
+		if cjk {
+			ends = append(ends, j)
+			continue
+		}
+		if j == len(text) {
+			ends = append(ends, j)
+			break
+		}
+		if next, _ := utf8.DecodeRuneInString(text[j:]); !unicode.IsSpace(next) {
+			continue // "3.5", "example.com", "z.B"
+		}
+		rest := strings.TrimLeft(text[j:], " \t")
+		if rest == "" || rest[0] == '\n' || rest[0] == '\r' {
+			ends = append(ends, j)
+			continue
+		}
+		if next, _ := utf8.DecodeRuneInString(rest); unicode.IsLower(next) {
+			continue
+		}
+		if !strong && text[start:j] == "." && !endsSentence(text[:start]) {
+			continue
+		}
+		ends = append(ends, j)
+	}
+	return ends
+}
+
+// endsSentence reports whether a period after the last word of before ends the
+// sentence, i.e. the word is not an abbreviation, initial or ordinal number.
+func endsSentence(before string) bool {
+	word := before[strings.LastIndexFunc(before, unicode.IsSpace)+1:]
+	word = strings.TrimLeft(word, "([\"'„“‚‘«»")
+	if word == "" {
+		return true
+	}
+	if sentenceAbbreviations[word] || dottedAbbreviation(word) {
+		return false // "Dr.", "z.B.", "e.g."
+	}
+	if len(word) <= 2 && strings.Trim(word, "0123456789") == "" {
+		return false // "am 3. Mai", "der 21. Juni"
+	}
+	if utf8.RuneCountInString(word) == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return !unicode.IsUpper(r) // "J. R. R. Tolkien"
+	}
+	return true
+}
+
+// dottedAbbreviation reports whether word consists of dotted one- or two-letter
+// parts such as "z.B" or "i.d.R", but not "example.com".
+func dottedAbbreviation(word string) bool {
+	parts := strings.Split(word, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, p := range parts {
+		if n := utf8.RuneCountInString(p); n == 0 || n > 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitInHalf splits text in two at the sentence end closest to its middle, or
+// at the closest space if text is a single sentence. Text that cannot be split is
+// returned as the only part.
+func SplitInHalf(text string) []string {
+	text = strings.TrimSpace(text)
+	middle := len(text) / 2
+	best := -1
+	for _, end := range sentenceEnds(text) {
+		if end < len(text) && (best < 0 || abs(end-middle) < abs(best-middle)) {
+			best = end
+		}
+	}
+	if best < 0 {
+		for i, r := range text {
+			if unicode.IsSpace(r) && (best < 0 || abs(i-middle) < abs(best-middle)) {
+				best = i
+			}
+		}
+	}
+	if best <= 0 {
+		return []string{text}
+	}
+	return []string{strings.TrimSpace(text[:best]), strings.TrimSpace(text[best:])}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
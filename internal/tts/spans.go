@@ -0,0 +1,110 @@
+package tts
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// span is a byte range [start, end) of text, such as quoted speech.
+type span struct{ start, end int }
+
+// phraseSpans returns the quoted passages and parenthesized asides of text that
+// a chunk boundary should not cut through, sorted by start. Quotes and brackets
+// without a partner are ignored, so a stray quote does not protect the rest of
+// the text. Typographic quotes of German („…“, »…«, ‚…‘) and English (“…”, ‘…’)
+// are recognized, as well as straight double quotes; straight single quotes and
+// ’ are too often apostrophes to count.
+func phraseSpans(text string) []span {
+	type open struct {
+		r   rune
+		pos int
+	}
+	var stack []open
+	var spans []span
+	closeTo := func(opener rune, end int) bool {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].r == opener {
+				spans = append(spans, span{stack[i].pos, end})
+				stack = stack[:i]
+				return true
+			}
+		}
+		return false
+	}
+	top := func() rune {
+		if len(stack) == 0 {
+			return 0
+		}
+		return stack[len(stack)-1].r
+	}
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		switch r {
+		case '(', '[', '„', '‚':
+			stack = append(stack, open{r, i})
+		case ')':
+			closeTo('(', end)
+		case ']':
+			closeTo('[', end)
+		case '“':
+			// Closes German „…“, opens English “…”
+			if !closeTo('„', end) {
+				stack = append(stack, open{r, i})
+			}
+		case '”':
+			if !closeTo('“', end) {
+				closeTo('„', end)
+			}
+		case '‘':
+			if !closeTo('‚', end) {
+				stack = append(stack, open{r, i})
+			}
+		case '’':
+			if top() == '‘' {
+				closeTo('‘', end)
+			}
+		case '»', '«':
+			// German »…«, French and Swiss «…»
+			partner := '«'
+			if r == '«' {
+				partner = '»'
+			}
+			if top() == partner {
+				closeTo(partner, end)
+			} else {
+				stack = append(stack, open{r, i})
+			}
+		case '"':
+			if top() == '"' {
+				closeTo('"', end)
+			} else {
+				stack = append(stack, open{r, i})
+			}
+		}
+	}
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+	return spans
+}
+
+// insideSpan reports whether a boundary at pos would cut through one of spans.
+func insideSpan(spans []span, pos int) bool {
+	for _, s := range spans {
+		if s.start >= pos {
+			break
+		}
+		if pos < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// numeric reports whether word is a number such as "3,5", "1.000" or "12".
+func numeric(word string) bool {
+	word = strings.TrimRight(word, ".,;:")
+	return word != "" && strings.IndexFunc(word, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	}) < 0
+}
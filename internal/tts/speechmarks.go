@@ -0,0 +1,39 @@
+package tts
+
+import (
+	"slices"
+	"time"
+)
+
+// Types of speech marks, see Provider.GetSpeechMarkTypes.
+const (
+	SpeechMarkSentence = "sentence"
+	SpeechMarkWord     = "word"
+)
+
+// SpeechMark is when a sentence or word of a request's text starts in its audio.
+type SpeechMark struct {
+	Type  string        `json:"type"`  // SpeechMarkSentence or SpeechMarkWord
+	Start int           `json:"start"` // Byte offset of the sentence or word in the text
+	End   int           `json:"end"`
+	Time  time.Duration `json:"time"`
+}
+
+// SpeechMarks asks a provider for speech marks along with the audio of a
+// request. Providers that report marks of the requested types set Text and
+// Marks; the others leave it untouched.
+type SpeechMarks struct {
+	Types []string     // Requested mark types
+	Text  string       // The text the marks refer to, as sent to the provider
+	Marks []SpeechMark // In the order they play
+}
+
+// Wants reports whether marks of markType were requested.
+func (m *SpeechMarks) Wants(markType string) bool {
+	return m != nil && slices.Contains(m.Types, markType)
+}
+
+// SupportsSpeechMarks reports whether provider reports marks of markType.
+func SupportsSpeechMarks(provider Provider, markType string) bool {
+	return slices.Contains(provider.GetSpeechMarkTypes(), markType)
+}
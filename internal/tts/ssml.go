@@ -0,0 +1,214 @@
+package tts
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"easy-tts/internal/preprocess"
+)
+
+// maxSSMLBytes is Google's input limit; longer SSML is sent as plain text instead.
+const maxSSMLBytes = 5000
+
+var (
+	sayAsISODateRegex  = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)
+	sayAsFullDateRegex = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{2}|\d{4})\b`)
+	sayAsDayMonthRegex = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.`)
+	sayAsTime12Regex   = regexp.MustCompile(`(?i)\b(?:1[0-2]|0?[1-9]):[0-5]\d\s?[ap]\.?m\.?`)
+	sayAsTime24Regex   = regexp.MustCompile(`\b(?:[01]?\d|2[0-3]):[0-5]\d(?::[0-5]\d)?\b`)
+	sayAsOrdinalEN     = regexp.MustCompile(`\b(\d+)(?:st|nd|rd|th)\b`)
+	sayAsOrdinalDE     = regexp.MustCompile(`\b(\d{1,3})\.\s+(\p{L}+)`)
+	sayAsWordBefore    = regexp.MustCompile(`(\p{L}+)\s+$`)
+
+	// phonemeMarkerRegex matches "{{ipa:word|phonemes}}".
+	phonemeMarkerRegex = regexp.MustCompile(`\{\{[ \t]*ipa[ \t]*:([^|}]+)\|([^}]+)\}\}`)
+	// rateMarkerRegex matches "{{rate:90%}}" and "{{/rate}}".
+	rateMarkerRegex = regexp.MustCompile(`(?i)\{\{[ \t]*(/?)rate[ \t]*(?::[ \t]*([^}]*?))?[ \t]*\}\}`)
+
+	// germanOrdinalCues precede ordinals ("am 3. Mai", "der 2. Platz").
+	germanOrdinalCues = map[string]bool{
+		"am": true, "im": true, "vom": true, "zum": true, "zur": true, "beim": true, "bis": true,
+		"der": true, "die": true, "das": true, "dem": true, "den": true, "des": true,
+		"ein": true, "eine": true, "einem": true, "einen": true, "einer": true,
+	}
+	germanMonths = map[string]bool{
+		"januar": true, "februar": true, "märz": true, "april": true, "mai": true, "juni": true,
+		"juli": true, "august": true, "september": true, "oktober": true, "november": true, "dezember": true,
+	}
+)
+
+// ssmlSpan replaces text[start:end] with an SSML element.
+type ssmlSpan struct {
+	start, end int
+	element    string
+	prosody    int // +1 opens a <prosody> element, -1 closes it
+}
+
+// SSML converts text for providers that accept SSML. "{{ipa:word|phonemes}}"
+// markers become <phoneme> elements, "{{rate:90%}}" … "{{/rate}}" a <prosody>
+// element; with sayAs, dates, times and ordinals are
+// wrapped in <say-as> elements so "3.5." is read as a date rather than "three
+// point five". It returns false if the text needs no SSML, in which case
+// PlainText(text) should be sent instead.
+func SSML(text, languageCode string, sayAs bool) (string, bool) {
+	return buildSSML(text, languageCode, sayAs, false)
+}
+
+// MarkedSSML is SSML with a <mark name="N"/> before sentence N of text, see
+// SentenceSpans, for providers that report when each mark is reached.
+func MarkedSSML(text, languageCode string, sayAs bool) string {
+	ssml, _ := buildSSML(text, languageCode, sayAs, true)
+	return ssml
+}
+
+func buildSSML(text, languageCode string, sayAs, marks bool) (string, bool) {
+	var spans []ssmlSpan
+	if marks {
+		for i, s := range SentenceSpans(text) {
+			spans = append(spans, ssmlSpan{s[0], s[0], `<mark name="` + strconv.Itoa(i) + `"/>`, 0})
+		}
+	}
+	for _, m := range phonemeMarkerRegex.FindAllStringSubmatchIndex(text, -1) {
+		word, ph := strings.TrimSpace(text[m[2]:m[3]]), strings.TrimSpace(text[m[4]:m[5]])
+		spans = append(spans, ssmlSpan{m[0], m[1],
+			`<phoneme alphabet="ipa" ph="` + escapeSSML(ph) + `">` + escapeSSML(word) + `</phoneme>`, 0})
+	}
+	for _, m := range rateMarkerRegex.FindAllStringSubmatchIndex(text, -1) {
+		if m[2] < m[3] {
+			spans = append(spans, ssmlSpan{m[0], m[1], "</prosody>", -1})
+			continue
+		}
+		rate := ""
+		if m[4] >= 0 {
+			rate, _ = preprocess.NormalizeRate(text[m[4]:m[5]])
+		}
+		if rate == "" {
+			spans = append(spans, ssmlSpan{m[0], m[1], "</prosody>", -1}) // ends a previous rate
+			continue
+		}
+		spans = append(spans, ssmlSpan{m[0], m[1], `<prosody rate="` + rate + `">`, +1})
+	}
+	if sayAs {
+		spans = append(spans, sayAsSpans(text, languageCode)...)
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	// Earlier and longer matches win over overlapping ones; marks take no text
+	// and go before a match at the same place
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		if mi, mj := spans[i].start == spans[i].end, spans[j].start == spans[j].end; mi != mj {
+			return mi
+		}
+		return spans[i].end > spans[j].end
+	})
+	var b strings.Builder
+	b.WriteString("<speak>")
+	pos := 0
+	inProsody := false
+	for _, s := range spans {
+		if s.start < pos {
+			continue
+		}
+		b.WriteString(escapeSSML(text[pos:s.start]))
+		pos = s.end
+		// Rates do not nest: a new rate replaces the current one, stray ends are dropped
+		switch {
+		case s.prosody > 0 && inProsody:
+			b.WriteString("</prosody>")
+		case s.prosody < 0 && !inProsody:
+			continue
+		}
+		if s.prosody != 0 {
+			inProsody = s.prosody > 0
+		}
+		b.WriteString(s.element)
+	}
+	b.WriteString(escapeSSML(text[pos:]))
+	if inProsody {
+		b.WriteString("</prosody>")
+	}
+	b.WriteString("</speak>")
+	return b.String(), true
+}
+
+// PlainText replaces "{{ipa:word|phonemes}}" markers with their word and drops
+// rate markers, for providers or voices without SSML support.
+func PlainText(text string) string {
+	text = rateMarkerRegex.ReplaceAllString(text, "")
+	return phonemeMarkerRegex.ReplaceAllStringFunc(text, func(m string) string {
+		return strings.TrimSpace(phonemeMarkerRegex.FindStringSubmatch(m)[1])
+	})
+}
+
+// sayAsSpans finds dates, times and ordinals in text.
+func sayAsSpans(text, languageCode string) []ssmlSpan {
+	var spans []ssmlSpan
+	add := func(start, end int, content, attrs string) {
+		spans = append(spans, ssmlSpan{start, end, "<say-as " + attrs + ">" + escapeSSML(content) + "</say-as>", 0})
+	}
+
+	for _, m := range sayAsISODateRegex.FindAllStringIndex(text, -1) {
+		add(m[0], m[1], text[m[0]:m[1]], `interpret-as="date" format="ymd"`)
+	}
+	for _, m := range sayAsFullDateRegex.FindAllStringSubmatchIndex(text, -1) {
+		if validDayMonth(text[m[2]:m[3]], text[m[4]:m[5]]) {
+			add(m[0], m[1], text[m[0]:m[1]], `interpret-as="date" format="dmy"`)
+		}
+	}
+	for _, m := range sayAsDayMonthRegex.FindAllStringSubmatchIndex(text, -1) {
+		if m[1] < len(text) && unicode.IsDigit(rune(text[m[1]])) {
+			continue // part of a full date
+		}
+		if validDayMonth(text[m[2]:m[3]], text[m[4]:m[5]]) {
+			add(m[0], m[1], text[m[0]:m[1]], `interpret-as="date" format="dm"`)
+		}
+	}
+	for _, m := range sayAsTime12Regex.FindAllStringIndex(text, -1) {
+		add(m[0], m[1], text[m[0]:m[1]], `interpret-as="time" format="hms12"`)
+	}
+	for _, m := range sayAsTime24Regex.FindAllStringIndex(text, -1) {
+		add(m[0], m[1], text[m[0]:m[1]], `interpret-as="time" format="hms24"`)
+	}
+
+	switch preprocess.BaseLanguage(languageCode) {
+	case "en":
+		for _, m := range sayAsOrdinalEN.FindAllStringSubmatchIndex(text, -1) {
+			add(m[0], m[1], text[m[2]:m[3]], `interpret-as="ordinal"`)
+		}
+	case "de":
+		for _, m := range sayAsOrdinalDE.FindAllStringSubmatchIndex(text, -1) {
+			next := text[m[4]:m[5]]
+			cue := false
+			if w := sayAsWordBefore.FindStringSubmatch(text[:m[0]]); w != nil {
+				cue = germanOrdinalCues[strings.ToLower(w[1])]
+			}
+			first := []rune(next)[0]
+			if cue || unicode.IsLower(first) || germanMonths[strings.ToLower(next)] {
+				add(m[0], m[2]+len(text[m[2]:m[3]])+1, text[m[2]:m[3]], `interpret-as="ordinal"`)
+			}
+		}
+	}
+	return spans
+}
+
+// validDayMonth reports whether day and month form a plausible calendar date.
+func validDayMonth(day, month string) bool {
+	d, err1 := strconv.Atoi(day)
+	m, err2 := strconv.Atoi(month)
+	return err1 == nil && err2 == nil && d >= 1 && d <= 31 && m >= 1 && m <= 12
+}
+
+var ssmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// escapeSSML escapes text for use inside an SSML document.
+func escapeSSML(text string) string {
+	return ssmlEscaper.Replace(text)
+}
@@ -0,0 +1,58 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxContextRunes bounds the previous text passed as context to a chunk.
+const maxContextRunes = 300
+
+// acceptsInstructions reports whether an OpenAI model takes speaking instructions;
+// tts-1 and tts-1-hd do not.
+func acceptsInstructions(model string) bool {
+	return !strings.HasPrefix(model, "tts-1")
+}
+
+// stitchesContext reports whether chunks for provider can be given the end of
+// the previous chunk as context.
+func stitchesContext(provider Provider, request *UnifiedRequest) bool {
+	return ProviderCapabilities(provider.GetName()).Instructions && acceptsInstructions(request.Model)
+}
+
+// contextInstructions extends instructions by the last sentence of the previous
+// chunk, so the voice continues its intonation instead of starting afresh.
+func contextInstructions(instructions, previous string) string {
+	sentence := lastSentence(PlainText(previous))
+	if sentence == "" {
+		return instructions
+	}
+	note := fmt.Sprintf("The text continues directly from a passage ending with: \"%s\" "+
+		"Continue in the same tone, pace and intonation as if there were no break. Do not read this passage aloud.", sentence)
+	if instructions == "" {
+		return note
+	}
+	return instructions + "\n\n" + note
+}
+
+// lastSentence returns the last sentence of text, shortened to its final words
+// if it is longer than maxContextRunes.
+func lastSentence(text string) string {
+	text = strings.TrimSpace(text)
+	ends := sentenceEnds(text)
+	for i := len(ends) - 1; i >= 0; i-- {
+		if ends[i] < len(text) {
+			text = strings.TrimSpace(text[ends[i]:])
+			break
+		}
+	}
+	if utf8.RuneCountInString(text) > maxContextRunes {
+		runes := []rune(text)
+		text = string(runes[len(runes)-maxContextRunes:])
+		if i := strings.IndexAny(text, " \n"); i >= 0 {
+			text = "…" + strings.TrimSpace(text[i:])
+		}
+	}
+	return text
+}
@@ -0,0 +1,63 @@
+package tts
+
+import "strings"
+
+// Speaking styles for UnifiedRequest.Style. Each provider maps them to its own
+// mechanism; providers without style support ignore the field.
+const (
+	StyleCheerful  = "cheerful"
+	StyleCalm      = "calm"
+	StyleNewscast  = "newscast"
+	StyleNarration = "narration"
+	StyleSerious   = "serious"
+)
+
+// styleInstructions describes each style to models that take speaking instructions.
+var styleInstructions = map[string]string{
+	StyleCheerful:  "Speak in a warm, cheerful and upbeat tone, with a smile in the voice.",
+	StyleCalm:      "Speak in a calm, relaxed and soothing tone, at an unhurried pace.",
+	StyleNewscast:  "Speak like a news anchor: crisp, neutral and authoritative, with clear articulation.",
+	StyleNarration: "Speak like an audiobook narrator: engaging and expressive, but never theatrical.",
+	StyleSerious:   "Speak in a serious, measured and matter-of-fact tone.",
+}
+
+// Capabilities lists what a provider supports beyond plain synthesis.
+type Capabilities struct {
+	Styles       []string // Speaking styles accepted in UnifiedRequest.Style
+	Instructions bool     // Takes free-form speaking instructions
+	SSML         bool     // Renders SSML features such as say-as and prosody
+	Models       []string // Models in UnifiedRequest.Model, the default first; none if there is no choice
+}
+
+// capabilityMatrix holds the capabilities per provider.
+var capabilityMatrix = map[string]Capabilities{
+	"openai": {
+		Styles:       []string{StyleCheerful, StyleCalm, StyleNewscast, StyleNarration, StyleSerious},
+		Instructions: true,
+		Models:       []string{"gpt-4o-mini-tts", "tts-1", "tts-1-hd"},
+	},
+	"google": {SSML: true}, // Google voices have no speaking styles
+}
+
+// ProviderCapabilities returns the capabilities of a provider; unknown providers have none.
+func ProviderCapabilities(providerName string) Capabilities {
+	return capabilityMatrix[providerName]
+}
+
+// DefaultModel returns the model a provider synthesizes with when none is
+// chosen, "" for providers without a choice of models.
+func DefaultModel(providerName string) string {
+	if models := capabilityMatrix[providerName].Models; len(models) > 0 {
+		return models[0]
+	}
+	return ""
+}
+
+// styledInstructions prepends the description of style to instructions.
+func styledInstructions(instructions, style string) string {
+	text, ok := styleInstructions[style]
+	if !ok {
+		return instructions
+	}
+	return strings.TrimSpace(text + "\n\n" + instructions)
+}
@@ -0,0 +1,260 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Subtitle formats written alongside the audio.
+const (
+	SubtitlesSRT = "srt"
+	SubtitlesVTT = "vtt"
+)
+
+const (
+	// maxCueChars splits longer sentences into several cues, two lines of maxCueLine.
+	maxCueChars = 84
+	maxCueLine  = 42
+)
+
+// SentenceSpans returns the start and end byte offsets of each sentence in text,
+// without the whitespace between them. Text after the last sentence end counts
+// as a sentence of its own.
+func SentenceSpans(text string) [][2]int {
+	var spans [][2]int
+	start := 0
+	for _, end := range append(sentenceEnds(text), len(text)) {
+		s := start + len(text[start:end]) - len(strings.TrimLeftFunc(text[start:end], unicode.IsSpace))
+		if strings.TrimSpace(text[s:end]) != "" {
+			spans = append(spans, [2]int{s, end})
+		}
+		start = end
+	}
+	return spans
+}
+
+// SentenceTiming is when a sentence of a chunk plays in the assembled audio.
+type SentenceTiming struct {
+	Chunk      int // Index of the chunk in the report
+	TextStart  int // Byte offset of the sentence in the chunk's text
+	TextEnd    int
+	Text       string // The sentence as spoken, without markers
+	Start, End time.Duration
+	Estimated  bool // The times are estimated from the text, not marked by the provider
+}
+
+// SentenceTimings lays the sentences of the report's chunks out along the
+// assembled audio. Sentences start where the provider marked them, or otherwise
+// at estimates in proportion to their length within their chunk. Failed chunks
+// have none.
+func SentenceTimings(report *Report) []SentenceTiming {
+	var timings []SentenceTiming
+	for i, c := range report.Chunks {
+		if c.Error != "" || c.Duration <= 0 {
+			continue
+		}
+		spans := SentenceSpans(c.Text)
+		sentences := make([]string, len(spans))
+		for i, s := range spans {
+			sentences[i] = strings.Join(strings.Fields(PlainText(c.Text[s[0]:s[1]])), " ")
+		}
+		starts, ok := markedStarts(c.Marks, spans, c.Duration)
+		if !ok {
+			starts = estimateStarts(sentences, c.Duration)
+		}
+		for j, sentence := range sentences {
+			end := c.Duration
+			if j+1 < len(starts) {
+				end = starts[j+1]
+			}
+			timings = append(timings, SentenceTiming{
+				Chunk: i, TextStart: spans[j][0], TextEnd: spans[j][1], Text: sentence,
+				Start: c.Start + starts[j], End: c.Start + end, Estimated: !ok,
+			})
+		}
+	}
+	return timings
+}
+
+// Cue is one subtitle.
+type Cue struct {
+	Start, End time.Duration
+	Text       string // Up to two lines
+}
+
+// Cues returns the subtitles of the report's sentences, see SentenceTimings.
+func Cues(report *Report) []Cue {
+	var cues []Cue
+	for _, t := range SentenceTimings(report) {
+		cues = append(cues, splitCue(t.Text, t.Start, t.End)...)
+	}
+	return cues
+}
+
+// markedStarts returns when each sentence at spans starts according to the
+// sentence marks among marks. It returns false unless every sentence has a mark
+// and they play in order within duration.
+func markedStarts(marks []SpeechMark, spans [][2]int, duration time.Duration) ([]time.Duration, bool) {
+	times := make(map[int]time.Duration)
+	for _, m := range marks {
+		if m.Type == SpeechMarkSentence {
+			times[m.Start] = m.Time
+		}
+	}
+	starts := make([]time.Duration, len(spans))
+	for i, s := range spans {
+		t, ok := times[s[0]]
+		if !ok || t > duration || i > 0 && t <= starts[i-1] {
+			return nil, false
+		}
+		starts[i] = t
+	}
+	return starts, true
+}
+
+// estimateStarts divides duration among sentences by their number of letters.
+func estimateStarts(sentences []string, duration time.Duration) []time.Duration {
+	total := 0
+	for _, s := range sentences {
+		total += utf8.RuneCountInString(s)
+	}
+	starts := make([]time.Duration, len(sentences))
+	done := 0
+	for i, s := range sentences {
+		if total > 0 {
+			starts[i] = duration * time.Duration(done) / time.Duration(total)
+		}
+		done += utf8.RuneCountInString(s)
+	}
+	return starts
+}
+
+// splitCue turns a sentence shown from start to end into cues of at most
+// maxCueChars, dividing the time by their length.
+func splitCue(sentence string, start, end time.Duration) []Cue {
+	var parts []string
+	var part strings.Builder
+	for _, word := range strings.Fields(sentence) {
+		if part.Len() > 0 && utf8.RuneCountInString(part.String())+1+utf8.RuneCountInString(word) > maxCueChars {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		if part.Len() > 0 {
+			part.WriteByte(' ')
+		}
+		part.WriteString(word)
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	starts := estimateStarts(parts, end-start)
+	cues := make([]Cue, len(parts))
+	for i, p := range parts {
+		cues[i] = Cue{Start: start + starts[i], End: end, Text: wrapCue(p)}
+		if i+1 < len(parts) {
+			cues[i].End = start + starts[i+1]
+		}
+	}
+	return cues
+}
+
+// wrapCue breaks text longer than maxCueLine into two lines at the space
+// nearest its middle.
+func wrapCue(text string) string {
+	if utf8.RuneCountInString(text) <= maxCueLine {
+		return text
+	}
+	best := -1
+	for i, r := range text {
+		if r == ' ' && (best < 0 || abs(i-len(text)/2) < abs(best-len(text)/2)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return text
+	}
+	return text[:best] + "\n" + text[best+1:]
+}
+
+// WriteSubtitles writes cues in format, SubtitlesSRT or SubtitlesVTT.
+func WriteSubtitles(w io.Writer, format string, cues []Cue) error {
+	vtt := format == SubtitlesVTT
+	if !vtt && format != SubtitlesSRT {
+		return fmt.Errorf("unknown subtitle format %q", format)
+	}
+	var b strings.Builder
+	if vtt {
+		b.WriteString("WEBVTT\n\n")
+	}
+	for i, c := range cues {
+		if !vtt {
+			fmt.Fprintf(&b, "%d\n", i+1)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", cueTime(c.Start, vtt), cueTime(c.End, vtt), c.Text)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cueTime formats d as "00:01:02,345" for SRT or "00:01:02.345" for WebVTT.
+func cueTime(d time.Duration, vtt bool) string {
+	ms := d.Milliseconds()
+	sep := ","
+	if vtt {
+		sep = "."
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// timingJSON is the document WriteTimingJSON writes.
+type timingJSON struct {
+	Audio     string           `json:"audio"`
+	Chunks    []timingChunk    `json:"chunks"`
+	Sentences []timingSentence `json:"sentences"`
+}
+
+type timingChunk struct {
+	Index int     `json:"index"`
+	Voice string  `json:"voice"`
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Error string  `json:"error,omitempty"`
+}
+
+type timingSentence struct {
+	Chunk     int     `json:"chunk"`
+	TextStart int     `json:"text_start"`
+	TextEnd   int     `json:"text_end"`
+	Text      string  `json:"text"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	Estimated bool    `json:"estimated,omitempty"`
+}
+
+// WriteTimingJSON writes the start and end in seconds of every chunk and
+// sentence of the report's audio, named audioName, for read-along apps and
+// editing tools. Sentences refer to their chunk's text by byte offsets.
+func WriteTimingJSON(w io.Writer, audioName string, report *Report) error {
+	doc := timingJSON{Audio: audioName, Chunks: []timingChunk{}, Sentences: []timingSentence{}}
+	for _, c := range report.Chunks {
+		doc.Chunks = append(doc.Chunks, timingChunk{
+			Index: c.Index, Voice: c.Voice, Text: c.Text,
+			Start: c.Start.Seconds(), End: (c.Start + c.Duration).Seconds(), Error: c.Error,
+		})
+	}
+	for _, t := range SentenceTimings(report) {
+		doc.Sentences = append(doc.Sentences, timingSentence{
+			Chunk: t.Chunk, TextStart: t.TextStart, TextEnd: t.TextEnd, Text: t.Text,
+			Start: t.Start.Seconds(), End: t.End.Seconds(), Estimated: t.Estimated,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
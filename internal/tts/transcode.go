@@ -0,0 +1,69 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/devstats"
+)
+
+// transcodingProvider serves formats the wrapped provider cannot produce by
+// requesting one it can and converting the audio, e.g. WAV from OpenAI. Every
+// chunk of a job then has the same container, even after a provider switch.
+type transcodingProvider struct {
+	Provider
+}
+
+// GetSupportedFormats adds the containers the provider's audio converts into.
+func (p *transcodingProvider) GetSupportedFormats() []string {
+	formats := p.Provider.GetSupportedFormats()
+	for _, to := range []string{"mp3", "wav"} {
+		if !p.produces(to) && p.source(to) != "" {
+			formats = append(formats, to)
+		}
+	}
+	return formats
+}
+
+func (p *transcodingProvider) GenerateSpeech(ctx context.Context, req *UnifiedRequest) ([]byte, error) {
+	to := audio.NormalizeFormat(req.Format)
+	from := p.source(to)
+	if p.produces(to) || from == "" {
+		return p.Provider.GenerateSpeech(ctx, req)
+	}
+	override := *req
+	override.Format = from
+	data, err := p.Provider.GenerateSpeech(ctx, &override)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := audio.Convert(data, to, req.Bitrate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s audio to %s: %w", audio.NormalizeFormat(from), to, err)
+	}
+	devstats.Add("transcode.chunks", 1)
+	logger(ctx).Debug("Converted audio", "from", audio.NormalizeFormat(from), "to", to, "bytes", len(converted))
+	return converted, nil
+}
+
+// produces reports whether the provider returns container itself.
+func (p *transcodingProvider) produces(container string) bool {
+	for _, f := range p.Provider.GetSupportedFormats() {
+		if audio.NormalizeFormat(f) == container {
+			return true
+		}
+	}
+	return false
+}
+
+// source returns the provider's format to request for audio converted into
+// container, or "" if it has none that converts.
+func (p *transcodingProvider) source(container string) string {
+	for _, f := range p.Provider.GetSupportedFormats() {
+		if audio.CanConvert(f, container) {
+			return f
+		}
+	}
+	return ""
+}
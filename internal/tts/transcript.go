@@ -0,0 +1,63 @@
+package tts
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JobSettings are the settings an output was synthesized with, written next to
+// it with its transcript so the audio can be reproduced later.
+type JobSettings struct {
+	Audio         string            `json:"audio"`
+	Provider      string            `json:"provider"`
+	Voice         string            `json:"voice"`
+	Model         string            `json:"model,omitempty"`
+	Speed         float64           `json:"speed"`
+	Format        string            `json:"format"`
+	LanguageCode  string            `json:"language_code,omitempty"`
+	SayAs         bool              `json:"say_as,omitempty"`
+	Instructions  string            `json:"instructions,omitempty"`
+	Style         string            `json:"style,omitempty"`
+	SampleRate    int               `json:"sample_rate,omitempty"`
+	Bitrate       int               `json:"bitrate,omitempty"`
+	ChunkLimit    int               `json:"chunk_limit"`
+	ChunkUnit     string            `json:"chunk_unit"` // "bytes" or "tokens"
+	StitchContext bool              `json:"stitch_context,omitempty"`
+	Settings      map[string]string `json:"settings,omitempty"` // Other settings, see JobSummary.Settings
+	Created       time.Time         `json:"created"`
+}
+
+// NewJobSettings returns the settings of a job that sent request to provider,
+// split into chunks of at most chunkLimit (0 for the provider's default).
+func NewJobSettings(provider Provider, request *UnifiedRequest, chunkLimit int, stitchContext bool) JobSettings {
+	limit, _ := ChunkLimit(provider, chunkLimit)
+	unit := "tokens"
+	if provider.GetName() == "google" {
+		unit = "bytes"
+	}
+	return JobSettings{
+		Provider:      provider.GetName(),
+		Voice:         request.Voice,
+		Model:         request.Model,
+		Speed:         request.Speed,
+		Format:        request.Format,
+		LanguageCode:  request.LanguageCode,
+		SayAs:         request.SayAs,
+		Instructions:  request.Instructions,
+		Style:         request.Style,
+		SampleRate:    request.SampleRate,
+		Bitrate:       request.Bitrate,
+		ChunkLimit:    limit,
+		ChunkUnit:     unit,
+		StitchContext: stitchContext,
+		Created:       time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// WriteJobSettings writes settings as indented JSON.
+func WriteJobSettings(w io.Writer, settings JobSettings) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(settings)
+}
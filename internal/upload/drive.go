@@ -0,0 +1,68 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// GoogleDrive uploads into a folder of Google Drive with the application default
+// credentials, which need the drive.file scope: a service account the folder is
+// shared with, or "gcloud auth application-default login" with
+// --scopes=https://www.googleapis.com/auth/drive.file,https://www.googleapis.com/auth/cloud-platform.
+type GoogleDrive struct {
+	service  *drive.Service
+	folderID string
+}
+
+// NewGoogleDrive returns an uploader into the Drive folder with folderID, the
+// last part of its URL; "" for My Drive.
+func NewGoogleDrive(ctx context.Context, folderID string) (*GoogleDrive, error) {
+	service, err := drive.NewService(ctx, option.WithScopes(drive.DriveFileScope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Google Drive: %w", err)
+	}
+	if folderID == "" {
+		folderID = "root"
+	}
+	return &GoogleDrive{service: service, folderID: folderID}, nil
+}
+
+// Upload puts the file at path into the folder, replacing the content of a file
+// of the same name there, since Drive would otherwise keep both.
+func (g *GoogleDrive) Upload(ctx context.Context, path string) error {
+	f, _, contentType, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	name := filepath.Base(path)
+	query := fmt.Sprintf("name = '%s' and '%s' in parents and trashed = false", driveEscape(name), driveEscape(g.folderID))
+	existing, err := g.service.Files.List().Q(query).Fields("files(id)").PageSize(1).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	if len(existing.Files) > 0 {
+		_, err = g.service.Files.Update(existing.Files[0].Id, &drive.File{}).Media(f).Context(ctx).Do()
+		return err
+	}
+	file := &drive.File{Name: name, Parents: []string{g.folderID}, MimeType: contentType}
+	_, err = g.service.Files.Create(file).Media(f).Context(ctx).Do()
+	return err
+}
+
+func (g *GoogleDrive) String() string {
+	if g.folderID == "root" {
+		return "Google Drive"
+	}
+	return "Google Drive folder " + g.folderID
+}
+
+// driveEscape quotes s for a string literal of a Drive search query.
+func driveEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
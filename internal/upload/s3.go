@@ -0,0 +1,175 @@
+package upload
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload leaves the body out of the signature, so files are streamed
+// instead of read twice. S3 accepts it over HTTPS.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3 uploads into a bucket of Amazon S3 or storage with the same API, such as
+// Cloudflare R2 or MinIO.
+type S3 struct {
+	endpoint          *url.URL // nil for AWS
+	region, bucket    string
+	prefix            string
+	accessKey, secret string
+}
+
+// NewS3 returns an uploader into bucket under prefix. An empty endpoint selects
+// AWS; other endpoints are addressed path-style, "https://host/bucket/key".
+func NewS3(endpoint, region, bucket, prefix, accessKey, secret string) (*S3, error) {
+	if bucket == "" || accessKey == "" || secret == "" {
+		return nil, errors.New("S3 needs a bucket, an access key ID and a secret access key")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	s := &S3{region: region, bucket: bucket, prefix: strings.TrimPrefix(prefix, "/"), accessKey: accessKey, secret: secret}
+	if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q is not an S3 endpoint URL", endpoint)
+		}
+		s.endpoint = u
+	}
+	return s, nil
+}
+
+// Upload puts the file at path into the bucket as prefix + its base name.
+func (s *S3) Upload(ctx context.Context, path string) error {
+	f, size, contentType, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(s.prefix+filepath.Base(path)), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	s.sign(req, unsignedPayload, time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		if code := xmlValue(string(body), "Code"); code != "" {
+			return fmt.Errorf("%w: %s", statusError(resp.StatusCode), code)
+		}
+		return statusError(resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *S3) objectURL(key string) string {
+	if s.endpoint == nil {
+		return (&url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com", Path: "/" + key}).String()
+	}
+	return s.endpoint.JoinPath(s.bucket, key).String()
+}
+
+// sign adds an AWS Signature Version 4 to req, signing its host and all headers
+// set so far.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		awsEscapePath(req.URL.EscapedPath()),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonical)
+	key := hmacSHA256([]byte("AWS4"+s.secret), day)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func (s *S3) String() string {
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+// awsEscapePath re-escapes an escaped URL path the way AWS signs it: every byte
+// but unreserved characters and slashes percent-encoded.
+func awsEscapePath(escaped string) string {
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		path = escaped
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// xmlValue returns the text of the first <tag> element in an S3 error document.
+func xmlValue(doc, tag string) string {
+	_, after, ok := strings.Cut(doc, "<"+tag+">")
+	if !ok {
+		return ""
+	}
+	value, _, _ := strings.Cut(after, "</"+tag+">")
+	return value
+}
@@ -0,0 +1,85 @@
+// Package upload copies finished outputs to remote storage: a WebDAV folder, an
+// S3 bucket or a Google Drive folder, e.g. one that syncs to a phone.
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// Kinds of upload targets.
+const (
+	KindWebDAV      = "webdav"
+	KindS3          = "s3"
+	KindGoogleDrive = "gdrive"
+)
+
+// Uploader puts files on remote storage, replacing files of the same name.
+type Uploader interface {
+	// Upload copies the file at path into the target under its base name.
+	Upload(ctx context.Context, path string) error
+	// String describes the target for messages, e.g. "s3://bucket/prefix".
+	String() string
+}
+
+// Target is the configuration of an upload target.
+type Target struct {
+	Kind     string // KindWebDAV, KindS3 or KindGoogleDrive
+	URL      string // The WebDAV folder, or the S3 endpoint for storage other than AWS
+	Username string // The WebDAV user or S3 access key ID
+	Secret   string // The WebDAV password or S3 secret access key
+	Bucket   string // S3 only
+	Region   string // S3 only; "" for us-east-1
+	Prefix   string // S3 only: key prefix, e.g. "audiobooks/"
+	FolderID string // Google Drive only; "" for My Drive
+}
+
+// New returns the uploader configured by t.
+func New(ctx context.Context, t Target) (Uploader, error) {
+	switch t.Kind {
+	case KindWebDAV:
+		return NewWebDAV(t.URL, t.Username, t.Secret)
+	case KindS3:
+		return NewS3(t.URL, t.Region, t.Bucket, t.Prefix, t.Username, t.Secret)
+	case KindGoogleDrive:
+		return NewGoogleDrive(ctx, t.FolderID)
+	}
+	return nil, fmt.Errorf("unknown upload target %q", t.Kind)
+}
+
+// Files uploads the files at paths to u, continuing past failures. It returns
+// the number of files uploaded.
+func Files(ctx context.Context, u Uploader, paths []string) (int, error) {
+	var errs []error
+	n := 0
+	for _, path := range paths {
+		if err := u.Upload(ctx, path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// open opens the file at path for upload and returns its size and content type.
+func open(path string) (*os.File, int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, "", err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return f, info.Size(), contentType, nil
+}
@@ -0,0 +1,86 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// WebDAV uploads into a folder of a WebDAV server, such as Nextcloud.
+type WebDAV struct {
+	folder             *url.URL
+	username, password string
+}
+
+// NewWebDAV returns an uploader into the WebDAV folder at folderURL, e.g.
+// "https://cloud.example.com/remote.php/dav/files/me/Audio".
+func NewWebDAV(folderURL, username, password string) (*WebDAV, error) {
+	u, err := url.Parse(strings.TrimSpace(folderURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not a WebDAV folder URL", folderURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &WebDAV{folder: u, username: username, password: password}, nil
+}
+
+// Upload puts the file at path into the folder, creating the folder once if
+// the server reports it missing.
+func (w *WebDAV) Upload(ctx context.Context, path string) error {
+	err := w.put(ctx, path)
+	if status, ok := err.(statusError); ok && (status == http.StatusNotFound || status == http.StatusConflict) {
+		if err = w.do(ctx, "MKCOL", w.folder.String(), nil, 0, ""); err == nil {
+			err = w.put(ctx, path)
+		}
+	}
+	return err
+}
+
+func (w *WebDAV) put(ctx context.Context, path string) error {
+	f, size, contentType, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return w.do(ctx, http.MethodPut, w.folder.JoinPath(filepath.Base(path)).String(), f, size, contentType)
+}
+
+func (w *WebDAV) do(ctx context.Context, method, target string, body io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return statusError(resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *WebDAV) String() string {
+	return w.folder.Redacted()
+}
+
+// statusError is an unexpected HTTP status of a storage server.
+type statusError int
+
+func (s statusError) Error() string {
+	return fmt.Sprintf("the server answered %d %s", int(s), http.StatusText(int(s)))
+}
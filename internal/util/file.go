@@ -1,37 +1,103 @@
 package util
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultFilenameTemplate names outputs after the first two words of the text,
+// e.g. "Text_Hello_World.mp3".
+const DefaultFilenameTemplate = "Text_{title:2}.{ext}"
+
+// defaultTitleWords is the number of words of {title} without a count.
+const defaultTitleWords = 5
+
+var filenamePlaceholderRegex = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+// FilenameFields are the values filled into a filename template.
+type FilenameFields struct {
+	Text     string // The input text, whose first words make the title
+	Voice    string
+	Provider string
+	Speed    float64
+	Ext      string // Without the dot, e.g. "mp3"
+	Time     time.Time
+}
+
 // GenerateFilename creates a filename based on the first few words of the input text.
 func GenerateFilename(inputText string) string {
-	words := strings.Fields(inputText)
-	filename := "Text_output.mp3"
-	if len(words) >= 2 {
-		w1, w2 := SanitizeFilenameWord(words[0]), SanitizeFilenameWord(words[1])
-		filename = fmt.Sprintf("Text_%s_%s.mp3", w1, w2)
-	} else if len(words) == 1 {
-		w1 := SanitizeFilenameWord(words[0])
-		filename = fmt.Sprintf("Text_%s.mp3", w1)
+	return ExpandFilenameTemplate(DefaultFilenameTemplate, FilenameFields{Text: inputText, Ext: "mp3"})
+}
+
+// ExpandFilenameTemplate returns the filename template describes for fields.
+// Placeholders are {title} (the first five words of the text, {title:N} for N
+// words), {date} (2006-01-02), {time} (15-04), {voice}, {provider}, {speed} and
+// {ext}; unknown ones are left out. Without {ext} the extension is appended, and
+// an empty template is DefaultFilenameTemplate.
+func ExpandFilenameTemplate(template string, fields FilenameFields) string {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultFilenameTemplate
+	}
+	hasExt := false
+	name := filenamePlaceholderRegex.ReplaceAllStringFunc(template, func(m string) string {
+		parts := filenamePlaceholderRegex.FindStringSubmatch(m)
+		switch strings.ToLower(parts[1]) {
+		case "title":
+			n := defaultTitleWords
+			if parts[2] != "" {
+				n, _ = strconv.Atoi(parts[2])
+			}
+			return filenameTitle(fields.Text, n)
+		case "date":
+			return fields.Time.Format("2006-01-02")
+		case "time":
+			return fields.Time.Format("15-04")
+		case "voice":
+			return SanitizeFilenameWord(fields.Voice)
+		case "provider":
+			return SanitizeFilenameWord(fields.Provider)
+		case "speed":
+			return strconv.FormatFloat(fields.Speed, 'f', -1, 64)
+		case "ext":
+			hasExt = true
+			return fields.Ext
+		}
+		return ""
+	})
+	if !hasExt {
+		name += "." + fields.Ext
 	}
-	return filename
+	if name = SanitizeFilename(name); name == "" {
+		return "output." + fields.Ext
+	}
+	return name
 }
 
-// SaveAudioFile saves the audio data to the Downloads directory.
-func SaveAudioFile(data []byte, filename string) (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+// filenameTitle joins the first n words of text, or "output" if it has none.
+func filenameTitle(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return "output"
+	}
+	words = words[:min(n, len(words))]
+	for i, w := range words {
+		words[i] = SanitizeFilenameWord(w)
 	}
-	outPath := filepath.Join(homeDir, "Downloads", filename)
+	return strings.Join(words, "_")
+}
 
-	err = os.WriteFile(outPath, data, 0644)
+// SaveAudioFile saves the audio data to the Downloads directory, under a
+// numbered name if filename is taken, see UniqueOutputPath.
+func SaveAudioFile(data []byte, filename string) (string, error) {
+	outPath, err := OutputPath("", filename)
 	if err != nil {
-		return "", fmt.Errorf("failed to save file to %s: %w", outPath, err)
+		return "", err
+	}
+	outPath = UniqueOutputPath(outPath)
+	if err := WriteAudioFile(outPath, data); err != nil {
+		return "", err
 	}
 	return outPath, nil
 }
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outputLocks holds the output paths reserved by running or queued jobs.
+var outputLocks = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: map[string]bool{}}
+
+// OutputPath returns the location for filename in dir, or in the Downloads
+// directory if dir is empty.
+func OutputPath(dir, filename string) (string, error) {
+	if dir != "" {
+		return filepath.Join(dir, filename), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Downloads", filename), nil
+}
+
+// LockOutputPath reserves path for one job. It returns false if another job holds it.
+func LockOutputPath(path string) bool {
+	outputLocks.Lock()
+	defer outputLocks.Unlock()
+	if outputLocks.paths[path] {
+		return false
+	}
+	outputLocks.paths[path] = true
+	return true
+}
+
+// UnlockOutputPath releases a path reserved with LockOutputPath.
+func UnlockOutputPath(path string) {
+	outputLocks.Lock()
+	defer outputLocks.Unlock()
+	delete(outputLocks.paths, path)
+}
+
+// OutputLocked reports whether another job has reserved path.
+func OutputLocked(path string) bool {
+	outputLocks.Lock()
+	defer outputLocks.Unlock()
+	return outputLocks.paths[path]
+}
+
+// OutputExists reports whether a file already exists at path.
+func OutputExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// UniqueOutputPath returns path, or "name (2).ext", "name (3).ext", ... if path
+// exists on disk or is reserved by another job.
+func UniqueOutputPath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for n := 2; OutputExists(candidate) || OutputLocked(candidate); n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+	return candidate
+}
+
+// WriteAudioFile writes data to path.
+func WriteAudioFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save file to %s: %w", path, err)
+	}
+	return nil
+}
+
+// CreateTempOutput creates a hidden file next to path for a job to write its
+// audio into while it runs. MoveAudioFile puts it in place once it is complete,
+// so a half-written file never appears under the final name.
+func CreateTempOutput(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the output folder: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary output: %w", err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to create temporary output: %w", err)
+	}
+	return f, nil
+}
+
+// MoveAudioFile moves the file at tmp to path, replacing any file there.
+func MoveAudioFile(tmp, path string) error {
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save file to %s: %w", path, err)
+	}
+	return nil
+}
+
+// CopyAudioFile writes the audio read from r to path.
+func CopyAudioFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err == nil {
+		_, err = io.Copy(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save file to %s: %w", path, err)
+	}
+	return nil
+}
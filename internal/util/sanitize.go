@@ -2,6 +2,8 @@ package util
 
 import (
 	"regexp"
+	"strings"
+	"unicode/utf8"
 )
 
 // SanitizeFilenameWord cleans a single word for use in a filename.
@@ -17,3 +19,36 @@ func SanitizeFilenameWord(word string) string {
 	}
 	return sanitized
 }
+
+// maxFilenameBytes leaves room for a " (2)" suffix within the 255 bytes most
+// file systems allow for a name.
+const maxFilenameBytes = 240
+
+var (
+	// Path separators, the characters Windows reserves and control characters
+	unsafeFilenameRegex = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f\x7f]`)
+	// Device names Windows does not allow as file names, with any extension
+	reservedFilenameRegex = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])(\..*)?$`)
+)
+
+// SanitizeFilename makes name usable as the name of a file in the output
+// folder. Path separators and other characters file systems reserve become
+// underscores, and leading dots as well as trailing dots and spaces are removed,
+// so the name can neither point to another folder nor hide the file. It returns
+// "" if no usable name is left, e.g. for "..".
+func SanitizeFilename(name string) string {
+	name = unsafeFilenameRegex.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	if len(name) > maxFilenameBytes {
+		cut := maxFilenameBytes
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	name = strings.TrimRight(name, ". ")
+	if reservedFilenameRegex.MatchString(name) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxTextFileBytes is the largest document ReadTextFile reads; longer ones make
+// the input field unresponsive and are better split into several jobs.
+const MaxTextFileBytes = 4 << 20
+
+// ErrNotText is returned for files that do not look like text, such as a PDF.
+var ErrNotText = errors.New("not a text file")
+
+// windows1252 maps the bytes 0x80-0x9F of Windows-1252 to their characters; the
+// other bytes above 0x7F are the same as in Latin-1. Unassigned bytes map to U+FFFD.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// ReadTextFile reads a text document of at most MaxTextFileBytes from r, see DecodeText.
+func ReadTextFile(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxTextFileBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxTextFileBytes {
+		return "", fmt.Errorf("the file is larger than %d MB, please split it", MaxTextFileBytes>>20)
+	}
+	return DecodeText(data)
+}
+
+// DecodeText returns data as text: UTF-8 with or without a byte order mark,
+// UTF-16 with one, or otherwise Windows-1252, which covers Latin-1 files from
+// older editors. Line endings become "\n".
+func DecodeText(data []byte) (string, error) {
+	var text string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		text = string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		text = decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		text = decodeUTF16(data[2:], binary.BigEndian)
+	case bytes.IndexByte(data, 0) >= 0:
+		return "", ErrNotText
+	case utf8.Valid(data):
+		text = string(data)
+	default:
+		var b strings.Builder
+		for _, c := range data {
+			switch {
+			case c < 0x80 || c > 0x9F:
+				b.WriteRune(rune(c))
+			default:
+				b.WriteRune(windows1252[c-0x80])
+			}
+		}
+		text = b.String()
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n"), nil
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
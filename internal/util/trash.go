@@ -0,0 +1,163 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MoveToTrash moves path, a file or folder, to the system trash (macOS Trash, the freedesktop.org trash
+// on Linux, the Recycle Bin on Windows) so it can be restored. Files are never
+// deleted permanently; if no trash is available an error is returned instead.
+func MoveToTrash(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot trash %s: %w", path, err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		// Finder records where the file came from, so "Put Back" works.
+		script := fmt.Sprintf(`tell application "Finder" to delete POSIX file %q`, path)
+		if out, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to move %s to the Trash: %v: %s", path, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case "windows":
+		method := "DeleteFile"
+		if info.IsDir() {
+			method = "DeleteDirectory"
+		}
+		script := fmt.Sprintf(`Add-Type -AssemblyName Microsoft.VisualBasic; `+
+			`[Microsoft.VisualBasic.FileIO.FileSystem]::%s('%s', 'OnlyErrorDialogs', 'SendToRecycleBin')`,
+			method, strings.ReplaceAll(path, "'", "''"))
+		if out, err := exec.Command("powershell", "-NoProfile", "-Command", script).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to move %s to the Recycle Bin: %v: %s", path, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	default:
+		return moveToXDGTrash(path)
+	}
+}
+
+// moveToXDGTrash implements the freedesktop.org trash specification: a path on
+// the filesystem of the home trash goes there, one on another filesystem, such
+// as an external drive, to the trash at the top of its own filesystem, as moving
+// it to the home trash would copy it.
+func moveToXDGTrash(path string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	trashDir, infoPath := filepath.Join(dataHome, "Trash"), path
+	if topdir, ok := trashTopdir(path, dataHome); ok {
+		dir, err := topdirTrash(topdir)
+		if err != nil {
+			return err
+		}
+		// Paths in the trash of a filesystem are relative to its top, so they
+		// stay valid wherever it is mounted
+		trashDir = dir
+		if rel, err := filepath.Rel(topdir, path); err == nil {
+			infoPath = rel
+		}
+	}
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash: %w", err)
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash: %w", err)
+	}
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+	name := stem + ext
+	var info *os.File
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			info = f
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to write trash info: %w", err)
+		}
+		name = fmt.Sprintf("%s.%d%s", stem, n, ext)
+	}
+	infoFile := info.Name()
+	_, err := fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: infoPath}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	info.Close()
+	if err != nil {
+		os.Remove(infoFile)
+		return fmt.Errorf("failed to write trash info: %w", err)
+	}
+	if err := os.Rename(path, filepath.Join(filesDir, name)); err != nil {
+		os.Remove(infoFile)
+		return fmt.Errorf("failed to move %s to the trash: %w", path, err)
+	}
+	return nil
+}
+
+// trashTopdir returns the top directory of the filesystem of path if that is
+// not the filesystem of the home trash in dataHome.
+func trashTopdir(path, dataHome string) (string, bool) {
+	dev, ok := deviceID(path)
+	if !ok {
+		return "", false
+	}
+	// The home trash may not exist yet; its nearest existing parent tells
+	home := dataHome
+	for {
+		if _, err := os.Stat(home); err == nil || filepath.Dir(home) == home {
+			break
+		}
+		home = filepath.Dir(home)
+	}
+	if homeDev, ok := deviceID(home); !ok || homeDev == dev {
+		return "", false
+	}
+	top := filepath.Dir(path)
+	for parent := filepath.Dir(top); parent != top; parent = filepath.Dir(top) {
+		if d, ok := deviceID(parent); !ok || d != dev {
+			break
+		}
+		top = parent
+	}
+	return top, true
+}
+
+// topdirTrash returns the trash of the current user at the top directory of a
+// filesystem: $topdir/.Trash/$uid if an administrator set up $topdir/.Trash,
+// a real folder with the sticky bit, else $topdir/.Trash-$uid.
+func topdirTrash(topdir string) (string, error) {
+	uid := strconv.Itoa(os.Getuid())
+	shared := filepath.Join(topdir, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(shared, uid)
+		if err := os.MkdirAll(dir, 0700); err == nil {
+			return dir, nil
+		}
+	}
+	dir := filepath.Join(topdir, ".Trash-"+uid)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create trash: %w", err)
+	}
+	return dir, nil
+}
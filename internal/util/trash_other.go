@@ -0,0 +1,8 @@
+//go:build !unix
+
+package util
+
+// deviceID is not available here, so everything goes to the home trash.
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,321 @@
+// Package webpage downloads web pages and extracts the body of their article,
+// leaving out navigation, sidebars, comments and other page furniture.
+package webpage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxPageBytes is the largest page Fetch downloads.
+	maxPageBytes = 10 << 20
+	// fetchTimeout bounds the whole download.
+	fetchTimeout = 30 * time.Second
+)
+
+// Article is the readable content of a web page.
+type Article struct {
+	Title string
+	Text  string // Paragraphs separated by blank lines, headings and lists as Markdown
+}
+
+// Markdown returns the article under its title as a top-level heading.
+func (a *Article) Markdown() string {
+	if a.Title == "" {
+		return a.Text
+	}
+	return "# " + a.Title + "\n\n" + a.Text
+}
+
+// Fetch downloads the page at rawURL and extracts its article.
+func Fetch(ctx context.Context, rawURL string) (*Article, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err == nil && u.Scheme == "" {
+		u, err = url.Parse("https://" + strings.TrimSpace(rawURL))
+	}
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not a web address", rawURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Quacker-TTS (+https://github.com/anschmieg/Quacker-TTS)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,text/plain;q=0.8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the page: %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	body := io.LimitReader(resp.Body, maxPageBytes)
+	if strings.HasPrefix(contentType, "text/plain") {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return &Article{Text: strings.TrimSpace(strings.ToValidUTF8(string(data), "�"))}, nil
+	}
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, fmt.Errorf("the page is %s, not a web page", contentType)
+	}
+	r, err := charset.NewReader(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(r)
+}
+
+// Extract parses an HTML page from r and returns its article: the <article> or
+// <main> element if the page has one, otherwise the element whose paragraphs
+// hold the most text that is not links.
+func Extract(r io.Reader) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the page: %w", err)
+	}
+	a := &Article{Title: pageTitle(doc)}
+	prune(doc)
+	root := findFirst(doc, atom.Article)
+	if root == nil || textLength(root) < 200 {
+		root = findFirst(doc, atom.Main)
+	}
+	if root == nil || textLength(root) < 200 {
+		root = bestCandidate(doc)
+	}
+	if root == nil {
+		return nil, errors.New("the page has no readable text")
+	}
+	var w textWriter
+	w.write(root)
+	w.flush()
+	a.Text = strings.Join(w.paragraphs, "\n\n")
+	// The article usually repeats the title as its first heading
+	if first, rest, _ := strings.Cut(a.Text, "\n\n"); strings.EqualFold(strings.TrimLeft(first, "# "), a.Title) {
+		a.Text = rest
+	}
+	if strings.TrimSpace(a.Text) == "" {
+		return nil, errors.New("the page has no readable text")
+	}
+	return a, nil
+}
+
+// pageTitle returns the og:title of the page, or else its <title> or first <h1>.
+func pageTitle(doc *html.Node) string {
+	title := ""
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Meta:
+				if attr(n, "property") == "og:title" && attr(n, "content") != "" {
+					title = attr(n, "content")
+					return
+				}
+			case atom.Title:
+				if title == "" {
+					title = collapse(text(n))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if title == "" {
+		if h1 := findFirst(doc, atom.H1); h1 != nil {
+			title = collapse(text(h1))
+		}
+	}
+	return collapse(title)
+}
+
+// furniture are elements that never belong to an article.
+var furniture = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Select: true, atom.Textarea: true, atom.Dialog: true, atom.Menu: true,
+}
+
+// furnitureHints mark page furniture by class or id.
+var furnitureHints = []string{"comment", "sidebar", "share", "social", "related", "newsletter",
+	"cookie", "banner", "advert", "promo", "breadcrumb", "footer", "header", "menu", "nav"}
+
+// prune removes page furniture from the tree.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && (furniture[c.DataAtom] || isFurniture(c)) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isFurniture(n *html.Node) bool {
+	if attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" || attr(n, "role") == "navigation" {
+		return true
+	}
+	if n.DataAtom == atom.Html || n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	hints := strings.ToLower(attr(n, "class") + " " + attr(n, "id"))
+	for _, h := range furnitureHints {
+		if strings.Contains(hints, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// bestCandidate returns the element whose paragraphs hold the most text, less
+// the text of their links.
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := make(map[*html.Node]int)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Blockquote) && n.Parent != nil {
+			scores[n.Parent] += textLength(n) - 2*linkLength(n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	var best *html.Node
+	for n, s := range scores {
+		if s > 0 && (best == nil || s > scores[best]) {
+			best = n
+		}
+	}
+	if best == nil {
+		return findFirst(doc, atom.Body)
+	}
+	return best
+}
+
+// textWriter turns an element into paragraphs of text.
+type textWriter struct {
+	paragraphs []string
+	current    strings.Builder
+	prefix     string // Markdown of the paragraph being written, e.g. "## " or "- "
+}
+
+var headingLevels = map[atom.Atom]int{atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6}
+
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Blockquote: true,
+	atom.Li: true, atom.Tr: true, atom.Br: true, atom.Hr: true, atom.Dt: true, atom.Dd: true,
+	atom.Figcaption: true, atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Table: true,
+}
+
+func (w *textWriter) write(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.current.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if n.DataAtom == atom.Img || n.DataAtom == atom.Picture || n.DataAtom == atom.Video || n.DataAtom == atom.Audio {
+			return
+		}
+	}
+	level, heading := headingLevels[n.DataAtom]
+	block := heading || blockElements[n.DataAtom]
+	if block {
+		w.flush()
+		switch {
+		case heading:
+			w.prefix = strings.Repeat("#", max(level, 2)) + " " // below the article title
+		case n.DataAtom == atom.Li:
+			w.prefix = "- "
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.write(c)
+	}
+	if block {
+		w.flush()
+	}
+}
+
+func (w *textWriter) flush() {
+	p := collapse(w.current.String())
+	w.current.Reset()
+	if p != "" {
+		w.paragraphs = append(w.paragraphs, w.prefix+p)
+	}
+	w.prefix = ""
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(text(c))
+	}
+	return b.String()
+}
+
+func textLength(n *html.Node) int {
+	return utf8.RuneCountInString(collapse(text(n)))
+}
+
+func linkLength(n *html.Node) int {
+	length := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			length += textLength(c)
+		} else {
+			length += linkLength(c)
+		}
+	}
+	return length
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
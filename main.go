@@ -1,44 +1,124 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 
+	"easy-tts/internal/audio"
+	"easy-tts/internal/cache"
+	"easy-tts/internal/checkpoint"
+	"easy-tts/internal/chunkstore"
 	"easy-tts/internal/config"
+	"easy-tts/internal/demo"
+	"easy-tts/internal/epub"
 	"easy-tts/internal/gui"
+	"easy-tts/internal/history"
+	"easy-tts/internal/preprocess"
+	"easy-tts/internal/project"
+	"easy-tts/internal/recovery"
+	"easy-tts/internal/retention"
+	"easy-tts/internal/scheduler"
+	"easy-tts/internal/script"
+	"easy-tts/internal/sidecar"
 	"easy-tts/internal/tts"
+	"easy-tts/internal/upload"
 	"easy-tts/internal/util"
+	"easy-tts/internal/webpage"
 )
 
 func main() {
 	// Load configuration
 	config.LoadEnvFiles()
+	setupLogging()
 	appConfig, err := config.LoadConfig()
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(exitInvalid)
+	}
+
+	// Load non-secret preferences
+	appSettings, err := config.LoadSettings()
+	if err != nil {
+		slog.Warn("Failed to load settings, using defaults", "err", err)
+	}
+	if _, err := config.SyncSettings(appSettings); err != nil {
+		slog.Warn("Settings sync skipped", "err", err)
+	}
+	if err := applyOverrides(appConfig.File, appSettings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(exitInvalid)
+	}
+
+	// Open job history and the deferred jobs
+	var jobHistory *history.Store
+	var deferredJobs *scheduler.Scheduler
+	var checkpoints *checkpoint.Store
+	var recentDocs *history.Recent
+	var drafts *recovery.Store
+	if dataDir, err := config.AppDataDir(); err == nil {
+		jobHistory, err = history.Open(dataDir)
+		if err != nil {
+			slog.Warn("History disabled, failed to load it", "err", err)
+			jobHistory = nil
+		}
+		recentDocs, err = history.OpenRecent(dataDir)
+		if err != nil {
+			slog.Warn("Recent documents reset, failed to load them", "err", err)
+		}
+		deferredJobs, err = scheduler.Open(filepath.Join(dataDir, "deferred"))
+		if err != nil {
+			slog.Warn("Deferred jobs disabled", "err", err)
+			deferredJobs = nil
+		}
+		checkpoints = checkpoint.Open(filepath.Join(dataDir, "checkpoint"))
+		drafts = recovery.Open(dataDir)
+	} else {
+		slog.Warn("History disabled", "err", err)
 	}
 
+	// Enforce the retention policy in the background
+	go func() {
+		res, err := retention.Apply(retentionPolicy(appSettings), retentionCacheDirs(jobHistory), jobHistory, time.Now())
+		if err != nil {
+			slog.Warn("Retention cleanup failed", "err", err)
+			return
+		}
+		if res.CacheFilesRemoved > 0 || res.OutputsArchived > 0 {
+			slog.Info("Retention cleanup done", "cache_files_removed", res.CacheFilesRemoved, "outputs_archived", res.OutputsArchived)
+		}
+	}()
+
 	// Create TTS provider configuration
 	providerConfig := &tts.ProviderConfig{
-		OpenAIAPIKey:     appConfig.OpenAIAPIKey,
-		GoogleProjectID:  appConfig.GoogleProjectID,
-		GoogleAPIKey:     appConfig.GoogleAPIKey,
-		GoogleAuthMethod: appConfig.GoogleAuthMethod,
-		DefaultProvider:  appConfig.DefaultProvider,
+		OpenAIAPIKey:      appConfig.OpenAIAPIKey,
+		GoogleProjectID:   appConfig.GoogleProjectID,
+		GoogleAPIKey:      appConfig.GoogleAPIKey,
+		GoogleAuthMethod:  appConfig.GoogleAuthMethod,
+		GoogleCredentials: appConfig.GoogleCredentials,
+		DefaultProvider:   appConfig.DefaultProvider,
 	}
+	providerConfig.OpenAIBaseURL, providerConfig.OpenAIOrganization, providerConfig.OpenAIProject = appSettings.OpenAIEndpoint()
 
 	// Initialize TTS manager
 	ttsManager := tts.NewManager(providerConfig)
@@ -46,14 +126,25 @@ func main() {
 	// Get available providers
 	availableProviders := ttsManager.GetAvailableProviders()
 	if len(availableProviders) == 0 {
-		fmt.Println("No TTS providers configured. Please configure at least one provider.")
+		fmt.Fprintln(os.Stderr, "No TTS providers configured. Please configure at least one provider.")
+	}
+
+	// Commands such as "synth FILE" or "batch DIR" run without opening a window
+	if len(os.Args) > 1 {
+		defaultProvider := appConfig.DefaultProvider
+		if defaultProvider == "" && len(availableProviders) > 0 {
+			defaultProvider = availableProviders[0]
+		}
+		if code, ok := runCommand(ttsManager, appSettings, jobHistory, defaultProvider, os.Args[1], os.Args[2:]); ok {
+			os.Exit(code)
+		}
 	}
 
 	// Placeholder for settings dialog callback
 	var showSettings func()
 
 	// Initialize the Fyne app
-	a := app.New()
+	a := app.NewWithID("com.anschmieg.quacker")
 
 	// Current provider state
 	var currentProvider string
@@ -67,232 +158,198 @@ func main() {
 	// Track initialization state
 	var uiInitialized bool
 
+	// The .quack project open in the window, if any
+	session := &projectSession{}
+
 	// Create the UI with callbacks
 	var ui *gui.UI
 	ui = gui.NewUI(a, availableProviders,
-		func() { handleSubmit(ui, ttsManager, currentProvider) },
+		func() {
+			handleSubmit(ui, ttsManager, currentProvider, appSettings, jobHistory, deferredJobs, checkpoints, session)
+		},
 		func() { showSettings() },
 		func(provider string) {
 			currentProvider = provider
 			if uiInitialized {
-				updateVoiceForProvider(ui, ttsManager, provider)
+				updateVoiceForProvider(ui, ttsManager, provider, appSettings)
 			}
 		},
 	)
 
+	if appSettings.DefaultSpeed != 0 {
+		ui.Speed.SetValue(appSettings.DefaultSpeed)
+	}
+
 	// Mark UI as initialized
 	uiInitialized = true
 
-	// Define settings dialog function for configuring providers
-	showSettings = func() {
-		showProviderSettingsDialog(ui, ttsManager, &currentProvider)
+	if jobHistory != nil {
+		ui.AddMenuItem("Quacker", "History", func() {
+			gui.ShowHistoryWindow(a, jobHistory, func(text string) {
+				ui.Input.SetText(text)
+				ui.Window.RequestFocus()
+			})
+		})
 	}
 
-	// Set initial provider after UI is fully initialized
-	if currentProvider != "" {
-		ui.ProviderSelect.SetSelected(currentProvider)
-		updateVoiceForProvider(ui, ttsManager, currentProvider)
+	ui.AddMenuItem("Quacker", "Preview processed text", func() {
+		showPreview(a, ui, ttsManager, currentProvider, appSettings, session)
+	})
+	ui.AddMenuItem("Quacker", "Estimate cost and duration", func() {
+		showEstimate(ui, ttsManager, currentProvider, appSettings, session)
+	})
+	ui.AddMenuItem("Quacker", "Voice matrix", func() {
+		showVoiceMatrix(a, ui, ttsManager, currentProvider, appSettings, session)
+	})
+	ui.AddMenuItem("Quacker", "Review document", func() {
+		showDocumentReview(a, ui, appSettings)
+	})
+	if checkpoints != nil {
+		ui.AddMenuItem("Quacker", "Resume last job", func() {
+			resumeLastJob(ui, ttsManager, deferredJobs, jobHistory, appSettings, checkpoints)
+		})
 	}
-
-	// Show settings dialog at startup only if no providers are configured
-	if len(availableProviders) == 0 {
-		showSettings()
+	ui.AddMenuItem("Quacker", "Try a demo", func() {
+		startDemo(a, ui, ttsManager, appSettings, session)
+	})
+	ui.SetPasteAndSpeak(func() {
+		handleSubmit(ui, ttsManager, currentProvider, appSettings, jobHistory, deferredJobs, checkpoints, session)
+	})
+	// File → Open Recent and Recent Outputs follow opened documents and finished jobs
+	var refreshRecent func()
+	opened := func(path string) {
+		if recentDocs != nil {
+			if err := recentDocs.Add(path); err != nil {
+				slog.Warn("Failed to remember recent document", "path", path, "err", err)
+			}
+			refreshRecent()
+		}
 	}
-
-	// Run the app
-	ui.Window.ShowAndRun()
-}
-
-// Remove Markdown formatting (common symbols)
-func stripMarkdown(s string) string {
-	reg := regexp.MustCompile(`[\\*_#\\[\\]()>~\` + "`" + `]+`)
-	return reg.ReplaceAllString(s, "")
-}
-
-// Helper: extract language code from a voice string (e.g. de-DE-Chirp3-HD-Sulafat -> de-DE)
-func extractLangCode(voice string) string {
-	parts := strings.Split(voice, "-")
-	if len(parts) >= 2 {
-		return parts[0] + "-" + parts[1]
-	}
-	return "en-US"
-}
-
-// Helper: build fallback voices list
-func buildFallbackVoices(origLang, origVoice string) []string {
-	return []string{
-		fmt.Sprintf("%s-Chirp3-HD-%s", origLang, origVoice),
-		fmt.Sprintf("%s-Chirp-HD-O", origLang),
-		fmt.Sprintf("%s-Neural2-G", origLang),
-		fmt.Sprintf("%s-Standard-G", origLang),
-		fmt.Sprintf("%s-Studio-C", origLang),
-	}
-}
-
-// Recursive chunk processing with sub-chunking on failure, one-word min, special char/Markdown sanitization, and voice fallback
-func processChunkRecursively(
-	ctx context.Context,
-	provider tts.Provider,
-	request *tts.UnifiedRequest,
-	chunk string,
-	chunkLimit int,
-	minLimit int,
-	isGoogle bool,
-	progressCb func(),
-	uiErrorCb func(string),
-) ([]byte, error) {
-	var data []byte
-	var err error
-	origVoice := request.Voice
-	origLang := extractLangCode(origVoice)
-	words := strings.Fields(chunk)
-
-	// 1. Normal attempts
-	for attempt := 1; attempt <= 3; attempt++ {
-		data, err = provider.GenerateSpeech(ctx, &tts.UnifiedRequest{
-			Text:   chunk,
-			Voice:  request.Voice,
-			Speed:  request.Speed,
-			Format: request.Format,
-			Model:  request.Model,
-		})
-		if err == nil {
-			if progressCb != nil {
-				progressCb()
+	ui.AddMenuItem("File", "Open...", func() {
+		openTextFile(ui, session, opened)
+	})
+	ui.AddMenuItem("File", "Save Project", func() {
+		saveProject(ui, session, currentProvider, appSettings, false, opened)
+	})
+	ui.AddMenuItem("File", "Save Project As...", func() {
+		saveProject(ui, session, currentProvider, appSettings, true, opened)
+	})
+	if recentDocs != nil || jobHistory != nil {
+		refreshRecent = func() {
+			var docs []string
+			if recentDocs != nil {
+				docs = recentDocs.Paths()
 			}
-			return data, nil
+			ui.SetRecentMenus(docs, recentJobs(jobHistory), func(path string) {
+				if _, err := os.Stat(path); err != nil {
+					ui.ShowError(fmt.Sprintf("Cannot open %s: %v", filepath.Base(path), err))
+					recentDocs.Remove(path)
+					refreshRecent()
+					return
+				}
+				if openDocument(ui, session, path) {
+					opened(path)
+				}
+			})
 		}
-		if attempt < 3 && (strings.Contains(err.Error(), "502") ||
-			strings.Contains(err.Error(), "context deadline exceeded") ||
-			strings.Contains(err.Error(), "DeadlineExceeded")) {
-			time.Sleep(2 * time.Second)
-			continue
+		refreshRecent()
+		if jobHistory != nil {
+			jobHistory.OnChange(refreshRecent)
 		}
-		break
+	}
+	ui.AddMenuItem("File", "Read from URL...", func() {
+		readFromURL(ui)
+	})
+	ui.AddMenuItem("File", "Convert folder...", func() {
+		convertFolder(ui, ttsManager, currentProvider, appSettings, jobHistory)
+	})
+	ui.SetEstimator(durationEstimate)
+
+	// Hidden developer panel: Cmd/Ctrl+Shift+D
+	ui.Window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+		gui.ShowDeveloperPanel(a)
+	})
+
+	if deferredJobs != nil {
+		deferredJobs.Handle(resumeJobKind, func(job scheduler.Job) error {
+			return resumeQuotaJob(ttsManager, deferredJobs, jobHistory, appSettings, job)
+		})
+		deferredJobs.Start()
 	}
 
-	// 2. Sub-chunking if possible
-	if chunkLimit > minLimit && len(words) > 1 {
-		var subChunks []string
-		if isGoogle {
-			subChunks = tts.SplitTextByteLimit(chunk, chunkLimit/2)
-		} else {
-			subChunks = tts.SplitTextTokenLimit(chunk, "cl100k_base", chunkLimit/2)
-		}
-		var audio []byte
-		for _, sub := range subChunks {
-			subData, subErr := processChunkRecursively(ctx, provider, request, sub, chunkLimit/2, minLimit, isGoogle, progressCb, uiErrorCb)
-			if subErr != nil {
-				return nil, subErr
-			}
-			audio = append(audio, subData...)
-		}
-		return audio, nil
+	// Define settings dialog function for configuring providers
+	showSettings = func() {
+		showProviderSettingsDialog(ui, ttsManager, &currentProvider, appSettings, jobHistory, session)
 	}
 
-	// 3. If chunk is a single word and <200 bytes, try sanitizing and retry once
-	if len(words) == 1 && len([]byte(chunk)) < 200 {
-		sanitized := sanitizeWordForTTS(chunk)
-		if sanitized != chunk && sanitized != "" {
-			data, err = provider.GenerateSpeech(ctx, &tts.UnifiedRequest{
-				Text:   sanitized,
-				Voice:  request.Voice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
-			if err == nil {
-				if progressCb != nil {
-					progressCb()
-				}
-				return data, nil
-			}
-		}
-		// Try stripping Markdown and retry once more
-		mdStripped := stripMarkdown(chunk)
-		if mdStripped != chunk && mdStripped != "" {
-			data, err = provider.GenerateSpeech(ctx, &tts.UnifiedRequest{
-				Text:   mdStripped,
-				Voice:  request.Voice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
-			if err == nil {
-				if progressCb != nil {
-					progressCb()
-				}
-				return data, nil
-			}
-		}
+	// Bring back the window as it was left; the last provider and voice take
+	// the place of the default if still configured
+	lastProvider, lastVoice := ui.RestoreState(a.Preferences())
+	if slices.Contains(availableProviders, lastProvider) {
+		currentProvider = lastProvider
 	}
 
-	// 4. Fallback voices for Google provider only
-	if isGoogle && len([]byte(chunk)) <= 200 {
-		fallbackVoices := buildFallbackVoices(origLang, origVoice)
-		for _, fallbackVoice := range fallbackVoices {
-			data, err = provider.GenerateSpeech(ctx, &tts.UnifiedRequest{
-				Text:   chunk,
-				Voice:  fallbackVoice,
-				Speed:  request.Speed,
-				Format: request.Format,
-				Model:  request.Model,
-			})
-			if err == nil {
-				if progressCb != nil {
-					progressCb()
-				}
-				log.Printf("Fallback voice succeeded: %s", fallbackVoice)
-				return data, nil
-			}
+	// Set initial provider after UI is fully initialized
+	if currentProvider != "" {
+		ui.ProviderSelect.SetSelected(currentProvider)
+		updateVoiceForProvider(ui, ttsManager, currentProvider, appSettings)
+		if currentProvider == lastProvider && lastVoice != "" {
+			ui.Voice.SetText(lastVoice)
 		}
 	}
 
-	// 5. Final fallback: error message chunk (en-US)
-	if isGoogle && len([]byte(chunk)) <= 200 {
-		log.Printf("All fallback voices failed for chunk (len=%d): %.100s", len(chunk), chunk)
-		if uiErrorCb != nil {
-			uiErrorCb(fmt.Sprintf(
-				"A section could not be processed (%.40s...). Substituting error message and continuing.", chunk))
-		}
-		data, err = provider.GenerateSpeech(ctx, &tts.UnifiedRequest{
-			Text:   "Error converting Text. Continuing.",
-			Voice:  "en-US-" + origVoice,
-			Speed:  request.Speed,
-			Format: request.Format,
-			Model:  request.Model,
-		})
-		if err == nil {
-			if progressCb != nil {
-				progressCb()
+	// Without any configured provider, offer the demo before asking for credentials
+	if len(availableProviders) == 0 {
+		go func() {
+			if ui.AskConfirm("Welcome to Quacker", "No TTS provider is configured yet. Would you like to try a demo first? It needs no account.") {
+				fyne.Do(func() { startDemo(a, ui, ttsManager, appSettings, session) })
+			} else {
+				fyne.Do(showSettings)
 			}
-			return data, nil
+		}()
+	} else if checkpoints != nil {
+		// Offer to finish a job interrupted by a crash or quit
+		if state, ok := checkpoints.Last(); ok {
+			go func() {
+				if ui.AskConfirm("Resume last job", fmt.Sprintf("%s was interrupted after %d chunk(s). Resume it where it stopped?",
+					history.TitleFromText(state.InputText), state.Chunks)) {
+					fyne.Do(func() { resumeLastJob(ui, ttsManager, deferredJobs, jobHistory, appSettings, checkpoints) })
+				}
+			}()
 		}
 	}
 
-	// Log and show user-friendly error
-	log.Printf("Final failed chunk (len=%d): %.100s", len(chunk), chunk)
-	if uiErrorCb != nil {
-		uiErrorCb(fmt.Sprintf(
-			"A section could not be processed (%.40s...). Try rephrasing or splitting it manually.", chunk))
+	// Offer the text autosaved before a crash or quit, then keep autosaving it
+	var autosaving atomic.Bool
+	if drafts != nil {
+		startDraft := editorDraft(ui)
+		go func() {
+			offerRecovery(ui, drafts, jobHistory, startDraft)
+			autosaving.Store(true)
+			for range time.Tick(autosaveInterval) {
+				var draft recovery.Draft
+				fyne.DoAndWait(func() { draft = editorDraft(ui) })
+				if err := drafts.Save(draft); err != nil {
+					slog.Warn("Autosave failed", "err", err)
+				}
+			}
+		}()
 	}
-	return nil, err
-}
-
-// Remove special characters, keep only letters, numbers, and spaces
-func sanitizeWordForTTS(s string) string {
-	var b strings.Builder
-	for _, r := range s {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == ' ' {
-			b.WriteRune(r)
+	a.Lifecycle().SetOnStopped(func() {
+		ui.SaveState(a.Preferences(), currentProvider)
+		if autosaving.Load() {
+			if err := drafts.Save(editorDraft(ui)); err != nil {
+				slog.Warn("Autosave failed", "err", err)
+			}
 		}
-	}
-	return b.String()
+	})
+
+	// Run the app
+	ui.Window.ShowAndRun()
 }
 
 // handleSubmit processes the submit action
-func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
+func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string, settings *config.Settings, jobHistory *history.Store, deferredJobs *scheduler.Scheduler, checkpoints *checkpoint.Store, session *projectSession) {
 	if providerName == "" {
 		fyne.Do(func() {
 			ui.ShowError("Error: No TTS provider selected.")
@@ -310,7 +367,9 @@ func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
 
 	// Capture UI values before starting goroutine
 	inputText := ui.Input.Text
+	instructions := ui.Instructions.Text
 	voice := ui.Voice.Text
+	style := ui.SelectedStyle()
 	speed := ui.Speed.Value
 
 	// Basic validation
@@ -323,8 +382,8 @@ func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
 	ui.SetSubmitEnabled(false)
 	ui.SetProcessingMessage("Starting TTS processing...")
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// Create the job context; the processor applies the per-chunk and job timeouts
+	ctx, cancel := context.WithCancel(context.Background())
 	// Do NOT defer cancel() here! Only call cancel() if you want to abort early or after all work is done.
 
 	// Get provider instance
@@ -340,7 +399,7 @@ func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("Panic in submit handler: %v", r)
+				slog.Error("Panic in submit handler", "panic", r)
 				ui.SetSubmitEnabled(true)
 				ui.ShowError(fmt.Sprintf("Internal error: %v", r))
 			} else {
@@ -348,85 +407,346 @@ func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
 			}
 		}()
 
-		log.Printf("Starting TTS request: provider=%s, voice=%s, speed=%f, text_length=%d",
-			providerName, voice, speed, len(inputText))
+		started := time.Now()
+		slog.Info("Starting TTS request", "provider", providerName, "voice", voice, "speed", speed, "text_bytes", len(inputText))
 
 		// 1. Authorization check
 		ui.SetProcessingMessage("Checking authorization...")
-		if err := provider.CheckAuth(ctx); err != nil {
-			log.Printf("Authorization failed: %v", err)
+		authCtx, cancelAuth := context.WithTimeout(ctx, time.Minute)
+		err := provider.CheckAuth(authCtx)
+		cancelAuth()
+		if err != nil {
+			slog.Error("Authorization failed", "err", err)
 			ui.ShowError(fmt.Sprintf("Authorization failed: %v", err))
 			return
 		}
 
-		// 2. Prepare request template
+		// 2. Preprocess the text and split it into segments with their voices
+		text, segments, hook, err := prepareJob(providerName, inputText, voice, session.scriptFor(settings), settings)
+		if err != nil {
+			ui.ShowError(err.Error())
+			return
+		}
+
+		// 3. Prepare request template
 		request := &tts.UnifiedRequest{
-			Text:   inputText,
+			Text:   text,
 			Voice:  voice,
 			Speed:  speed,
-			Format: "mp3",
+			Format: tts.FormatFor(provider, settings.OutputFormat),
+			Style:  style,
+
+			SampleRate: settings.SampleRate,
+			Bitrate:    settings.Bitrate,
+			Model:      modelFor(providerName, settings),
 		}
 		if providerName == "openai" {
-			request.Model = "gpt-4o-mini-tts"
+			request.Instructions = instructions
 		}
-
-		// Determine total chunks for progress reporting
-		var totalChunks int
-		if provider.GetName() == "google" {
-			totalChunks = len(tts.SplitTextByteLimit(inputText, tts.DefaultByteLimit))
-		} else {
-			totalChunks = len(tts.SplitTextTokenLimit(inputText, "cl100k_base", provider.GetMaxTokensPerChunk()))
+		request.SayAs = settings.SayAsHints
+
+		// Estimate total chunks for progress reporting; chunks are split while synthesizing
+		chunkLimit := session.chunkLimitFor(providerName, settings)
+		reviewed := session.chunksFor(inputText)
+		if settings.ReviewChunks {
+			ui.SetProcessingMessage("Waiting for the chunk review...")
+			var ok bool
+			if segments, reviewed, ok = reviewChunks(ui, provider, segments, voice, chunkLimit, reviewed); !ok {
+				ui.SetProcessingMessage("Cancelled.")
+				cancel()
+				return
+			}
+			session.setChunks(inputText, reviewed)
+		} else if reviewed != nil {
+			// Chunks reviewed earlier, e.g. saved in the project, still apply to the same text
+			if s, ok := applyChunks(segments, reviewed); ok {
+				segments = s
+			}
 		}
+		pauser := tts.NewPauser()
+		totalChunks := tts.EstimateChunks(provider, segments, chunkLimit)
 		ui.SetProgress(0)
 		ui.SetProcessingMessage(fmt.Sprintf("Processing chunk 1 of %d...", totalChunks))
 
-		// 3. Call the processor
-		var audioData []byte
-		progressCb := func(completed, total int) {
-			ui.SetProgress(float64(completed) / float64(total))
-			ui.SetProcessingMessage(fmt.Sprintf("Processing chunk %d of %d...", completed, total))
+		// 4. Call the processor
+		var report *tts.Report
+		processed, total := 0, totalChunks
+		progressCb := func(completed, chunks int) {
+			processed, total = completed, chunks
+			ui.SetProgress(float64(completed) / float64(chunks))
+			ui.SetProcessingMessage(fmt.Sprintf("Processing chunk %d of %d...", completed, chunks))
+		}
+		pauser.OnPaused = func() {
+			ui.SetProcessingMessage(fmt.Sprintf("Paused after %d of %d chunks. Press Resume to continue.", processed, total))
 		}
 		uiErrorCb := func(msg string) {
 			ui.ShowError(msg)
 		}
 
-		audioData, err = tts.ProcessTextToSpeech(ctx, provider, request, progressCb, uiErrorCb, nil)
-		// Always save audio file if any audio was produced, even on error
-		if len(audioData) > 0 {
-			filename := util.GenerateFilename(inputText)
-			savedPath, saveErr := util.SaveAudioFile(audioData, filename)
-			if err != nil {
-				// Error occurred, but we have partial audio
-				if saveErr == nil {
-					ui.ShowError(fmt.Sprintf("Partial audio saved to %s. Some sections could not be processed.", filepath.Base(savedPath)))
-					fyne.CurrentApp().SendNotification(&fyne.Notification{
-						Title:   "Partial Success",
-						Content: fmt.Sprintf("Partial audio saved to: %s", filepath.Base(savedPath)),
-					})
-				} else {
-					ui.ShowError(fmt.Sprintf("Error occurred and failed to save partial audio: %v", saveErr))
+		cfg := tts.DefaultProcessorConfig()
+		cfg.ChunkLimit = chunkLimit
+		cfg.StitchContext = settings.StitchContext
+		cfg.Pause = pauser
+		cfg.Cache = synthesisCache(settings)
+		applyRetrySettings(cfg, settings)
+		applyClipSettings(cfg, settings, true)
+		cfg.Crossfade = time.Duration(settings.Crossfade * float64(time.Second))
+		cfg.SpeechMarks = settings.Subtitles != "" || settings.TimingJSON
+		cfg.OnBreakerTrip = askProviderFailing(ui, ttsManager, settings)
+		cfg.JobID = tts.NewJobID()
+		cfg.Hooks.OnRetry = func(e tts.RetryEvent) {
+			ui.SetProcessingMessage(fmt.Sprintf("Chunk %d failed, retrying in %v...", e.Index+1, e.Delay.Round(time.Second)))
+		}
+		state := tts.ResumeState{
+			Provider:      providerName,
+			Request:       *request,
+			Segments:      segments,
+			InputText:     inputText,
+			Filename:      outputFilename(settings, providerName, inputText, request, hook),
+			ChunkLimit:    chunkLimit,
+			StitchContext: cfg.StitchContext,
+			CustomScript:  hook != nil,
+		}
+		if checkpoints != nil {
+			// The checkpoint outlives a crash or quit; once the job got this far it is done with
+			if err := checkpoints.Begin(state, nil); err != nil {
+				slog.Warn("Checkpoints disabled", "err", err)
+			} else {
+				cfg.Checkpoint = saveCheckpoint(checkpoints)
+				defer checkpoints.Finish()
+			}
+		}
+		// The output is assembled in a temporary file next to it, so long documents
+		// do not fill the memory and no half-written file appears
+		outPath, err := util.OutputPath(settings.OutputDir, state.Filename)
+		var out *os.File
+		if err == nil {
+			out, err = util.CreateTempOutput(outPath)
+		}
+		if err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to create the output file: %v", err))
+			return
+		}
+		defer os.Remove(out.Name()) // already gone once moved into place
+		// Each chunk is kept in a file of its own and merged into the output at the
+		// end, so failed sections can be replaced later; the files stay as long as
+		// there are any
+		chunks, keepChunks := openChunkStore(), false
+		defer func() {
+			if chunks != nil && !keepChunks {
+				chunks.Remove()
+			}
+		}()
+		if chunks != nil {
+			cfg.Chunks = chunks
+		} else {
+			cfg.Output = out
+		}
+		ui.SetPausable(pauser)
+		_, report, err = tts.ProcessSegments(ctx, provider, request, segments, progressCb, uiErrorCb, cfg)
+		ui.SetPausable(nil)
+		if chunks != nil {
+			if _, mergeErr := chunks.Merge(out); mergeErr != nil && err == nil {
+				err = mergeErr
+			}
+		}
+		if fixErr := audio.FixFile(out); fixErr != nil && err == nil {
+			err = fixErr
+		}
+		size := 0
+		if info, statErr := out.Stat(); statErr == nil {
+			size = int(info.Size())
+		}
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write audio: %w", closeErr)
+		}
+		var quotaErr *tts.QuotaExhaustedError
+		if errors.As(err, &quotaErr) && deferredJobs != nil {
+			done := len(report.Succeeded())
+			resetAt := tts.QuotaResetTime(time.Now())
+			slog.Warn("Daily quota exhausted", "chunks_done", done, "chunks", totalChunks, "err", quotaErr.Err)
+			if ui.AskConfirm("Daily quota exhausted", fmt.Sprintf(
+				"The daily quota of %s ran out after %d of %d chunks. Resume automatically when it resets (%s)? You will be notified when the file is complete.",
+				providerName, done, totalChunks, resetAt.Local().Format("Mon 15:04"))) {
+				state.Segments = quotaErr.Remaining
+				state.Chapters = report.Chapters
+				state.Chunks = done
+				partial, err := os.Open(out.Name())
+				if err == nil {
+					err = scheduleResume(deferredJobs, state, partial, resetAt)
+					partial.Close()
+				}
+				if err != nil {
+					ui.ShowError(fmt.Sprintf("Failed to schedule the remaining chunks: %v", err))
+					return
 				}
+				ui.ShowSuccess(fmt.Sprintf("Quota exhausted – the remaining %d chunk(s) will be processed at %s", totalChunks-done, resetAt.Local().Format("Mon 15:04")))
 				return
 			}
-			log.Printf("TTS generation successful, audio data size: %d bytes", len(audioData))
 		}
-
-		// Update UI for file saving
+		if err != nil && size == 0 {
+			ui.ShowError(fmt.Sprintf("No audio could be generated: %v", err))
+			return
+		}
+		// Save the audio once, partial audio too if some sections failed
 		ui.SetProcessingMessage("Saving audio file...")
-
-		filename := util.GenerateFilename(inputText)
-		log.Printf("Saving audio file: %s", filename)
-		savedPath, err := util.SaveAudioFile(audioData, filename)
+		filename := outputFilename(settings, providerName, inputText, request, hook)
+		slog.Info("Saving audio file", "path", filename)
+		savedPath, saveErr := saveOutput(ui, settings, out.Name(), filename)
 		if err != nil {
-			log.Printf("Failed to save file: %v", err)
-			ui.ShowError(fmt.Sprintf("Failed to save file: %v", err))
+			// Error occurred, but we have partial audio
+			if saveErr == nil && savedPath == "" {
+				ui.ShowError("Some sections could not be processed; the partial audio was not saved.")
+			} else if saveErr == nil {
+				ui.ShowError(fmt.Sprintf("Partial audio saved to %s. Some sections could not be processed.", filepath.Base(savedPath)))
+				fyne.CurrentApp().SendNotification(&fyne.Notification{
+					Title:   "Partial Success",
+					Content: fmt.Sprintf("Partial audio saved to: %s", filepath.Base(savedPath)),
+				})
+			} else {
+				ui.ShowError(fmt.Sprintf("Error occurred and failed to save partial audio: %v", saveErr))
+			}
+			return
+		}
+		slog.Info("TTS generation successful", "bytes", size)
+		if saveErr != nil {
+			slog.Error("Failed to save file", "err", saveErr)
+			ui.ShowError(fmt.Sprintf("Failed to save file: %v", saveErr))
 			return
 		}
-		log.Printf("Audio file saved successfully: %s", savedPath)
+		if savedPath == "" {
+			slog.Info("Skipped saving, the file already exists", "path", filename)
+			ui.ShowSuccess(fmt.Sprintf("Kept the existing %s, the new audio was not saved", filename))
+			return
+		}
+		slog.Info("Audio file saved", "path", savedPath)
+
+		// Headings configured as split points get their own files as well
+		partPaths, err := writeParts(savedPath, report.Chapters, nil)
+		if err != nil {
+			slog.Error("Failed to save the chapter files", "err", err)
+			ui.ShowError(fmt.Sprintf("Failed to save %v", err))
+		}
+		// Everything written along with the output, for the history to delete later
+		files := slices.Clone(partPaths)
+		sidecars, sidecarErr := writeSidecars(settings, append([]string{savedPath}, partPaths...))
+		files = append(files, sidecars...)
+		timings, err := writeTimings(settings, savedPath, report)
+		if err != nil {
+			slog.Warn("Failed to save timings", "err", err)
+		}
+		files = append(files, timings...)
+		chunkDir, err := writeChunkFiles(settings, savedPath, report, chunks)
+		if err != nil {
+			slog.Warn("Failed to save the chunk files", "err", err)
+		}
+		if chunkDir != "" {
+			files = append(files, chunkDir)
+		}
+		transcript, err := writeTranscript(settings, savedPath, provider, request, chunkLimit, cfg.StitchContext, hook != nil)
+		if err != nil {
+			slog.Warn("Failed to save the transcript", "err", err)
+		}
+		files = append(files, transcript...)
+		outputs := append([]string{savedPath}, files...)
+
+		// Record the job in the history
+		if jobHistory != nil {
+			if err := jobHistory.SaveText(inputText); err != nil {
+				slog.Warn("Failed to cache input text", "err", err)
+			}
+			if _, err := jobHistory.Add(history.Entry{
+				Title:      history.TitleFromText(inputText),
+				TextHash:   history.HashText(inputText),
+				Provider:   providerName,
+				Voice:      voice,
+				Speed:      speed,
+				OutputPath: savedPath,
+				Files:      files,
+			}); err != nil {
+				slog.Warn("Failed to record history entry", "err", err)
+			}
+		}
 
 		// Show success message
-		log.Printf("TTS request completed successfully")
-		ui.ShowSuccess(fmt.Sprintf("File saved to %s (Provider: %s)", filepath.Base(savedPath), providerName))
+		slog.Info("TTS request completed")
+		successMsg := fmt.Sprintf("File saved to %s (Provider: %s)", filepath.Base(savedPath), providerName)
+		if short := report.Flagged(tts.FlagShortAudio); len(short) > 0 {
+			for _, c := range short {
+				slog.Warn("Chunk stayed suspiciously short", "chunk", c.Index+1, "duration", c.Duration)
+			}
+			successMsg += fmt.Sprintf(" – %d section(s) may be truncated, please spot-check", len(short))
+		}
+		if sidecarErr != nil {
+			successMsg += fmt.Sprintf(" – checksums incomplete: %v", sidecarErr)
+		}
+		ui.ShowSuccess(successMsg)
+
+		// Acoustic QA so the user knows whether to spot-check before publishing;
+		// it is shown again after failed sections were retried
+		finished := time.Now()
+		var showSummary func()
+		showSummary = func() {
+			// The analysis needs the whole file, which the job itself never held in memory
+			audioData, err := os.ReadFile(savedPath)
+			if err != nil {
+				slog.Warn("Failed to read the output for the quality check", "path", savedPath, "err", err)
+			}
+			qa := tts.BuildQASummary(audioData, report, text, speed)
+			slog.Info("QA summary", "status", qa.Status, "summary", qa.String())
+			job := tts.JobSummary{
+				Title:      history.TitleFromText(inputText),
+				Provider:   providerName,
+				Voice:      voice,
+				Model:      request.Model,
+				Speed:      speed,
+				Format:     request.Format,
+				OutputPath: savedPath,
+				Started:    started,
+				Finished:   finished,
+				Settings:   jobSettings(settings, hook != nil),
+			}
+			exportReport := func(format string, w io.Writer) error {
+				switch format {
+				case "html":
+					return tts.WriteHTMLReport(w, job, report, qa)
+				case "json":
+					return tts.WriteFailedJSON(w, job, report)
+				case "csv":
+					return tts.WriteFailedCSV(w, report)
+				}
+				return tts.WriteMarkdownReport(w, job, report, qa)
+			}
+			var retry func()
+			if len(report.Failed()) > 0 {
+				retry = func() {
+					go func() {
+						if retryFailedSections(ui, ttsManager, settings, providerName, request, report, chunks, savedPath, partPaths) {
+							showSummary()
+							uploadInBackground(ui, settings, outputs)
+						}
+					}()
+				}
+			} else {
+				if chunks != nil {
+					chunks.Remove()
+				}
+				// Nothing left to retry, so the whole file is no longer needed
+				removeWholeOutput(settings, savedPath, partPaths)
+			}
+			var timeline func()
+			if _, err := os.Stat(savedPath); err == nil { // gone if only the chapter files are kept
+				timeline = func() {
+					gui.ShowTimeline(fyne.CurrentApp(), filepath.Base(savedPath), timelineChunks(report), func(index int) error {
+						return playChunk(savedPath, report, index)
+					})
+				}
+			}
+			ui.ShowQASummary(string(qa.Status), qa.String(), exportReport, strings.TrimSuffix(filepath.Base(savedPath), filepath.Ext(savedPath)), retry, timeline)
+		}
+		keepChunks = true // until no failed sections are left
+		showSummary()
+		uploadInBackground(ui, settings, outputs)
 		fyne.CurrentApp().SendNotification(&fyne.Notification{
 			Title:   "Success",
 			Content: fmt.Sprintf("Audio saved to: %s", filepath.Base(savedPath)),
@@ -436,147 +756,1603 @@ func handleSubmit(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
 	}()
 }
 
-// updateVoiceForProvider updates the voice field with the provider's default voice
-func updateVoiceForProvider(ui *gui.UI, ttsManager *tts.Manager, providerName string) {
-	if ui == nil || providerName == "" {
-		return
+// setupLogging routes the log records of the app, including those written with
+// the log package, through a structured logger. QUACKER_LOG_LEVEL (debug, info,
+// warn, error) sets the level, info by default; QUACKER_LOG_FORMAT=json writes
+// JSON lines for log collectors instead of text.
+func setupLogging() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("QUACKER_LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
 	}
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if strings.EqualFold(os.Getenv("QUACKER_LOG_FORMAT"), "json") {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
 
-	provider, err := ttsManager.GetProvider(providerName)
+// askProviderFailing returns the processor's circuit breaker prompt: it asks the
+// user whether to wait, switch provider or abort when the provider keeps failing.
+func askProviderFailing(ui *gui.UI, ttsManager *tts.Manager, settings *config.Settings) func(tts.BreakerTrip) tts.BreakerDecision {
+	defaultVoice := func(name string) string {
+		if p, err := ttsManager.GetProvider(name); err == nil {
+			return p.GetDefaultVoice()
+		}
+		return ""
+	}
+	return func(trip tts.BreakerTrip) tts.BreakerDecision {
+		slog.Warn("Provider keeps failing", "provider", trip.Provider, "failures", trip.Failures, "err", trip.Err)
+		ui.SetProcessingMessage(fmt.Sprintf("%s keeps failing, waiting for your decision...", trip.Provider))
+		choice := ui.AskProviderFailing(trip.Provider, trip.Failures, trip.Err.Error(), ttsManager.GetAvailableProviders(), defaultVoice)
+		switch choice.Action {
+		case gui.FailingWait:
+			return tts.BreakerDecision{Action: tts.BreakerWait}
+		case gui.FailingSwitch:
+			provider, err := ttsManager.GetProvider(choice.Provider)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Provider error: %v", err))
+				return tts.BreakerDecision{Action: tts.BreakerWait}
+			}
+			decision := tts.BreakerDecision{Action: tts.BreakerSwitch, Provider: provider, Voice: choice.Voice, Model: modelFor(choice.Provider, settings)}
+			ui.SetProcessingMessage(fmt.Sprintf("Continuing with %s...", choice.Provider))
+			return decision
+		}
+		return tts.BreakerDecision{Action: tts.BreakerAbort}
+	}
+}
+
+// retryFailedSections asks which provider and voice to retry the failed chunks of
+// report with, synthesizes them and splices the audio into the output saved at
+// savedPath and its parts. If the job's chunks are kept, the chunk files are
+// replaced and merged again. It reports whether anything was retried.
+func retryFailedSections(ui *gui.UI, ttsManager *tts.Manager, settings *config.Settings, providerName string, request *tts.UnifiedRequest,
+	report *tts.Report, chunks *chunkstore.Store, savedPath string, partPaths []string) bool {
+	failed := report.Failed()
+	sections := make([]string, len(failed))
+	for i, c := range failed {
+		sections[i] = fmt.Sprintf("Section %d: %.60s... (%s)", c.Index+1, c.Text, c.Error)
+	}
+	defaultVoice := func(name string) string {
+		if p, err := ttsManager.GetProvider(name); err == nil {
+			return p.GetDefaultVoice()
+		}
+		return ""
+	}
+	choice, ok := ui.AskRetryFailed(sections, ttsManager.GetAvailableProviders(), providerName, request.Voice, defaultVoice)
+	if !ok {
+		return false
+	}
+	provider, err := ttsManager.GetProvider(choice.Provider)
 	if err != nil {
-		return
+		ui.ShowError(fmt.Sprintf("Provider error: %v", err))
+		return false
+	}
+	retryRequest := *request
+	voice := choice.Voice
+	if choice.Provider != providerName {
+		retryRequest.Model, retryRequest.Instructions = modelFor(choice.Provider, settings), ""
+		retryRequest.Format = tts.FormatFor(provider, request.Format)
+	} else if voice == request.Voice {
+		voice = "" // keep each section's own voice, e.g. per speaker or language
 	}
 
-	defaultVoice := provider.GetDefaultVoice()
-	ui.Voice.SetText(defaultVoice)
+	ui.SetSubmitEnabled(false)
+	defer ui.SetSubmitEnabled(true)
+	ui.SetProgress(0)
+	ui.SetProcessingMessage(fmt.Sprintf("Retrying %d failed section(s)...", len(failed)))
+	progressCb := func(completed, total int) {
+		ui.SetProgress(float64(completed) / float64(total))
+		ui.SetProcessingMessage(fmt.Sprintf("Retrying section %d of %d...", completed, total))
+	}
+	cfg := tts.DefaultProcessorConfig()
+	cfg.ChunkLimit = settings.ChunkLimits[choice.Provider]
+	cfg.Cache = synthesisCache(settings)
+	applyRetrySettings(cfg, settings)
+	errorCb := func(msg string) { ui.ShowError(msg) }
+	if chunks != nil {
+		tts.RetryFailedChunks(context.Background(), provider, &retryRequest, chunks, report, voice, progressCb, errorCb, cfg)
+		err = mergeChunks(chunks, savedPath)
+	} else {
+		var audioData []byte
+		if audioData, err = os.ReadFile(savedPath); err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to read %s: %v", filepath.Base(savedPath), err))
+			return false
+		}
+		audioData = tts.RetryFailed(context.Background(), provider, &retryRequest, audioData, report, voice, progressCb, errorCb, cfg)
+		err = util.WriteAudioFile(savedPath, audioData)
+	}
+	fixed := len(failed) - len(report.Failed())
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Failed to update %s: %v", filepath.Base(savedPath), err))
+		return true
+	}
+	if len(partPaths) > 0 {
+		if _, err := writeParts(savedPath, report.Chapters, partPaths); err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to update %v", err))
+			return true
+		}
+	}
+	// Same files as the first time, already in the history
+	if _, err := writeSidecars(settings, append([]string{savedPath}, partPaths...)); err != nil {
+		slog.Warn("Failed to update sidecars", "err", err)
+	}
+	if _, err := writeTimings(settings, savedPath, report); err != nil {
+		slog.Warn("Failed to update timings", "err", err)
+	}
+	if _, err := writeChunkFiles(settings, savedPath, report, chunks); err != nil {
+		slog.Warn("Failed to update the chunk files", "err", err)
+	}
+	ui.ShowSuccess(fmt.Sprintf("Retried %d section(s), %d fixed – %s updated", len(failed), fixed, filepath.Base(savedPath)))
+	return true
 }
 
-// showProviderSettingsDialog shows the provider configuration dialog
-func showProviderSettingsDialog(ui *gui.UI, ttsManager *tts.Manager, currentProvider *string) {
-	// Provider selection (moved above tabs)
-	providerInfo := ttsManager.GetProviderInfo()
-	var providerNames []string
-	for _, info := range providerInfo {
-		providerNames = append(providerNames, info.Name)
+// prepareJob compiles the script hook src, if any, runs the preprocessing
+// pipeline for the voice's language and splits the result into segments with
+// their voices, exactly as they are sent to the provider.
+func prepareJob(providerName, inputText, voice, src string, settings *config.Settings) (string, []tts.Segment, *script.Hook, error) {
+	var hook *script.Hook
+	if strings.TrimSpace(src) != "" {
+		var err error
+		if hook, err = script.Compile(src); err != nil {
+			return "", nil, nil, fmt.Errorf("Custom script: %v", err)
+		}
 	}
 
-	defaultProviderSelect := widget.NewSelect(providerNames, nil)
-	defaultProviderSelect.SetSelected(ttsManager.GetConfig().DefaultProvider)
+	language := tts.LanguageCodeForVoice(voice)
+	if language == "" {
+		language = preprocess.DetectLanguage(inputText)
+	}
+	stages, acronyms, _ := documentChoices(settings, inputText)
+	pipeline := preprocess.NewPipeline(stages)
+	text := pipeline.Run(inputText, preprocess.Options{Language: language, MixedLanguages: settings.AutoLanguageVoices, KeepHeadings: true, Script: hook, Replacements: settings.Replacements, QuoteRate: settings.QuoteRate, DefinitionRate: settings.DefinitionRate, TableHeaders: settings.TableHeaders, FootnoteLeadIn: settings.FootnoteLeadIn, FootnoteVoice: settings.FootnoteVoices[providerName], Acronyms: acronyms})
+	if text == "" {
+		return "", nil, nil, errors.New("Nothing left to read after preprocessing. Check the preprocessing settings.")
+	}
 
-	// Create tabs for different providers
-	tabs := container.NewAppTabs()
+	// Split the document at headings, then assign voices per dialogue speaker
+	// or per paragraph language if enabled
+	doc := preprocess.ParseDocument(text)
+	segments := tts.DocumentSegments(doc, voice, settings.HeadingStyles)
+	if settings.DialogueVoices && tts.ParseDialogue(text) != nil {
+		speakers := tts.NewSpeakerVoices(settings.SpeakerVoices[providerName], voice, tts.DialogueVoicePool(providerName, voice))
+		segments = tts.ExpandSegments(segments, func(seg tts.Segment) []tts.Segment {
+			return tts.DialogueSegments(tts.SplitTurns(seg.Text), speakers)
+		})
+		slog.Debug("Dialogue script", "segments", len(segments))
+	} else if settings.AutoLanguageVoices {
+		mapping := settings.LanguageVoices[providerName]
+		segments = tts.ExpandSegments(segments, func(seg tts.Segment) []tts.Segment {
+			return tts.SplitByLanguage(seg.Text, voice, func(lang string) string {
+				return tts.VoiceForLanguage(voice, lang, mapping)
+			})
+		})
+		for _, seg := range segments {
+			slog.Debug("Language segment", "lang", seg.Language, "voice", seg.Voice, "bytes", len(seg.Text))
+		}
+	}
 
-	// OpenAI tab
-	openAIAPIKeyEntry := widget.NewPasswordEntry()
-	openAIAPIKeyEntry.SetText(ttsManager.GetConfig().OpenAIAPIKey)
+	// Inline [pause 2s] and {{voice:...}} markers override everything else
+	return text, tts.ApplyInlineMarkers(segments), hook, nil
+}
 
-	openAIContent := container.New(layout.NewFormLayout(),
-		widget.NewLabel("API Key:"), openAIAPIKeyEntry,
-	)
-	tabs.Append(container.NewTabItem("OpenAI", openAIContent))
-
-	// Google Cloud tab
-	googleProjectEntry := widget.NewEntry()
-	googleProjectEntry.SetText(ttsManager.GetConfig().GoogleProjectID)
-	googleProjectLabel := widget.NewLabel("Project ID:")
-
-	googleAPIKeyEntry := widget.NewPasswordEntry()
-	googleAPIKeyEntry.SetText(ttsManager.GetConfig().GoogleAPIKey)
-	googleAPIKeyLabel := widget.NewLabel("API Key:")
-
-	// updateGoogleFields toggles visibility of provider-specific fields
-	updateGoogleFields := func(method string) {
-		if method == "API Key" {
-			googleProjectLabel.Hide()
-			googleProjectEntry.Hide()
-			googleAPIKeyLabel.Show()
-			googleAPIKeyEntry.Show()
-		} else { // "gcloud auth"
-			googleProjectLabel.Show()
-			googleProjectEntry.Show()
-			googleAPIKeyLabel.Hide()
-			googleAPIKeyEntry.Hide()
-		}
-	}
-
-	// Google Cloud authentication method selection
-	googleAuthMethods := []string{"gcloud auth", "API Key"}
-	googleAuthSelect := widget.NewSelect(googleAuthMethods, updateGoogleFields)
-
-	// Set current auth method from config and trigger initial field visibility
-	currentAuthMethod := ttsManager.GetConfig().GoogleAuthMethod
-	if currentAuthMethod == "" {
-		currentAuthMethod = "gcloud auth" // Default to gcloud auth
-	}
-	googleAuthSelect.SetSelected(currentAuthMethod)
-	updateGoogleFields(currentAuthMethod)
-
-	googleContent := container.New(layout.NewFormLayout(),
-		widget.NewLabel("Auth Method:"), googleAuthSelect,
-		googleProjectLabel, googleProjectEntry,
-		googleAPIKeyLabel, googleAPIKeyEntry,
-	)
-	tabs.Append(container.NewTabItem("Google Cloud", googleContent))
+// showPreview shows the text of every chunk as it will be sent to the provider.
+func showPreview(a fyne.App, ui *gui.UI, ttsManager *tts.Manager, providerName string, settings *config.Settings, session *projectSession) {
+	provider, err := ttsManager.GetProvider(providerName)
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Provider error: %v", err))
+		return
+	}
+	voice := ui.Voice.Text
+	_, segments, _, err := prepareJob(providerName, ui.Input.Text, voice, session.scriptFor(settings), settings)
+	if err != nil {
+		ui.ShowError(err.Error())
+		return
+	}
+	var chunks []gui.PreviewChunk
+	for _, seg := range segments {
+		segVoice := seg.Voice
+		if segVoice == "" {
+			segVoice = voice
+		}
+		limit, measure := tts.ChunkLimit(provider, settings.ChunkLimits[providerName])
+		for i, chunk := range tts.SplitText(seg.Text, limit, measure) {
+			c := gui.PreviewChunk{Text: chunk, Voice: segVoice, Speaker: seg.Speaker}
+			if i == 0 {
+				c.PauseBefore, c.NewFile, c.Heading = seg.PauseBefore, seg.NewFile, seg.Heading
+			}
+			chunks = append(chunks, c)
+		}
+	}
+	gui.ShowPreviewWindow(a, providerName, chunks, &gui.Audition{
+		Favorites: settings.FavoriteVoices[providerName],
+		Render: func(v string) (string, error) {
+			return renderSample(provider, providerName, segments, v, ui.Speed.Value, settings)
+		},
+		UseVoice: func(v string) { ui.Voice.SetText(v) },
+	})
+}
 
-	mainContent := container.NewVBox(
-		container.New(layout.NewFormLayout(),
-			widget.NewLabel("Default Provider:"), defaultProviderSelect,
-		),
-		tabs,
-	)
+// durationEstimate is the predicted length of the audio of text read at speed,
+// shown next to the submit button. It goes by the raw text, before
+// preprocessing; the Estimate menu item also counts pauses and chunks.
+func durationEstimate(text string, speed float64) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	d := tts.EstimateSpokenDuration(text, "", speed)
+	switch {
+	case d < time.Minute:
+		return "≈ < 1 min of audio"
+	case d < time.Hour:
+		return fmt.Sprintf("≈ %d min of audio", int(d.Round(time.Minute)/time.Minute))
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("≈ %d h %02d min of audio", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
 
-	dialog := dialog.NewCustomConfirm("Provider Settings", "Save", "Cancel", mainContent, func(ok bool) {
-		if !ok {
-			return
+// showEstimate chunks the document for every configured provider, with the
+// selected voice for the current provider and the default voice for the others,
+// and shows the predicted chunks, size, duration and cost. Nothing is sent.
+func showEstimate(ui *gui.UI, ttsManager *tts.Manager, providerName string, settings *config.Settings, session *projectSession) {
+	if strings.TrimSpace(ui.Input.Text) == "" {
+		ui.ShowError("Please enter some text to estimate.")
+		return
+	}
+	var rows []gui.EstimateRow
+	for _, name := range ttsManager.GetAvailableProviders() {
+		provider, err := ttsManager.GetProvider(name)
+		if err != nil {
+			continue
 		}
-
-		// Update configuration
-		newConfig := &tts.ProviderConfig{
-			OpenAIAPIKey:     openAIAPIKeyEntry.Text,
-			GoogleProjectID:  googleProjectEntry.Text,
-			GoogleAPIKey:     googleAPIKeyEntry.Text,
-			GoogleAuthMethod: googleAuthSelect.Selected,
-			DefaultProvider:  defaultProviderSelect.Selected,
+		request := &tts.UnifiedRequest{Voice: provider.GetDefaultVoice(), Speed: ui.Speed.Value, Model: modelFor(name, settings)}
+		if name == providerName && strings.TrimSpace(ui.Voice.Text) != "" {
+			request.Voice = ui.Voice.Text
+		}
+		row := gui.EstimateRow{Provider: name, Voice: request.Voice}
+		_, segments, _, err := prepareJob(name, ui.Input.Text, request.Voice, session.scriptFor(settings), settings)
+		if err != nil {
+			row.Err = err.Error()
+		} else {
+			e := tts.EstimateJob(provider, request, segments, settings.ChunkLimits[name])
+			row.Chunks, row.Tokens, row.Chars = e.Chunks, e.Tokens, e.Chars
+			row.Duration, row.Cost, row.CostKnown = e.Duration, e.Cost, e.CostKnown
 		}
+		rows = append(rows, row)
+	}
+	ui.ShowEstimate(rows)
+}
 
-		// Save to keychain
-		if openAIAPIKeyEntry.Text != "" {
-			config.SetOpenAIAPIKey(openAIAPIKeyEntry.Text)
+// openTextFile asks for a text, Markdown, EPUB or project file and loads it into the window.
+func openTextFile(ui *gui.UI, session *projectSession, onOpened func(path string)) {
+	open := dialog.NewFileOpen(func(f fyne.URIReadCloser, err error) {
+		if err != nil || f == nil {
+			return
 		}
-		if googleProjectEntry.Text != "" {
-			config.SetGoogleProjectID(googleProjectEntry.Text)
+		f.Close()
+		if path := f.URI().Path(); openDocument(ui, session, path) {
+			onOpened(path)
 		}
-		if googleAPIKeyEntry.Text != "" {
-			config.SetGoogleAPIKey(googleAPIKeyEntry.Text)
+	}, ui.Window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".txt", ".md", ".markdown", ".epub", project.Extension}))
+	open.Show()
+}
+
+// openDocument loads the text file at path into the input field, lets the
+// user pick chapters of an EPUB, or opens a project. It reports whether the
+// document could be read.
+func openDocument(ui *gui.UI, session *projectSession, path string) bool {
+	if strings.EqualFold(filepath.Ext(path), project.Extension) {
+		return openProject(ui, session, path)
+	}
+	session.reset()
+	if strings.EqualFold(filepath.Ext(path), ".epub") {
+		return openEPUB(ui, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Cannot open %s: %v", filepath.Base(path), err))
+		return false
+	}
+	defer f.Close()
+	text, err := util.ReadTextFile(f)
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Cannot open %s: %v", filepath.Base(path), err))
+		return false
+	}
+	ui.Input.SetText(text)
+	ui.ShowSuccess(fmt.Sprintf("Opened %s (%d words)", filepath.Base(path), len(strings.Fields(text))))
+	return true
+}
+
+// recentJobs returns the latest jobs of jobHistory whose output is still
+// there, whole or as chapter files, for File → Recent Outputs.
+func recentJobs(jobHistory *history.Store) []history.Entry {
+	if jobHistory == nil {
+		return nil
+	}
+	var jobs []history.Entry
+	for _, e := range jobHistory.Entries() {
+		if len(jobs) == history.MaxRecent {
+			break
 		}
-		if googleAuthSelect.Selected != "" {
-			config.SetGoogleAuthMethod(googleAuthSelect.Selected)
+		ext := filepath.Ext(e.OutputPath)
+		if slices.ContainsFunc(e.Outputs(), func(f string) bool {
+			_, err := os.Stat(f)
+			return err == nil && filepath.Ext(f) == ext
+		}) {
+			jobs = append(jobs, e)
 		}
+	}
+	return jobs
+}
 
- 		// Persist default provider to keychain
- 		if err := config.SetDefaultProvider(defaultProviderSelect.Selected); err != nil {
- 			log.Printf("Failed to save default provider to keychain: %v", err)
- 		}
+// readFromURL asks for the address of a web page and loads the text of its
+// article into the input field.
+func readFromURL(ui *gui.UI) {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/article")
+	items := []*widget.FormItem{widget.NewFormItem("Address:", urlEntry)}
+	form := dialog.NewForm("Read from URL", "Read", "Cancel", items, func(ok bool) {
+		address := strings.TrimSpace(urlEntry.Text)
+		if !ok || address == "" {
+			return
+		}
+		ui.SetProcessingMessage("Downloading " + address + "...")
+		go func() {
+			article, err := webpage.Fetch(context.Background(), address)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Cannot read %s: %v", address, err))
+				return
+			}
+			text := article.Markdown()
+			fyne.Do(func() { ui.Input.SetText(text) })
+			ui.ShowSuccess(fmt.Sprintf("Loaded %q (%d words)", article.Title, len(strings.Fields(text))))
+		}()
+	}, ui.Window)
+	form.Resize(fyne.NewSize(560, 0))
+	form.Show()
+}
+
+// openEPUB lets the user pick chapters of the EPUB at path and loads them into
+// the input field, each under a top-level heading. It reports whether the book
+// could be read.
+func openEPUB(ui *gui.UI, path string) bool {
+	book, err := epub.Open(path)
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Cannot open %s: %v", filepath.Base(path), err))
+		return false
+	}
+	labels := make([]string, len(book.Chapters))
+	for i, c := range book.Chapters {
+		labels[i] = fmt.Sprintf("%s (%d words)", c.Title, c.Words())
+	}
+	title := book.Title
+	if title == "" {
+		title = filepath.Base(path)
+	}
+	ui.ShowChapterPicker(title, labels, func(indexes []int) {
+		if len(indexes) == 0 {
+			return
+		}
+		text := book.Markdown(indexes)
+		ui.Input.SetText(text)
+		ui.ShowSuccess(fmt.Sprintf("Loaded %d chapter(s) of %s (%d words)", len(indexes), title, len(strings.Fields(text))))
+	})
+	return true
+}
+
+// startDemo loads the bundled sample document, selects the demo provider and
+// walks the user through preview, synthesis and playback.
+func startDemo(a fyne.App, ui *gui.UI, ttsManager *tts.Manager, settings *config.Settings, session *projectSession) {
+	ttsManager.EnableDemo()
+	if !slices.Contains(ui.ProviderSelect.Options, "demo") {
+		ui.ProviderSelect.Options = append(ui.ProviderSelect.Options, "demo")
+		ui.ProviderSelect.Refresh()
+	}
+	ui.ProviderSelect.SetSelected("demo")
+	ui.Input.SetText(demo.Sample)
+
+	actions := map[string]func(){
+		demo.ActionPreview:    func() { showPreview(a, ui, ttsManager, "demo", settings, session) },
+		demo.ActionSynthesize: func() { ui.SubmitBtn.OnTapped() },
+	}
+	var steps []gui.TourStep
+	for _, step := range demo.Tour {
+		steps = append(steps, gui.TourStep{Title: step.Title, Text: step.Text, Button: step.Button, OnNext: actions[step.Action]})
+	}
+	ui.ShowTour(steps)
+}
+
+// reviewChunks shows the chunks of segments in the chunk editor and returns one
+// segment per edited chunk along with the chunks, or false if the user cancelled
+// the job. Chunks that still exceed the limit are split again while synthesizing.
+func reviewChunks(ui *gui.UI, provider tts.Provider, segments []tts.Segment, voice string, chunkLimit int, reviewed []gui.EditableChunk) ([]tts.Segment, []gui.EditableChunk, bool) {
+	limit, measure := tts.ChunkLimit(provider, chunkLimit)
+	unit := "tokens"
+	if provider.GetName() == "google" {
+		unit = "bytes"
+	}
+	// Chunks reviewed before, e.g. saved in a project, are shown as they were left
+	chunks := reviewed
+	if _, ok := applyChunks(segments, reviewed); !ok || len(reviewed) == 0 {
+		chunks = nil
+		for i, seg := range segments {
+			segVoice := seg.Voice
+			if segVoice == "" {
+				segVoice = voice
+			}
+			for _, chunk := range tts.SplitText(seg.Text, limit, measure) {
+				chunks = append(chunks, gui.EditableChunk{Text: chunk, Voice: segVoice, Group: i})
+			}
+		}
+	}
+	edited, ok := ui.EditChunks(chunks, gui.ChunkLimits{Limit: limit, Unit: unit, Measure: measure, Split: tts.SplitInHalf})
+	if !ok {
+		return nil, nil, false
+	}
+	segments, _ = applyChunks(segments, edited)
+	return segments, edited, true
+}
+
+// renderSample synthesizes the first sentences of the document with voice and
+// writes them to a temporary file for auditioning.
+func renderSample(provider tts.Provider, providerName string, segments []tts.Segment, voice string, speed float64, settings *config.Settings) (string, error) {
+	var sample string
+	for _, seg := range segments {
+		if chunk, ok := tts.NewChunker(seg.Text, 300, tts.ByteMeasure).NextChunk(); ok {
+			sample = chunk
+			break
+		}
+	}
+	if sample == "" {
+		return "", errors.New("nothing to read")
+	}
+	data, err := synthesizeSample(provider, providerName, sample, voice, speed, settings)
+	if err != nil {
+		return "", err
+	}
+	return writeSample(providerName, voice, data)
+}
+
+// modelFor returns the model providerName synthesizes with: the one chosen in
+// the settings, else the provider's default.
+func modelFor(providerName string, settings *config.Settings) string {
+	if model := settings.Models[providerName]; model != "" {
+		return model
+	}
+	return tts.DefaultModel(providerName)
+}
+
+// synthesizeSample synthesizes a short text in a single request.
+func synthesizeSample(provider tts.Provider, providerName, text, voice string, speed float64, settings *config.Settings) ([]byte, error) {
+	request := &tts.UnifiedRequest{Text: text, Voice: voice, Speed: speed, Format: "mp3", SayAs: settings.SayAsHints, Model: modelFor(providerName, settings)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return provider.GenerateSpeech(ctx, request)
+}
+
+// writeSample stores a sample in the temporary directory, one file per voice.
+func writeSample(providerName, voice string, data []byte) (string, error) {
+	path := filepath.Join(os.TempDir(), "quacker-sample-"+providerName+"-"+util.SanitizeFilenameWord(voice)+".mp3")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write sample: %w", err)
+	}
+	return path, nil
+}
+
+// showVoiceMatrix opens the voice matrix for the first paragraph of the input
+// text, with the favorite voices of every configured provider.
+func showVoiceMatrix(a fyne.App, ui *gui.UI, ttsManager *tts.Manager, currentProvider string, settings *config.Settings, session *projectSession) {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(ui.Input.Text), "\n\n")
+	var voices []gui.MatrixVoice
+	for _, name := range ttsManager.GetAvailableProviders() {
+		for _, v := range settings.FavoriteVoices[name] {
+			voices = append(voices, gui.MatrixVoice{Provider: name, Voice: v})
+		}
+	}
+	if len(voices) == 0 && ui.Voice.Text != "" {
+		voices = append(voices, gui.MatrixVoice{Provider: currentProvider, Voice: ui.Voice.Text})
+	}
+	speed := ui.Speed.Value
+	src := session.scriptFor(settings)
+	gui.ShowVoiceMatrix(a, paragraph, currentProvider, voices, func(v gui.MatrixVoice, text string) (gui.MatrixSample, error) {
+		provider, err := ttsManager.GetProvider(v.Provider)
+		if err != nil {
+			return gui.MatrixSample{}, err
+		}
+		// Preprocess the paragraph as a job for this voice would
+		_, segments, _, err := prepareJob(v.Provider, text, v.Voice, src, settings)
+		if err != nil {
+			return gui.MatrixSample{}, err
+		}
+		var parts []string
+		for _, seg := range segments {
+			if seg.Text != "" {
+				parts = append(parts, seg.Text)
+			}
+		}
+		processed := strings.Join(parts, " ")
+		data, err := synthesizeSample(provider, v.Provider, processed, v.Voice, speed, settings)
+		if err != nil {
+			return gui.MatrixSample{}, err
+		}
+		sample := gui.MatrixSample{}
+		if info, err := audio.Probe(data); err == nil {
+			sample.Duration = info.Duration
+		}
+		sample.Cost, sample.CostKnown = tts.EstimateCost(v.Provider, modelFor(v.Provider, settings), v.Voice, len([]rune(tts.PlainText(processed))), sample.Duration)
+		sample.Path, err = writeSample(v.Provider, v.Voice, data)
+		return sample, err
+	})
+}
+
+// acronymPolicy builds the acronym policy from the user's settings.
+func acronymPolicy(settings *config.Settings) preprocess.AcronymPolicy {
+	return preprocess.AcronymPolicy{Modes: settings.AcronymModes, Overrides: settings.AcronymOverrides}
+}
+
+// documentChoices returns the preprocessing stages and acronym policy for a text:
+// the user's settings with the corrections remembered for this document on top.
+func documentChoices(settings *config.Settings, inputText string) (map[string]bool, preprocess.AcronymPolicy, *config.Corrections) {
+	corrections, err := config.LoadCorrections(history.HashText(inputText))
+	if err != nil {
+		slog.Warn("Failed to load document corrections", "err", err)
+	}
+	stages := preprocess.DefaultEnabled()
+	for name, on := range settings.PreprocessStages {
+		stages[name] = on
+	}
+	for name, on := range corrections.Stages {
+		stages[name] = on
+	}
+	policy := acronymPolicy(settings)
+	if len(corrections.Acronyms) > 0 {
+		policy.Overrides = map[string]string{}
+		for token, rule := range settings.AcronymOverrides {
+			policy.Overrides[token] = rule
+		}
+		for token, rule := range corrections.Acronyms {
+			policy.Overrides[token] = rule
+		}
+	}
+	return stages, policy, corrections
+}
+
+// showDocumentReview lets the user correct how the problem tokens of the current
+// text are read and which preprocessing stages apply to it. Corrections are
+// remembered for this document (by content hash) or, for acronyms, globally.
+func showDocumentReview(a fyne.App, ui *gui.UI, settings *config.Settings) {
+	inputText := ui.Input.Text
+	if strings.TrimSpace(inputText) == "" {
+		ui.ShowError("Please enter some text first")
+		return
+	}
+	language := tts.LanguageCodeForVoice(ui.Voice.Text)
+	if language == "" {
+		language = preprocess.DetectLanguage(inputText)
+	}
+	stages, policy, corrections := documentChoices(settings, inputText)
+	review := gui.DocumentReview{
+		Acronyms: preprocess.FindAcronyms(inputText),
+		Rules:    policy.Overrides,
+		Spoken: func(token, rule string) string {
+			p := preprocess.AcronymPolicy{Modes: policy.Modes, Overrides: map[string]string{}}
+			if rule != "" {
+				p.Overrides[token] = rule
+			}
+			return p.Spoken(token, language)
+		},
+		Stages:  preprocess.Stages(),
+		Enabled: stages,
+	}
+	gui.ShowDocumentReview(a, review, func(c gui.ReviewCorrections) {
+		global := preprocess.DefaultEnabled()
+		for name, on := range settings.PreprocessStages {
+			global[name] = on
+		}
+		corrections.Stages = map[string]bool{}
+		for name, on := range c.Enabled {
+			if on != global[name] {
+				corrections.Stages[name] = on
+			}
+		}
+
+		if corrections.Acronyms == nil {
+			corrections.Acronyms = map[string]string{}
+		}
+		if c.DocumentOnly {
+			for token, rule := range c.Rules {
+				if rule == "" || rule == settings.AcronymOverrides[token] {
+					delete(corrections.Acronyms, token)
+				} else {
+					corrections.Acronyms[token] = rule
+				}
+			}
+		} else {
+			if settings.AcronymOverrides == nil {
+				settings.AcronymOverrides = map[string]string{}
+			}
+			for token, rule := range c.Rules {
+				delete(corrections.Acronyms, token)
+				if rule == "" {
+					delete(settings.AcronymOverrides, token)
+				} else {
+					settings.AcronymOverrides[token] = rule
+				}
+			}
+			if err := config.SaveSettings(settings); err != nil {
+				ui.ShowError(fmt.Sprintf("Failed to save acronym corrections: %v", err))
+			}
+		}
+		if err := config.SaveCorrections(history.HashText(inputText), corrections); err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to save document corrections: %v", err))
+		}
+	})
+}
+
+// updateVoiceForProvider updates the voice field with the default voice of the
+// config file or else the provider's
+func updateVoiceForProvider(ui *gui.UI, ttsManager *tts.Manager, providerName string, settings *config.Settings) {
+	if ui == nil || providerName == "" {
+		return
+	}
+
+	provider, err := ttsManager.GetProvider(providerName)
+	if err != nil {
+		return
+	}
+
+	defaultVoice := settings.DefaultVoices[providerName]
+	if defaultVoice == "" {
+		defaultVoice = provider.GetDefaultVoice()
+	}
+	ui.Voice.SetText(defaultVoice)
+	ui.SetStyles(tts.ProviderCapabilities(providerName).Styles)
+}
+
+// jobSettings lists the settings that shaped a job, for the conversion report.
+// customScript reports whether a script hook preprocessed its text.
+func jobSettings(settings *config.Settings, customScript bool) map[string]string {
+	enabled := preprocess.DefaultEnabled()
+	for name, on := range settings.PreprocessStages {
+		enabled[name] = on
+	}
+	var stages []string
+	for _, stage := range preprocess.Stages() {
+		if enabled[stage.Name()] {
+			stages = append(stages, stage.Name())
+		}
+	}
+	return map[string]string{
+		"Preprocessing":          strings.Join(stages, ", "),
+		"Voice per language":     strconv.FormatBool(settings.AutoLanguageVoices),
+		"Voice per speaker":      strconv.FormatBool(settings.DialogueVoices),
+		"Custom script":          strconv.FormatBool(customScript),
+		"Heading styles defined": strconv.Itoa(len(settings.HeadingStyles)),
+	}
+}
 
- 		// Update manager
- 		ttsManager.UpdateConfig(newConfig)
+// The sample rates and MP3 bitrates offered under Settings → Storage; 0 is the default.
+var (
+	outputSampleRates = []int{0, 8000, 16000, 22050, 24000, 32000, 44100, 48000}
+	outputBitrates    = []int{0, 32, 48, 64, 96, 128, 160, 192, 256, 320}
+)
 
- 		// Update UI
- 		availableProviders := ttsManager.GetAvailableProviders()
- 		ui.ProviderSelect.Options = availableProviders
+// sampleRateLabel returns how rate is shown in the sample rate select.
+func sampleRateLabel(rate int) string {
+	if rate == 0 {
+		return "Voice default"
+	}
+	return fmt.Sprintf("%d Hz", rate)
+}
 
- 		if len(availableProviders) > 0 {
- 			newProvider := newConfig.DefaultProvider
- 			if newProvider == "" {
- 				newProvider = availableProviders[0]
- 			}
- 			*currentProvider = newProvider
- 			ui.ProviderSelect.SetSelected(newProvider)
- 			updateVoiceForProvider(ui, ttsManager, newProvider)
- 		}
+// bitrateLabel returns how bitrate is shown in the bitrate select.
+func bitrateLabel(bitrate int) string {
+	if bitrate == 0 {
+		return "Default (64 kbit/s per channel)"
+	}
+	return fmt.Sprintf("%d kbit/s", bitrate)
+}
 
- 	}, ui.Window)
+// outputFilename returns the file name for a job of request, made from the
+// filename template in the settings, letting the script's filename() hook rename
+// it. The hook gets and returns the name without extension.
+func outputFilename(settings *config.Settings, providerName, inputText string, request *tts.UnifiedRequest, hook *script.Hook) string {
+	filename := util.ExpandFilenameTemplate(settings.FilenameTemplate, util.FilenameFields{
+		Text:     inputText,
+		Voice:    request.Voice,
+		Provider: providerName,
+		Speed:    request.Speed,
+		Ext:      audio.NormalizeFormat(request.Format),
+		Time:     time.Now(),
+	})
+	if hook == nil || !hook.HasFilename() {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	name, err := hook.Filename(inputText, strings.TrimSuffix(filename, ext))
+	if err != nil {
+		slog.Warn("Filename hook failed", "filename", filename, "err", err)
+		return filename
+	}
+	// The script must not place the file outside the output folder
+	if name = util.SanitizeFilename(name); name == "" {
+		return filename
+	}
+	return name + ext
+}
+
+// saveOutput moves the finished audio at tmp to filename in the output folder.
+// While the file is moved its path is locked, so a concurrent job resolving to the
+// same name gets a numbered name instead of overwriting it. If the file already
+// exists the user chooses to rename, skip or overwrite, unless the settings never
+// overwrite, which renames without asking; a skipped save returns an empty path.
+func saveOutput(ui *gui.UI, settings *config.Settings, tmp, filename string) (string, error) {
+	outPath, err := util.OutputPath(settings.OutputDir, filename)
+	if err != nil {
+		return "", err
+	}
+	if settings.NeverOverwrite {
+		outPath = util.UniqueOutputPath(outPath)
+	} else if util.OutputExists(outPath) && !util.OutputLocked(outPath) {
+		switch ui.AskOutputConflict(outPath) {
+		case gui.ConflictSkip:
+			return "", nil
+		case gui.ConflictRename:
+			outPath = util.UniqueOutputPath(outPath)
+		}
+	}
+	for !util.LockOutputPath(outPath) {
+		slog.Info("Output is in use by another job, renaming", "path", outPath)
+		outPath = util.UniqueOutputPath(outPath)
+	}
+	defer util.UnlockOutputPath(outPath)
+	if err := util.MoveAudioFile(tmp, outPath); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// mergeChunks merges the chunk files of a job into a new output replacing path.
+func mergeChunks(chunks *chunkstore.Store, path string) error {
+	out, err := util.CreateTempOutput(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	if _, err = chunks.Merge(out); err == nil {
+		err = audio.FixFile(out)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return util.MoveAudioFile(out.Name(), path)
+}
+
+// writeParts cuts the audio file at path at the chapter offsets into files of
+// their own, see audio.Slice, and writes each part next to it as "name_01.ext",
+// "name_02_Chapter_Title.ext"... named after the heading it starts with, or over
+// partPaths, the parts written before. It returns the paths written; without
+// chapters there are none.
+func writeParts(path string, chapters []tts.Chapter, partPaths []string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("parts: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("parts: %w", err)
+	}
+	ranges := tts.ChapterRanges(int(info.Size()), chapters)
+	titles := tts.ChapterTitles(int(info.Size()), chapters)
+	if len(ranges) < 2 {
+		return nil, nil
+	}
+	head := readHead(f)
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	var written []string
+	for i, r := range ranges {
+		name := fmt.Sprintf("%s_%02d", base, i+1)
+		if words := strings.Fields(titles[i]); len(words) > 0 {
+			name += "_" + util.SanitizeFilenameWord(strings.Join(words, "_"))
+		}
+		partPath := util.UniqueOutputPath(name + ext)
+		if partPaths != nil {
+			if i >= len(partPaths) {
+				break
+			}
+			partPath = partPaths[i]
+		}
+		if err := writeSlice(f, head, r[0], r[1], partPath); err != nil {
+			return written, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		written = append(written, partPath)
+		slog.Info("Saved part", "part", i+1, "parts", len(ranges), "path", partPath)
+	}
+	return written, nil
+}
 
-	dialog.Resize(fyne.NewSize(500, 400))
-	dialog.Show()
+// chunksDir returns the folder the chunk files of the output at path go in,
+// "name_chunks" next to name.mp3.
+func chunksDir(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "_chunks"
+}
+
+// writeChunkFiles writes the audio of every chunk of report that did not fail
+// as a file of its own, "001_First_words.mp3"..., into chunksDir if enabled,
+// replacing what the folder held before. The audio is cut from the output at
+// path like the parts; a chunk ends where its file in chunks ends, or without
+// one where the next chunk starts. It returns the folder, "" if disabled.
+func writeChunkFiles(settings *config.Settings, path string, report *tts.Report, chunks *chunkstore.Store) (string, error) {
+	if !settings.ChunkFiles || report == nil {
+		return "", nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	dir := chunksDir(path)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	head := readHead(f)
+	ext := filepath.Ext(path)
+	for i, c := range report.Chunks {
+		if c.Error != "" {
+			continue
+		}
+		end := int(info.Size())
+		if i+1 < len(report.Chunks) {
+			end = report.Chunks[i+1].Offset
+		}
+		if chunks != nil && c.File != "" {
+			if fi, err := os.Stat(filepath.Join(chunks.Dir(), c.File)); err == nil {
+				end = min(end, c.Offset+int(fi.Size()))
+			}
+		}
+		if end <= c.Offset {
+			continue
+		}
+		name := fmt.Sprintf("%03d", c.Index+1)
+		if words := strings.Fields(tts.PlainText(c.Text)); len(words) > 0 {
+			name += "_" + util.SanitizeFilenameWord(strings.Join(words[:min(4, len(words))], "_"))
+		}
+		if err := writeSlice(f, head, c.Offset, end, filepath.Join(dir, name+ext)); err != nil {
+			return dir, fmt.Errorf("chunk %d: %w", c.Index+1, err)
+		}
+	}
+	slog.Info("Saved the chunk files", "output", filepath.Base(path), "dir", dir)
+	return dir, nil
+}
+
+// removeWholeOutput deletes the output at path with its sidecar, signature,
+// subtitles and transcript when only the chapter parts are kept, see Settings.ChapterFilesOnly.
+// Without parts the output is all there is and stays.
+func removeWholeOutput(settings *config.Settings, path string, partPaths []string) {
+	if !settings.ChapterFilesOnly || len(partPaths) == 0 {
+		return
+	}
+	for _, f := range []string{path, path + ".json", path + ".minisig", subtitlesPath(path, tts.SubtitlesSRT), subtitlesPath(path, tts.SubtitlesVTT), subtitlesPath(path, "timing.json"), subtitlesPath(path, "transcript.txt"), subtitlesPath(path, "settings.json")} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove file", "path", f, "err", err)
+		}
+	}
+	slog.Info("Kept only the chapter files", "output", filepath.Base(path), "parts", len(partPaths))
+}
+
+// readHead reads the start of the assembled audio in f, enough for the headers
+// of WAV and Ogg audio.
+func readHead(f *os.File) []byte {
+	head := make([]byte, 64<<10)
+	n, _ := f.ReadAt(head, 0)
+	return head[:n]
+}
+
+// writeSlice writes bytes [start, end) of the assembled audio in f, whose start
+// is head, to path as a file of its own, see audio.SliceHeader.
+func writeSlice(f *os.File, head []byte, start, end int, path string) error {
+	header, from := audio.SliceHeader(head, start, end)
+	slice := io.MultiReader(bytes.NewReader(header), io.NewSectionReader(f, int64(from), int64(end-from)))
+	if err := util.CopyAudioFile(path, slice); err != nil {
+		return err
+	}
+	return fixPart(path)
+}
+
+// timelineChunks returns the chunks of report for the timeline window.
+func timelineChunks(report *tts.Report) []gui.TimelineChunk {
+	chunks := make([]gui.TimelineChunk, len(report.Chunks))
+	for i, c := range report.Chunks {
+		chunks[i] = gui.TimelineChunk{Text: c.Text, Voice: c.Voice, Start: c.Start, Duration: c.Duration, Failed: c.Error != ""}
+	}
+	return chunks
+}
+
+// playChunk cuts the audio of chunk index of report from the output at path
+// into a temporary file and opens it in the default player.
+func playChunk(path string, report *tts.Report, index int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("the output is gone: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	start, end := report.Chunks[index].Offset, int(info.Size())
+	if index+1 < len(report.Chunks) {
+		end = report.Chunks[index+1].Offset
+	}
+	if end <= start {
+		return errors.New("the chunk has no audio")
+	}
+	tmp := filepath.Join(os.TempDir(), fmt.Sprintf("quacker-chunk-%d%s", index+1, filepath.Ext(path)))
+	if err := writeSlice(f, readHead(f), start, end, tmp); err != nil {
+		return err
+	}
+	return fyne.CurrentApp().OpenURL(&url.URL{Scheme: "file", Path: tmp})
+}
+
+// fixPart fixes the headers of a part written by writeParts, see audio.SliceHeader.
+func fixPart(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	err = audio.FixFile(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// subtitlesPath returns where the subtitles in format of the output at path go,
+// e.g. name.srt next to name.mp3; "timing.json" for the timing JSON,
+// "transcript.txt" and "settings.json" for the transcript and its settings.
+func subtitlesPath(path, format string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + format
+}
+
+// writeTimings writes the subtitles and the timing JSON of the output at path,
+// laid out from report, if enabled. It returns the files written.
+func writeTimings(settings *config.Settings, path string, report *tts.Report) ([]string, error) {
+	if report == nil {
+		return nil, nil
+	}
+	var written []string
+	var errs []error
+	if settings.Subtitles != "" {
+		var b bytes.Buffer
+		err := tts.WriteSubtitles(&b, settings.Subtitles, tts.Cues(report))
+		if err == nil {
+			err = os.WriteFile(subtitlesPath(path, settings.Subtitles), b.Bytes(), 0644)
+		}
+		if err == nil {
+			written = append(written, subtitlesPath(path, settings.Subtitles))
+		}
+		errs = append(errs, err)
+	}
+	if settings.TimingJSON {
+		var b bytes.Buffer
+		err := tts.WriteTimingJSON(&b, filepath.Base(path), report)
+		if err == nil {
+			err = os.WriteFile(subtitlesPath(path, "timing.json"), b.Bytes(), 0644)
+		}
+		if err == nil {
+			written = append(written, subtitlesPath(path, "timing.json"))
+		}
+		errs = append(errs, err)
+	}
+	return written, errors.Join(errs...)
+}
+
+// writeTranscript writes the text of the output at path as sent to provider, and
+// the settings it was synthesized with, if enabled. It returns the files written.
+func writeTranscript(settings *config.Settings, path string, provider tts.Provider, request *tts.UnifiedRequest, chunkLimit int, stitchContext, customScript bool) ([]string, error) {
+	if !settings.Transcript {
+		return nil, nil
+	}
+	job := tts.NewJobSettings(provider, request, chunkLimit, stitchContext)
+	job.Audio = filepath.Base(path)
+	job.Settings = jobSettings(settings, customScript)
+	var b bytes.Buffer
+	if err := tts.WriteJobSettings(&b, job); err != nil {
+		return nil, err
+	}
+	text, settingsPath := subtitlesPath(path, "transcript.txt"), subtitlesPath(path, "settings.json")
+	if err := os.WriteFile(text, []byte(request.Text), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(settingsPath, b.Bytes(), 0644); err != nil {
+		return []string{text}, err
+	}
+	return []string{text, settingsPath}, nil
+}
+
+// uploadOutputs copies outputs, the output followed by the files written with
+// it, to the upload target in the settings, if any. It returns the target and
+// the number of files uploaded.
+func uploadOutputs(ctx context.Context, settings *config.Settings, outputs []string) (string, int, error) {
+	t := settings.Upload
+	if t.Kind == "" {
+		return "", 0, nil
+	}
+	uploader, err := upload.New(ctx, upload.Target{
+		Kind: t.Kind, URL: t.URL, Username: t.Username, Secret: config.GetUploadSecret(),
+		Bucket: t.Bucket, Region: t.Region, Prefix: t.Prefix, FolderID: t.FolderID,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	var files []string
+	for _, f := range outputs {
+		if info, err := os.Stat(f); err == nil && info.Mode().IsRegular() {
+			files = append(files, f)
+		}
+	}
+	n, err := upload.Files(ctx, uploader, files)
+	return uploader.String(), n, err
+}
+
+// uploadInBackground uploads outputs, see uploadOutputs, and shows how it went.
+func uploadInBackground(ui *gui.UI, settings *config.Settings, outputs []string) {
+	if settings.Upload.Kind == "" {
+		return
+	}
+	path := outputs[0]
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		target, n, err := uploadOutputs(ctx, settings, outputs)
+		if err != nil {
+			slog.Error("Upload failed", "path", path, "err", err)
+			ui.ShowError(fmt.Sprintf("Upload of %s failed: %v", filepath.Base(path), err))
+			return
+		}
+		slog.Info("Uploaded output", "path", path, "files", n, "target", target)
+		ui.ShowSuccess(fmt.Sprintf("Uploaded %s to %s", filepath.Base(path), target))
+	}()
+}
+
+// writeSidecars writes the checksum sidecar of every output if enabled, signing
+// the outputs when a signing key is configured. The audio is kept on failure.
+// It returns the sidecars and signatures written.
+func writeSidecars(settings *config.Settings, outputs []string) ([]string, error) {
+	if !settings.Checksums {
+		return nil, nil
+	}
+	var key *sidecar.SigningKey
+	var errs []error
+	if settings.SigningKey != "" {
+		var err error
+		if key, err = sidecar.LoadSigningKey(settings.SigningKey, config.GetSigningKeyPassword()); err != nil {
+			errs = append(errs, fmt.Errorf("outputs not signed: %w", err))
+		}
+	}
+	var written []string
+	for _, path := range outputs {
+		sc, err := sidecar.WriteFile(path, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		written = append(written, sidecar.Path(path))
+		if sc.Signature != "" {
+			written = append(written, sidecar.SignaturePath(path))
+		}
+	}
+	return written, errors.Join(errs...)
+}
+
+// autosaveInterval is how often the editor's text is written to the recovery file.
+const autosaveInterval = 30 * time.Second
+
+// editorDraft returns the text and instructions in the window.
+func editorDraft(ui *gui.UI) recovery.Draft {
+	return recovery.Draft{Input: ui.Input.Text, Instructions: ui.Instructions.Text}
+}
+
+// offerRecovery asks whether to restore the draft autosaved by the last run.
+// Drafts the window starts with anyway, or whose text was converted and can be
+// found in the history, are not offered; a declined draft is removed.
+func offerRecovery(ui *gui.UI, drafts *recovery.Store, jobHistory *history.Store, startDraft recovery.Draft) {
+	draft, ok := drafts.Load()
+	if !ok || strings.TrimSpace(draft.Input) == "" {
+		return
+	}
+	if draft.Input == startDraft.Input && draft.Instructions == startDraft.Instructions {
+		return
+	}
+	if jobHistory != nil && draft.Instructions == startDraft.Instructions {
+		hash := history.HashText(draft.Input)
+		for _, e := range jobHistory.Entries() {
+			if e.TextHash == hash {
+				return
+			}
+		}
+	}
+	if !ui.AskConfirm("Restore unsaved text", fmt.Sprintf("Quacker was closed while editing %s (%d words, autosaved %s). Restore the text?",
+		history.TitleFromText(draft.Input), len(strings.Fields(draft.Input)), draft.SavedAt.Local().Format("Mon 15:04"))) {
+		if err := drafts.Clear(); err != nil {
+			slog.Warn("Failed to discard the recovered text", "err", err)
+		}
+		return
+	}
+	fyne.Do(func() {
+		ui.Input.SetText(draft.Input)
+		ui.Instructions.SetText(draft.Instructions)
+	})
+}
+
+// resumeJobKind identifies deferred jobs that finish a job stopped by an exhausted quota.
+const resumeJobKind = "resume-after-quota"
+
+// scheduleResume stores the partial audio read from partial and schedules the
+// remaining segments for runAt.
+func scheduleResume(deferredJobs *scheduler.Scheduler, state tts.ResumeState, partial io.Reader, runAt time.Time) error {
+	path := filepath.Join(deferredJobs.Dir(), fmt.Sprintf("partial-%d.%s", time.Now().UnixNano(), state.Request.Format))
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to keep partial audio: %w", err)
+	}
+	n, err := io.Copy(f, partial)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || n == 0 {
+		os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("failed to keep partial audio: %w", err)
+		}
+	} else {
+		state.PartialAudio = path
+	}
+	_, err = deferredJobs.Schedule(resumeJobKind, history.TitleFromText(state.InputText), runAt, state)
+	return err
+}
+
+// resumeLastJob finishes the job recorded in checkpoints, showing its progress.
+func resumeLastJob(ui *gui.UI, ttsManager *tts.Manager, deferredJobs *scheduler.Scheduler, jobHistory *history.Store, settings *config.Settings, checkpoints *checkpoint.Store) {
+	state, ok := checkpoints.Last()
+	if !ok {
+		ui.ShowError("There is no interrupted job to resume.")
+		return
+	}
+	title := history.TitleFromText(state.InputText)
+	ui.SetSubmitEnabled(false)
+	ui.SetProcessingMessage(fmt.Sprintf("Resuming %s after %d chunk(s)...", title, state.Chunks))
+	go func() {
+		defer ui.SetSubmitEnabled(true)
+		progressCb := func(completed, total int) {
+			ui.SetProgress(float64(completed) / float64(total))
+			ui.SetProcessingMessage(fmt.Sprintf("Processing chunk %d of %d...", state.Chunks+completed, state.Chunks+total))
+		}
+		outPath, err := resumeJob(ttsManager, deferredJobs, jobHistory, settings, checkpoints, *state, title, progressCb)
+		switch {
+		case err != nil:
+			ui.ShowError(fmt.Sprintf("Failed to resume %s: %v", title, err))
+		case outPath == "":
+			ui.ShowSuccess(fmt.Sprintf("Quota exhausted – %s will continue when it resets", title))
+		default:
+			ui.ShowSuccess(fmt.Sprintf("File saved to %s (Provider: %s)", filepath.Base(outPath), state.Provider))
+		}
+	}()
+}
+
+// resumeQuotaJob synthesizes the remaining segments of a job once the quota has reset.
+func resumeQuotaJob(ttsManager *tts.Manager, deferredJobs *scheduler.Scheduler, jobHistory *history.Store, settings *config.Settings, job scheduler.Job) error {
+	var state tts.ResumeState
+	if err := json.Unmarshal(job.Payload, &state); err != nil {
+		return fmt.Errorf("invalid resume job: %w", err)
+	}
+	_, err := resumeJob(ttsManager, deferredJobs, jobHistory, settings, nil, state, job.Title, nil)
+	return err
+}
+
+// resumeJob synthesizes the remaining segments of an interrupted job, saves the
+// complete file and notifies the user. If the quota is exhausted the job is
+// scheduled again for the next reset. With checkpoints the resumed run is itself
+// checkpointed, so it can be resumed in turn. progressCb may be nil.
+func resumeJob(ttsManager *tts.Manager, deferredJobs *scheduler.Scheduler, jobHistory *history.Store, settings *config.Settings,
+	checkpoints *checkpoint.Store, state tts.ResumeState, title string, progressCb tts.ProgressCallback) (string, error) {
+	notify := func(heading, content string) {
+		fyne.CurrentApp().SendNotification(&fyne.Notification{Title: heading, Content: content})
+	}
+	provider, err := ttsManager.GetProvider(state.Provider)
+	if err != nil {
+		notify("Resume failed", fmt.Sprintf("%s: %v", title, err))
+		return "", err
+	}
+	var partial []byte
+	if state.PartialAudio != "" {
+		if partial, err = os.ReadFile(state.PartialAudio); err != nil {
+			notify("Resume failed", fmt.Sprintf("%s: the partial audio is gone", title))
+			return "", fmt.Errorf("failed to read partial audio: %w", err)
+		}
+		audio.FixHeaders(partial) // a checkpoint's headers are only fixed when the job is done
+	}
+
+	errorCb := func(msg string) { slog.Warn("Resumed job", "title", title, "msg", msg) }
+	cfg := tts.DefaultProcessorConfig()
+	cfg.ChunkLimit = state.ChunkLimit
+	cfg.StitchContext = state.StitchContext
+	cfg.Cache = synthesisCache(settings)
+	applyRetrySettings(cfg, settings)
+	applyClipSettings(cfg, settings, len(partial) == 0) // the partial audio starts with the intro
+	cfg.Crossfade = time.Duration(settings.Crossfade * float64(time.Second))
+	if checkpoints != nil {
+		if err := checkpoints.Begin(state, partial); err != nil {
+			slog.Warn("Checkpoints disabled", "title", title, "err", err)
+		} else {
+			cfg.Checkpoint = saveCheckpoint(checkpoints)
+		}
+	}
+	audioData, report, err := tts.ProcessSegments(context.Background(), provider, &state.Request, state.Segments, progressCb, errorCb, cfg)
+
+	// Chapters of the resumed part start after the partial audio
+	var partialDuration time.Duration
+	if info, probeErr := audio.Probe(partial); probeErr == nil {
+		partialDuration = info.Duration
+	}
+	joined := audio.Join(partial, audioData)
+	for _, c := range report.Chapters {
+		c.Offset += len(joined) - len(audioData)
+		c.Start += partialDuration
+		state.Chapters = append(state.Chapters, c)
+	}
+	audioData = joined
+	state.Chunks += len(report.Succeeded())
+
+	var quotaErr *tts.QuotaExhaustedError
+	if errors.As(err, &quotaErr) && deferredJobs != nil {
+		resetAt := tts.QuotaResetTime(time.Now())
+		state.Segments = quotaErr.Remaining
+		if err := scheduleResume(deferredJobs, state, bytes.NewReader(audioData), resetAt); err != nil {
+			notify("Resume failed", fmt.Sprintf("%s: %v", title, err))
+			return "", err
+		}
+		os.Remove(state.PartialAudio)
+		if checkpoints != nil {
+			checkpoints.Finish()
+		}
+		notify("Quota exhausted", fmt.Sprintf("%s will continue at %s", title, resetAt.Local().Format("Mon 15:04")))
+		return "", nil
+	}
+	if len(audioData) == 0 {
+		notify("Resume failed", fmt.Sprintf("%s: no audio could be generated", title))
+		return "", fmt.Errorf("no audio generated: %v", err)
+	}
+
+	outPath, err := util.OutputPath(settings.OutputDir, state.Filename)
+	if err != nil {
+		return "", err
+	}
+	// Nobody is around to answer a conflict prompt, so never overwrite
+	outPath = util.UniqueOutputPath(outPath)
+	for !util.LockOutputPath(outPath) {
+		outPath = util.UniqueOutputPath(outPath)
+	}
+	err = util.WriteAudioFile(outPath, audioData)
+	util.UnlockOutputPath(outPath)
+	if err != nil {
+		notify("Resume failed", fmt.Sprintf("%s: %v", title, err))
+		return "", err
+	}
+	os.Remove(state.PartialAudio)
+	if checkpoints != nil {
+		checkpoints.Finish()
+	}
+	partPaths, err := writeParts(outPath, state.Chapters, nil)
+	if err != nil {
+		slog.Error("Failed to save the chapter files", "err", err)
+	}
+	files := slices.Clone(partPaths)
+	sidecars, err := writeSidecars(settings, append([]string{outPath}, partPaths...))
+	if err != nil {
+		slog.Warn("Resumed job", "title", title, "err", err)
+	}
+	files = append(files, sidecars...)
+	transcript, err := writeTranscript(settings, outPath, provider, &state.Request, state.ChunkLimit, state.StitchContext, state.CustomScript)
+	if err != nil {
+		slog.Warn("Resumed job", "title", title, "err", err)
+	}
+	files = append(files, transcript...)
+	removeWholeOutput(settings, outPath, partPaths)
+	if target, n, err := uploadOutputs(context.Background(), settings, append([]string{outPath}, files...)); err != nil {
+		notify("Upload failed", fmt.Sprintf("%s: %v", title, err))
+	} else if n > 0 {
+		slog.Info("Resumed job uploaded", "title", title, "files", n, "target", target)
+	}
+
+	if jobHistory != nil {
+		if err := jobHistory.SaveText(state.InputText); err != nil {
+			slog.Warn("Failed to cache input text", "err", err)
+		}
+		if _, err := jobHistory.Add(history.Entry{
+			Title:      history.TitleFromText(state.InputText),
+			TextHash:   history.HashText(state.InputText),
+			Provider:   state.Provider,
+			Voice:      state.Request.Voice,
+			Speed:      state.Request.Speed,
+			OutputPath: outPath,
+			Files:      files,
+		}); err != nil {
+			slog.Warn("Failed to record history entry", "err", err)
+		}
+	}
+	slog.Info("Resumed job complete", "title", title, "path", outPath)
+	notify("Audio complete", fmt.Sprintf("%s is complete: %s", title, filepath.Base(outPath)))
+	return outPath, nil
+}
+
+// saveCheckpoint returns a processor callback that records the progress in checkpoints.
+func saveCheckpoint(checkpoints *checkpoint.Store) func(tts.Checkpoint) {
+	return func(cp tts.Checkpoint) {
+		if err := checkpoints.Save(cp); err != nil {
+			slog.Warn("Failed to save checkpoint", "err", err)
+		}
+	}
+}
+
+// retentionPolicy builds the retention policy from the settings, defaulting the archive folder.
+func retentionPolicy(settings *config.Settings) retention.Policy {
+	policy := retention.Policy{
+		CacheDays:   settings.CacheRetentionDays,
+		ArchiveDays: settings.ArchiveAfterDays,
+		ArchiveDir:  settings.ArchiveDir,
+		Compress:    settings.CompressArchive,
+	}
+	if policy.ArchiveDir == "" {
+		if dir, err := retention.DefaultArchiveDir(); err == nil {
+			policy.ArchiveDir = dir
+		}
+	}
+	return policy
+}
+
+// retentionCacheDirs lists the cache directories subject to the retention policy.
+func retentionCacheDirs(jobHistory *history.Store) []string {
+	var dirs []string
+	if jobHistory != nil {
+		dirs = append(dirs, jobHistory.TextsDir())
+	}
+	if dir, err := synthesisCacheDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := chunkStoreDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// synthesisCacheDir returns where synthesized chunks are cached.
+func synthesisCacheDir() (string, error) {
+	dataDir, err := config.AppDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "cache"), nil
+}
+
+// synthesisCache opens the synthesis cache, or returns nil if it is disabled or unavailable.
+func synthesisCache(settings *config.Settings) *cache.Cache {
+	if settings.DisableCache {
+		return nil
+	}
+	dir, err := synthesisCacheDir()
+	if err != nil {
+		slog.Warn("Synthesis cache disabled", "err", err)
+		return nil
+	}
+	c, err := cache.Open(dir)
+	if err != nil {
+		slog.Warn("Synthesis cache disabled", "err", err)
+		return nil
+	}
+	return c
+}
+
+// chunkStoreDir returns where the chunk files of jobs are kept.
+func chunkStoreDir() (string, error) {
+	dataDir, err := config.AppDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "chunks"), nil
+}
+
+// openChunkStore opens a new store for the chunk files of a job, or returns nil
+// if it is unavailable and the job should write its output directly.
+func openChunkStore() *chunkstore.Store {
+	dir, err := chunkStoreDir()
+	if err == nil {
+		var chunks *chunkstore.Store
+		if chunks, err = chunkstore.Open(filepath.Join(dir, strconv.FormatInt(time.Now().UnixNano(), 10))); err == nil {
+			return chunks
+		}
+	}
+	slog.Warn("Chunk files disabled", "err", err)
+	return nil
+}
+
+// defaultRetrySettings returns the processor's default retry schedule.
+func defaultRetrySettings() config.RetrySettings {
+	return config.RetrySettings{
+		MaxRetries: tts.DefaultMaxRetries,
+		BaseDelay:  tts.DefaultRetryBaseDelay.Seconds(),
+		Multiplier: tts.DefaultRetryMultiplier,
+		Jitter:     tts.DefaultRetryJitter,
+	}
+}
+
+// applyOverrides sets the values of the config file, then those of QUACKER_*
+// variables on settings for this run, and checks what the config package
+// cannot.
+func applyOverrides(file *config.File, settings *config.Settings) error {
+	if file.OutputFormat != "" {
+		if err := checkOutputFormat(file.OutputFormat); err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+	}
+	for provider, size := range file.ChunkSizes {
+		if err := tts.ValidateChunkLimit(provider, size); err != nil {
+			return fmt.Errorf("%s: chunk size of %s: %w", file.Path, provider, err)
+		}
+	}
+	if file.Retry != (config.FileRetry{}) {
+		// Checked on its own, the settings made in the app may keep it from applying
+		fileOnly := &config.Settings{}
+		file.Apply(fileOnly, defaultRetrySettings())
+		if err := validateRetrySettings(*fileOnly.Retry); err != nil {
+			return fmt.Errorf("%s: retry: %w", file.Path, err)
+		}
+	}
+	err := settings.Override(func(s *config.Settings) error {
+		file.Apply(s, defaultRetrySettings())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = settings.Override(func(s *config.Settings) error {
+		return config.ApplyEnv(s, defaultRetrySettings())
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(envChecks)) {
+		if v, ok := os.LookupEnv(name); ok {
+			if err := envChecks[name](settings); err != nil {
+				return fmt.Errorf("%s=%s: %w", name, v, err)
+			}
+		}
+	}
+	return nil
+}
+
+// envChecks check the settings QUACKER_* variables set as the settings dialog
+// does, by the variable that sets them.
+var envChecks = map[string]func(s *config.Settings) error{
+	"QUACKER_OUTPUT_FORMAT": func(s *config.Settings) error { return checkOutputFormat(s.OutputFormat) },
+	"QUACKER_CHUNK_LIMITS": func(s *config.Settings) error {
+		for provider, size := range s.ChunkLimits {
+			if err := tts.ValidateChunkLimit(provider, size); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"QUACKER_RETRY_MAX_RETRIES":  retryFieldCheck(func(r, from *config.RetrySettings) { r.MaxRetries = from.MaxRetries }),
+	"QUACKER_RETRY_BASE_DELAY_S": retryFieldCheck(func(r, from *config.RetrySettings) { r.BaseDelay = from.BaseDelay }),
+	"QUACKER_RETRY_MULTIPLIER":   retryFieldCheck(func(r, from *config.RetrySettings) { r.Multiplier = from.Multiplier }),
+	"QUACKER_RETRY_JITTER":       retryFieldCheck(func(r, from *config.RetrySettings) { r.Jitter = from.Jitter }),
+	"QUACKER_SAMPLE_RATE":        func(s *config.Settings) error { return checkOffered(s.SampleRate, outputSampleRates) },
+	"QUACKER_BITRATE":            func(s *config.Settings) error { return checkOffered(s.Bitrate, outputBitrates) },
+	"QUACKER_CROSSFADE":          func(s *config.Settings) error { return checkNotNegative(s.Crossfade) },
+	"QUACKER_CACHE_RETENTION_DAYS": func(s *config.Settings) error {
+		return checkNotNegative(s.CacheRetentionDays)
+	},
+	"QUACKER_ARCHIVE_AFTER_DAYS": func(s *config.Settings) error { return checkNotNegative(s.ArchiveAfterDays) },
+	"QUACKER_QUOTE_RATE": func(s *config.Settings) error {
+		_, err := preprocess.NormalizeRate(s.QuoteRate)
+		return err
+	},
+	"QUACKER_DEFINITION_RATE": func(s *config.Settings) error {
+		_, err := preprocess.NormalizeRate(s.DefinitionRate)
+		return err
+	},
+}
+
+// retryFieldCheck checks the field of the retry policy that set copies, with
+// the defaults for the others.
+func retryFieldCheck(set func(r, from *config.RetrySettings)) func(*config.Settings) error {
+	return func(s *config.Settings) error {
+		r := defaultRetrySettings()
+		set(&r, s.Retry)
+		return validateRetrySettings(r)
+	}
+}
+
+// checkOutputFormat checks an output format set outside the settings dialog.
+func checkOutputFormat(format string) error {
+	if !strings.Contains(" mp3 wav ogg ", " "+audio.NormalizeFormat(format)+" ") {
+		return fmt.Errorf("unsupported output format %q, use mp3, wav or ogg", format)
+	}
+	return nil
+}
+
+// checkOffered checks that v is one of the values offered in the settings
+// dialog, the first of which is the default, 0.
+func checkOffered(v int, offered []int) error {
+	if !slices.Contains(offered, v) {
+		return fmt.Errorf("%d is not supported, use one of %s, or 0 for the default", v, strings.Trim(fmt.Sprint(offered[1:]), "[]"))
+	}
+	return nil
+}
+
+// checkNotNegative checks a count or duration set outside the settings dialog.
+func checkNotNegative[T int | float64](v T) error {
+	if v < 0 {
+		return fmt.Errorf("%v is negative", v)
+	}
+	return nil
+}
+
+// validateRetrySettings checks a retry policy entered by the user.
+func validateRetrySettings(r config.RetrySettings) error {
+	return tts.ValidateRetryPolicy(r.MaxRetries, time.Duration(r.BaseDelay*float64(time.Second)), r.Multiplier, r.Jitter)
+}
+
+// applyRetrySettings sets the user's retry schedule and failed-section policy on cfg.
+func applyRetrySettings(cfg *tts.ProcessorConfig, settings *config.Settings) {
+	if settings.FailedSection != "" {
+		cfg.FailedSection = settings.FailedSection
+	}
+	if settings.FailedPhrase != "" {
+		cfg.FailedPhrase = settings.FailedPhrase
+	}
+	if r := settings.Retry; r != nil {
+		cfg.MaxRetries = r.MaxRetries
+		cfg.RetryBaseDelay = time.Duration(r.BaseDelay * float64(time.Second))
+		cfg.RetryMultiplier = r.Multiplier
+		cfg.RetryJitter = r.Jitter
+	}
+}
+
+// applyClipSettings sets the intro and outro files of settings on cfg, leaving
+// out the intro for the rest of a job whose audio already starts with it. A file
+// that cannot be read is logged and left out.
+func applyClipSettings(cfg *tts.ProcessorConfig, settings *config.Settings, intro bool) {
+	read := func(name, path string) []byte {
+		if path == "" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Leaving out a file", "file", name, "err", err)
+			return nil
+		}
+		return data
+	}
+	if intro {
+		cfg.Intro = read("intro", settings.IntroFile)
+	}
+	cfg.Outro = read("outro", settings.OutroFile)
 }
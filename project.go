@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"easy-tts/internal/config"
+	"easy-tts/internal/gui"
+	"easy-tts/internal/history"
+	"easy-tts/internal/project"
+	"easy-tts/internal/tts"
+)
+
+// projectSession is the project file open in the window, if any, and the
+// chunks reviewed for the current text, which a saved project keeps.
+type projectSession struct {
+	mu         sync.Mutex
+	path       string // "" until the project is saved or opened
+	provider   string // the provider chunkLimit applies to
+	chunkLimit int
+	script     string // the project's script hook, see scriptFor
+	text       string // the text chunks were reviewed for
+	chunks     []gui.EditableChunk
+}
+
+// reset forgets the project, e.g. when a plain document is opened instead.
+func (s *projectSession) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path, s.provider, s.chunkLimit, s.script = "", "", 0, ""
+	s.text, s.chunks = "", nil
+}
+
+// chunkLimitFor returns the chunk size for providerName: the project's if it
+// was saved with that provider, otherwise the one set in the settings.
+func (s *projectSession) chunkLimitFor(providerName string, settings *config.Settings) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chunkLimit > 0 && s.provider == providerName {
+		return s.chunkLimit
+	}
+	return settings.ChunkLimits[providerName]
+}
+
+// scriptFor returns the script hook of the open project, or the default one set
+// in the settings for a text that is not part of a project.
+func (s *projectSession) scriptFor(settings *config.Settings) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		return s.script
+	}
+	return settings.Script
+}
+
+// setScript changes the script of the project at path, which is saved with it
+// the next time the project is saved. It does nothing if another project has
+// been opened since.
+func (s *projectSession) setScript(path, src string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == path {
+		s.script = src
+	}
+}
+
+// chunksFor returns the reviewed chunks if they were made of text.
+func (s *projectSession) chunksFor(text string) []gui.EditableChunk {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.text != text {
+		return nil
+	}
+	return s.chunks
+}
+
+// setChunks remembers the chunks reviewed for text.
+func (s *projectSession) setChunks(text string, chunks []gui.EditableChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.text, s.chunks = text, chunks
+}
+
+// applyChunks splits segments into the given chunks, grouped by segment index.
+// It reports false if the chunks do not belong to segments.
+func applyChunks(segments []tts.Segment, chunks []gui.EditableChunk) ([]tts.Segment, bool) {
+	texts := make([][]string, len(segments))
+	for _, c := range chunks {
+		if c.Group < 0 || c.Group >= len(segments) {
+			return nil, false
+		}
+		texts[c.Group] = append(texts[c.Group], c.Text)
+	}
+	group := 0
+	return tts.ExpandSegments(segments, func(seg tts.Segment) []tts.Segment {
+		parts := make([]tts.Segment, len(texts[group]))
+		for j, text := range texts[group] {
+			parts[j] = tts.Segment{Text: text, Voice: seg.Voice, Language: seg.Language, Speaker: seg.Speaker, Speed: seg.Speed}
+		}
+		group++
+		return parts
+	}), true
+}
+
+// saveProject writes the window's text and settings to the open project, asking
+// for a file first if there is none or saveAs is set. onSaved is called with
+// the path of the saved project.
+func saveProject(ui *gui.UI, session *projectSession, providerName string, settings *config.Settings, saveAs bool, onSaved func(path string)) {
+	p := &project.Project{
+		Text:         ui.Input.Text,
+		Instructions: ui.Instructions.Text,
+		Provider:     providerName,
+		Voice:        ui.Voice.Text,
+		Style:        ui.SelectedStyle(),
+		Speed:        ui.Speed.Value,
+		ChunkLimit:   session.chunkLimitFor(providerName, settings),
+		Script:       session.scriptFor(settings),
+	}
+	for _, c := range session.chunksFor(p.Text) {
+		p.Chunks = append(p.Chunks, project.Chunk{Text: c.Text, Voice: c.Voice, Group: c.Group})
+	}
+	write := func(path string) {
+		if err := project.Save(path, p); err != nil {
+			ui.ShowError(fmt.Sprintf("Cannot save %s: %v", filepath.Base(path), err))
+			return
+		}
+		session.mu.Lock()
+		session.path, session.provider, session.chunkLimit, session.script = path, p.Provider, p.ChunkLimit, p.Script
+		session.mu.Unlock()
+		ui.ShowSuccess("Saved project " + filepath.Base(path))
+		onSaved(path)
+	}
+
+	session.mu.Lock()
+	path := session.path
+	session.mu.Unlock()
+	if path != "" && !saveAs {
+		write(path)
+		return
+	}
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil || w == nil {
+			return
+		}
+		w.Close()
+		path := w.URI().Path()
+		if !strings.EqualFold(filepath.Ext(path), project.Extension) {
+			path += project.Extension
+		}
+		write(path)
+	}, ui.Window)
+	if path != "" {
+		save.SetFileName(filepath.Base(path))
+	} else {
+		save.SetFileName(history.TitleFromText(p.Text) + project.Extension)
+	}
+	save.Show()
+}
+
+// openProject loads the project file at path into the window. It reports
+// whether the project could be read.
+func openProject(ui *gui.UI, session *projectSession, path string) bool {
+	p, err := project.Load(path)
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Cannot open %s: %v", filepath.Base(path), err))
+		return false
+	}
+	// Selecting the provider resets the voice and styles, so it goes first
+	if p.Provider != "" {
+		if slices.Contains(ui.ProviderSelect.Options, p.Provider) {
+			ui.ProviderSelect.SetSelected(p.Provider)
+		} else {
+			ui.ShowError(fmt.Sprintf("%s uses %s, which is not configured", filepath.Base(path), p.Provider))
+		}
+	}
+	if p.Voice != "" {
+		ui.Voice.SetText(p.Voice)
+	}
+	if p.Style != "" && slices.Contains(ui.Style.Options, p.Style) {
+		ui.Style.SetSelected(p.Style)
+	}
+	if p.Speed > 0 {
+		ui.Speed.SetValue(p.Speed)
+	}
+	ui.Instructions.SetText(p.Instructions)
+	ui.Input.SetText(p.Text)
+
+	chunks := make([]gui.EditableChunk, len(p.Chunks))
+	for i, c := range p.Chunks {
+		chunks[i] = gui.EditableChunk{Text: c.Text, Voice: c.Voice, Group: c.Group}
+	}
+	session.mu.Lock()
+	session.path, session.provider, session.chunkLimit, session.script = path, p.Provider, p.ChunkLimit, p.Script
+	session.text, session.chunks = p.Text, chunks
+	session.mu.Unlock()
+	if len(chunks) > 0 {
+		ui.ShowSuccess(fmt.Sprintf("Opened project %s (%d reviewed chunks)", filepath.Base(path), len(chunks)))
+	} else {
+		ui.ShowSuccess("Opened project " + filepath.Base(path))
+	}
+	return true
+}
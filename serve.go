@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"easy-tts/internal/config"
+	"easy-tts/internal/history"
+	"easy-tts/internal/queue"
+	"easy-tts/internal/tts"
+	"easy-tts/internal/util"
+)
+
+// synthesizeRequest is the body of POST /synthesize.
+type synthesizeRequest struct {
+	Text         string  `json:"text"`
+	Provider     string  `json:"provider,omitempty"`
+	Voice        string  `json:"voice,omitempty"`
+	Speed        float64 `json:"speed,omitempty"`
+	Style        string  `json:"style,omitempty"`
+	Instructions string  `json:"instructions,omitempty"`
+	Format       string  `json:"format,omitempty"`
+}
+
+// serverJob is a job of the server as GET /jobs/{id} reports it.
+type serverJob struct {
+	ID       string     `json:"id"`
+	Status   string     `json:"status"` // see the statuses of package queue
+	Title    string     `json:"title"`
+	Provider string     `json:"provider"`
+	Voice    string     `json:"voice,omitempty"`
+	Chunk    int        `json:"chunk"`
+	Chunks   int        `json:"chunks"`
+	Output   string     `json:"output,omitempty"`
+	AudioURL string     `json:"audio_url,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// server runs synthesis jobs posted over HTTP one at a time, in the order they
+// came in, with the same pipeline and settings as the window. The jobs are
+// kept on disk, so those not finished run after a restart.
+type server struct {
+	ttsManager      *tts.Manager
+	settings        *config.Settings
+	jobHistory      *history.Store
+	defaultProvider string
+	token           string // required as "Authorization: Bearer" if set
+
+	queue *queue.Queue
+}
+
+// handler returns the routes of the API.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /synthesize", s.synthesize)
+	mux.HandleFunc("GET /jobs", s.listJobs)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /jobs/{id}/audio", s.getAudio)
+	mux.HandleFunc("GET /voices", s.voices)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Web pages the user visits can reach a local server too: browsers send
+		// an Origin with their requests, and a page rebinding its DNS name to
+		// 127.0.0.1 still sends its own name as the Host
+		if r.Header.Get("Origin") != "" {
+			writeJSONError(w, http.StatusForbidden, errors.New("requests from web pages are not accepted"))
+			return
+		}
+		if s.token == "" {
+			// The Host header is the client's to choose, the address it reached is not
+			if local, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr); !isLoopbackAddr(local) {
+				writeJSONError(w, http.StatusForbidden, errors.New("without a token only 127.0.0.1 and ::1 are served"))
+				return
+			}
+		} else if !s.validToken(r.Header.Get("Authorization")) {
+			writeJSONError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// validToken reports whether authorization, the Authorization header or gRPC
+// metadata of a request, carries the server's bearer token.
+func (s *server) validToken(authorization string) bool {
+	return subtle.ConstantTimeCompare([]byte(authorization), []byte("Bearer "+s.token)) == 1
+}
+
+// isLoopbackAddr reports whether addr, the address a connection was accepted
+// on, is a loopback address, which only this machine can reach.
+func isLoopbackAddr(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// listenLocal listens on addr, which without a token must be a loopback
+// address: the API could be used by anyone who can reach it otherwise.
+func listenLocal(addr, token string) (net.Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" && !isLoopbackAddr(lis.Addr()) {
+		lis.Close()
+		return nil, fmt.Errorf("%s is not a loopback address; set -token or QUACKER_SERVER_TOKEN to serve other machines", addr)
+	}
+	return lis, nil
+}
+
+// synthesize queues a job and answers 202 Accepted with it.
+func (s *server) synthesize(w http.ResponseWriter, r *http.Request) {
+	// A form or text/plain body is what a web page can send without asking
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, errors.New("send the request as Content-Type: application/json"))
+		return
+	}
+	var req synthesizeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<20)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if _, err := s.newJob(req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	j, err := s.submit(req)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, j)
+}
+
+// listJobs answers all jobs, oldest first.
+func (s *server) listJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := []serverJob{}
+	for _, j := range s.queue.Jobs() {
+		jobs = append(jobs, jobView(j))
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// getJob answers the status and progress of a job.
+func (s *server) getJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.job(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, errors.New("no such job"))
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// getAudio answers the audio of a finished job.
+func (s *server) getAudio(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.job(r.PathValue("id"))
+	if !ok || j.Output == "" {
+		writeJSONError(w, http.StatusNotFound, errors.New("no audio for this job"))
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(j.Output)))
+	http.ServeFile(w, r, j.Output)
+}
+
+// voices answers the voices of the provider query parameter, or all configured
+// providers, filtered by the language and gender parameters.
+func (s *server) voices(w http.ResponseWriter, r *http.Request) {
+	providers := s.ttsManager.GetAvailableProviders()
+	if name := r.URL.Query().Get("provider"); name != "" {
+		if err := s.ttsManager.ValidateProvider(name); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		providers = []string{name}
+	}
+	voices := []tts.VoiceInfo{}
+	for _, name := range providers {
+		listed, err := s.ttsManager.ListVoices(r.Context(), name)
+		if err != nil {
+			writeJSONError(w, http.StatusBadGateway, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		voices = append(voices, tts.FilterVoices(listed, r.URL.Query().Get("language"), r.URL.Query().Get("gender"))...)
+	}
+	writeJSON(w, http.StatusOK, voices)
+}
+
+// newJob checks req and fills in the defaults of the server.
+func (s *server) newJob(req synthesizeRequest) (batchJob, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return batchJob{}, errors.New("text is empty")
+	}
+	job := batchJob{Provider: req.Provider, Voice: req.Voice, Speed: req.Speed, Style: req.Style, Instructions: req.Instructions, Format: req.Format}
+	if job.Provider == "" {
+		job.Provider = s.defaultProvider
+	}
+	if job.Speed == 0 {
+		job.Speed = defaultSpeed(s.settings)
+	}
+	if err := s.ttsManager.ValidateProvider(job.Provider); err != nil {
+		return batchJob{}, fmt.Errorf("provider '%s' configuration error: %w", job.Provider, err)
+	}
+	return job, nil
+}
+
+// submit queues req, checked by newJob, and returns the job. The provider is
+// stored, so the job keeps it if the default changes before it runs.
+func (s *server) submit(req synthesizeRequest) (serverJob, error) {
+	if req.Provider == "" {
+		req.Provider = s.defaultProvider
+	}
+	j, err := s.queue.Add(history.TitleFromText(req.Text), req)
+	if err != nil {
+		return serverJob{}, err
+	}
+	return jobView(j), nil
+}
+
+// job returns the job id.
+func (s *server) job(id string) (serverJob, bool) {
+	j, ok := s.queue.Get(id)
+	if !ok {
+		return serverJob{}, false
+	}
+	return jobView(j), true
+}
+
+// jobView returns j as the API reports it.
+func jobView(j queue.Job) serverJob {
+	var req synthesizeRequest
+	json.Unmarshal(j.Payload, &req)
+	view := serverJob{
+		ID:       j.ID,
+		Status:   j.Status,
+		Title:    j.Title,
+		Provider: req.Provider,
+		Voice:    req.Voice,
+		Chunk:    j.Chunk,
+		Chunks:   j.Chunks,
+		Output:   j.Output,
+		Error:    j.Error,
+		Created:  j.Created,
+		Finished: j.Finished,
+	}
+	if view.Output != "" {
+		view.AudioURL = "/jobs/" + view.ID + "/audio"
+	}
+	return view
+}
+
+// run works through the queue until ctx is done. A job interrupted by that is
+// left running, so the queue runs it again after a restart.
+func (s *server) run(ctx context.Context) {
+	for {
+		j, err := s.queue.Next(ctx)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			slog.Error("Server failed", "err", err)
+		}
+		var req synthesizeRequest
+		if err := json.Unmarshal(j.Payload, &req); err != nil {
+			s.finish(j.ID, "", fmt.Errorf("invalid job: %w", err))
+			continue
+		}
+		job, err := s.newJob(req)
+		if err != nil {
+			s.finish(j.ID, "", err)
+			continue
+		}
+		output, err := synthesizeText(ctx, s.ttsManager, s.settings, s.jobHistory, job, "", req.Text, func(completed, chunks int) {
+			s.queue.SetProgress(j.ID, completed, chunks)
+		})
+		if ctx.Err() != nil && output == "" {
+			slog.Info("Server job interrupted, it runs again on the next start", "job", j.ID)
+			return
+		}
+		s.finish(j.ID, output, err)
+	}
+}
+
+// finish records the outcome of the job id.
+func (s *server) finish(id, output string, err error) {
+	if saveErr := s.queue.Finish(id, output, err); saveErr != nil {
+		slog.Error("Failed to save the server jobs", "err", saveErr)
+	}
+	if j, ok := s.queue.Get(id); ok {
+		slog.Info("Server job finished", "job", j.ID, "status", j.Status)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// serveCommand runs "serve [flags]" from the command line: a local HTTP API for
+// other apps and scripts. It returns the exit code once interrupted: exitOK, or
+// exitFailed if the server failed.
+func serveCommand(ttsManager *tts.Manager, settings *config.Settings, jobHistory *history.Store, defaultProvider string, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags]\n\nServes a local HTTP API:\n\n"+
+			"  POST /synthesize        {\"text\": ..., \"provider\", \"voice\", \"speed\", \"style\", \"instructions\", \"format\"}\n"+
+			"  GET  /jobs/{id}         status and progress of a job\n"+
+			"  GET  /jobs/{id}/audio   the audio of a finished job\n"+
+			"  GET  /voices            voices, filtered by ?provider=, ?language= and ?gender=\n\n"+
+			"and the same over gRPC, with streamed audio and progress (see api/quackerpb/quacker.proto).\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "127.0.0.1:8765", "address to listen on")
+	grpcAddr := fs.String("grpc-addr", "127.0.0.1:8766", "address to serve gRPC on; \"\" to turn it off")
+	provider := fs.String("provider", defaultProvider, "provider of requests that name none")
+	rpm := fs.Int("rpm", 0, "requests per minute to each provider, over all jobs together (default: no limit)")
+	token := fs.String("token", os.Getenv("QUACKER_SERVER_TOKEN"), "bearer token clients must send (default: $QUACKER_SERVER_TOKEN); required to listen on other than a loopback address")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitInvalid
+	}
+	if code := startCommand(ttsManager, batchJob{Provider: *provider}); code != exitOK {
+		return code
+	}
+
+	dataDir, err := config.AppDataDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	jobs, err := queue.Open(filepath.Join(dataDir, "queue"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	if *rpm > 0 {
+		ttsManager.LimitRate(*rpm)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := &server{
+		ttsManager:      ttsManager,
+		settings:        settings,
+		jobHistory:      jobHistory,
+		defaultProvider: *provider,
+		token:           *token,
+		queue:           jobs,
+	}
+	httpLis, err := listenLocal(*addr, *token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	go s.run(ctx)
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	var grpcSrv *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := listenLocal(*grpcAddr, *token)
+		if err != nil {
+			httpLis.Close()
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		grpcSrv = newGRPCServer(s)
+		go grpcSrv.Serve(lis)
+		fmt.Fprintf(os.Stderr, "Quacker serves gRPC on %s\n", lis.Addr())
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+		if grpcSrv != nil {
+			grpcSrv.Stop()
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Quacker is listening on http://%s\n", httpLis.Addr())
+	if err := srv.Serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// submitCommand runs "submit [flags] FILE..." from the command line, queueing
+// documents with a running server so they are converted even after this
+// command, or the window, is closed. It returns the exit code: exitOK once the
+// jobs are queued, or with -wait once they are finished, see exitCodeFor.
+func submitCommand(settings *config.Settings, args []string) int {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s submit [flags] FILE...\n\nQueues documents with a server started by \"%[1]s serve\", which keeps the queue\non disk. FILE \"-\" reads stdin.\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	job := batchJob{}
+	jobFlags(fs, &job, "", settings) // the server's default provider
+	fs.StringVar(&job.Format, "format", "", "output format: mp3, wav or ogg (default: the setting of the server)")
+	addr := fs.String("server", "http://127.0.0.1:8765", "URL of the server")
+	token := fs.String("token", os.Getenv("QUACKER_SERVER_TOKEN"), "bearer token of the server (default: $QUACKER_SERVER_TOKEN)")
+	wait := fs.Bool("wait", false, "wait until the jobs are finished")
+	if err := fs.Parse(args); err != nil {
+		return exitInvalid
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitInvalid
+	}
+
+	call := func(method, path string, body, out any) error {
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return err
+			}
+			r = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, strings.TrimSuffix(*addr, "/")+path, r)
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if *token != "" {
+			req.Header.Set("Authorization", "Bearer "+*token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("%w (is \"%s serve\" running?)", err, filepath.Base(os.Args[0]))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			var e struct{ Error string }
+			json.NewDecoder(resp.Body).Decode(&e)
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	code := exitOK
+	submitted := map[string]string{} // job ID -> document
+	var ids []string
+	for _, path := range fs.Args() {
+		f := os.Stdin
+		if path != "-" {
+			var err error
+			if f, err = os.Open(path); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				code = exitInvalid
+				continue
+			}
+		}
+		text, err := util.ReadTextFile(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = exitInvalid
+			continue
+		}
+		var j serverJob
+		err = call(http.MethodPost, "/synthesize", synthesizeRequest{
+			Text: text, Provider: job.Provider, Voice: job.Voice, Speed: job.Speed,
+			Style: job.Style, Instructions: job.Instructions, Format: job.Format,
+		}, &j)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			if code == exitOK {
+				code = exitCodeFor("", err)
+			}
+			continue
+		}
+		fmt.Printf("%s\t%s\n", j.ID, path)
+		submitted[j.ID] = path
+		ids = append(ids, j.ID)
+	}
+	if !*wait || len(ids) == 0 {
+		return code
+	}
+
+	converted := 0
+	for _, id := range ids {
+		var j serverJob
+		for {
+			if err := call(http.MethodGet, "/jobs/"+id, nil, &j); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", submitted[id], err)
+				return exitFailed
+			}
+			if j.Status == queue.Done || j.Status == queue.Failed {
+				break
+			}
+			if j.Chunks > 0 {
+				fmt.Fprintf(os.Stderr, "\r%s: chunk %d of %d\033[K", submitted[id], j.Chunk, j.Chunks)
+			}
+			time.Sleep(time.Second)
+		}
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		switch {
+		case j.Error != "" && j.Output != "":
+			fmt.Printf("%s -> %s (%s)\n", submitted[id], j.Output, j.Error)
+		case j.Error != "":
+			fmt.Printf("%s: %s\n", submitted[id], j.Error)
+		default:
+			fmt.Printf("%s -> %s\n", submitted[id], j.Output)
+		}
+		if j.Output != "" {
+			converted++
+		}
+		if j.Error != "" && code == exitOK {
+			code = exitCodeFor(j.Output, errors.New(j.Error))
+		}
+	}
+	if converted > 0 && code != exitOK {
+		code = exitPartial
+	}
+	return code
+}
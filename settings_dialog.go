@@ -0,0 +1,1108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"easy-tts/internal/audio"
+	"easy-tts/internal/config"
+	"easy-tts/internal/googleauth"
+	"easy-tts/internal/gui"
+	"easy-tts/internal/history"
+	"easy-tts/internal/preprocess"
+	"easy-tts/internal/retention"
+	"easy-tts/internal/script"
+	"easy-tts/internal/tts"
+	"easy-tts/internal/upload"
+	"easy-tts/internal/util"
+)
+
+// settingsTab is a tab of the provider settings dialog. apply sets the values
+// entered on settings when the dialog is saved; values that do not check out
+// are reported and left as they were.
+type settingsTab struct {
+	title   string
+	content fyne.CanvasObject
+	apply   func(settings *config.Settings)
+}
+
+// showProviderSettingsDialog shows the provider configuration dialog
+func showProviderSettingsDialog(ui *gui.UI, ttsManager *tts.Manager, currentProvider *string, settings *config.Settings, jobHistory *history.Store, session *projectSession) {
+	// Provider selection (moved above tabs)
+	providerInfo := ttsManager.GetProviderInfo()
+	var providerNames []string
+	for _, info := range providerInfo {
+		providerNames = append(providerNames, info.Name)
+	}
+
+	defaultProviderSelect := widget.NewSelect(providerNames, nil)
+	defaultProviderSelect.SetSelected(ttsManager.GetConfig().DefaultProvider)
+
+	// The provider tabs fill in the credentials of newConfig
+	newConfig := &tts.ProviderConfig{}
+	settingsTabs := []settingsTab{
+		openAISettingsTab(ui, ttsManager.GetConfig(), newConfig, settings),
+		googleSettingsTab(ui, ttsManager.GetConfig(), newConfig, settings),
+		preprocessingSettingsTab(ui, settings, *currentProvider),
+		replacementsSettingsTab(ui, settings),
+		acronymsSettingsTab(settings),
+		headingsSettingsTab(settings),
+		languagesSettingsTab(settings, *currentProvider),
+		dialogueSettingsTab(settings, *currentProvider),
+		favoritesSettingsTab(settings, *currentProvider),
+		scriptSettingsTab(ui, settings, session),
+		retriesSettingsTab(ui, settings),
+		storageSettingsTab(ui, settings, jobHistory),
+		uploadSettingsTab(settings),
+	}
+	tabs := container.NewAppTabs()
+	for _, tab := range settingsTabs {
+		tabs.Append(container.NewTabItem(tab.title, tab.content))
+	}
+
+	mainContent := container.NewVBox(
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Default Provider:"), defaultProviderSelect,
+		),
+		tabs,
+	)
+
+	dialog := dialog.NewCustomConfirm("Provider Settings", "Save", "Cancel", mainContent, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		for _, tab := range settingsTabs {
+			tab.apply(settings)
+		}
+		newConfig.DefaultProvider = defaultProviderSelect.Selected
+		newConfig.OpenAIBaseURL, newConfig.OpenAIOrganization, newConfig.OpenAIProject = settings.OpenAIEndpoint()
+		if err := config.SaveSettings(settings); err != nil {
+			slog.Error("Failed to save settings", "err", err)
+		}
+		if conflicts, err := config.SyncSettings(settings); err != nil {
+			ui.ShowError(fmt.Sprintf("Settings sync failed: %v", err))
+		} else if len(conflicts) > 0 {
+			ui.ShowError(fmt.Sprintf("Settings changed on another machine too (%s); a conflict copy was saved in the sync folder", strings.Join(conflicts, ", ")))
+		}
+
+		// Persist default provider to keychain
+		if err := config.SetDefaultProvider(defaultProviderSelect.Selected); err != nil {
+			slog.Error("Failed to save default provider to keychain", "err", err)
+		}
+
+		// Update manager
+		ttsManager.UpdateConfig(newConfig)
+
+		// Update UI
+		availableProviders := ttsManager.GetAvailableProviders()
+		ui.ProviderSelect.Options = availableProviders
+
+		if len(availableProviders) > 0 {
+			newProvider := newConfig.DefaultProvider
+			if newProvider == "" {
+				newProvider = availableProviders[0]
+			}
+			*currentProvider = newProvider
+			ui.ProviderSelect.SetSelected(newProvider)
+			updateVoiceForProvider(ui, ttsManager, newProvider, settings)
+		}
+
+	}, ui.Window)
+
+	dialog.Resize(fyne.NewSize(500, 400))
+	dialog.Show()
+}
+
+// openAISettingsTab is the OpenAI tab. Its apply also sets the API key on
+// newConfig and saves it to the keychain.
+func openAISettingsTab(ui *gui.UI, current, newConfig *tts.ProviderConfig, settings *config.Settings) settingsTab {
+	openAIAPIKeyEntry := widget.NewPasswordEntry()
+	openAIAPIKeyEntry.SetText(current.OpenAIAPIKey)
+
+	// Chunk sizes: smaller chunks start playing sooner, larger ones keep prosody consistent
+	chunkSizeEntry := chunkLimitEntry(settings, "openai", tts.DefaultTokenLimit)
+	// tts-1 is the fastest, tts-1-hd the clearest; only gpt-4o-mini-tts takes instructions
+	openAIModelSelect := widget.NewSelect(tts.ProviderCapabilities("openai").Models, nil)
+	openAIModelSelect.SetSelected(modelFor("openai", settings))
+	stitchContextCheck := widget.NewCheck("Pass the previous sentence as context so intonation carries over (gpt-4o-mini-tts)", nil)
+	stitchContextCheck.SetChecked(settings.StitchContext)
+	// A gateway, such as Azure OpenAI, and the organization and project billed
+	openAIBaseURLEntry := widget.NewEntry()
+	openAIBaseURLEntry.SetPlaceHolder(tts.DefaultOpenAIBaseURL)
+	openAIBaseURLEntry.SetText(settings.OpenAIBaseURL)
+	openAIOrganizationEntry := widget.NewEntry()
+	openAIOrganizationEntry.SetPlaceHolder("Default of the key")
+	openAIOrganizationEntry.SetText(settings.OpenAIOrganization)
+	openAIProjectEntry := widget.NewEntry()
+	openAIProjectEntry.SetPlaceHolder("Default of the key")
+	openAIProjectEntry.SetText(settings.OpenAIProject)
+	content := container.New(layout.NewFormLayout(),
+		widget.NewLabel("API Key:"), openAIAPIKeyEntry,
+		widget.NewLabel("Base URL:"), openAIBaseURLEntry,
+		widget.NewLabel("Organization:"), openAIOrganizationEntry,
+		widget.NewLabel("Project:"), openAIProjectEntry,
+		widget.NewLabel("Model:"), openAIModelSelect,
+		widget.NewLabel("Chunk size (tokens):"), chunkSizeEntry,
+		widget.NewLabel("Chunk boundaries:"), stitchContextCheck,
+	)
+
+	apply := func(settings *config.Settings) {
+		newConfig.OpenAIAPIKey = openAIAPIKeyEntry.Text
+		if openAIAPIKeyEntry.Text != "" {
+			config.SetOpenAIAPIKey(openAIAPIKeyEntry.Text)
+		}
+		if baseURL := strings.TrimSpace(openAIBaseURLEntry.Text); baseURL == "" {
+			settings.OpenAIBaseURL = ""
+		} else if err := tts.ValidateOpenAIBaseURL(baseURL); err != nil {
+			ui.ShowError(fmt.Sprintf("OpenAI base URL not saved: %v", err))
+		} else {
+			settings.OpenAIBaseURL = baseURL
+		}
+		settings.OpenAIOrganization = strings.TrimSpace(openAIOrganizationEntry.Text)
+		settings.OpenAIProject = strings.TrimSpace(openAIProjectEntry.Text)
+		settings.StitchContext = stitchContextCheck.Checked
+		if settings.Models == nil {
+			settings.Models = map[string]string{}
+		}
+		settings.Models["openai"] = openAIModelSelect.Selected
+		applyChunkLimit(ui, settings, "openai", chunkSizeEntry)
+	}
+	return settingsTab{title: "OpenAI", content: content, apply: apply}
+}
+
+// googleSettingsTab is the Google Cloud tab. Its apply also sets the
+// authentication on newConfig and saves it to the keychain.
+func googleSettingsTab(ui *gui.UI, current, newConfig *tts.ProviderConfig, settings *config.Settings) settingsTab {
+	googleProjectEntry := widget.NewEntry()
+	googleProjectEntry.SetText(current.GoogleProjectID)
+	googleProjectLabel := widget.NewLabel("Project ID:")
+
+	googleAPIKeyEntry := widget.NewPasswordEntry()
+	googleAPIKeyEntry.SetText(current.GoogleAPIKey)
+	googleAPIKeyLabel := widget.NewLabel("API Key:")
+
+	// A service account key is chosen as a file or pasted as JSON
+	googleCredentialsEntry := widget.NewPasswordEntry()
+	googleCredentialsEntry.SetPlaceHolder("Key file, or its JSON pasted")
+	googleCredentialsEntry.SetText(current.GoogleCredentials)
+	googleCredentialsLabel := widget.NewLabel("Service Account:")
+	googleCredentialsField := container.NewBorder(nil, nil, nil, widget.NewButton("Choose...", func() {
+		dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
+			if err != nil || f == nil {
+				return
+			}
+			f.Close()
+			googleCredentialsEntry.SetText(f.URI().Path())
+		}, ui.Window)
+	}), googleCredentialsEntry)
+
+	// A Google account is signed in to in the browser and saved right away
+	googleAccount := config.GetGoogleAccount()
+	googleAccountStatus := widget.NewLabel("Not signed in")
+	if googleAccount != "" {
+		googleAccountStatus.SetText("Signed in")
+	}
+	googleAccountLabel := widget.NewLabel("Google Account:")
+	var googleSignInButton *widget.Button
+	googleSignInButton = widget.NewButton("Sign in with Google...", func() {
+		googleSignInButton.Disable()
+		googleAccountStatus.SetText("Waiting for the sign-in in the browser...")
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			creds, err := googleauth.SignIn(ctx, func(u string) error {
+				parsed, err := url.Parse(u)
+				if err != nil {
+					return err
+				}
+				return fyne.CurrentApp().OpenURL(parsed)
+			})
+			if err == nil {
+				err = config.SetGoogleAccount(string(creds))
+			}
+			fyne.Do(func() {
+				googleSignInButton.Enable()
+				if err != nil {
+					googleAccountStatus.SetText("Not signed in")
+					ui.ShowError(fmt.Sprintf("Google sign-in failed: %v", err))
+					return
+				}
+				googleAccount = string(creds)
+				googleAccountStatus.SetText("Signed in")
+			})
+		}()
+	})
+	googleAccountField := container.NewBorder(nil, nil, nil, googleSignInButton, googleAccountStatus)
+
+	// updateGoogleFields toggles visibility of provider-specific fields
+	updateGoogleFields := func(method string) {
+		googleCredentialsLabel.Hide()
+		googleCredentialsField.Hide()
+		googleAccountLabel.Hide()
+		googleAccountField.Hide()
+		googleProjectEntry.SetPlaceHolder("")
+		if method == "API Key" {
+			googleProjectLabel.Hide()
+			googleProjectEntry.Hide()
+			googleAPIKeyLabel.Show()
+			googleAPIKeyEntry.Show()
+		} else { // "gcloud auth", "Service Account" or "Google Account"
+			googleProjectLabel.Show()
+			googleProjectEntry.Show()
+			googleAPIKeyLabel.Hide()
+			googleAPIKeyEntry.Hide()
+		}
+		if method == "Service Account" {
+			googleProjectEntry.SetPlaceHolder("From the key if empty")
+			googleCredentialsLabel.Show()
+			googleCredentialsField.Show()
+		}
+		if method == "Google Account" {
+			googleAccountLabel.Show()
+			googleAccountField.Show()
+		}
+	}
+
+	// Google Cloud authentication method selection
+	googleAuthMethods := []string{"gcloud auth", "API Key", "Service Account", "Google Account"}
+	googleAuthSelect := widget.NewSelect(googleAuthMethods, updateGoogleFields)
+
+	// Set current auth method from config and trigger initial field visibility
+	currentAuthMethod := current.GoogleAuthMethod
+	if currentAuthMethod == "" {
+		currentAuthMethod = "gcloud auth" // Default to gcloud auth
+	}
+	googleAuthSelect.SetSelected(currentAuthMethod)
+	updateGoogleFields(currentAuthMethod)
+
+	chunkSizeEntry := chunkLimitEntry(settings, "google", tts.DefaultByteLimit)
+	content := container.New(layout.NewFormLayout(),
+		widget.NewLabel("Auth Method:"), googleAuthSelect,
+		googleProjectLabel, googleProjectEntry,
+		googleAPIKeyLabel, googleAPIKeyEntry,
+		googleCredentialsLabel, googleCredentialsField,
+		googleAccountLabel, googleAccountField,
+		widget.NewLabel("Chunk size (bytes):"), chunkSizeEntry,
+	)
+
+	apply := func(settings *config.Settings) {
+		// A service account key names its project
+		if googleAuthSelect.Selected == "Service Account" {
+			project, err := config.GoogleCredentialsProjectID(googleCredentialsEntry.Text)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Service account key not usable: %v", err))
+			} else if googleProjectEntry.Text == "" {
+				googleProjectEntry.SetText(project)
+			}
+		}
+
+		newConfig.GoogleProjectID = googleProjectEntry.Text
+		newConfig.GoogleAPIKey = googleAPIKeyEntry.Text
+		newConfig.GoogleAuthMethod = googleAuthSelect.Selected
+		newConfig.GoogleCredentials = googleCredentialsEntry.Text
+		if googleAuthSelect.Selected == "Google Account" {
+			newConfig.GoogleCredentials = googleAccount
+		}
+
+		// Save to keychain
+		if googleProjectEntry.Text != "" {
+			config.SetGoogleProjectID(googleProjectEntry.Text)
+		}
+		if googleAPIKeyEntry.Text != "" {
+			config.SetGoogleAPIKey(googleAPIKeyEntry.Text)
+		}
+		if googleAuthSelect.Selected != "" {
+			config.SetGoogleAuthMethod(googleAuthSelect.Selected)
+		}
+		if googleCredentialsEntry.Text != "" {
+			config.SetGoogleCredentials(googleCredentialsEntry.Text)
+		}
+		applyChunkLimit(ui, settings, "google", chunkSizeEntry)
+	}
+	return settingsTab{title: "Google Cloud", content: content, apply: apply}
+}
+
+// chunkLimitEntry is an entry for the chunk limit of provider, empty for the default.
+func chunkLimitEntry(settings *config.Settings, provider string, def int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(fmt.Sprintf("Default: %d", def))
+	if n := settings.ChunkLimits[provider]; n > 0 {
+		entry.SetText(strconv.Itoa(n))
+	}
+	return entry
+}
+
+// applyChunkLimit sets the chunk limit of provider entered in entry.
+func applyChunkLimit(ui *gui.UI, settings *config.Settings, provider string, entry *widget.Entry) {
+	text := strings.TrimSpace(entry.Text)
+	if text == "" {
+		delete(settings.ChunkLimits, provider)
+		return
+	}
+	n, err := strconv.Atoi(text)
+	if err == nil {
+		err = tts.ValidateChunkLimit(provider, n)
+	}
+	if err != nil {
+		ui.ShowError(fmt.Sprintf("Chunk size not saved: %v", err))
+		return
+	}
+	if n <= 0 {
+		delete(settings.ChunkLimits, provider)
+		return
+	}
+	if settings.ChunkLimits == nil {
+		settings.ChunkLimits = map[string]int{}
+	}
+	settings.ChunkLimits[provider] = n
+}
+
+// preprocessingSettingsTab has one checkbox per pipeline stage, and how quotes,
+// definitions, tables and footnotes are read.
+func preprocessingSettingsTab(ui *gui.UI, settings *config.Settings, provider string) settingsTab {
+	enabledStages := preprocess.DefaultEnabled()
+	for name, on := range settings.PreprocessStages {
+		enabledStages[name] = on
+	}
+	stageChecks := container.NewVBox()
+	for _, stage := range preprocess.Stages() {
+		name := stage.Name()
+		check := widget.NewCheck(stage.Description(), func(on bool) {
+			enabledStages[name] = on
+		})
+		check.SetChecked(enabledStages[name])
+		stageChecks.Add(check)
+	}
+	sayAsCheck := widget.NewCheck("Read dates, times and ordinals as such via SSML say-as (Google, not Chirp voices)", nil)
+	sayAsCheck.SetChecked(settings.SayAsHints)
+	stageChecks.Add(widget.NewSeparator())
+	stageChecks.Add(sayAsCheck)
+	reviewChunksCheck := widget.NewCheck("Review, edit, merge and split chunks before synthesis starts", nil)
+	reviewChunksCheck.SetChecked(settings.ReviewChunks)
+	stageChecks.Add(reviewChunksCheck)
+	quoteRateEntry := widget.NewEntry()
+	quoteRateEntry.SetPlaceHolder("e.g. 90% or slow")
+	quoteRateEntry.SetText(settings.QuoteRate)
+	definitionRateEntry := widget.NewEntry()
+	definitionRateEntry.SetPlaceHolder("e.g. 85%")
+	definitionRateEntry.SetText(settings.DefinitionRate)
+	tableHeaderLabels := map[string]string{
+		preprocess.TableHeadersEachRow: "Repeat in every row",
+		preprocess.TableHeadersOnce:    "Read once before the rows",
+	}
+	tableHeadersSelect := widget.NewSelect([]string{tableHeaderLabels[preprocess.TableHeadersEachRow], tableHeaderLabels[preprocess.TableHeadersOnce]}, nil)
+	tableHeadersSelect.SetSelected(tableHeaderLabels[preprocess.TableHeadersEachRow])
+	if label, ok := tableHeaderLabels[settings.TableHeaders]; ok {
+		tableHeadersSelect.SetSelected(label)
+	}
+	footnoteLeadInEntry := widget.NewEntry()
+	footnoteLeadInEntry.SetPlaceHolder("Footnote {id}:")
+	footnoteLeadInEntry.SetText(settings.FootnoteLeadIn)
+	footnoteVoiceEntry := widget.NewEntry()
+	footnoteVoiceEntry.SetPlaceHolder("Same voice as the text")
+	footnoteVoiceEntry.SetText(settings.FootnoteVoices[provider])
+	stageChecks.Add(container.New(layout.NewFormLayout(),
+		widget.NewLabel("Speaking rate of quotes:"), quoteRateEntry,
+		widget.NewLabel("Speaking rate of definitions:"), definitionRateEntry,
+		widget.NewLabel("Table column headers:"), tableHeadersSelect,
+		widget.NewLabel("Footnote lead-in:"), footnoteLeadInEntry,
+		widget.NewLabel(fmt.Sprintf("Footnote voice (%s):", provider)), footnoteVoiceEntry,
+	))
+
+	apply := func(settings *config.Settings) {
+		settings.PreprocessStages = enabledStages
+		settings.SayAsHints = sayAsCheck.Checked
+		settings.ReviewChunks = reviewChunksCheck.Checked
+		settings.TableHeaders = ""
+		if tableHeadersSelect.Selected == tableHeaderLabels[preprocess.TableHeadersOnce] {
+			settings.TableHeaders = preprocess.TableHeadersOnce
+		}
+		settings.FootnoteLeadIn = strings.TrimSpace(footnoteLeadInEntry.Text)
+		if settings.FootnoteVoices == nil {
+			settings.FootnoteVoices = map[string]string{}
+		}
+		settings.FootnoteVoices[provider] = strings.TrimSpace(footnoteVoiceEntry.Text)
+		quoteRate, quoteErr := preprocess.NormalizeRate(quoteRateEntry.Text)
+		definitionRate, definitionErr := preprocess.NormalizeRate(definitionRateEntry.Text)
+		if err := errors.Join(quoteErr, definitionErr); err != nil {
+			ui.ShowError(fmt.Sprintf("Speaking rates not saved: %v", err))
+		} else {
+			settings.QuoteRate, settings.DefinitionRate = quoteRate, definitionRate
+		}
+	}
+	return settingsTab{title: "Preprocessing", content: stageChecks, apply: apply}
+}
+
+// replacementsSettingsTab holds custom regex find/replace rules, applied in order.
+func replacementsSettingsTab(ui *gui.UI, settings *config.Settings) settingsTab {
+	type replaceRow struct {
+		pattern, replacement *widget.Entry
+	}
+	var replaceRows []*replaceRow
+	replaceList := container.NewVBox()
+	var addReplaceRow func(rule preprocess.ReplaceRule)
+	addReplaceRow = func(rule preprocess.ReplaceRule) {
+		row := &replaceRow{pattern: widget.NewEntry(), replacement: widget.NewEntry()}
+		row.pattern.SetPlaceHolder(`Regex, e.g. (\w)-\n(\w)`)
+		row.pattern.SetText(rule.Pattern)
+		row.replacement.SetPlaceHolder("Replacement, e.g. $1$2")
+		row.replacement.SetText(rule.Replacement)
+		replaceRows = append(replaceRows, row)
+		var line *fyne.Container
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			for i, r := range replaceRows {
+				if r == row {
+					replaceRows = append(replaceRows[:i], replaceRows[i+1:]...)
+					break
+				}
+			}
+			replaceList.Remove(line)
+		})
+		line = container.NewBorder(nil, nil, nil, removeBtn, container.NewGridWithColumns(2, row.pattern, row.replacement))
+		replaceList.Add(line)
+	}
+	for _, rule := range settings.Replacements {
+		addReplaceRow(rule)
+	}
+	replaceRules := func() []preprocess.ReplaceRule {
+		var rules []preprocess.ReplaceRule
+		for _, row := range replaceRows {
+			if row.pattern.Text != "" {
+				rules = append(rules, preprocess.ReplaceRule{Pattern: row.pattern.Text, Replacement: row.replacement.Text})
+			}
+		}
+		return rules
+	}
+	replaceStatus := widget.NewLabel("Rules run in order before chunking. Use $1 or ${name} to insert captured groups.")
+	replaceStatus.Wrapping = fyne.TextWrapWord
+	checkRulesBtn := widget.NewButton("Check rules", func() {
+		if _, err := preprocess.CompileRules(replaceRules()); err != nil {
+			replaceStatus.SetText(err.Error())
+			return
+		}
+		replaceStatus.SetText("Rules OK.")
+	})
+	addRuleBtn := widget.NewButtonWithIcon("Add rule", theme.ContentAddIcon(), func() {
+		addReplaceRow(preprocess.ReplaceRule{})
+	})
+	content := container.NewBorder(nil,
+		container.NewVBox(replaceStatus, container.NewHBox(addRuleBtn, checkRulesBtn)), nil, nil,
+		container.NewVScroll(replaceList))
+
+	apply := func(settings *config.Settings) {
+		if rules := replaceRules(); len(rules) == 0 {
+			settings.Replacements = nil
+		} else if _, err := preprocess.CompileRules(rules); err != nil {
+			ui.ShowError(fmt.Sprintf("Replacement rules not saved: %v", err))
+		} else {
+			settings.Replacements = rules
+		}
+	}
+	return settingsTab{title: "Replacements", content: content, apply: apply}
+}
+
+// acronymsSettingsTab sets how all-caps tokens are read per language, plus
+// per-token corrections.
+func acronymsSettingsTab(settings *config.Settings) settingsTab {
+	acronymModeLabels := map[string]string{
+		preprocess.AcronymDictionary: "Look up pronunciation",
+		preprocess.AcronymSpell:      "Spell letter by letter",
+		preprocess.AcronymWord:       "Read as a word",
+	}
+	acronymModeSelects := map[string]*widget.Select{}
+	acronymForm := container.New(layout.NewFormLayout())
+	for _, lang := range []struct{ code, label string }{{"de", "German:"}, {"en", "English:"}} {
+		sel := widget.NewSelect([]string{acronymModeLabels[preprocess.AcronymDictionary], acronymModeLabels[preprocess.AcronymSpell], acronymModeLabels[preprocess.AcronymWord]}, nil)
+		sel.SetSelected(acronymModeLabels[acronymPolicy(settings).Mode(lang.code)])
+		acronymModeSelects[lang.code] = sel
+		acronymForm.Add(widget.NewLabel(lang.label))
+		acronymForm.Add(sel)
+	}
+	acronymOverridesEntry := widget.NewMultiLineEntry()
+	acronymOverridesEntry.SetPlaceHolder("USB = spell\nNASA = word\nSQL = sequel")
+	acronymOverridesEntry.SetText(preprocess.FormatAcronymOverrides(settings.AcronymOverrides))
+	acronymOverridesEntry.SetMinRowsVisible(6)
+	content := container.NewVBox(
+		acronymForm,
+		widget.NewLabel("Corrections (spell, word or a spoken form); Quacker → Review document adds them from the current text:"),
+		acronymOverridesEntry,
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.AcronymModes = map[string]string{}
+		for lang, sel := range acronymModeSelects {
+			for mode, label := range acronymModeLabels {
+				if label == sel.Selected {
+					settings.AcronymModes[lang] = mode
+				}
+			}
+		}
+		settings.AcronymOverrides = preprocess.ParseAcronymOverrides(acronymOverridesEntry.Text)
+	}
+	return settingsTab{title: "Acronyms", content: content, apply: apply}
+}
+
+// headingsSettingsTab sets announcements, pauses and file splits per heading level.
+func headingsSettingsTab(settings *config.Settings) settingsTab {
+	modeLabels := map[string]string{
+		preprocess.AnnounceTitle:    "Title only",
+		preprocess.AnnounceTemplate: "Template",
+		preprocess.AnnounceSilent:   "Silent",
+	}
+	modeOptions := []string{"Title only", "Template", "Silent"}
+	type headingRow struct {
+		mode                    *widget.Select
+		template, before, after *widget.Entry
+		split                   *widget.Check
+	}
+	headingRows := map[int]headingRow{}
+	headingGrid := container.NewGridWithColumns(6,
+		widget.NewLabel("Level"), widget.NewLabel("Announce"), widget.NewLabel("Template"),
+		widget.NewLabel("Pause before (s)"), widget.NewLabel("Pause after (s)"), widget.NewLabel("New file"))
+	for level := 1; level <= 3; level++ {
+		style := settings.HeadingStyles[level]
+		row := headingRow{
+			mode:     widget.NewSelect(modeOptions, nil),
+			template: widget.NewEntry(),
+			before:   widget.NewEntry(),
+			after:    widget.NewEntry(),
+			split:    widget.NewCheck("", nil),
+		}
+		row.mode.SetSelected(modeLabels[preprocess.AnnounceTitle])
+		if label, ok := modeLabels[style.Mode]; ok {
+			row.mode.SetSelected(label)
+		}
+		row.template.SetPlaceHolder("Kapitel {n}: {title}")
+		row.template.SetText(style.Template)
+		if style.PauseBefore > 0 {
+			row.before.SetText(strconv.FormatFloat(style.PauseBefore, 'f', -1, 64))
+		}
+		if style.PauseAfter > 0 {
+			row.after.SetText(strconv.FormatFloat(style.PauseAfter, 'f', -1, 64))
+		}
+		row.split.SetChecked(style.Split)
+		headingRows[level] = row
+		headingGrid.Add(widget.NewLabel(strings.Repeat("#", level)))
+		headingGrid.Add(row.mode)
+		headingGrid.Add(row.template)
+		headingGrid.Add(row.before)
+		headingGrid.Add(row.after)
+		headingGrid.Add(row.split)
+	}
+	chapterFilesOnlyCheck := widget.NewCheck("Keep only the per-chapter files, not the whole document", nil)
+	chapterFilesOnlyCheck.SetChecked(settings.ChapterFilesOnly)
+	crossfadeEntry := widget.NewEntry()
+	crossfadeEntry.SetPlaceHolder("0 = hard cut")
+	if settings.Crossfade > 0 {
+		crossfadeEntry.SetText(strconv.FormatFloat(settings.Crossfade, 'f', -1, 64))
+	}
+	content := container.NewVBox(
+		headingGrid,
+		widget.NewLabel("Template placeholders: {n} chapter number at this level, {number} full number (2.1), {title}."),
+		widget.NewLabel("Headings that start a new file split the output into name_01_Title.mp3, name_02_Title.mp3..."),
+		chapterFilesOnlyCheck,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Crossfade between sections (s):"), crossfadeEntry,
+		),
+		widget.NewLabel("Sections joined without a pause, e.g. where the voice changes, overlap by the crossfade (MP3 and WAV)."),
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.HeadingStyles = map[int]preprocess.HeadingStyle{}
+		for level, row := range headingRows {
+			style := preprocess.HeadingStyle{Mode: preprocess.AnnounceTitle, Template: strings.TrimSpace(row.template.Text), Split: row.split.Checked}
+			for mode, label := range modeLabels {
+				if label == row.mode.Selected {
+					style.Mode = mode
+				}
+			}
+			style.PauseBefore, _ = strconv.ParseFloat(strings.TrimSpace(row.before.Text), 64)
+			style.PauseAfter, _ = strconv.ParseFloat(strings.TrimSpace(row.after.Text), 64)
+			if style != (preprocess.HeadingStyle{Mode: preprocess.AnnounceTitle}) {
+				settings.HeadingStyles[level] = style
+			}
+		}
+		settings.Crossfade, _ = strconv.ParseFloat(strings.TrimSpace(crossfadeEntry.Text), 64)
+		settings.Crossfade = max(settings.Crossfade, 0)
+		settings.ChapterFilesOnly = chapterFilesOnlyCheck.Checked
+	}
+	return settingsTab{title: "Headings", content: content, apply: apply}
+}
+
+// languagesSettingsTab sets per-paragraph voice switching for mixed-language documents.
+func languagesSettingsTab(settings *config.Settings, provider string) settingsTab {
+	autoLanguageCheck := widget.NewCheck("Switch voices by detected paragraph language", nil)
+	autoLanguageCheck.SetChecked(settings.AutoLanguageVoices)
+	languageVoiceEntries := map[string]*widget.Entry{}
+	languageForm := container.New(layout.NewFormLayout())
+	for _, lang := range []struct{ code, label string }{{"de", "German voice:"}, {"en", "English voice:"}} {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("Derived from the selected voice")
+		entry.SetText(settings.LanguageVoices[provider][lang.code])
+		languageVoiceEntries[lang.code] = entry
+		languageForm.Add(widget.NewLabel(lang.label))
+		languageForm.Add(entry)
+	}
+	content := container.NewVBox(
+		autoLanguageCheck,
+		widget.NewLabel(fmt.Sprintf("Voices used with %s:", provider)),
+		languageForm,
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.AutoLanguageVoices = autoLanguageCheck.Checked
+		if settings.LanguageVoices == nil {
+			settings.LanguageVoices = map[string]map[string]string{}
+		}
+		languageVoices := map[string]string{}
+		for lang, entry := range languageVoiceEntries {
+			if v := strings.TrimSpace(entry.Text); v != "" {
+				languageVoices[lang] = v
+			}
+		}
+		settings.LanguageVoices[provider] = languageVoices
+	}
+	return settingsTab{title: "Languages", content: content, apply: apply}
+}
+
+// dialogueSettingsTab sets one voice per speaker in "Name: text" scripts.
+func dialogueSettingsTab(settings *config.Settings, provider string) settingsTab {
+	dialogueCheck := widget.NewCheck("Read \"Name: text\" dialogue scripts with one voice per speaker", nil)
+	dialogueCheck.SetChecked(settings.DialogueVoices)
+	speakerVoicesEntry := widget.NewMultiLineEntry()
+	speakerVoicesEntry.SetPlaceHolder("Anna = nova\nBen = onyx")
+	speakerVoicesEntry.SetText(tts.FormatSpeakerVoices(settings.SpeakerVoices[provider]))
+	speakerVoicesEntry.SetMinRowsVisible(5)
+	content := container.NewVBox(
+		dialogueCheck,
+		widget.NewLabel(fmt.Sprintf("Speaker voices for %s (others are assigned automatically):", provider)),
+		speakerVoicesEntry,
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.DialogueVoices = dialogueCheck.Checked
+		if settings.SpeakerVoices == nil {
+			settings.SpeakerVoices = map[string]map[string]string{}
+		}
+		settings.SpeakerVoices[provider] = tts.ParseSpeakerVoices(speakerVoicesEntry.Text)
+	}
+	return settingsTab{title: "Dialogue", content: content, apply: apply}
+}
+
+// favoritesSettingsTab sets the voices bound to the keys 1-9 when auditioning in the preview.
+func favoritesSettingsTab(settings *config.Settings, provider string) settingsTab {
+	favoriteVoicesEntry := widget.NewMultiLineEntry()
+	favoriteVoicesEntry.SetPlaceHolder("nova\nshimmer\nonyx")
+	favoriteVoicesEntry.SetText(strings.Join(settings.FavoriteVoices[provider], "\n"))
+	favoriteVoicesEntry.SetMinRowsVisible(9)
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Favorite voices for %s, one per line (keys 1-9 in Quacker → Preview processed text):", provider)),
+		favoriteVoicesEntry,
+	)
+
+	apply := func(settings *config.Settings) {
+		var favorites []string
+		for _, line := range strings.Split(favoriteVoicesEntry.Text, "\n") {
+			if v := strings.TrimSpace(line); v != "" && len(favorites) < 9 {
+				favorites = append(favorites, v)
+			}
+		}
+		if settings.FavoriteVoices == nil {
+			settings.FavoriteVoices = map[string][]string{}
+		}
+		settings.FavoriteVoices[provider] = favorites
+	}
+	return settingsTab{title: "Favorites", content: content, apply: apply}
+}
+
+// scriptSettingsTab holds the Starlark hook for custom transforms and file names:
+// the one of the open project, or the default for other texts and new projects.
+func scriptSettingsTab(ui *gui.UI, settings *config.Settings, session *projectSession) settingsTab {
+	session.mu.Lock()
+	path := session.path
+	session.mu.Unlock()
+	scopeLabel := widget.NewLabel("Default script, for texts outside a project. New projects are saved with it.")
+	if path != "" {
+		scopeLabel.SetText(fmt.Sprintf("Script of the project %s, saved with it.", filepath.Base(path)))
+	}
+	scopeLabel.Wrapping = fyne.TextWrapWord
+	scriptEntry := widget.NewMultiLineEntry()
+	scriptEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	scriptEntry.SetPlaceHolder("def transform(text, language):\n    return text.replace(\"e.g.\", \"for example\")\n\ndef filename(text, default):\n    return default")
+	scriptEntry.SetText(session.scriptFor(settings))
+	scriptEntry.SetMinRowsVisible(8)
+	scriptStatus := widget.NewLabel("Sandboxed Starlark: no file or network access. re_sub(pattern, repl, text) is available.")
+	scriptStatus.Wrapping = fyne.TextWrapWord
+	checkScriptBtn := widget.NewButton("Check script", func() {
+		if _, err := script.Compile(scriptEntry.Text); err != nil {
+			scriptStatus.SetText(err.Error())
+			return
+		}
+		scriptStatus.SetText("Script OK.")
+	})
+	content := container.NewBorder(scopeLabel, container.NewVBox(scriptStatus, checkScriptBtn), nil, nil, scriptEntry)
+
+	apply := func(settings *config.Settings) {
+		if _, err := script.Compile(scriptEntry.Text); err != nil {
+			ui.ShowError(fmt.Sprintf("Custom script not saved: %v", err))
+		} else if path != "" {
+			session.setScript(path, scriptEntry.Text)
+		} else {
+			settings.Script = scriptEntry.Text
+		}
+	}
+	return settingsTab{title: "Script", content: content, apply: apply}
+}
+
+// retriesSettingsTab sets how failed chunks are retried before they are split
+// or given up, and what takes their place then.
+func retriesSettingsTab(ui *gui.UI, settings *config.Settings) settingsTab {
+	retry := defaultRetrySettings()
+	if settings.Retry != nil {
+		retry = *settings.Retry
+	}
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	maxRetriesEntry := widget.NewEntry()
+	maxRetriesEntry.SetText(strconv.Itoa(retry.MaxRetries))
+	retryBaseEntry := widget.NewEntry()
+	retryBaseEntry.SetText(formatFloat(retry.BaseDelay))
+	retryMultiplierEntry := widget.NewEntry()
+	retryMultiplierEntry.SetText(formatFloat(retry.Multiplier))
+	retryJitterEntry := widget.NewEntry()
+	retryJitterEntry.SetText(formatFloat(retry.Jitter))
+	failedPolicyLabels := map[string]string{
+		tts.FailedPhrase:  "Speak a phrase",
+		tts.FailedSilence: "Insert silence",
+		tts.FailedBeep:    "Insert a beep (WAV output; silence otherwise)",
+		tts.FailedSkip:    "Skip silently",
+		tts.FailedAbort:   "Abort the job",
+	}
+	var failedPolicyOptions []string
+	for _, policy := range tts.FailedPolicies {
+		failedPolicyOptions = append(failedPolicyOptions, failedPolicyLabels[policy])
+	}
+	failedPolicySelect := widget.NewSelect(failedPolicyOptions, nil)
+	failedPolicySelect.SetSelected(failedPolicyLabels[tts.FailedPhrase])
+	if label, ok := failedPolicyLabels[settings.FailedSection]; ok {
+		failedPolicySelect.SetSelected(label)
+	}
+	failedPhraseEntry := widget.NewEntry()
+	failedPhraseEntry.SetPlaceHolder(tts.DefaultFailedPhrase)
+	failedPhraseEntry.SetText(settings.FailedPhrase)
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Waits grow from the base delay by the multiplier, up to %v, and vary randomly by the jitter.\nA delay requested by the provider (Retry-After) takes precedence.", tts.DefaultRetryMaxDelay)),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Attempts per chunk:"), maxRetriesEntry,
+			widget.NewLabel("Base delay (seconds):"), retryBaseEntry,
+			widget.NewLabel("Multiplier:"), retryMultiplierEntry,
+			widget.NewLabel("Jitter (0-1):"), retryJitterEntry,
+			widget.NewLabel("When a section still fails:"), failedPolicySelect,
+			widget.NewLabel("Phrase:"), failedPhraseEntry,
+		),
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.FailedSection = ""
+		for policy, label := range failedPolicyLabels {
+			if label == failedPolicySelect.Selected && policy != tts.FailedPhrase {
+				settings.FailedSection = policy
+			}
+		}
+		settings.FailedPhrase = strings.TrimSpace(failedPhraseEntry.Text)
+		maxRetries, retriesErr := strconv.Atoi(strings.TrimSpace(maxRetriesEntry.Text))
+		retryBase, baseErr := strconv.ParseFloat(strings.TrimSpace(retryBaseEntry.Text), 64)
+		retryMultiplier, multiplierErr := strconv.ParseFloat(strings.TrimSpace(retryMultiplierEntry.Text), 64)
+		retryJitter, jitterErr := strconv.ParseFloat(strings.TrimSpace(retryJitterEntry.Text), 64)
+		retry := config.RetrySettings{MaxRetries: maxRetries, BaseDelay: retryBase, Multiplier: retryMultiplier, Jitter: retryJitter}
+		err := errors.Join(retriesErr, baseErr, multiplierErr, jitterErr)
+		if err == nil {
+			err = validateRetrySettings(retry)
+		}
+		if err != nil {
+			ui.ShowError(fmt.Sprintf("Retry settings not saved: %v", err))
+		} else if retry != defaultRetrySettings() {
+			settings.Retry = &retry
+		} else {
+			settings.Retry = nil
+		}
+	}
+	return settingsTab{title: "Retries", content: content, apply: apply}
+}
+
+// storageSettingsTab shows the disk usage and sets the retention policy, where
+// and how outputs are saved and the files written next to them. Its apply also
+// saves the signing key password to the keychain.
+func storageSettingsTab(ui *gui.UI, settings *config.Settings, jobHistory *history.Store) settingsTab {
+	usageLabel := widget.NewLabel("")
+	refreshUsage := func() {
+		u := retention.DiskUsage(retentionPolicy(settings), retentionCacheDirs(jobHistory), jobHistory)
+		usageLabel.SetText(fmt.Sprintf("Cache: %s   Outputs: %s   Archive: %s",
+			retention.FormatBytes(u.Cache), retention.FormatBytes(u.Outputs), retention.FormatBytes(u.Archive)))
+	}
+	refreshUsage()
+	cacheDaysEntry := widget.NewEntry()
+	cacheDaysEntry.SetPlaceHolder("0 = keep forever")
+	if settings.CacheRetentionDays > 0 {
+		cacheDaysEntry.SetText(strconv.Itoa(settings.CacheRetentionDays))
+	}
+	archiveDaysEntry := widget.NewEntry()
+	archiveDaysEntry.SetPlaceHolder("0 = never archive")
+	if settings.ArchiveAfterDays > 0 {
+		archiveDaysEntry.SetText(strconv.Itoa(settings.ArchiveAfterDays))
+	}
+	archiveDirEntry := widget.NewEntry()
+	archiveDirEntry.SetText(retentionPolicy(settings).ArchiveDir)
+	syncDirEntry := widget.NewEntry()
+	syncDirEntry.SetPlaceHolder("Optional, e.g. a Dropbox or iCloud Drive folder")
+	syncDirEntry.SetText(settings.SyncDir)
+	syncBrowseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				syncDirEntry.SetText(dir.Path())
+			}
+		}, ui.Window)
+	})
+	cacheCheck := widget.NewCheck("Reuse the audio of unchanged chunks when a document is synthesized again", nil)
+	cacheCheck.SetChecked(!settings.DisableCache)
+	compressCheck := widget.NewCheck("Compress archived outputs", nil)
+	compressCheck.SetChecked(settings.CompressArchive)
+	outputFormatLabels := map[string]string{"mp3": "MP3", "wav": "WAV", "ogg": "Ogg Opus"}
+	outputFormatSelect := widget.NewSelect([]string{"MP3", "WAV", "Ogg Opus"}, nil)
+	outputFormatSelect.SetSelected(outputFormatLabels[audio.NormalizeFormat(settings.OutputFormat)])
+	sampleRateSelect := widget.NewSelect(nil, nil)
+	for _, rate := range outputSampleRates {
+		sampleRateSelect.Options = append(sampleRateSelect.Options, sampleRateLabel(rate))
+	}
+	sampleRateSelect.SetSelected(sampleRateLabel(settings.SampleRate))
+	bitrateSelect := widget.NewSelect(nil, nil)
+	for _, bitrate := range outputBitrates {
+		bitrateSelect.Options = append(bitrateSelect.Options, bitrateLabel(bitrate))
+	}
+	bitrateSelect.SetSelected(bitrateLabel(settings.Bitrate))
+	outputDirEntry := widget.NewEntry()
+	outputDirEntry.SetPlaceHolder("Downloads")
+	outputDirEntry.SetText(settings.OutputDir)
+	outputDirBrowseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				outputDirEntry.SetText(dir.Path())
+			}
+		}, ui.Window)
+	})
+	filenameEntry := widget.NewEntry()
+	filenameEntry.SetPlaceHolder(util.DefaultFilenameTemplate)
+	filenameEntry.SetText(settings.FilenameTemplate)
+	introEntry, introRow := audioFileEntry(ui.Window, settings.IntroFile)
+	outroEntry, outroRow := audioFileEntry(ui.Window, settings.OutroFile)
+	subtitlesLabels := map[string]string{"": "None", tts.SubtitlesSRT: "SRT", tts.SubtitlesVTT: "WebVTT"}
+	subtitlesSelect := widget.NewSelect([]string{"None", "SRT", "WebVTT"}, nil)
+	subtitlesSelect.SetSelected(subtitlesLabels[settings.Subtitles])
+	timingJSONCheck := widget.NewCheck("Write sentence timings (name.timing.json) for read-along apps and editors", nil)
+	timingJSONCheck.SetChecked(settings.TimingJSON)
+	transcriptCheck := widget.NewCheck("Write the preprocessed text (name.transcript.txt) and the settings used (name.settings.json)", nil)
+	transcriptCheck.SetChecked(settings.Transcript)
+	chunkFilesCheck := widget.NewCheck("Also keep every chunk as a file of its own (name_chunks folder), e.g. to fix a sentence in an audio editor", nil)
+	chunkFilesCheck.SetChecked(settings.ChunkFiles)
+	neverOverwriteCheck := widget.NewCheck("Never overwrite existing files: number new outputs, e.g. name (2).mp3, without asking", nil)
+	neverOverwriteCheck.SetChecked(settings.NeverOverwrite)
+	checksumsCheck := widget.NewCheck("Write a SHA-256 checksum sidecar (name.mp3.json) next to every output", nil)
+	checksumsCheck.SetChecked(settings.Checksums)
+	signingKeyEntry := widget.NewEntry()
+	signingKeyEntry.SetPlaceHolder("Optional minisign secret key, e.g. ~/.minisign/minisign.key")
+	signingKeyEntry.SetText(settings.SigningKey)
+	signingKeyBrowseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
+			if err == nil && f != nil {
+				signingKeyEntry.SetText(f.URI().Path())
+				f.Close()
+			}
+		}, ui.Window)
+	})
+	signingPasswordEntry := widget.NewPasswordEntry()
+	signingPasswordEntry.SetPlaceHolder("Stored in the keychain")
+	cleanupBtn := widget.NewButton("Clean up now", func() {
+		// Clean up with the values shown, which are only kept if the dialog is saved
+		shown := &config.Settings{ArchiveDir: strings.TrimSpace(archiveDirEntry.Text), CompressArchive: compressCheck.Checked}
+		shown.CacheRetentionDays, _ = strconv.Atoi(strings.TrimSpace(cacheDaysEntry.Text))
+		shown.ArchiveAfterDays, _ = strconv.Atoi(strings.TrimSpace(archiveDaysEntry.Text))
+		res, err := retention.Apply(retentionPolicy(shown), retentionCacheDirs(jobHistory), jobHistory, time.Now())
+		if err != nil {
+			ui.ShowError(fmt.Sprintf("Cleanup failed: %v", err))
+		} else {
+			ui.ShowSuccess(fmt.Sprintf("Cleanup removed %d cache file(s), archived %d output(s), freed %s",
+				res.CacheFilesRemoved, res.OutputsArchived, retention.FormatBytes(res.BytesFreed)))
+		}
+		refreshUsage()
+	})
+	content := container.NewVBox(
+		usageLabel,
+		cacheCheck,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Delete cache after (days):"), cacheDaysEntry,
+			widget.NewLabel("Archive outputs after (days):"), archiveDaysEntry,
+			widget.NewLabel("Archive folder:"), archiveDirEntry,
+			widget.NewLabel("Sync preferences via:"), container.NewBorder(nil, nil, nil, syncBrowseBtn, syncDirEntry),
+		),
+		compressCheck,
+		cleanupBtn,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Output options", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Output format:"), outputFormatSelect,
+			widget.NewLabel("Sample rate:"), sampleRateSelect,
+			widget.NewLabel("MP3 bitrate:"), bitrateSelect,
+			widget.NewLabel("Save outputs in:"), container.NewBorder(nil, nil, nil, outputDirBrowseBtn, outputDirEntry),
+			widget.NewLabel("File names:"), filenameEntry,
+			widget.NewLabel("Intro:"), introRow,
+			widget.NewLabel("Outro:"), outroRow,
+			widget.NewLabel("Subtitles:"), subtitlesSelect,
+		),
+		widget.NewLabel("The sample rate applies to Google voices. The bitrate applies to MP3 audio converted\nor resampled by Quacker, such as chunks joined to audio with another sample rate.\nFile names take {title} (first five words, {title:N} for N), {date}, {time}, {voice}, {provider}, {speed} and {ext}.\nThe intro and outro are put before and after every output, converted to its format.\nSubtitles are timed by Google's sentence marks, estimated from the text for other voices."),
+		timingJSONCheck,
+		transcriptCheck,
+		chunkFilesCheck,
+		neverOverwriteCheck,
+		checksumsCheck,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Sign outputs with:"), container.NewBorder(nil, nil, nil, signingKeyBrowseBtn, signingKeyEntry),
+			widget.NewLabel("Key password:"), signingPasswordEntry,
+		),
+	)
+
+	apply := func(settings *config.Settings) {
+		settings.DisableCache = !cacheCheck.Checked
+		settings.CacheRetentionDays, _ = strconv.Atoi(strings.TrimSpace(cacheDaysEntry.Text))
+		settings.ArchiveAfterDays, _ = strconv.Atoi(strings.TrimSpace(archiveDaysEntry.Text))
+		settings.ArchiveDir = strings.TrimSpace(archiveDirEntry.Text)
+		settings.CompressArchive = compressCheck.Checked
+		settings.SyncDir = strings.TrimSpace(syncDirEntry.Text)
+		settings.OutputFormat = ""
+		for format, label := range outputFormatLabels {
+			if label == outputFormatSelect.Selected && format != "mp3" {
+				settings.OutputFormat = format
+			}
+		}
+		settings.SampleRate, settings.Bitrate = 0, 0
+		for _, rate := range outputSampleRates {
+			if sampleRateLabel(rate) == sampleRateSelect.Selected {
+				settings.SampleRate = rate
+			}
+		}
+		for _, bitrate := range outputBitrates {
+			if bitrateLabel(bitrate) == bitrateSelect.Selected {
+				settings.Bitrate = bitrate
+			}
+		}
+		settings.OutputDir = strings.TrimSpace(outputDirEntry.Text)
+		settings.FilenameTemplate = strings.TrimSpace(filenameEntry.Text)
+		settings.IntroFile = strings.TrimSpace(introEntry.Text)
+		settings.OutroFile = strings.TrimSpace(outroEntry.Text)
+		settings.Subtitles = ""
+		for format, label := range subtitlesLabels {
+			if label == subtitlesSelect.Selected {
+				settings.Subtitles = format
+			}
+		}
+		settings.TimingJSON = timingJSONCheck.Checked
+		settings.Transcript = transcriptCheck.Checked
+		settings.ChunkFiles = chunkFilesCheck.Checked
+		settings.NeverOverwrite = neverOverwriteCheck.Checked
+		settings.Checksums = checksumsCheck.Checked
+		settings.SigningKey = strings.TrimSpace(signingKeyEntry.Text)
+		if signingPasswordEntry.Text != "" {
+			config.SetSigningKeyPassword(signingPasswordEntry.Text)
+		}
+	}
+	return settingsTab{title: "Storage", content: content, apply: apply}
+}
+
+// audioFileEntry is an entry for the path of an audio file with a button to pick it.
+func audioFileEntry(w fyne.Window, path string) (*widget.Entry, fyne.CanvasObject) {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Optional MP3 or WAV file")
+	entry.SetText(path)
+	browse := widget.NewButton("Browse...", func() {
+		dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
+			if err == nil && f != nil {
+				entry.SetText(f.URI().Path())
+				f.Close()
+			}
+		}, w)
+	})
+	return entry, container.NewBorder(nil, nil, nil, browse, entry)
+}
+
+// uploadSettingsTab sets the remote storage every output is copied to. Its
+// apply also saves the password or secret key to the keychain.
+func uploadSettingsTab(settings *config.Settings) settingsTab {
+	uploadKindLabels := map[string]string{"": "None", upload.KindWebDAV: "WebDAV", upload.KindS3: "S3", upload.KindGoogleDrive: "Google Drive"}
+	uploadKindSelect := widget.NewSelect([]string{"None", "WebDAV", "S3", "Google Drive"}, nil)
+	uploadKindSelect.SetSelected(uploadKindLabels[settings.Upload.Kind])
+	uploadURLEntry := widget.NewEntry()
+	uploadURLEntry.SetPlaceHolder("https://cloud.example.com/remote.php/dav/files/me/Audio")
+	uploadURLEntry.SetText(settings.Upload.URL)
+	uploadUserEntry := widget.NewEntry()
+	uploadUserEntry.SetText(settings.Upload.Username)
+	uploadSecretEntry := widget.NewPasswordEntry()
+	uploadSecretEntry.SetPlaceHolder("Stored in the keychain")
+	uploadBucketEntry := widget.NewEntry()
+	uploadBucketEntry.SetText(settings.Upload.Bucket)
+	uploadRegionEntry := widget.NewEntry()
+	uploadRegionEntry.SetPlaceHolder("us-east-1")
+	uploadRegionEntry.SetText(settings.Upload.Region)
+	uploadPrefixEntry := widget.NewEntry()
+	uploadPrefixEntry.SetPlaceHolder("Optional, e.g. audiobooks/")
+	uploadPrefixEntry.SetText(settings.Upload.Prefix)
+	uploadFolderEntry := widget.NewEntry()
+	uploadFolderEntry.SetPlaceHolder("Optional, the last part of the folder's URL")
+	uploadFolderEntry.SetText(settings.Upload.FolderID)
+	content := container.NewVBox(
+		widget.NewLabel("Every saved output, its chapter files and the files written next to it are copied here."),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Upload to:"), uploadKindSelect,
+			widget.NewLabel("URL:"), uploadURLEntry,
+			widget.NewLabel("User / access key ID:"), uploadUserEntry,
+			widget.NewLabel("Password / secret key:"), uploadSecretEntry,
+			widget.NewLabel("S3 bucket:"), uploadBucketEntry,
+			widget.NewLabel("S3 region:"), uploadRegionEntry,
+			widget.NewLabel("S3 key prefix:"), uploadPrefixEntry,
+			widget.NewLabel("Drive folder ID:"), uploadFolderEntry,
+		),
+		widget.NewLabel("WebDAV takes the URL of a folder, e.g. in Nextcloud. S3 takes the URL of the endpoint\nfor storage other than AWS, such as R2 or MinIO. Google Drive uses the application default\ncredentials with the drive.file scope and needs no password."),
+	)
+
+	apply := func(settings *config.Settings) {
+		if uploadSecretEntry.Text != "" {
+			config.SetUploadSecret(uploadSecretEntry.Text)
+		}
+		settings.Upload = config.UploadTarget{
+			URL:      strings.TrimSpace(uploadURLEntry.Text),
+			Username: strings.TrimSpace(uploadUserEntry.Text),
+			Bucket:   strings.TrimSpace(uploadBucketEntry.Text),
+			Region:   strings.TrimSpace(uploadRegionEntry.Text),
+			Prefix:   strings.TrimSpace(uploadPrefixEntry.Text),
+			FolderID: strings.TrimSpace(uploadFolderEntry.Text),
+		}
+		for kind, label := range uploadKindLabels {
+			if label == uploadKindSelect.Selected {
+				settings.Upload.Kind = kind
+			}
+		}
+	}
+	return settingsTab{title: "Upload", content: content, apply: apply}
+}